@@ -0,0 +1,181 @@
+// 命令框架的脚本测试：testdata/cmdscript 下的每个 txtar 文件是一个测试，
+// 注释部分为脚本，其余文件解压到临时目录中作为工作目录。
+// 测试二进制自身充当 go 命令（设置 GO_CMDSCRIPT_MAIN=1 时 TestMain 直接调用 main），
+// 因此脚本覆盖的是完整的命令分发流程：别名、拼写建议、钩子、flag 校验、退出码等。
+//
+// 脚本每行一条命令，# 开头为注释，命令前加 ! 表示期望失败或不匹配：
+//
+//	go args...       执行 go 命令，期望退出码为 0（加 ! 时期望非 0）
+//	exit n           上一次 go 命令的退出码应为 n
+//	stdout regexp    上一次 go 命令的标准输出应匹配 regexp
+//	stderr regexp    同上，检查标准错误
+//	env KEY=VALUE    为之后的 go 命令设置环境变量
+//	exists file      工作目录中应存在 file
+//	cp src dst       复制工作目录中的文件
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"cmd/go/internal/txtar"
+)
+
+func TestMain(m *testing.M) {
+	if os.Getenv("GO_CMDSCRIPT_MAIN") == "1" {
+		// 作为被测的 go 命令运行：去掉测试框架的 flag，使 main 看到的参数与真实的 go 命令相同
+		flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func TestCmdScript(t *testing.T) {
+	files, err := filepath.Glob("testdata/cmdscript/*.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		file := file
+		name := strings.TrimSuffix(filepath.Base(file), ".txt")
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			runCmdScript(t, file)
+		})
+	}
+}
+
+// cmdScript 是一个脚本执行时的状态
+type cmdScript struct {
+	t      *testing.T
+	file   string
+	dir    string   // 临时工作目录
+	env    []string // 执行 go 命令时的环境变量
+	stdout string   // 上一次 go 命令的输出
+	stderr string
+	code   int // 上一次 go 命令的退出码
+}
+
+func runCmdScript(t *testing.T, file string) {
+	a, err := txtar.ParseFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &cmdScript{
+		t:    t,
+		file: file,
+		dir:  t.TempDir(),
+		env: append(os.Environ(),
+			"GO_CMDSCRIPT_MAIN=1",
+			"NO_COLOR=1",
+			"GOFLAGS=",
+		),
+	}
+	for _, f := range a.Files {
+		path := filepath.Join(s.dir, f.Name)
+		if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, f.Data, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i, line := range strings.Split(string(a.Comment), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		neg := false
+		if strings.HasPrefix(line, "!") {
+			neg = true
+			line = strings.TrimSpace(line[1:])
+		}
+		args := strings.Fields(line)
+		if err := s.exec(neg, args[0], args[1:]); err != nil {
+			t.Fatalf("%s:%d: %s: %v\nstdout:\n%s\nstderr:\n%s", file, i+1, line, err, s.stdout, s.stderr)
+		}
+	}
+}
+
+func (s *cmdScript) exec(neg bool, verb string, args []string) error {
+	switch verb {
+	case "go":
+		return s.runGo(neg, args)
+	case "exit":
+		want, err := strconv.Atoi(args[0])
+		if err != nil {
+			return err
+		}
+		if (s.code == want) == neg {
+			return fmt.Errorf("exit status %d", s.code)
+		}
+	case "stdout":
+		return match(neg, "stdout", s.stdout, args)
+	case "stderr":
+		return match(neg, "stderr", s.stderr, args)
+	case "env":
+		s.env = append(s.env, args...)
+	case "cp":
+		data, err := os.ReadFile(filepath.Join(s.dir, args[0]))
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(s.dir, args[1]), data, 0666)
+	case "exists":
+		_, err := os.Stat(filepath.Join(s.dir, args[0]))
+		if (err == nil) == neg {
+			return fmt.Errorf("exists %s = %v", args[0], err == nil)
+		}
+	default:
+		return fmt.Errorf("unknown command %q", verb)
+	}
+	return nil
+}
+
+func (s *cmdScript) runGo(neg bool, args []string) error {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Dir = s.dir
+	cmd.Env = s.env
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	s.stdout, s.stderr, s.code = stdout.String(), stderr.String(), 0
+	var ee *exec.ExitError
+	if errors.As(err, &ee) {
+		s.code = ee.ExitCode()
+	} else if err != nil {
+		return err
+	}
+	if (s.code == 0) == neg {
+		return fmt.Errorf("unexpected exit status %d", s.code)
+	}
+	return nil
+}
+
+// match 检查 out 是否匹配由 args 拼成的正则表达式
+func match(neg bool, name, out string, args []string) error {
+	re, err := regexp.Compile(`(?m)` + strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+	if re.MatchString(out) == neg {
+		if neg {
+			return fmt.Errorf("%s unexpectedly matches %s", name, re)
+		}
+		return fmt.Errorf("%s does not match %s", name, re)
+	}
+	return nil
+}
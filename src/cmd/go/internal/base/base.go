@@ -3,13 +3,14 @@ package base
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"flag"
 	"fmt"
 	"go/scanner"
-	"log"
 	"os"
 	"os/exec"
+	"runtime/debug"
 	"strings"
 	"sync"
 
@@ -22,6 +23,15 @@ type Command struct {
 	// 运行命令
 	Run func(cmd *Command, args []string) // Run 是一个 func 类型
 
+	// RunE 是返回错误的 Run，设置后代替 Run。错误由 Execute 统一报告并设置退出状态，
+	// 命令中无需调用 Fatalf，因而可以通过 ExecuteE 在单元测试中执行
+	RunE func(cmd *Command, args []string) error
+
+	// PreRun 和 PostRun 由 Execute 在 Run 前后调用，用于环境检查、计时、清理等通用逻辑，
+	// 为 nil 时跳过。Run 中调用 Exit 时 PostRun 不会执行，需要在退出时清理的用 AtExit 注册
+	PreRun  func(cmd *Command, args []string)
+	PostRun func(cmd *Command, args []string)
+
 	// 单行显示的使用信息
 	// The words between "go" and the first flag or argument in the line are taken to be the command name.
 	UsageLine string
@@ -43,12 +53,25 @@ type Command struct {
 	// The order here is the order in which they are printed by 'go help'.
 	// Note that subcommands are in general best avoided.
 	Commands []*Command
+
+	// Deprecated 不为空时表示命令已弃用，执行时输出该信息作为迁移提示
+	Deprecated string
+
+	// Hidden 的命令可以执行，但不出现在帮助列表、补全和生成的文档中
+	Hidden bool
+
+	// Experimental 的命令需要设置 GOEXPERIMENTALCMD=1 才能执行，执行时提示其不稳定
+	Experimental bool
+
+	deprecatedFlags map[string]string // 已弃用的 flag 及其提示，见 DeprecateFlag
+	requiredFlags   []string          // 必须给出的 flag，见 MarkFlagRequired
+	exclusiveFlags  [][]string        // 每组最多给出一个的 flag，见 MarkFlagsMutuallyExclusive
 }
 
 var Go = &Command{
 	UsageLine: "go",
 	Long:      `Go is a tool for managing Go source code.`,
-	// Commands initialized in package main
+	// Commands registered in package main, see Register
 }
 
 // LongName 返回了命令的长名称：介于 go 和参数间的所有的单词
@@ -73,43 +96,88 @@ func (c *Command) Name() string {
 }
 
 func (c *Command) Usage() {
-	fmt.Fprintf(os.Stderr, "usage: %s\n", c.UsageLine)
+	fmt.Fprintf(os.Stderr, "%s %s\n", ErrorText(os.Stderr, "usage:"), BoldText(os.Stderr, c.UsageLine))
 	fmt.Fprintf(os.Stderr, "Run 'go help %s' for details.\n", c.LongName())
-	SetExitStatus(2)
+	SetExitReason(ExitUsage, "")
 	Exit()
 }
 
+// Execute 检查弃用、实验性和 flag 组的声明后依次调用 PreRun、Run（或 RunE）和 PostRun，
+// args 为已解析过 flag 的参数。RunE 返回的错误在这里报告，见 ExecuteE
+func (c *Command) Execute(args []string) {
+	c.checkDeprecated()
+	c.checkExperimental()
+	c.checkFlagGroups()
+	c.recordUsage()
+	if err := c.ExecuteE(args); err != nil {
+		c.handleError(err)
+	}
+}
+
 // 判断一个命令是不是可以执行的；如果不是，那它就是一个伪命令，如 importpath
 func (c *Command) Runnable() bool {
-	return c.Run != nil // 看上面 Run 是一个 func 类型
+	return c.Run != nil || c.RunE != nil // 看上面 Run 是一个 func 类型
 }
 
-// 定义了一个 func 数组，看样子是在退出的时候去挨个执行的
-// 这个是不是就是 defer 字义的函数啊？
-var atExitFuncs []func()
+// 定义了一个 func 数组，在退出的时候去挨个执行
+// 作用类似 defer：os.Exit 不会执行 defer，需要在退出时做的清理就注册在这里
+var (
+	atExitMu    sync.Mutex
+	atExitFuncs []func()
+)
 
-func AtExit(f func()) { // 加入 func()
+// AtExit 注册退出时执行的函数，可在多个协程中调用
+func AtExit(f func()) {
+	atExitMu.Lock()
 	atExitFuncs = append(atExitFuncs, f)
+	atExitMu.Unlock()
 }
 
 // 退出函数会先执行 atExitFuncs 中的 func
 // 然后调 os.Exit() 终止程序
 func Exit() {
-	for _, f := range atExitFuncs {
-		f() // 退出时挨个执行 func
-	}
+	runAtExit()
+	printExitSummary()
 	os.Exit(exitStatus) // 定义在 src/os/proc.go 中，退出时有一个退出码，0 表示 success
 	// os.Exit 函数执行时，程序立刻结束，定义的 defer 函数将不再执行
 }
 
+// runAtExit 按注册的相反顺序执行退出函数，与 defer 相同，后注册的依赖先注册的资源时能正确清理。
+// 每个函数先从列表中取出再执行，因此函数中再次调用 Exit 也不会重复执行；
+// 某个函数 panic 时打印后继续执行剩下的
+func runAtExit() {
+	for {
+		atExitMu.Lock()
+		n := len(atExitFuncs)
+		if n == 0 {
+			atExitMu.Unlock()
+			return
+		}
+		f := atExitFuncs[n-1]
+		atExitFuncs = atExitFuncs[:n-1]
+		atExitMu.Unlock()
+		callAtExit(f)
+	}
+}
+
+func callAtExit(f func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Fprintf(os.Stderr, "go: panic in exit handler: %v\n%s", r, debug.Stack())
+			SetExitReason(ExitPanic, "panic in exit handler")
+		}
+	}()
+	f()
+}
+
 func Fatalf(format string, args ...interface{}) {
 	Errorf(format, args...)
 	Exit()
 }
 
 func Errorf(format string, args ...interface{}) {
-	log.Printf(format, args...)
-	SetExitStatus(1)
+	printLog("error", format, args...)
+	SetExitReason(ExitFailure, "")
 }
 
 func ExitIfErrors() {
@@ -125,6 +193,7 @@ var exitStatus = 0
 var exitMu sync.Mutex
 
 // SetExitStatus 只能 set 比原状态大的值，这是为什么？
+// 新代码应使用 SetExitReason 报告具名的原因，见 exitcode.go
 func SetExitStatus(n int) {
 	exitMu.Lock()
 	if exitStatus < n {
@@ -143,25 +212,21 @@ func GetExitStatus() int {
 // 执行命令
 func Run(cmdargs ...interface{}) {
 	cmdline := str.StringList(cmdargs...) // 获取参数列表，每个参数必须是 []string 或 string
-	if cfg.BuildN || cfg.BuildX { // cfg 是包，一些环境配置信息。这两个是 -n 和 -x 参数，是用在 build 等的 flag
-		fmt.Printf("%s\n", strings.Join(cmdline, " "))　
-		if cfg.BuildN { // 若指定了 -n
-			return
-		}
-	}
-
-	// exec.Command 返回了要执行命令的结构体（Path 和 Args）
-	// Path 是可执行文件的路径和可执行文件本身
-	cmd := exec.Command(cmdline[0], cmdline[1:]...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	// 执行命令。
-	// 这个函数中包含了 Start 和 Wait 两个部分，是很基层的调用，后面可以看一下
-	if err := cmd.Run(); err != nil {
+	// -n 和 -x 的处理交给 Effects：-n 只打印，-x 打印并执行，见 CurrentEffects
+	if err := CurrentEffects().Exec(os.Stdout, os.Stderr, cmdline); err != nil {
 		Errorf("%v", err)
 	}
 }
 
+// RunOutput 与 Run 一样执行命令并处理 -n、-x，但捕获子进程的 stdout 和 stderr 返回给调用方，
+// 失败时返回错误而不是调用 Errorf。指定 -n 时不执行，返回的输出都为空
+func RunOutput(cmdargs ...interface{}) (stdout, stderr []byte, err error) {
+	cmdline := str.StringList(cmdargs...)
+	var outb, errb bytes.Buffer
+	err = CurrentEffects().Exec(&outb, &errb, cmdline)
+	return outb.Bytes(), errb.Bytes(), err
+}
+
 // RunStdin 只是比 Run 多了个与 Stdin 的连接
 func RunStdin(cmdline []string) {
 	cmd := exec.Command(cmdline[0], cmdline[1:]...)
@@ -170,11 +235,52 @@ func RunStdin(cmdline []string) {
 	cmd.Stderr = os.Stderr
 	cmd.Env = cfg.OrigEnv
 	StartSigHandlers() // 它会开一个协程，等待一个信号（os.Signal）发生
-	if err := cmd.Run(); err != nil {
+	if err := CurrentEffects().execCmd(cmd, runForward); err != nil {
 		Errorf("%v", err)
 	}
 }
 
+// runForward 执行 cmd，期间收到的 SIGINT/SIGTERM 转发给子进程，见 forwardSignals
+func runForward(cmd *exec.Cmd) error {
+	prepareForward(cmd)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	stop := forwardSignals(cmd)
+	defer stop()
+	return cmd.Wait()
+}
+
+// RunContext 是 Run 可取消的版本：ctx 结束时杀死子进程并返回 ctx.Err()，
+// 可用于给耗时的外部工具设置超时。与 Run 不同，失败时只返回错误，由调用方决定是否 Errorf
+func RunContext(ctx context.Context, cmdargs ...interface{}) error {
+	cmdline := str.StringList(cmdargs...)
+	cmd := exec.CommandContext(ctx, cmdline[0], cmdline[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return runContext(ctx, cmd)
+}
+
+// RunStdinContext 是 RunStdin 可取消的版本，错误的处理同 RunContext
+func RunStdinContext(ctx context.Context, cmdline []string) error {
+	cmd := exec.CommandContext(ctx, cmdline[0], cmdline[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = cfg.OrigEnv
+	StartSigHandlers()
+	return runContext(ctx, cmd)
+}
+
+// runContext 经 Effects 执行 cmd，子进程因 ctx 结束被杀死时返回 ctx.Err() 而不是 "signal: killed"
+func runContext(ctx context.Context, cmd *exec.Cmd) error {
+	err := CurrentEffects().execCmd(cmd, runForward)
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
 // Usage is the usage-reporting function, filled in by package main
 // but here for reference by other packages.
 var Usage func()
@@ -182,6 +288,7 @@ var Usage func()
 // ExpandScanner expands a scanner.List error into all the errors in the list.
 // The default Error method only shows the first error
 // and does not shorten paths.
+// 文件名按 PathDisplay 的策略显示
 func ExpandScanner(err error) error {
 	// Look for parser errors.
 	if err, ok := err.(scanner.ErrorList); ok {
@@ -192,7 +299,7 @@ func ExpandScanner(err error) error {
 		// instead of just the first, as err.Error does.
 		var buf bytes.Buffer
 		for _, e := range err {
-			e.Pos.Filename = ShortPath(e.Pos.Filename)
+			e.Pos.Filename = DisplayPath(e.Pos.Filename)
 			buf.WriteString("\n")
 			buf.WriteString(e.Error())
 		}
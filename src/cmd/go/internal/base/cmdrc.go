@@ -0,0 +1,125 @@
+package base
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// .gocmdrc 为各命令提供默认的 flag，团队可以把它放在代码树中共享。每行的格式为
+//
+//	命令名: -flag=value -boolflag
+//
+// 如 "build: -trimpath"、"mod tidy: -v"，# 开头的行为注释；与 GOFLAGS 相同，非 bool 的 flag 必须写成 -flag=value。
+//
+// 依次读取用户主目录下的 .gocmdrc，以及从当前目录向上找到的第一个 .gocmdrc，后者覆盖前者。
+// 整体的优先级为 命令行 > GO_<CMD>_<FLAG> 环境变量 > 代码树中的 .gocmdrc > 主目录的 .gocmdrc > GOFLAGS。
+// 设置 GOCMDRC=off 时不读取这些文件
+
+const cmdrcName = ".gocmdrc"
+
+// SetFromCmdRC 用 .gocmdrc 设置 cmd 的 flag，在 SetFromGOFLAGS 之后、SetFromEnv 之前调用
+func SetFromCmdRC(cmd *Command) {
+	if os.Getenv("GOCMDRC") == "off" {
+		return
+	}
+	for _, file := range cmdrcFiles() {
+		if err := applyCmdRC(cmd, file); err != nil {
+			fmt.Fprintf(os.Stderr, "go: %v\n", err)
+			SetExitReason(ExitUsage, "invalid "+cmdrcName)
+			Exit()
+		}
+	}
+}
+
+// cmdrcFiles 按优先级从低到高返回存在的 .gocmdrc
+func cmdrcFiles() []string {
+	var files []string
+	home, _ := os.UserHomeDir()
+	if home != "" {
+		if file := filepath.Join(home, cmdrcName); isFile(file) {
+			files = append(files, file)
+		}
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return files
+	}
+	for {
+		if file := filepath.Join(dir, cmdrcName); isFile(file) {
+			if dir != home { // 主目录下的已经读过
+				files = append(files, file)
+			}
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return files
+}
+
+func isFile(name string) bool {
+	fi, err := os.Stat(name)
+	return err == nil && fi.Mode().IsRegular()
+}
+
+// applyCmdRC 应用 file 中属于 cmd 的行
+func applyCmdRC(cmd *Command, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for line := 1; s.Scan(); line++ {
+		text := strings.TrimSpace(s.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		i := strings.Index(text, ":")
+		if i < 0 {
+			return fmt.Errorf("%s:%d: missing ':' after command name", file, line)
+		}
+		if strings.Join(strings.Fields(text[:i]), " ") != cmd.LongName() {
+			continue
+		}
+		for _, arg := range strings.Fields(text[i+1:]) {
+			if err := setRCFlag(cmd, arg); err != nil {
+				return fmt.Errorf("%s:%d: %v", file, line, err)
+			}
+		}
+	}
+	return s.Err()
+}
+
+// setRCFlag 设置一个 -flag=value 或 -boolflag 形式的 flag
+func setRCFlag(cmd *Command, arg string) error {
+	name := strings.TrimLeft(arg, "-")
+	if name == arg || name == "" {
+		return fmt.Errorf("%q is not a flag", arg)
+	}
+	value, hasValue := "", false
+	if i := strings.Index(name, "="); i >= 0 {
+		name, value, hasValue = name[:i], name[i+1:], true
+	}
+	f := cmd.Flag.Lookup(name)
+	if f == nil {
+		return fmt.Errorf("go %s: unknown flag -%s", cmd.LongName(), name)
+	}
+	if !hasValue {
+		if bf, ok := f.Value.(interface{ IsBoolFlag() bool }); !ok || !bf.IsBoolFlag() {
+			return fmt.Errorf("flag -%s requires a value; use -%s=value", name, name)
+		}
+		value = "true"
+	}
+	if err := cmd.Flag.Set(name, value); err != nil {
+		return fmt.Errorf("invalid value %q for flag -%s: %v", value, name, err)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package base
+
+import (
+	"os"
+)
+
+// NoColor 由 go 的全局 flag -no-color 设置，为 true 时不输出颜色
+var NoColor bool
+
+const (
+	ansiReset  = "\x1b[0m"
+	ansiRed    = "\x1b[31m"
+	ansiYellow = "\x1b[33m"
+	ansiBold   = "\x1b[1m"
+)
+
+// colorEnabled 判断写到 f 的内容是否上色：f 是终端，且没有 -no-color、NO_COLOR 或 TERM=dumb。
+// NO_COLOR 的约定见 https://no-color.org
+func colorEnabled(f *os.File) bool {
+	if NoColor || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return isTerminal(f)
+}
+
+// isTerminal 判断 f 是否是终端（字符设备）
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+func style(f *os.File, code, s string) string {
+	if !colorEnabled(f) {
+		return s
+	}
+	return code + s + ansiReset
+}
+
+// ErrorText 返回写到 f 时显示为错误（红色）的 s
+func ErrorText(f *os.File, s string) string { return style(f, ansiRed, s) }
+
+// WarningText 返回写到 f 时显示为警告（黄色）的 s
+func WarningText(f *os.File, s string) string { return style(f, ansiYellow, s) }
+
+// BoldText 返回写到 f 时加粗显示的 s
+func BoldText(f *os.File, s string) string { return style(f, ansiBold, s) }
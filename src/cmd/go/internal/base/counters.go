@@ -0,0 +1,120 @@
+package base
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// 使用计数只在设置了 GOCOUNTERS=on 时记录，写在本地文件中，不会上传。
+// 维护者可以据此了解哪些命令和 flag 真正在用，再决定是否修改它们
+
+// CountersEnabled 报告是否开启了使用计数
+func CountersEnabled() bool {
+	return os.Getenv("GOCOUNTERS") == "on"
+}
+
+// CountersFile 返回计数文件的路径，默认为用户配置目录下的 go/counters.json，可用 GOCOUNTERSFILE 指定
+func CountersFile() (string, error) {
+	if file := os.Getenv("GOCOUNTERSFILE"); file != "" {
+		return file, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "go", "counters.json"), nil
+}
+
+// Counter 是一项计数，Name 为命令名（如 "mod tidy"）或命令名加 flag（如 "build -race"）
+type Counter struct {
+	Name  string
+	Count int64
+}
+
+// ReadCounters 读取计数文件，按次数从多到少排列；文件不存在时返回空
+func ReadCounters() ([]Counter, error) {
+	m, err := readCounterMap()
+	if err != nil {
+		return nil, err
+	}
+	var list []Counter
+	for name, n := range m {
+		list = append(list, Counter{name, n})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Count != list[j].Count {
+			return list[i].Count > list[j].Count
+		}
+		return list[i].Name < list[j].Name
+	})
+	return list, nil
+}
+
+// ResetCounters 删除计数文件
+func ResetCounters() error {
+	file, err := CountersFile()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func readCounterMap() (map[string]int64, error) {
+	m := make(map[string]int64)
+	file, err := CountersFile()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// recordUsage 在开启计数时记录一次 c 的执行及命令行中出现的 flag（不记录 flag 的值）。
+// 计数只是参考，任何错误都被忽略，不影响命令执行；并发执行的 go 命令可能丢失少量计数
+func (c *Command) recordUsage() {
+	if !CountersEnabled() {
+		return
+	}
+	m, err := readCounterMap()
+	if err != nil {
+		return
+	}
+	name := c.LongName()
+	m[name]++
+	c.Flag.Visit(func(f *flag.Flag) {
+		m[name+" -"+f.Name]++
+	})
+
+	file, err := CountersFile()
+	if err != nil {
+		return
+	}
+	data, err := json.MarshalIndent(m, "", "\t")
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0777); err != nil {
+		return
+	}
+	// 先写临时文件再改名，避免读到写了一半的文件
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, data, 0666); err != nil {
+		return
+	}
+	os.Rename(tmp, file)
+}
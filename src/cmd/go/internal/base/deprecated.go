@@ -0,0 +1,42 @@
+package base
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+// DeprecateFlag 标记 c 的 flag name 已弃用，命令行中使用它时输出 msg 作为迁移提示，
+// 一般在命令包的 init 中调用
+func (c *Command) DeprecateFlag(name, msg string) {
+	if c.Flag.Lookup(name) == nil {
+		panic("base: DeprecateFlag of undefined flag -" + name)
+	}
+	if c.deprecatedFlags == nil {
+		c.deprecatedFlags = make(map[string]string)
+	}
+	c.deprecatedFlags[name] = msg
+}
+
+// checkDeprecated 对已弃用的命令和命令行中出现的已弃用 flag 输出统一格式的警告。
+// 环境变量 GODEPRECATION=error 时改为报错退出，便于在 CI 中找出仍在使用旧用法的地方
+func (c *Command) checkDeprecated() {
+	var warned bool
+	warn := func(what, msg string) {
+		Warnf("go: %s is deprecated: %s", what, msg)
+		warned = true
+	}
+	if c.Deprecated != "" {
+		warn("'go "+c.LongName()+"'", c.Deprecated)
+	}
+	c.Flag.Visit(func(f *flag.Flag) { // Visit 只访问命令行中设置过的 flag
+		if msg, ok := c.deprecatedFlags[f.Name]; ok {
+			warn("'go "+c.LongName()+" -"+f.Name+"'", msg)
+		}
+	})
+	if warned && os.Getenv("GODEPRECATION") == "error" {
+		fmt.Fprintf(os.Stderr, "go: deprecated usage is an error because GODEPRECATION=error\n")
+		SetExitReason(ExitUsage, "deprecated usage")
+		Exit()
+	}
+}
@@ -0,0 +1,173 @@
+package base
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"cmd/go/internal/cfg"
+)
+
+// EffectMode 决定 Effects 如何处理副作用，可以组合
+type EffectMode uint8
+
+const (
+	EffectExecute EffectMode = 1 << iota // 真正执行
+	EffectPrint                          // 以 shell 命令的形式打印，同 -x
+	EffectRecord                         // 记录下来，之后可用 WriteJSON 输出
+)
+
+// Effect 是一次副作用
+type Effect struct {
+	Kind string   // exec、write、remove、mkdir、setenv
+	Args []string `json:",omitempty"` // exec 的命令行
+	Path string   `json:",omitempty"` // 文件操作的路径，setenv 时为变量名
+	Data string   `json:",omitempty"` // write 的内容，setenv 时为值
+}
+
+// Effects 是副作用的统一出口：执行外部命令、写文件、修改环境变量都经过它，
+// 于是所有命令都能得到可信的 dry-run：-n 时只打印，-x 时打印并执行，也可以记录为 JSON 供检查
+type Effects struct {
+	Mode EffectMode
+	Out  io.Writer // 打印的目标，默认 os.Stdout
+
+	mu       sync.Mutex
+	recorded []Effect
+}
+
+var effects *Effects // SetEffects 设置的全局实例
+
+// SetEffects 设置全局的 Effects，之后 CurrentEffects 都返回它；传 nil 恢复为按 -n/-x 决定
+func SetEffects(e *Effects) {
+	effects = e
+}
+
+// CurrentEffects 返回当前的 Effects：设置过 SetEffects 时返回该实例，
+// 否则按 -n（只打印）和 -x（打印并执行）构造
+func CurrentEffects() *Effects {
+	if effects != nil {
+		return effects
+	}
+	mode := EffectExecute
+	if cfg.BuildN {
+		mode = EffectPrint
+	} else if cfg.BuildX {
+		mode |= EffectPrint
+	}
+	return &Effects{Mode: mode}
+}
+
+// DryRun 报告副作用是否不会真正执行
+func (e *Effects) DryRun() bool {
+	return e.Mode&EffectExecute == 0
+}
+
+// note 按 Mode 打印和记录一次副作用
+func (e *Effects) note(eff Effect) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.Mode&EffectRecord != 0 {
+		e.recorded = append(e.recorded, eff)
+	}
+	if e.Mode&EffectPrint != 0 && !Quiet {
+		out := e.Out
+		if out == nil {
+			out = os.Stdout
+		}
+		fmt.Fprintf(out, "%s\n", eff.shell())
+	}
+}
+
+// shell 返回与副作用等价的 shell 命令，用于打印
+func (eff Effect) shell() string {
+	switch eff.Kind {
+	case "exec":
+		return strings.Join(eff.Args, " ")
+	case "write":
+		return fmt.Sprintf("cat >%s << 'EOF' # internal\n%sEOF", eff.Path, eff.Data)
+	case "remove":
+		return "rm -rf " + eff.Path
+	case "mkdir":
+		return "mkdir -p " + eff.Path
+	case "setenv":
+		return "export " + eff.Path + "=" + eff.Data
+	}
+	return eff.Kind
+}
+
+// Exec 执行外部命令，输出连接到 stdout 和 stderr
+func (e *Effects) Exec(stdout, stderr io.Writer, cmdline []string) error {
+	cmd := exec.Command(cmdline[0], cmdline[1:]...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	return e.ExecCmd(cmd)
+}
+
+// ExecCmd 执行调用方构造好的 cmd，用于需要设置 Stdin、Env，
+// 或者用 exec.CommandContext 支持取消的场景；记录和打印的命令行为 cmd.Args
+func (e *Effects) ExecCmd(cmd *exec.Cmd) error {
+	return e.execCmd(cmd, (*exec.Cmd).Run)
+}
+
+// execCmd 同 ExecCmd，但用 run 执行 cmd，如需要转发信号时传入 runForward
+func (e *Effects) execCmd(cmd *exec.Cmd, run func(*exec.Cmd) error) error {
+	e.note(Effect{Kind: "exec", Args: cmd.Args})
+	if e.DryRun() {
+		return nil
+	}
+	return run(cmd)
+}
+
+// WriteFile 写文件，同 os.WriteFile
+func (e *Effects) WriteFile(name string, data []byte, perm os.FileMode) error {
+	e.note(Effect{Kind: "write", Path: name, Data: string(data)})
+	if e.DryRun() {
+		return nil
+	}
+	return os.WriteFile(name, data, perm)
+}
+
+// RemoveAll 删除文件或目录，同 os.RemoveAll
+func (e *Effects) RemoveAll(path string) error {
+	e.note(Effect{Kind: "remove", Path: path})
+	if e.DryRun() {
+		return nil
+	}
+	return os.RemoveAll(path)
+}
+
+// MkdirAll 创建目录，同 os.MkdirAll
+func (e *Effects) MkdirAll(path string, perm os.FileMode) error {
+	e.note(Effect{Kind: "mkdir", Path: path})
+	if e.DryRun() {
+		return nil
+	}
+	return os.MkdirAll(path, perm)
+}
+
+// Setenv 设置环境变量，同 os.Setenv
+func (e *Effects) Setenv(key, value string) error {
+	e.note(Effect{Kind: "setenv", Path: key, Data: value})
+	if e.DryRun() {
+		return nil
+	}
+	return os.Setenv(key, value)
+}
+
+// Recorded 返回 EffectRecord 模式下记录的副作用
+func (e *Effects) Recorded() []Effect {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]Effect(nil), e.recorded...)
+}
+
+// WriteJSON 以 JSON 数组输出记录的副作用
+func (e *Effects) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(e.Recorded())
+}
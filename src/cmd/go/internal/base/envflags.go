@@ -0,0 +1,33 @@
+package base
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// EnvFlagName 返回绑定到命令 cmd 的 flag name 的环境变量名，
+// 如 go build 的 -race 对应 GO_BUILD_RACE，go mod edit 的 -go 对应 GO_MOD_EDIT_GO
+func EnvFlagName(cmd *Command, name string) string {
+	r := strings.NewReplacer(" ", "_", "-", "_", ".", "_")
+	return "GO_" + strings.ToUpper(r.Replace(cmd.LongName())) + "_" + strings.ToUpper(r.Replace(name))
+}
+
+// SetFromEnv 用 GO_<CMD>_<FLAG> 形式的环境变量设置 cmd 的 flag，
+// CI 等环境可以借此配置命令而无需修改调用方式。
+// 在 SetFromGOFLAGS 和 SetFromCmdRC 之后、解析命令行之前调用，得到的优先级为 命令行 > 环境变量 > .gocmdrc > GOFLAGS
+func SetFromEnv(cmd *Command) {
+	cmd.Flag.VisitAll(func(f *flag.Flag) {
+		env := EnvFlagName(cmd, f.Name)
+		v, ok := os.LookupEnv(env)
+		if !ok {
+			return
+		}
+		if err := cmd.Flag.Set(f.Name, v); err != nil {
+			fmt.Fprintf(os.Stderr, "go: invalid value %q for $%s: %v\n", v, env, err)
+			SetExitReason(ExitUsage, "invalid $"+env)
+			Exit()
+		}
+	})
+}
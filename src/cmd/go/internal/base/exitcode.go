@@ -0,0 +1,89 @@
+package base
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ExitReason 是进程非零退出的具名原因，由 RegisterExitReason 登记并映射到退出码。
+// 命令用 SetExitReason 报告原因，而不是直接 SetExitStatus(n)
+type ExitReason int
+
+// 预先登记的退出原因，退出码与以前直接使用的数字保持一致
+var (
+	ExitFailure = RegisterExitReason(1, "failure")        // 一般错误，Errorf 使用
+	ExitBuild   = RegisterExitReason(1, "build failed")   // 编译失败
+	ExitTool    = RegisterExitReason(1, "tool failed")    // 调用的外部工具失败
+	ExitUsage   = RegisterExitReason(2, "usage error")    // 命令或参数用法错误
+	ExitSignal  = RegisterExitReason(130, "interrupted")  // 被信号中断，128+SIGINT
+	ExitPanic   = RegisterExitReason(2, "internal error") // go 命令自身出错，如退出函数 panic
+)
+
+type exitReasonInfo struct {
+	code int
+	name string
+}
+
+var (
+	exitReasonInfos []exitReasonInfo // 下标为 ExitReason
+	exitReported    []string         // 已报告的原因及说明，按报告顺序
+)
+
+// RegisterExitReason 登记一个退出原因，应在包初始化时调用
+func RegisterExitReason(code int, name string) ExitReason {
+	exitMu.Lock()
+	defer exitMu.Unlock()
+	exitReasonInfos = append(exitReasonInfos, exitReasonInfo{code, name})
+	return ExitReason(len(exitReasonInfos) - 1)
+}
+
+// Code 返回 r 对应的退出码
+func (r ExitReason) Code() int {
+	return exitReasonInfos[r].code
+}
+
+func (r ExitReason) String() string {
+	return exitReasonInfos[r].name
+}
+
+// SetExitReason 报告一个退出原因，detail 为可选的具体说明。
+// 退出状态与 SetExitStatus 一样只会变大；所有原因都会记录在退出时的汇总中
+func SetExitReason(r ExitReason, detail string) {
+	msg := r.String()
+	if detail != "" {
+		msg += " (" + detail + ")"
+	}
+	exitMu.Lock()
+	if !contains(exitReported, msg) { // 同样的原因如多次 Errorf 只记一次
+		exitReported = append(exitReported, msg)
+	}
+	exitMu.Unlock()
+	SetExitStatus(r.Code())
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// printExitSummary 在设置了 GOEXITSUMMARY=1 且退出状态非零时，输出一行说明为何以该状态退出
+func printExitSummary() {
+	if os.Getenv("GOEXITSUMMARY") != "1" {
+		return
+	}
+	exitMu.Lock()
+	defer exitMu.Unlock()
+	if exitStatus == 0 {
+		return
+	}
+	why := "unspecified failure"
+	if len(exitReported) > 0 {
+		why = strings.Join(exitReported, "; ")
+	}
+	fmt.Fprintf(os.Stderr, "go: exit status %d: %s\n", exitStatus, why)
+}
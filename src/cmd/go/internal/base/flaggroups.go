@@ -0,0 +1,64 @@
+package base
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// MarkFlagRequired 声明命令行中必须给出 c 的这些 flag，Execute 会在 Run 之前检查
+func (c *Command) MarkFlagRequired(names ...string) {
+	c.mustHaveFlags("MarkFlagRequired", names)
+	c.requiredFlags = append(c.requiredFlags, names...)
+}
+
+// MarkFlagsMutuallyExclusive 声明这些 flag 最多只能给出一个，Execute 会在 Run 之前检查
+func (c *Command) MarkFlagsMutuallyExclusive(names ...string) {
+	c.mustHaveFlags("MarkFlagsMutuallyExclusive", names)
+	c.exclusiveFlags = append(c.exclusiveFlags, names)
+}
+
+// mustHaveFlags 在声明引用了未定义的 flag 时 panic，这是命令实现的错误
+func (c *Command) mustHaveFlags(fn string, names []string) {
+	for _, name := range names {
+		if c.Flag.Lookup(name) == nil {
+			panic(fmt.Sprintf("base: %s of undefined flag -%s for go %s", fn, name, c.LongName()))
+		}
+	}
+}
+
+// checkFlagGroups 检查 MarkFlagRequired 和 MarkFlagsMutuallyExclusive 的声明，
+// 不满足时报告错误并按用法错误退出。CustomFlags 的命令自行解析 flag，不做检查
+func (c *Command) checkFlagGroups() {
+	if c.CustomFlags {
+		return
+	}
+	set := make(map[string]bool)
+	c.Flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	var errs []string
+	for _, name := range c.requiredFlags {
+		if !set[name] {
+			errs = append(errs, fmt.Sprintf("flag -%s is required", name))
+		}
+	}
+	for _, group := range c.exclusiveFlags {
+		var given []string
+		for _, name := range group {
+			if set[name] {
+				given = append(given, "-"+name)
+			}
+		}
+		if len(given) > 1 {
+			errs = append(errs, fmt.Sprintf("flags %s are mutually exclusive", strings.Join(given, " and ")))
+		}
+	}
+	if len(errs) == 0 {
+		return
+	}
+	for _, e := range errs {
+		fmt.Fprintf(os.Stderr, "go %s: %s\n", c.LongName(), e)
+	}
+	c.Usage()
+}
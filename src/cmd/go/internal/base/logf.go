@@ -0,0 +1,97 @@
+package base
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"cmd/go/internal/cfg"
+)
+
+// Verbosity 是全局的输出详细程度，每出现一次 go 之后的 -v 加一，如 go -v -v build
+var Verbosity int
+
+// LogFormat 是日志格式，由 --log-format 设置：text（默认）或 json，
+// json 时每条日志输出为一行 JSON，便于其他程序解析
+var LogFormat = "text"
+
+// verbosityFlag 实现可重复的 -v
+type verbosityFlag struct{}
+
+func (verbosityFlag) IsBoolFlag() bool { return true }
+func (verbosityFlag) String() string   { return strconv.Itoa(Verbosity) }
+
+func (verbosityFlag) Set(s string) error {
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	if v {
+		Verbosity++
+	} else {
+		Verbosity = 0
+	}
+	return nil
+}
+
+type logFormatFlag struct{}
+
+func (logFormatFlag) String() string { return LogFormat }
+
+func (logFormatFlag) Set(s string) error {
+	if s != "text" && s != "json" {
+		return fmt.Errorf("unknown log format %q; want text or json", s)
+	}
+	LogFormat = s
+	return nil
+}
+
+// AddLogFlags 在 fs 上定义 -v 和 -log-format，由 main 加到 go 命令自身的 flag 上
+func AddLogFlags(fs *flag.FlagSet) {
+	fs.Var(verbosityFlag{}, "v", "increase verbosity; may be repeated")
+	fs.Var(logFormatFlag{}, "log-format", "log `format`: text or json")
+}
+
+// Logf 在 Verbosity >= 1 时输出一条信息，Quiet 优先于 -v
+func Logf(format string, args ...interface{}) {
+	if Verbosity >= 1 && !Quiet {
+		printLog("info", format, args...)
+	}
+}
+
+// Debugf 在 Verbosity >= 2 时输出一条调试信息，Quiet 时同样省略
+func Debugf(format string, args ...interface{}) {
+	if Verbosity >= 2 && !Quiet {
+		printLog("debug", format, args...)
+	}
+}
+
+// logRecord 是 json 格式下的一条日志
+type logRecord struct {
+	Time  time.Time
+	Level string
+	Cmd   string `json:",omitempty"`
+	Msg   string
+}
+
+// printLog 按 LogFormat 输出一条日志到 stderr，Errorf 也经由这里输出
+func printLog(level, format string, args ...interface{}) {
+	msg := PathDisplay.Text(fmt.Sprintf(format, args...))
+	if LogFormat != "json" {
+		if level == "error" {
+			msg = ErrorText(os.Stderr, msg)
+		}
+		log.Print(msg)
+		return
+	}
+	b, err := json.Marshal(logRecord{Time: time.Now(), Level: level, Cmd: cfg.CmdName, Msg: msg})
+	if err != nil {
+		log.Print(msg)
+		return
+	}
+	os.Stderr.Write(append(b, '\n'))
+}
@@ -0,0 +1,91 @@
+package base
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+)
+
+// RunParallel 最多同时执行 max 个外部命令（max <= 0 时不限制），
+// 每个命令的输出按行加上 "[序号 命令名] " 前缀写到 stdout/stderr，不同命令的行不会交错在同一行内。
+// 失败的命令各自通过 Errorf 报告，因此退出状态是所有命令结果的汇总；所有命令结束后返回
+func RunParallel(jobs [][]string, max int) {
+	if max <= 0 || max > len(jobs) {
+		max = len(jobs)
+	}
+	e := CurrentEffects()
+	if e.DryRun() { // 不执行时按顺序打印，不必启动协程
+		for _, job := range jobs {
+			e.ExecCmd(exec.Command(job[0], job[1:]...))
+		}
+		return
+	}
+
+	var (
+		mu  sync.Mutex // 保证一次只写一整行
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, max)
+	)
+	for i, job := range jobs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, job []string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			prefix := fmt.Sprintf("[%d %s] ", i, job[0])
+			stdout := &prefixWriter{mu: &mu, w: os.Stdout, prefix: prefix}
+			stderr := &prefixWriter{mu: &mu, w: os.Stderr, prefix: prefix}
+			cmd := exec.Command(job[0], job[1:]...)
+			cmd.Stdout = stdout
+			cmd.Stderr = stderr
+			err := e.ExecCmd(cmd)
+			stdout.Flush()
+			stderr.Flush()
+			if err != nil {
+				Errorf("%s%v", prefix, err)
+			}
+		}(i, job)
+	}
+	wg.Wait()
+}
+
+// prefixWriter 缓存不完整的行，每凑满一行就加上前缀在 mu 保护下写出
+type prefixWriter struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	prefix string
+	buf    []byte
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.buf = append(p.buf, b...)
+	for {
+		i := bytes.IndexByte(p.buf, '\n')
+		if i < 0 {
+			break
+		}
+		p.writeLine(p.buf[:i+1])
+		p.buf = p.buf[i+1:]
+	}
+	return len(b), nil
+}
+
+// Flush 写出末尾没有换行的剩余内容
+func (p *prefixWriter) Flush() {
+	if len(p.buf) > 0 {
+		p.writeLine(append(p.buf, '\n'))
+		p.buf = nil
+	}
+}
+
+func (p *prefixWriter) writeLine(line []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	io.WriteString(p.w, p.prefix)
+	p.w.Write(line)
+}
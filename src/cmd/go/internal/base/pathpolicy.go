@@ -0,0 +1,126 @@
+package base
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// PathPolicy 决定错误和日志中的路径如何显示
+type PathPolicy struct {
+	// Base 不为空时，位于其下（或相对路径更短）的路径显示为相对于 Base 的路径，
+	// 一般为当前目录，也可以设为模块根目录；为空时显示绝对路径
+	Base string
+
+	// Home 为 true 时，用户主目录下的路径显示为 ~/...
+	Home bool
+
+	// Resolve 为 true 时先解析符号链接，显示真实路径；否则保留原样
+	Resolve bool
+}
+
+// PathDisplay 是 base 输出错误和日志时使用的路径显示策略，由环境变量 GOPATHDISPLAY 初始化，
+// 其值为逗号分隔的选项：
+//
+//	cwd      相对于当前目录显示（默认，与以前的 ShortPath 相同）
+//	abs      显示绝对路径
+//	home     主目录显示为 ~
+//	resolve  解析符号链接
+//
+// 需要相对于模块根目录显示时，由找到模块根目录的代码设置 PathDisplay.Base
+var PathDisplay = pathPolicyFromEnv(os.Getenv("GOPATHDISPLAY"))
+
+func pathPolicyFromEnv(env string) *PathPolicy {
+	p := new(PathPolicy)
+	if wd, err := os.Getwd(); err == nil {
+		p.Base = wd
+	}
+	for _, opt := range strings.Split(env, ",") {
+		switch strings.TrimSpace(opt) {
+		case "abs":
+			p.Base = ""
+		case "home":
+			p.Home = true
+		case "resolve":
+			p.Resolve = true
+		}
+	}
+	return p
+}
+
+// Path 按策略返回 path 的显示形式
+func (p *PathPolicy) Path(path string) string {
+	if p.Resolve {
+		if real, err := filepath.EvalSymlinks(path); err == nil {
+			path = real
+		}
+	}
+	if p.Base != "" && filepath.IsAbs(path) {
+		if rel, err := filepath.Rel(p.Base, path); err == nil && len(rel) < len(path) {
+			return rel
+		}
+	}
+	if p.Home {
+		if home := homeDir(); home != "" && strings.HasPrefix(path, home+string(filepath.Separator)) {
+			return "~" + path[len(home):]
+		}
+	}
+	return path
+}
+
+// textRewrite 缓存 Text 使用的正则表达式，键为 Base 和主目录
+var textRewrite struct {
+	sync.Mutex
+	key string
+	re  *regexp.Regexp
+}
+
+// Text 改写自由文本（如一行日志）中以 Base 或主目录开头的绝对路径，
+// 只改写出现在行首或空白、引号、括号、冒号、等号之后的路径，避免误改路径中间的片段
+func (p *PathPolicy) Text(s string) string {
+	home := ""
+	if p.Home {
+		home = homeDir()
+	}
+	if p.Base == "" && home == "" {
+		return s
+	}
+	sep := regexp.QuoteMeta(string(filepath.Separator))
+	key := p.Base + "\x00" + home
+	textRewrite.Lock()
+	if textRewrite.key != key || textRewrite.re == nil {
+		var alts []string
+		if p.Base != "" {
+			alts = append(alts, regexp.QuoteMeta(p.Base)+sep)
+		}
+		if home != "" {
+			alts = append(alts, regexp.QuoteMeta(home)+sep)
+		}
+		textRewrite.key = key
+		textRewrite.re = regexp.MustCompile(`(^|[\s"'(:=])((?:` + strings.Join(alts, "|") + `)[^\s"'):]*)`)
+	}
+	re := textRewrite.re
+	textRewrite.Unlock()
+
+	return re.ReplaceAllStringFunc(s, func(m string) string {
+		sub := re.FindStringSubmatch(m)
+		return sub[1] + p.Path(sub[2])
+	})
+}
+
+// DisplayPath 按 PathDisplay 返回 path 的显示形式
+func DisplayPath(path string) string {
+	return PathDisplay.Path(path)
+}
+
+var (
+	homeOnce sync.Once
+	home     string
+)
+
+func homeDir() string {
+	homeOnce.Do(func() { home, _ = os.UserHomeDir() })
+	return home
+}
@@ -0,0 +1,62 @@
+package base
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+)
+
+// JSONOutput 由 go 的全局 flag -json 设置，为 true 时 NewPrinter 返回输出 JSON 的 Printer，
+// 命令无需各自定义 -json 和编码方式就能提供结构化的输出
+var JSONOutput bool
+
+// AddOutputFlags 在 fs 上定义 -json、-no-color 和 -q/-quiet，由 main 加到 go 命令自身的 flag 上
+func AddOutputFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&JSONOutput, "json", false, "print command results as JSON")
+	fs.BoolVar(&NoColor, "no-color", false, "disable colored output")
+	fs.BoolVar(&Quiet, "q", false, "suppress all non-error output")
+	fs.BoolVar(&Quiet, "quiet", false, "suppress all non-error output")
+}
+
+// Printer 输出命令的结果，每次 Print 输出一个结果
+type Printer interface {
+	Print(v interface{}) error
+	// Flush 写出缓存的内容，命令结束前需要调用
+	Flush() error
+}
+
+// NewPrinter 返回写到 w 的 Printer：JSONOutput 时每个结果编码为一个缩进的 JSON 值，
+// 与 go list -json 相同，结果之间不加分隔；否则调用 text 以命令自己的文本格式输出
+func NewPrinter(w io.Writer, text func(w io.Writer, v interface{}) error) Printer {
+	bw := bufio.NewWriter(w)
+	if JSONOutput {
+		enc := json.NewEncoder(bw)
+		enc.SetIndent("", "\t")
+		return &jsonPrinter{w: bw, enc: enc}
+	}
+	if text == nil {
+		text = func(w io.Writer, v interface{}) error {
+			_, err := fmt.Fprintln(w, v)
+			return err
+		}
+	}
+	return &textPrinter{w: bw, text: text}
+}
+
+type jsonPrinter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func (p *jsonPrinter) Print(v interface{}) error { return p.enc.Encode(v) }
+func (p *jsonPrinter) Flush() error              { return p.w.Flush() }
+
+type textPrinter struct {
+	w    *bufio.Writer
+	text func(w io.Writer, v interface{}) error
+}
+
+func (p *textPrinter) Print(v interface{}) error { return p.text(p.w, v) }
+func (p *textPrinter) Flush() error              { return p.w.Flush() }
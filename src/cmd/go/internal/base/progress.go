@@ -0,0 +1,158 @@
+package base
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Progress 报告耗时操作的进度：stderr 是终端时原地刷新一行进度条（总量未知时为转圈），并给出预计剩余时间；
+// 否则退化为普通的日志行，只在阶段变化时及每隔 progressLogInterval 输出一次。
+// 方法可在多个协程中调用，结束时必须调用 Done
+type Progress struct {
+	mu      sync.Mutex
+	name    string
+	phase   string
+	total   int64 // <= 0 表示总量未知
+	done    int64
+	start   time.Time
+	lastLog time.Time
+	tty     bool
+	quiet   bool // Quiet 时不输出任何内容
+	frame   int
+	once    sync.Once // 保证 Done 只生效一次
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+const (
+	progressRedraw      = 100 * time.Millisecond
+	progressLogInterval = 10 * time.Second
+	progressBarWidth    = 30
+)
+
+var spinnerFrames = []string{"|", "/", "-", "\\"}
+
+// NewProgress 开始报告名为 name 的操作的进度，total 为工作总量，未知时传 0
+func NewProgress(name string, total int64) *Progress {
+	p := &Progress{
+		name:    name,
+		total:   total,
+		start:   time.Now(),
+		tty:     isTerminal(os.Stderr) && !Quiet,
+		quiet:   Quiet,
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	if p.tty {
+		go p.redraw()
+	} else {
+		close(p.stopped)
+		p.logLine()
+	}
+	return p
+}
+
+// Phase 设置当前阶段，如 "downloading"、"extracting"
+func (p *Progress) Phase(phase string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.phase = phase
+	if !p.tty {
+		p.logLineLocked()
+	}
+}
+
+// Add 记录又完成了 n 个单位的工作
+func (p *Progress) Add(n int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done += n
+	if !p.tty && time.Since(p.lastLog) >= progressLogInterval {
+		p.logLineLocked()
+	}
+}
+
+// Done 结束进度报告并输出总耗时，重复调用无效
+func (p *Progress) Done() {
+	p.once.Do(func() {
+		close(p.stop)
+		<-p.stopped
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.quiet {
+			return
+		}
+		if p.tty {
+			fmt.Fprintf(os.Stderr, "\r\x1b[K")
+		}
+		fmt.Fprintf(os.Stderr, "go: %s done in %v\n", p.name, time.Since(p.start).Round(time.Millisecond))
+	})
+}
+
+func (p *Progress) redraw() {
+	defer close(p.stopped)
+	t := time.NewTicker(progressRedraw)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			p.mu.Lock()
+			fmt.Fprintf(os.Stderr, "\r\x1b[K%s", p.lineLocked())
+			p.frame++
+			p.mu.Unlock()
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+func (p *Progress) logLine() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.logLineLocked()
+}
+
+func (p *Progress) logLineLocked() {
+	p.lastLog = time.Now()
+	if p.quiet {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "go: %s\n", p.lineLocked())
+}
+
+// lineLocked 生成一行进度，调用方需持有 mu
+func (p *Progress) lineLocked() string {
+	var b strings.Builder
+	b.WriteString(p.name)
+	if p.phase != "" {
+		b.WriteString(": " + p.phase)
+	}
+	if p.total <= 0 {
+		if p.tty {
+			b.WriteString(" " + spinnerFrames[p.frame%len(spinnerFrames)])
+		}
+		if p.done > 0 {
+			fmt.Fprintf(&b, " (%d)", p.done)
+		}
+		return b.String()
+	}
+
+	frac := float64(p.done) / float64(p.total)
+	if frac > 1 {
+		frac = 1
+	}
+	if p.tty {
+		n := int(frac * progressBarWidth)
+		fmt.Fprintf(&b, " [%s%s]", strings.Repeat("=", n), strings.Repeat(" ", progressBarWidth-n))
+	}
+	fmt.Fprintf(&b, " %d/%d %3.0f%%", p.done, p.total, frac*100)
+	if p.done > 0 && frac < 1 { // 按目前的平均速度估计剩余时间
+		elapsed := time.Since(p.start)
+		eta := time.Duration(float64(elapsed) * (1 - frac) / frac)
+		fmt.Fprintf(&b, " ETA %v", eta.Round(time.Second))
+	}
+	return b.String()
+}
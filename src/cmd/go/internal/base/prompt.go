@@ -0,0 +1,72 @@
+package base
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// AssumeYes 由 go 的全局 flag -yes 设置，为 true 时 Confirm 不再询问而直接确认
+var AssumeYes bool
+
+// AddPromptFlags 在 fs 上定义 -yes，由 main 加到 go 命令自身的 flag 上
+func AddPromptFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&AssumeYes, "yes", false, "assume yes for all confirmation prompts")
+}
+
+var (
+	stdinOnce   sync.Once
+	stdinReader *bufio.Reader
+)
+
+// readLine 从标准输入读取一行，多次调用共用同一个缓冲
+func readLine() (string, error) {
+	stdinOnce.Do(func() { stdinReader = bufio.NewReader(os.Stdin) })
+	line, err := stdinReader.ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// Confirm 在 stderr 输出 prompt 并等待用户输入 y 或 yes，有破坏性的命令统一用它请求确认。
+// 设置了 -yes 时直接返回 true；标准输入不是终端时无法询问，提示使用 -yes 并返回 false
+func Confirm(prompt string) bool {
+	if AssumeYes {
+		return true
+	}
+	if !isTerminal(os.Stdin) {
+		fmt.Fprintf(os.Stderr, "go: %s: confirmation required; rerun with 'go -yes' to proceed\n", prompt)
+		return false
+	}
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", prompt)
+	answer, err := readLine()
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(answer) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// Prompt 在 stderr 输出 prompt 并读取用户的输入，输入为空或标准输入不是终端时返回 def
+func Prompt(prompt, def string) string {
+	if !isTerminal(os.Stdin) {
+		return def
+	}
+	if def != "" {
+		fmt.Fprintf(os.Stderr, "%s [%s]: ", prompt, def)
+	} else {
+		fmt.Fprintf(os.Stderr, "%s: ", prompt)
+	}
+	answer, err := readLine()
+	if err != nil || answer == "" {
+		return def
+	}
+	return answer
+}
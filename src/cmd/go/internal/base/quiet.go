@@ -0,0 +1,18 @@
+package base
+
+import (
+	"fmt"
+	"os"
+)
+
+// Quiet 由 go 的全局 flag -q 或 -quiet 设置，为 true 时不输出任何非错误信息：
+// -x 回显的命令、进度、警告和 Logf 的信息都被省略，便于在脚本和 CI 中使用
+var Quiet bool
+
+// Warnf 输出一条警告，Quiet 时省略
+func Warnf(format string, args ...interface{}) {
+	if Quiet {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "%s\n", WarningText(os.Stderr, PathDisplay.Text(fmt.Sprintf(format, args...))))
+}
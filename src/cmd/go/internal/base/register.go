@@ -0,0 +1,82 @@
+package base
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// registerMu 保护命令树的修改
+var registerMu sync.Mutex
+
+// Lookup 返回完整名称为 name 的命令，如 "mod tidy"，空字符串为 Go 本身；找不到时返回 nil
+func Lookup(name string) *Command {
+	registerMu.Lock()
+	defer registerMu.Unlock()
+	return lookup(name)
+}
+
+func lookup(name string) *Command {
+	cmd := Go
+	for _, elem := range strings.Fields(name) {
+		var found *Command
+		for _, sub := range cmd.Commands {
+			if sub.Name() == elem {
+				found = sub
+				break
+			}
+		}
+		if found == nil {
+			return nil
+		}
+		cmd = found
+	}
+	return cmd
+}
+
+// Register 把 cmd 添加为 parent 的子命令，parent 为完整名称，空字符串表示 go 本身。
+// 子命令按注册的顺序出现在 go help 中，需要插到某个命令之前时使用 RegisterBefore。
+// 嵌入这个命令框架的程序可以在自己的 init 中注册命令，而无需修改 main
+func Register(parent string, cmd *Command) error {
+	return RegisterBefore(parent, "", cmd)
+}
+
+// RegisterBefore 与 Register 相同，但把 cmd 插到名为 before 的子命令之前，before 为空时追加到最后。
+// parent 不存在、已有同名子命令或 before 不存在时返回错误，命令树不变
+func RegisterBefore(parent, before string, cmd *Command) error {
+	registerMu.Lock()
+	defer registerMu.Unlock()
+
+	p := lookup(parent)
+	if p == nil {
+		return fmt.Errorf("register go %s: parent command %q not found", cmd.LongName(), parent)
+	}
+	if want := strings.TrimSpace(parent + " " + cmd.Name()); cmd.LongName() != want {
+		return fmt.Errorf("register go %s: UsageLine does not match parent %q", cmd.LongName(), parent)
+	}
+	at := len(p.Commands)
+	for i, sub := range p.Commands {
+		if sub.Name() == cmd.Name() {
+			return fmt.Errorf("register go %s: command already registered", cmd.LongName())
+		}
+		if sub.Name() == before {
+			at = i
+		}
+	}
+	if before != "" && at == len(p.Commands) {
+		return fmt.Errorf("register go %s: command %q not found in go %s", cmd.LongName(), before, parent)
+	}
+	p.Commands = append(p.Commands, nil)
+	copy(p.Commands[at+1:], p.Commands[at:])
+	p.Commands[at] = cmd
+	return nil
+}
+
+// MustRegister 与 Register 相同，但出错时 panic，用于 init 中注册内置命令
+func MustRegister(parent string, cmds ...*Command) {
+	for _, cmd := range cmds {
+		if err := Register(parent, cmd); err != nil {
+			panic(err)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package base
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+
+	"cmd/go/internal/str"
+)
+
+// Retry 配置 RunRetry 的重试策略，零值表示使用默认值
+type Retry struct {
+	Attempts int           // 最多执行的次数，默认 3
+	Initial  time.Duration // 第一次重试前的等待时间，之后每次翻倍，默认 1s
+	Max      time.Duration // 等待时间的上限，默认 30s
+
+	// Transient 判断一次失败是否是暂时性的、值得重试，stderr 为这次执行的错误输出。
+	// 默认为 TransientError
+	Transient func(err error, stderr []byte) bool
+}
+
+// transientMessages 是网络类暂时性错误常见的输出片段
+var transientMessages = []string{
+	"connection reset",
+	"connection refused",
+	"i/o timeout",
+	"TLS handshake timeout",
+	"temporary failure",
+	"unexpected EOF",
+	"502 Bad Gateway",
+	"503 Service Unavailable",
+	"504 Gateway Timeout",
+}
+
+// TransientError 是默认的失败分类：命令没能启动（如可执行文件不存在）时不重试，
+// 退出失败且错误输出中含有常见的网络暂时性错误时重试
+func TransientError(err error, stderr []byte) bool {
+	if _, ok := err.(*exec.ExitError); !ok {
+		return false
+	}
+	for _, msg := range transientMessages {
+		if bytes.Contains(stderr, []byte(msg)) {
+			return true
+		}
+	}
+	return false
+}
+
+// RunRetry 与 Run 相同，但失败被 r.Transient 判为暂时性时按指数退避重试，
+// 用于 get、下载模块等访问网络的外部工具。最后一次失败通过 Errorf 报告
+func RunRetry(r Retry, cmdargs ...interface{}) {
+	if r.Attempts <= 0 {
+		r.Attempts = 3
+	}
+	if r.Initial <= 0 {
+		r.Initial = time.Second
+	}
+	if r.Max <= 0 {
+		r.Max = 30 * time.Second
+	}
+	if r.Transient == nil {
+		r.Transient = TransientError
+	}
+
+	cmdline := str.StringList(cmdargs...)
+	e := CurrentEffects()
+	delay := r.Initial
+	for attempt := 1; ; attempt++ {
+		var errb bytes.Buffer // 错误输出照常显示，同时留一份给 Transient 判断
+		cmd := exec.Command(cmdline[0], cmdline[1:]...)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = io.MultiWriter(os.Stderr, &errb)
+		err := e.ExecCmd(cmd) // 每次尝试都经过 Effects，-x 时每次重试都会打印
+		if err == nil {
+			return
+		}
+		if attempt >= r.Attempts || !r.Transient(err, errb.Bytes()) {
+			Errorf("%v", err)
+			return
+		}
+		Warnf("go: %s failed (%v), retrying in %v (attempt %d/%d)", cmdline[0], err, delay, attempt+1, r.Attempts)
+		time.Sleep(delay)
+		if delay *= 2; delay > r.Max {
+			delay = r.Max
+		}
+	}
+}
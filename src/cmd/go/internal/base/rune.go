@@ -0,0 +1,51 @@
+package base
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+)
+
+// ReasonError 为错误附上退出原因，RunE 返回它时进程按该原因退出，否则按 ExitFailure
+type ReasonError struct {
+	Reason ExitReason
+	Err    error
+}
+
+func (e *ReasonError) Error() string { return e.Err.Error() }
+func (e *ReasonError) Unwrap() error { return e.Err }
+
+// ExecuteE 执行命令并返回 RunE 的错误而不退出进程，便于在测试中调用命令。
+// 只设置了 Run 的命令总是返回 nil；PostRun 在 RunE 返回后总会调用
+func (c *Command) ExecuteE(args []string) error {
+	if c.PreRun != nil {
+		c.PreRun(c, args)
+	}
+	var err error
+	if c.RunE != nil {
+		err = c.RunE(c, args)
+	} else {
+		c.Run(c, args)
+	}
+	if c.PostRun != nil {
+		c.PostRun(c, args)
+	}
+	return err
+}
+
+// handleError 报告 RunE 返回的错误：-json 时在 stdout 输出 {"Error": ...}，否则按 Errorf 的格式输出，
+// 并按错误的退出原因设置退出状态
+func (c *Command) handleError(err error) {
+	if JSONOutput {
+		b, _ := json.Marshal(struct{ Error string }{err.Error()})
+		os.Stdout.Write(append(b, '\n'))
+	} else {
+		printLog("error", "go %s: %v", c.LongName(), err)
+	}
+	reason := ExitFailure
+	var re *ReasonError
+	if errors.As(err, &re) {
+		reason = re.Reason
+	}
+	SetExitReason(reason, "")
+}
@@ -0,0 +1,19 @@
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !aix,!darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package base
+
+import (
+	"os/exec"
+	"time"
+)
+
+// SignalGrace 在不支持进程组的平台上不起作用
+var SignalGrace = 5 * time.Second
+
+func prepareForward(cmd *exec.Cmd) {}
+
+// forwardSignals 在这些平台上不转发信号，子进程与父进程一同收到控制台的中断
+func forwardSignals(cmd *exec.Cmd) (stop func()) {
+	return func() {}
+}
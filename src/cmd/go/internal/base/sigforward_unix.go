@@ -0,0 +1,66 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package base
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// SignalGrace 是转发 SIGINT/SIGTERM 之后等待子进程自行退出的时间，超时后发送 SIGKILL
+var SignalGrace = 5 * time.Second
+
+// prepareForward 在启动子进程前调用。标准输入不是终端时让子进程自成一个进程组，
+// 以便把信号转发给它派生的所有进程；交互运行时保持在前台进程组，
+// 否则子进程读终端会收到 SIGTTIN，而终端产生的信号本来就会送到整个前台进程组
+func prepareForward(cmd *exec.Cmd) {
+	if isTerminal(os.Stdin) {
+		return
+	}
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = new(syscall.SysProcAttr)
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// forwardSignals 在子进程启动后调用：把收到的 SIGINT/SIGTERM 转发给子进程（组），
+// 超过 SignalGrace 仍未退出时发送 SIGKILL，避免父进程先退出而留下孤儿进程。
+// 子进程留在前台进程组时，终端产生的 SIGINT 已经直接送到了它，只转发 SIGTERM，
+// 否则子进程会收到两次 SIGINT；SIGINT 仍然开始 SIGKILL 的计时。
+// 返回的 stop 在子进程结束后调用
+func forwardSignals(cmd *exec.Cmd) (stop func()) {
+	pid := cmd.Process.Pid
+	group := cmd.SysProcAttr != nil && cmd.SysProcAttr.Setpgid
+	if group {
+		pid = -pid // 负数表示整个进程组
+	}
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		var kill <-chan time.Time
+		for {
+			select {
+			case s := <-sig:
+				if group || s != syscall.SIGINT {
+					syscall.Kill(pid, s.(syscall.Signal))
+				}
+				if kill == nil {
+					kill = time.After(SignalGrace)
+				}
+			case <-kill:
+				syscall.Kill(pid, syscall.SIGKILL)
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(sig)
+		close(done)
+	}
+}
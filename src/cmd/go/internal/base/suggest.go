@@ -0,0 +1,73 @@
+package base
+
+import "sort"
+
+// Suggest 返回 c 的子命令中与 name 编辑距离最近的名字，用于输出 "did you mean" 提示
+// 只返回距离不超过 2（名字很短时为 1）的候选，按距离和名字排序；帮助主题也会参与比较
+func (c *Command) Suggest(name string) []string {
+	max := 2
+	if len(name) <= 3 {
+		max = 1
+	}
+	type cand struct {
+		name string
+		dist int
+	}
+	var cands []cand
+	for _, sub := range c.Commands {
+		if sub.Hidden {
+			continue
+		}
+		if d := editDistance(name, sub.Name()); d <= max {
+			cands = append(cands, cand{sub.Name(), d})
+		}
+	}
+	sort.Slice(cands, func(i, j int) bool {
+		if cands[i].dist != cands[j].dist {
+			return cands[i].dist < cands[j].dist
+		}
+		return cands[i].name < cands[j].name
+	})
+	names := make([]string, len(cands))
+	for i, cd := range cands {
+		names[i] = cd.name
+	}
+	return names
+}
+
+// editDistance 计算 a、b 之间的 Damerau-Levenshtein 距离（相邻字符交换算一次编辑），
+// 这样 buidl 与 build 的距离为 1
+func editDistance(a, b string) int {
+	// d[i][j] 为 a[:i] 与 b[:j] 的距离
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := 0; j <= len(b); j++ {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min3(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] && d[i-2][j-2]+1 < d[i][j] {
+				d[i][j] = d[i-2][j-2] + 1
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
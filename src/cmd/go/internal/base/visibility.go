@@ -0,0 +1,31 @@
+package base
+
+import (
+	"fmt"
+	"os"
+)
+
+// Visible 返回 c 的浅拷贝，其中去掉了 Hidden 的子命令，用于输出帮助列表
+func (c *Command) Visible() *Command {
+	v := *c
+	v.Commands = nil
+	for _, sub := range c.Commands {
+		if !sub.Hidden {
+			v.Commands = append(v.Commands, sub)
+		}
+	}
+	return &v
+}
+
+// checkExperimental 要求实验性命令必须设置 GOEXPERIMENTALCMD=1 才能执行，并在执行时提示其不稳定
+func (c *Command) checkExperimental() {
+	if !c.Experimental {
+		return
+	}
+	if os.Getenv("GOEXPERIMENTALCMD") != "1" {
+		fmt.Fprintf(os.Stderr, "go %s: command is experimental; set GOEXPERIMENTALCMD=1 to use it\n", c.LongName())
+		SetExitReason(ExitUsage, "experimental command")
+		Exit()
+	}
+	Warnf("go: 'go %s' is experimental; its behavior and flags may change", c.LongName())
+}
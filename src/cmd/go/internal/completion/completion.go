@@ -0,0 +1,166 @@
+// completion 包实现了隐藏的 go completion 命令，
+// 根据 base.Go 的命令树和各命令的 FlagSet 生成 shell 补全脚本，新增的命令和 flag 无需手工维护补全
+package completion
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"cmd/go/internal/base"
+)
+
+var CmdCompletion = &base.Command{
+	UsageLine: "go completion [bash|zsh|fish]",
+	Short:     "generate shell completion script",
+	Long: `
+Completion prints a shell completion script for the go command to standard output.
+The script completes commands, subcommands, help topics and flags.
+
+To load completions for the current bash session:
+
+	source <(go completion bash)
+
+For zsh, the bash script is loaded through bashcompinit.
+For fish:
+
+	go completion fish | source
+	`,
+	Hidden: true,
+}
+
+// 在 init 中赋值 Run，避免 runCompletion 引用 base.Go 造成初始化循环
+func init() {
+	CmdCompletion.Run = runCompletion
+}
+
+func runCompletion(cmd *base.Command, args []string) {
+	if len(args) != 1 {
+		cmd.Usage()
+	}
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+	switch args[0] {
+	case "bash":
+		writeBash(w, base.Go)
+	case "zsh":
+		fmt.Fprintf(w, "autoload -U +X bashcompinit && bashcompinit\n")
+		writeBash(w, base.Go)
+	case "fish":
+		writeFish(w, base.Go)
+	default:
+		base.Fatalf("go completion: unknown shell %q; want bash, zsh or fish", args[0])
+	}
+}
+
+// node 是命令树上的一个节点，path 为从 go 开始的完整命令，如 "go mod edit"
+type node struct {
+	path  string
+	cmd   *base.Command
+	subs  []*base.Command // 可执行的子命令或含子命令的命令
+	flags []string        // 不含前缀 - 的 flag 名
+}
+
+// walk 先序遍历命令树，帮助主题（不可执行且无子命令）不作为节点
+func walk(cmd *base.Command, path string, f func(n *node)) {
+	n := &node{path: path, cmd: cmd}
+	for _, sub := range cmd.Commands {
+		if !sub.Hidden && (sub.Runnable() || len(sub.Commands) > 0) {
+			n.subs = append(n.subs, sub)
+		}
+	}
+	cmd.Flag.VisitAll(func(fl *flag.Flag) {
+		n.flags = append(n.flags, fl.Name)
+	})
+	sort.Strings(n.flags)
+	f(n)
+	for _, sub := range n.subs {
+		walk(sub, path+" "+sub.Name(), f)
+	}
+}
+
+// topics 返回 go help 可以接受的所有名字，包括命令和帮助主题
+func topics(cmd *base.Command) []string {
+	var names []string
+	for _, sub := range cmd.Visible().Commands {
+		names = append(names, sub.Name())
+	}
+	return names
+}
+
+// writeBash 输出 bash 补全函数：先沿已输入的单词在命令树上向下走，再按所在节点给出候选
+func writeBash(w io.Writer, root *base.Command) {
+	var nodes []*node
+	walk(root, "go", func(n *node) { nodes = append(nodes, n) })
+
+	fmt.Fprintf(w, "# bash completion for go, generated by 'go completion bash'\n")
+	fmt.Fprintf(w, "_go() {\n")
+	fmt.Fprintf(w, "\tlocal cur=${COMP_WORDS[COMP_CWORD]} path=go i w words\n")
+	fmt.Fprintf(w, "\tfor ((i=1; i<COMP_CWORD; i++)); do\n")
+	fmt.Fprintf(w, "\t\tw=${COMP_WORDS[i]}\n")
+	fmt.Fprintf(w, "\t\t[[ $w == -* ]] && continue\n")
+	fmt.Fprintf(w, "\t\tcase \"$path $w\" in\n")
+	var paths []string
+	for _, n := range nodes[1:] {
+		paths = append(paths, fmt.Sprintf("%q", n.path))
+	}
+	paths = append(paths, `"go help"`)
+	fmt.Fprintf(w, "\t\t%s) path=\"$path $w\" ;;\n", strings.Join(paths, "|"))
+	fmt.Fprintf(w, "\t\t*) break ;;\n")
+	fmt.Fprintf(w, "\t\tesac\n")
+	fmt.Fprintf(w, "\tdone\n")
+	fmt.Fprintf(w, "\tcase $path in\n")
+	for _, n := range nodes {
+		var words []string
+		for _, sub := range n.subs {
+			words = append(words, sub.Name())
+		}
+		if n.cmd == root {
+			words = append(words, "help")
+		}
+		for _, fl := range n.flags {
+			words = append(words, "-"+fl)
+		}
+		fmt.Fprintf(w, "\t%q) words=%q ;;\n", n.path, strings.Join(words, " "))
+	}
+	fmt.Fprintf(w, "\t\"go help\") words=%q ;;\n", strings.Join(topics(root), " "))
+	fmt.Fprintf(w, "\tesac\n")
+	fmt.Fprintf(w, "\tCOMPREPLY=($(compgen -W \"$words\" -- \"$cur\"))\n")
+	fmt.Fprintf(w, "}\n")
+	fmt.Fprintf(w, "complete -o default -F _go go\n")
+}
+
+// writeFish 输出 fish 的 complete 规则，Go 的 flag 只有单横线形式，对应 fish 的 -o
+func writeFish(w io.Writer, root *base.Command) {
+	fmt.Fprintf(w, "# fish completion for go, generated by 'go completion fish'\n")
+	walk(root, "go", func(n *node) {
+		// 根节点只在还没输入子命令时补全，其余节点要求已出现该节点的名字
+		cond := "__fish_use_subcommand"
+		if n.cmd != root {
+			cond = "__fish_seen_subcommand_from " + n.cmd.Name()
+		}
+		for _, sub := range n.subs {
+			fmt.Fprintf(w, "complete -c go -f -n '%s' -a %s -d %s\n", cond, sub.Name(), fishQuote(sub.Short))
+		}
+		for _, fl := range n.flags {
+			fmt.Fprintf(w, "complete -c go -n '%s' -o %s -d %s\n", cond, fl, fishQuote(n.cmd.Flag.Lookup(fl).Usage))
+		}
+	})
+	fmt.Fprintf(w, "complete -c go -f -n '__fish_use_subcommand' -a help -d 'show help for a command or topic'\n")
+	for _, sub := range root.Visible().Commands {
+		fmt.Fprintf(w, "complete -c go -f -n '__fish_seen_subcommand_from help' -a %s -d %s\n", sub.Name(), fishQuote(sub.Short))
+	}
+}
+
+// fishQuote 将描述转为 fish 的单引号字符串，只保留第一行
+func fishQuote(s string) string {
+	if i := strings.Index(s, "\n"); i >= 0 {
+		s = s[:i]
+	}
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return "'" + strings.ReplaceAll(s, "'", `\'`) + "'"
+}
@@ -0,0 +1,59 @@
+// counters 包实现 go counters 命令，输出本地记录的命令使用计数
+package counters
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"cmd/go/internal/base"
+)
+
+var CmdCounters = &base.Command{
+	UsageLine: "go counters [-reset]",
+	Short:     "print local command usage counters",
+	Long: `
+Counters prints how many times each go command and flag has been used,
+most used first. Counting is opt-in: it happens only when GOCOUNTERS=on
+is set in the environment, and the counts are kept in a local file
+(GOCOUNTERSFILE, by default go/counters.json in the user configuration
+directory). Nothing is uploaded anywhere. Flag values are not recorded.
+
+The -json flag of the go command prints the counters as JSON.
+
+The -reset flag deletes the counter file.
+	`,
+}
+
+var countersReset = CmdCounters.Flag.Bool("reset", false, "")
+
+func init() {
+	CmdCounters.RunE = runCounters
+}
+
+func runCounters(cmd *base.Command, args []string) error {
+	if len(args) > 0 {
+		cmd.Usage()
+	}
+	if *countersReset {
+		return base.ResetCounters()
+	}
+	list, err := base.ReadCounters()
+	if err != nil {
+		return err
+	}
+	if !base.CountersEnabled() && len(list) == 0 {
+		fmt.Fprintf(os.Stderr, "go: usage counting is off; set GOCOUNTERS=on to enable it\n")
+	}
+	p := base.NewPrinter(os.Stdout, func(w io.Writer, v interface{}) error {
+		c := v.(base.Counter)
+		_, err := fmt.Fprintf(w, "%8d %s\n", c.Count, c.Name)
+		return err
+	})
+	for _, c := range list {
+		if err := p.Print(c); err != nil {
+			return err
+		}
+	}
+	return p.Flush()
+}
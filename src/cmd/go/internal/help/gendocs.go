@@ -0,0 +1,165 @@
+package help
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"cmd/go/internal/base"
+)
+
+// GenDocs 为 base.Go 下的每个命令和帮助主题生成文档，取代原先的 mkalldocs.sh：
+// man 不为空时在该目录写入 man(1) 页面，markdown 不为空时在该目录写入 markdown 文件。
+// 文件名由命令的完整名称连接而成，如 go-mod-edit.1、go-mod-edit.md
+func GenDocs(man, markdown string) error {
+	var err error
+	walkDocs(base.Go, func(cmd *base.Command) {
+		if err != nil {
+			return
+		}
+		if man != "" {
+			err = writeDoc(filepath.Join(man, docName(cmd)+".1"), cmd, writeMan)
+		}
+		if err == nil && markdown != "" {
+			err = writeDoc(filepath.Join(markdown, docName(cmd)+".md"), cmd, writeMarkdown)
+		}
+	})
+	return err
+}
+
+// walkDocs 先序遍历命令树
+func walkDocs(cmd *base.Command, f func(cmd *base.Command)) {
+	f(cmd)
+	for _, sub := range cmd.Visible().Commands {
+		walkDocs(sub, f)
+	}
+}
+
+// docName 返回命令的文档文件名（不含扩展名），如 go-mod-edit
+func docName(cmd *base.Command) string {
+	if cmd.LongName() == "" {
+		return "go"
+	}
+	return "go-" + strings.ReplaceAll(cmd.LongName(), " ", "-")
+}
+
+func writeDoc(file string, cmd *base.Command, write func(w io.Writer, cmd *base.Command)) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0777); err != nil {
+		return err
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	write(w, cmd)
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeMan 按 man(7) 宏输出一个命令的手册页
+func writeMan(w io.Writer, cmd *base.Command) {
+	name := docName(cmd)
+	fmt.Fprintf(w, ".TH %s 1 \"\" \"\" \"Go Manual\"\n", strings.ToUpper(name))
+	fmt.Fprintf(w, ".SH NAME\n%s \\- %s\n", name, manEscape(cmd.Short))
+	if cmd.Runnable() {
+		fmt.Fprintf(w, ".SH SYNOPSIS\n.B %s\n", manEscape(cmd.UsageLine))
+	}
+	if long := strings.TrimSpace(cmd.Long); long != "" {
+		fmt.Fprintf(w, ".SH DESCRIPTION\n")
+		// Long 中以 tab 缩进的行是示例，原样输出；空行分段
+		pre := false
+		for _, line := range strings.Split(long, "\n") {
+			switch {
+			case strings.HasPrefix(line, "\t"):
+				if !pre {
+					fmt.Fprintf(w, ".PP\n.nf\n")
+					pre = true
+				}
+				fmt.Fprintf(w, "%s\n", manEscape(line))
+				continue
+			case pre:
+				fmt.Fprintf(w, ".fi\n")
+				pre = false
+			}
+			if strings.TrimSpace(line) == "" {
+				fmt.Fprintf(w, ".PP\n")
+				continue
+			}
+			fmt.Fprintf(w, "%s\n", manEscape(line))
+		}
+		if pre {
+			fmt.Fprintf(w, ".fi\n")
+		}
+	}
+	var flags bool
+	cmd.Flag.VisitAll(func(f *flag.Flag) {
+		if !flags {
+			fmt.Fprintf(w, ".SH OPTIONS\n")
+			flags = true
+		}
+		fmt.Fprintf(w, ".TP\n.B \\-%s\n%s\n", f.Name, manEscape(f.Usage))
+	})
+	if subs := cmd.Visible().Commands; len(subs) > 0 {
+		fmt.Fprintf(w, ".SH SEE ALSO\n")
+		for i, sub := range subs {
+			sep := ","
+			if i == len(subs)-1 {
+				sep = ""
+			}
+			fmt.Fprintf(w, ".BR %s (1)%s\n", docName(sub), sep)
+		}
+	}
+}
+
+// manEscape 转义反斜杠，并避免以 . 或 ' 开头的行被当作宏
+func manEscape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\e`)
+	s = strings.ReplaceAll(s, "-", `\-`)
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = `\&` + s
+	}
+	return s
+}
+
+// writeMarkdown 输出一个命令的 markdown 文档，Long 中 tab 缩进的示例正好是 markdown 的代码块
+func writeMarkdown(w io.Writer, cmd *base.Command) {
+	title := "go"
+	if cmd.LongName() != "" {
+		title += " " + cmd.LongName()
+	}
+	fmt.Fprintf(w, "# %s\n\n", title)
+	if cmd.Short != "" {
+		fmt.Fprintf(w, "%s\n\n", cmd.Short)
+	}
+	if cmd.Runnable() {
+		fmt.Fprintf(w, "```\n%s\n```\n\n", cmd.UsageLine)
+	}
+	if long := strings.TrimSpace(cmd.Long); long != "" {
+		fmt.Fprintf(w, "%s\n\n", long)
+	}
+	var flags bool
+	cmd.Flag.VisitAll(func(f *flag.Flag) {
+		if !flags {
+			fmt.Fprintf(w, "## Flags\n\n| Flag | Default | Usage |\n| --- | --- | --- |\n")
+			flags = true
+		}
+		fmt.Fprintf(w, "| `-%s` | `%s` | %s |\n", f.Name, f.DefValue, strings.ReplaceAll(f.Usage, "|", `\|`))
+	})
+	if flags {
+		fmt.Fprintf(w, "\n")
+	}
+	if subs := cmd.Visible().Commands; len(subs) > 0 {
+		fmt.Fprintf(w, "## Commands\n\n")
+		for _, sub := range subs {
+			fmt.Fprintf(w, "- [%s](%s.md) %s\n", sub.Name(), docName(sub), sub.Short)
+		}
+	}
+}
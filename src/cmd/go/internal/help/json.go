@@ -0,0 +1,113 @@
+package help
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+	"strings"
+
+	"cmd/go/internal/base"
+)
+
+// CommandJSON 是 go help -json 输出的一个命令或帮助主题，子命令嵌套在 Commands 中
+type CommandJSON struct {
+	Name         string         // 命令的完整名称，如 "mod edit"，根命令为空
+	UsageLine    string         `json:",omitempty"`
+	Short        string         `json:",omitempty"`
+	Long         string         `json:",omitempty"`
+	Runnable     bool           // 为 false 时是帮助主题或只含子命令
+	Experimental bool           `json:",omitempty"`
+	Deprecated   string         `json:",omitempty"`
+	Flags        []FlagJSON     `json:",omitempty"`
+	Commands     []*CommandJSON `json:",omitempty"`
+}
+
+// FlagJSON 描述命令的一个 flag
+type FlagJSON struct {
+	Name    string
+	Usage   string
+	Default string
+	IsBool  bool `json:",omitempty"` // 可以不带值使用，如 -x
+}
+
+// HelpJSON 实现 go help -json [command...]：以 JSON 输出命令树的元数据，供 IDE 等外部工具读取。
+// args 为空时输出整个命令树，否则输出指定的命令及其子命令
+func HelpJSON(w io.Writer, args []string) {
+	cmd := base.Go
+	for _, arg := range args {
+		var found *base.Command
+		for _, sub := range cmd.Commands {
+			if sub.Name() == arg {
+				found = sub
+				break
+			}
+		}
+		if found == nil {
+			base.Fatalf("go help -json %s: unknown help topic", strings.Join(args, " "))
+		}
+		cmd = found
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	if err := enc.Encode(commandJSON(cmd)); err != nil {
+		base.Fatalf("go help -json: %v", err)
+	}
+}
+
+func commandJSON(cmd *base.Command) *CommandJSON {
+	c := &CommandJSON{
+		Name:         cmd.LongName(),
+		UsageLine:    cmd.UsageLine,
+		Short:        cmd.Short,
+		Long:         strings.TrimSpace(cmd.Long),
+		Runnable:     cmd.Runnable(),
+		Experimental: cmd.Experimental,
+		Deprecated:   cmd.Deprecated,
+	}
+	cmd.Flag.VisitAll(func(f *flag.Flag) {
+		fj := FlagJSON{Name: f.Name, Usage: f.Usage, Default: f.DefValue}
+		if bf, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && bf.IsBoolFlag() {
+			fj.IsBool = true
+		}
+		c.Flags = append(c.Flags, fj)
+	})
+	for _, sub := range cmd.Visible().Commands {
+		c.Commands = append(c.Commands, commandJSON(sub))
+	}
+	return c
+}
+
+// HelpMain 处理 go help 的参数：
+//
+//	go help -json [command...]          以 JSON 输出命令元数据
+//	go help -search term                按相关程度列出提到 term 的命令和主题
+//	go help -man dir [-markdown dir]    生成 man 页面和/或 markdown 文档
+//
+// 其他情况与 Help 相同
+func HelpMain(args []string) {
+	if len(args) > 0 && (args[0] == "-json" || args[0] == "--json") {
+		HelpJSON(os.Stdout, args[1:])
+		return
+	}
+	if len(args) > 0 && (args[0] == "-search" || args[0] == "--search") {
+		HelpSearch(os.Stdout, strings.Join(args[1:], " "))
+		return
+	}
+	if len(args) == 0 { // 与 Help 相同，但不列出 Hidden 的命令
+		PrintUsage(os.Stdout, base.Go.Visible())
+		return
+	}
+	if len(args) > 0 && (args[0] == "-man" || args[0] == "-markdown") {
+		fs := flag.NewFlagSet("help", flag.ExitOnError)
+		man := fs.String("man", "", "write man pages to `dir`")
+		markdown := fs.String("markdown", "", "write markdown files to `dir`")
+		fs.Parse(args)
+		if err := GenDocs(*man, *markdown); err != nil {
+			base.Fatalf("go help: %v", err)
+		}
+		return
+	}
+	Help(os.Stdout, args)
+}
@@ -0,0 +1,90 @@
+package help
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"cmd/go/internal/base"
+)
+
+// searchHit 是 go help -search 的一条结果
+type searchHit struct {
+	cmd   *base.Command
+	score int
+	flags []string // 名字或说明中含有搜索词的 flag
+}
+
+// 各处出现搜索词的得分，命令名最重要，Long 中每出现一次计一分
+const (
+	scoreNameExact = 100
+	scoreName      = 50
+	scoreShort     = 20
+	scoreFlagName  = 10
+	scoreFlagUsage = 5
+	scoreLong      = 1
+)
+
+// HelpSearch 实现 go help -search term：在所有命令和帮助主题的名字、Short、Long 以及 flag 说明中
+// 不区分大小写地查找 term，按得分从高到低输出匹配的命令，无需通读整个帮助目录
+func HelpSearch(w io.Writer, term string) {
+	term = strings.ToLower(strings.TrimSpace(term))
+	if term == "" {
+		base.Fatalf("go help -search: missing search term")
+	}
+
+	var hits []*searchHit
+	var walk func(cmd *base.Command)
+	walk = func(cmd *base.Command) {
+		for _, sub := range cmd.Visible().Commands {
+			if h := searchCommand(sub, term); h.score > 0 {
+				hits = append(hits, h)
+			}
+			walk(sub)
+		}
+	}
+	walk(base.Go)
+
+	if len(hits) == 0 {
+		fmt.Fprintf(w, "no help topics match %q\n", term)
+		return
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].score > hits[j].score })
+	for _, h := range hits {
+		fmt.Fprintf(w, "go %-20s %s\n", h.cmd.LongName(), h.cmd.Short)
+		if len(h.flags) > 0 {
+			fmt.Fprintf(w, "   %-20s flags: %s\n", "", strings.Join(h.flags, " "))
+		}
+	}
+}
+
+func searchCommand(cmd *base.Command, term string) *searchHit {
+	h := &searchHit{cmd: cmd}
+	name := strings.ToLower(cmd.Name())
+	switch {
+	case name == term:
+		h.score += scoreNameExact
+	case strings.Contains(name, term):
+		h.score += scoreName
+	}
+	if strings.Contains(strings.ToLower(cmd.Short), term) {
+		h.score += scoreShort
+	}
+	h.score += scoreLong * strings.Count(strings.ToLower(cmd.Long), term)
+	cmd.Flag.VisitAll(func(f *flag.Flag) {
+		s := 0
+		if strings.Contains(strings.ToLower(f.Name), term) {
+			s += scoreFlagName
+		}
+		if strings.Contains(strings.ToLower(f.Usage), term) {
+			s += scoreFlagUsage
+		}
+		if s > 0 {
+			h.score += s
+			h.flags = append(h.flags, "-"+f.Name)
+		}
+	})
+	return h
+}
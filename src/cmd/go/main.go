@@ -1,4 +1,4 @@
-//go:generate ./mkalldocs.sh
+//go:generate go run . help -man ../../../misc/man -markdown ../../../doc/cmd/go
 
 package main
 
@@ -15,6 +15,8 @@ import (
 	"cmd/go/internal/bug"
 	"cmd/go/internal/cfg"
 	"cmd/go/internal/clean"
+	"cmd/go/internal/completion"
+	"cmd/go/internal/counters"
 	"cmd/go/internal/doc"
 	"cmd/go/internal/envcmd"
 	"cmd/go/internal/fix"
@@ -35,11 +37,14 @@ import (
 	"cmd/go/internal/work"
 )
 
+// 内置命令通过 base.MustRegister 注册，其他包可以用 base.Register 添加自己的子命令
 func init() {
-	base.Go.Commands = []*base.Command{
+	base.MustRegister("",
 		bug.CmdBug,
 		work.CmdBuild,
 		clean.CmdClean,
+		completion.CmdCompletion,
+		counters.CmdCounters,
 		doc.CmdDoc,
 		envcmd.CmdEnv,
 		fix.CmdFix,
@@ -72,10 +77,16 @@ func init() {
 		help.HelpPackages,
 		test.HelpTestflag,
 		test.HelpTestfunc,
-	}
+	)
 }
 
 func main() {
+	// go 自身的 -v、-log-format、-json、-no-color、-q 和 -yes，
+	// 见 base.Logf、base.NewPrinter、base.ErrorText 和 base.Confirm
+	base.AddLogFlags(flag.CommandLine)
+	base.AddOutputFlags(flag.CommandLine)
+	base.AddPromptFlags(flag.CommandLine)
+
 	_ = go11tag             // 是一个 bool 的 true
 	flag.Usage = base.Usage // 是一个空的 func() 值
 	flag.Parse()            // 可以去读一下 flag 包，它解析了传入的 flags（command.Flag）
@@ -97,7 +108,7 @@ func main() {
 
 	cfg.CmdName = args[0] // for error messages
 	if args[0] == "help" {
-		help.Help(os.Stdout, args[1:]) // 生成 help 文档，这个得看一下
+		help.HelpMain(args[1:]) // 生成 help 文档，这个得看一下；-json、-search、-man、-markdown 见 HelpMain
 		return
 	}
 
@@ -161,8 +172,8 @@ BigCmdLoop:
 				bigCmd = cmd
 				args = args[1:]
 				if len(args) == 0 { // 子命令后无参数，输出使用信息
-					help.PrintUsage(os.Stderr, bigCmd)
-					base.SetExitStatus(2)
+					help.PrintUsage(os.Stderr, bigCmd.Visible())
+					base.SetExitReason(base.ExitUsage, "missing subcommand")
 					base.Exit()
 				}
 				if args[0] == "help" {
@@ -180,12 +191,15 @@ BigCmdLoop:
 			if cmd.CustomFlags {
 				args = args[1:]
 			} else {
+				// 优先级从低到高：GOFLAGS、.gocmdrc、环境变量，最后命令行覆盖前面所有
 				base.SetFromGOFLAGS(cmd.Flag)
+				base.SetFromCmdRC(cmd)
+				base.SetFromEnv(cmd)
 				cmd.Flag.Parse(args[1:])
 				args = cmd.Flag.Args()
 			}
-			// 执行命令
-			cmd.Run(cmd, args)
+			// 执行命令，前后会调用 PreRun 和 PostRun
+			cmd.Execute(args)
 			base.Exit()
 			return
 		}
@@ -194,8 +208,14 @@ BigCmdLoop:
 		if i := strings.LastIndex(cfg.CmdName, " "); i >= 0 {
 			helpArg = " " + cfg.CmdName[:i]
 		}
-		fmt.Fprintf(os.Stderr, "go %s: unknown command\nRun 'go help%s' for usage.\n", cfg.CmdName, helpArg)
-		base.SetExitStatus(2)
+		fmt.Fprintf(os.Stderr, "go %s: unknown command\n", cfg.CmdName)
+		// 给出拼写相近的命令，如 go buidl → go build
+		if names := bigCmd.Suggest(args[0]); len(names) > 0 {
+			prefix := strings.TrimSuffix(cfg.CmdName, args[0])
+			fmt.Fprintf(os.Stderr, "did you mean 'go %s' → 'go %s%s'?\n", cfg.CmdName, prefix, names[0])
+		}
+		fmt.Fprintf(os.Stderr, "Run 'go help%s' for usage.\n", helpArg)
+		base.SetExitReason(base.ExitUsage, "unknown command "+cfg.CmdName)
 		base.Exit()
 	}
 }
@@ -206,6 +226,6 @@ func init() {
 }
 
 func mainUsage() {
-	help.PrintUsage(os.Stderr, base.Go)
+	help.PrintUsage(os.Stderr, base.Go.Visible())
 	os.Exit(2) // 退出状态码为 2，可用 `echo $?` 查看
 }
@@ -0,0 +1,344 @@
+// mutexviz 把 -tags mutexdebug 构建的 sync 记录的 Mutex 状态变化（sync.MutexTraceDump 的输出）
+// 画成 HTML 中的 SVG 时间线：每个协程一行，实心条是持有锁的时间，浅色条是阻塞等待的时间，
+// 竖线标出自旋、唤醒和饥饿模式的移交，顶部的红色带是锁处于饥饿模式的时段。
+//
+// 用法：
+//
+//	go test -tags mutexdebug -run TestX -v sync > trace.txt   # 测试中 t.Log(sync.MutexTraceDump(nil))
+//	mutexviz [-o timeline.html] [-mutex 0xc000012345] [trace.txt]
+//
+// 不给文件时从标准输入读取。输入中不是事件的行会被忽略，所以可以直接喂给它整个测试输出。
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	outFile   = flag.String("o", "", "write the HTML to this file instead of standard output")
+	mutexAddr = flag.String("mutex", "", "only draw the mutex at this address, such as 0xc000012345")
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: mutexviz [-o file.html] [-mutex addr] [trace.txt]\n")
+	flag.PrintDefaults()
+	os.Exit(2)
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("mutexviz: ")
+	flag.Usage = usage
+	flag.Parse()
+
+	var in io.Reader = os.Stdin
+	switch flag.NArg() {
+	case 0:
+	case 1:
+		f, err := os.Open(flag.Arg(0))
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		in = f
+	default:
+		usage()
+	}
+
+	events, err := parse(in)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *mutexAddr != "" {
+		events = filter(events, *mutexAddr)
+	}
+	if len(events) == 0 {
+		log.Fatal("no mutex events in input")
+	}
+
+	out := os.Stdout
+	if *outFile != "" {
+		if out, err = os.Create(*outFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := render(out, timelines(events)); err != nil {
+		log.Fatal(err)
+	}
+	if err := out.Close(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// event 是 MutexEvent.String 格式的一行：
+//
+//	#12 @1234567 g7 0xc000012345 queue: locked waiters=0 -> locked|starving waiters=1
+type event struct {
+	seq      uint64
+	when     int64 // 纳秒
+	g        uint64
+	mutex    string
+	kind     string
+	starving bool // 变化后处于饥饿模式
+	waiters  int  // 变化后的等待者数
+}
+
+var eventRE = regexp.MustCompile(`#(\d+) @(\d+) g(\d+) (0x[0-9a-f]+) ([a-z-]+): (\S+) waiters=(\d+) -> (\S+) waiters=(\d+)`)
+
+// parse 读出输入中的所有事件，按序号排序；其他行被忽略
+func parse(r io.Reader) ([]event, error) {
+	var events []event
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		m := eventRE.FindStringSubmatch(sc.Text())
+		if m == nil {
+			continue
+		}
+		seq, _ := strconv.ParseUint(m[1], 10, 64)
+		when, _ := strconv.ParseInt(m[2], 10, 64)
+		g, _ := strconv.ParseUint(m[3], 10, 64)
+		waiters, _ := strconv.Atoi(m[9])
+		events = append(events, event{
+			seq:      seq,
+			when:     when,
+			g:        g,
+			mutex:    m[4],
+			kind:     m[5],
+			starving: strings.Contains(m[8], "starving"),
+			waiters:  waiters,
+		})
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].seq < events[j].seq })
+	return events, sc.Err()
+}
+
+func filter(events []event, mutex string) []event {
+	var out []event
+	for _, e := range events {
+		if e.mutex == mutex {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// span 是时间线上的一段，end 为 -1 表示到记录结束时还没结束
+type span struct {
+	g          uint64
+	start, end int64
+	kind       string // "hold" 或 "wait"
+}
+
+// mark 是时间线上的一个瞬间事件
+type mark struct {
+	g    uint64
+	when int64
+	kind string
+}
+
+// timeline 是一把锁的时间线
+type timeline struct {
+	mutex      string
+	start, end int64
+	goroutines []uint64 // 按首次出现的顺序
+	spans      []span
+	marks      []mark
+	starving   []span // 锁处于饥饿模式的时段，g 为 0
+}
+
+// 拿到锁的事件；handoff 是饥饿模式下被唤醒的等待者接手拥有权
+var acquireKinds = map[string]bool{"fast-lock": true, "slow-lock": true, "trylock": true, "handoff": true}
+
+// timelines 按锁把事件整理成持有和等待的时段
+func timelines(events []event) []*timeline {
+	byMutex := map[string]*timeline{}
+	var order []*timeline
+	holding := map[string]int{}    // 锁 -> 当前持有时段在 spans 中的下标
+	waiting := map[[2]string]int{} // (锁, 协程) -> 当前等待时段的下标
+	starvingSince := map[string]int64{}
+	for _, e := range events {
+		tl := byMutex[e.mutex]
+		if tl == nil {
+			tl = &timeline{mutex: e.mutex, start: e.when}
+			byMutex[e.mutex] = tl
+			order = append(order, tl)
+		}
+		tl.end = e.when
+		if !containsG(tl.goroutines, e.g) {
+			tl.goroutines = append(tl.goroutines, e.g)
+		}
+		key := [2]string{e.mutex, strconv.FormatUint(e.g, 10)}
+
+		switch {
+		case acquireKinds[e.kind]:
+			if i, ok := waiting[key]; ok {
+				tl.spans[i].end = e.when
+				delete(waiting, key)
+			}
+			holding[e.mutex] = len(tl.spans)
+			tl.spans = append(tl.spans, span{g: e.g, start: e.when, end: -1, kind: "hold"})
+		case e.kind == "unlock":
+			if i, ok := holding[e.mutex]; ok {
+				tl.spans[i].end = e.when
+				delete(holding, e.mutex)
+			}
+		case e.kind == "queue" || e.kind == "requeue-lifo":
+			if _, ok := waiting[key]; !ok {
+				waiting[key] = len(tl.spans)
+				tl.spans = append(tl.spans, span{g: e.g, start: e.when, end: -1, kind: "wait"})
+			}
+			tl.marks = append(tl.marks, mark{g: e.g, when: e.when, kind: e.kind})
+		default: // spin-woken、woken、exit-starving、unlock-wake、unlock-handoff、trylock-fail
+			tl.marks = append(tl.marks, mark{g: e.g, when: e.when, kind: e.kind})
+		}
+
+		since, was := starvingSince[e.mutex]
+		switch {
+		case e.starving && !was:
+			starvingSince[e.mutex] = e.when
+		case !e.starving && was:
+			tl.starving = append(tl.starving, span{start: since, end: e.when, kind: "starving"})
+			delete(starvingSince, e.mutex)
+		}
+	}
+	for m, since := range starvingSince {
+		byMutex[m].starving = append(byMutex[m].starving, span{start: since, end: -1, kind: "starving"})
+	}
+	return order
+}
+
+func containsG(gs []uint64, g uint64) bool {
+	for _, x := range gs {
+		if x == g {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	svgWidth  = 1200
+	labelW    = 60 // 左侧协程 id 的宽度
+	rowH      = 18
+	bandH     = 8 // 顶部饥饿模式带的高度
+	axisH     = 20
+	chartLeft = labelW
+)
+
+// markColors 是瞬间事件的颜色
+var markColors = map[string]string{
+	"queue":          "#888",
+	"requeue-lifo":   "#c60",
+	"spin-woken":     "#09c",
+	"woken":          "#06c",
+	"exit-starving":  "#0a0",
+	"unlock-wake":    "#06c",
+	"unlock-handoff": "#c00",
+	"trylock-fail":   "#aaa",
+}
+
+func render(w io.Writer, tls []*timeline) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprint(bw, `<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>mutex timeline</title>
+<style>
+body { font-family: sans-serif; font-size: 12px; }
+.hold { fill: #36c; }
+.wait { fill: #bcd; }
+.starving { fill: #e33; }
+text { font-size: 11px; }
+</style></head><body>
+<p>solid: holding the lock; light: blocked waiting; red band: starvation mode; ticks: spin, wake and handoff events. Hover for details.</p>
+`)
+	for _, tl := range tls {
+		renderTimeline(bw, tl)
+	}
+	fmt.Fprint(bw, "</body></html>\n")
+	return bw.Flush()
+}
+
+func renderTimeline(w io.Writer, tl *timeline) {
+	dur := tl.end - tl.start
+	if dur <= 0 {
+		dur = 1
+	}
+	x := func(t int64) float64 {
+		if t < 0 { // 到记录结束时还没结束
+			t = tl.end
+		}
+		return chartLeft + float64(t-tl.start)*float64(svgWidth-chartLeft)/float64(dur)
+	}
+	row := map[uint64]int{}
+	for i, g := range tl.goroutines {
+		row[g] = i
+	}
+	y := func(g uint64) int { return axisH + bandH + 4 + row[g]*rowH }
+	height := axisH + bandH + 4 + len(tl.goroutines)*rowH + 4
+
+	fmt.Fprintf(w, "<h3>mutex %s — %d goroutines, %s</h3>\n", html.EscapeString(tl.mutex), len(tl.goroutines), fmtDur(dur))
+	fmt.Fprintf(w, "<svg width=\"%d\" height=\"%d\" xmlns=\"http://www.w3.org/2000/svg\">\n", svgWidth, height)
+
+	// 时间轴，10 个刻度
+	for i := 0; i <= 10; i++ {
+		t := tl.start + dur*int64(i)/10
+		fmt.Fprintf(w, "<line x1=\"%.1f\" y1=\"%d\" x2=\"%.1f\" y2=\"%d\" stroke=\"#eee\"/>", x(t), axisH, x(t), height)
+		fmt.Fprintf(w, "<text x=\"%.1f\" y=\"12\" text-anchor=\"middle\">%s</text>\n", x(t), fmtDur(t-tl.start))
+	}
+	for _, s := range tl.starving {
+		fmt.Fprintf(w, "<rect class=\"starving\" x=\"%.1f\" y=\"%d\" width=\"%.1f\" height=\"%d\"><title>starvation mode %s</title></rect>\n",
+			x(s.start), axisH, width(x(s.start), x(s.end)), bandH, fmtDur(end(s, tl)-s.start))
+	}
+	for _, g := range tl.goroutines {
+		fmt.Fprintf(w, "<text x=\"4\" y=\"%d\">g%d</text>\n", y(g)+rowH-6, g)
+	}
+	for _, s := range tl.spans {
+		fmt.Fprintf(w, "<rect class=\"%s\" x=\"%.1f\" y=\"%d\" width=\"%.1f\" height=\"%d\"><title>g%d %s %s at +%s</title></rect>\n",
+			s.kind, x(s.start), y(s.g)+2, width(x(s.start), x(s.end)), rowH-4, s.g, s.kind, fmtDur(end(s, tl)-s.start), fmtDur(s.start-tl.start))
+	}
+	for _, m := range tl.marks {
+		c := markColors[m.kind]
+		if c == "" {
+			c = "#000"
+		}
+		fmt.Fprintf(w, "<line x1=\"%.1f\" y1=\"%d\" x2=\"%.1f\" y2=\"%d\" stroke=\"%s\" stroke-width=\"2\"><title>g%d %s at +%s</title></line>\n",
+			x(m.when), y(m.g)+1, x(m.when), y(m.g)+rowH-1, c, m.g, m.kind, fmtDur(m.when-tl.start))
+	}
+	fmt.Fprint(w, "</svg>\n")
+}
+
+// width 返回时段的宽度，至少 1 像素，使很短的持有也能看见
+func width(x0, x1 float64) float64 {
+	if x1-x0 < 1 {
+		return 1
+	}
+	return x1 - x0
+}
+
+func end(s span, tl *timeline) int64 {
+	if s.end < 0 {
+		return tl.end
+	}
+	return s.end
+}
+
+func fmtDur(ns int64) string {
+	switch {
+	case ns >= 1e9:
+		return fmt.Sprintf("%.2fs", float64(ns)/1e9)
+	case ns >= 1e6:
+		return fmt.Sprintf("%.2fms", float64(ns)/1e6)
+	case ns >= 1e3:
+		return fmt.Sprintf("%.1fµs", float64(ns)/1e3)
+	}
+	return fmt.Sprintf("%dns", ns)
+}
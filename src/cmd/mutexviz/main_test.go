@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// 两个协程争抢一把锁：g1 持有，g2 排队后在饥饿模式下接手
+const sampleTrace = `=== RUN   TestX
+#1 @1000 g1 0xc000010000 fast-lock: unlocked waiters=0 -> locked waiters=0
+#2 @1500 g2 0xc000010000 queue: locked waiters=0 -> locked waiters=1
+    some unrelated log line
+#3 @2600000 g2 0xc000010000 requeue-lifo: locked waiters=0 -> locked|starving waiters=1
+#4 @3000000 g1 0xc000010000 unlock: locked|starving waiters=1 -> starving waiters=1
+#5 @3000100 g1 0xc000010000 unlock-handoff: starving waiters=1 -> starving waiters=1
+#6 @3000200 g2 0xc000010000 handoff: starving waiters=1 -> locked waiters=0
+#7 @3000300 g2 0xc000010000 exit-starving: starving waiters=1 -> locked waiters=0
+#8 @3100000 g2 0xc000010000 unlock: locked waiters=0 -> unlocked waiters=0
+--- PASS: TestX
+`
+
+func TestTimelines(t *testing.T) {
+	events, err := parse(strings.NewReader(sampleTrace))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 8 {
+		t.Fatalf("parsed %d events, want 8", len(events))
+	}
+	tls := timelines(events)
+	if len(tls) != 1 {
+		t.Fatalf("got %d timelines, want 1", len(tls))
+	}
+	tl := tls[0]
+	want := []span{
+		{g: 1, start: 1000, end: 3000000, kind: "hold"},
+		{g: 2, start: 1500, end: 3000200, kind: "wait"},
+		{g: 2, start: 3000200, end: 3100000, kind: "hold"},
+	}
+	if len(tl.spans) != len(want) {
+		t.Fatalf("spans = %+v, want %+v", tl.spans, want)
+	}
+	for i := range want {
+		if tl.spans[i] != want[i] {
+			t.Errorf("span %d = %+v, want %+v", i, tl.spans[i], want[i])
+		}
+	}
+	if len(tl.starving) != 1 || tl.starving[0].start != 2600000 || tl.starving[0].end != 3000200 {
+		t.Errorf("starving = %+v, want one span from 2600000 to 3000200", tl.starving)
+	}
+
+	var buf bytes.Buffer
+	if err := render(&buf, tls); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"<svg", `class="hold"`, `class="wait"`, `class="starving"`, "g1 unlock-handoff"} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("HTML does not contain %q", s)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	events, _ := parse(strings.NewReader(sampleTrace + "#9 @4000000 g3 0xc000020000 fast-lock: unlocked waiters=0 -> locked waiters=0\n"))
+	if n := len(filter(events, "0xc000020000")); n != 1 {
+		t.Errorf("filter kept %d events, want 1", n)
+	}
+}
@@ -52,7 +52,10 @@ const (
 	//
 	// 正常模式有相当好的性能，因为即便存在多个被阻塞的等待者，一个协程可以连续得请求 mutex 多次。
 	// 饥饿模式对阻止队尾延迟（tail latency）的病态情况（pathological cases）很重要。
-	starvationThresholdNs = 1e6 // 成为饥饿状态的时间阈值：1ms
+	//
+	// 实际使用的阈值是 starvationThresholdNs：默认构建中它是等于本值的常量，
+	// 用 -tags mutexexperiment 构建时是可以调整的变量，见 mutex_threshold*.go
+	defaultStarvationThresholdNs = 1e6 // 成为饥饿状态的时间阈值：1ms
 )
 
 // Lock 方法会将 m 上锁。
@@ -60,6 +63,9 @@ const (
 func (m *Mutex) Lock() {
 	// 快路径（fast path）：拿到未上锁的 mutex。
 	if atomic.CompareAndSwapInt32(&m.state, 0, mutexLocked) {
+		mutexTrace(m, mutexEvFastLock, 0, mutexLocked)
+		mutexStat(m, mutexStatFast)
+		mutexOwnerAcquire(m)
 		if race.Enabled {
 			race.Acquire(unsafe.Pointer(m))
 		}
@@ -69,20 +75,21 @@ func (m *Mutex) Lock() {
 	var waitStartTime int64
 	starving := false // 标记是否在此线程内饥饿
 	awoke := false    // 标记是否在此线程内唤醒
-	iter := 0         // 用于判断是否可以自旋，（runtime/proc.go@sync_runtime_canSpin）
+	iter := 0         // 用于判断是否可以自旋，（runtime/proc.go@sync_runtime_canSpin，经由 canSpin，见 mutex_spin*.go）
 	old := m.state    // 调用 Lock 时，获取此时 state 的值，其包含了队列信息
 	for {
 		// 在饥饿模式中不自旋，拥有权会移交给等待者，所以我们不会再请求 mutex。
-		if old&(mutexLocked|mutexStarving) == mutexLocked && runtime_canSpin(iter) { // 如果是上锁状态，且不是饥饿状态，且可以自旋
+		if old&(mutexLocked|mutexStarving) == mutexLocked && canSpin(iter) { // 如果是上锁状态，且不是饥饿状态，且可以自旋
 			// 主动自旋（Active spinning）是有意义的。
 			// 尝试设置 mutexWoken 标志位以通知解锁。
 			if !awoke && old&mutexWoken == 0 && old>>mutexWaiterShift != 0 && // 如果本线程未唤醒它，且未被其他线程唤醒，且队列中有等待者
 				atomic.CompareAndSwapInt32(&m.state, old, old|mutexWoken) { // 则（本线程）唤醒它
 				awoke = true // 标记为唤醒
+				mutexTrace(m, mutexEvSpinWoken, old, old|mutexWoken)
 			}
-			runtime_doSpin() // 自旋，是为了不暂停线程，而再次去尝试获取锁
-			iter++           // 自旋标记加 1
-			old = m.state    // 自旋后，重新更新 old 状态
+			doSpin()      // 自旋，是为了不暂停线程，而再次去尝试获取锁
+			iter++        // 自旋标记加 1
+			old = m.state // 自旋后，重新更新 old 状态
 			continue
 		}
 		new := old
@@ -108,6 +115,14 @@ func (m *Mutex) Lock() {
 		} // 以上，则证明此锁已被唤醒，以下可以去抢占了。new 变量也已组装好了，只要 CAS 成功，就是抢占成功。
 		if atomic.CompareAndSwapInt32(&m.state, old, new) { // 如果 m.state 还是 old（未变化），则更新为新状态 new
 			if old&(mutexLocked|mutexStarving) == 0 { // 如果 old 即 未锁定 也 不是饥饿状态（已被此线程上锁）
+				mutexTrace(m, mutexEvSlowLock, old, new)
+				if waitStartTime == 0 { // 没有阻塞过；阻塞后被唤醒再拿到锁的已计入 mutexStatSemaBlock
+					if iter > 0 {
+						mutexStat(m, mutexStatSpin)
+					} else {
+						mutexStat(m, mutexStatSlow)
+					}
+				}
 				break // 退出 for，即不需要其他处理了
 			} // 若 old （前一状态）是锁定的，或是饥饿的，还需要以下处理
 			// 如果 waitStartTime 不为 0，排列到队列的前方。(LIFO: last in first out)
@@ -115,8 +130,14 @@ func (m *Mutex) Lock() {
 			if waitStartTime == 0 {         // 如果 waitStartTime 为 0，首次获取此锁
 				waitStartTime = runtime_nanotime() // 则记录获取锁的时间 waitStartTime。
 			}
+			if queueLifo {
+				mutexTrace(m, mutexEvRequeue, old, new)
+			} else {
+				mutexTrace(m, mutexEvQueue, old, new)
+			}
+			mutexStat(m, mutexStatSemaBlock)
 			runtime_SemacquireMutex(&m.sema, queueLifo)                                     // 根据 queueLifo 排到队列的前/后方
-			starving = starving || runtime_nanotime()-waitStartTime > starvationThresholdNs // 是否已在饥饿状态，或等待时间大于 1ms
+			starving = starving || runtime_nanotime()-waitStartTime > starvationThreshold() // 是否已在饥饿状态，或等待时间大于阈值（默认 1ms）
 			old = m.state                                                                   // 重新获取 old
 			if old&mutexStarving != 0 {                                                     // 如果 old 是 饥饿状态
 				// 如果一个协程被唤醒，并且 mutex 在饥饿模式中，其拥有权被移交给我们，
@@ -132,19 +153,54 @@ func (m *Mutex) Lock() {
 					// 饥饿模式是如此的低效，因为一旦他们转换 mutex 到饥饿模式时，两个协程可能无限得锁步（lock-step）。
 					delta -= mutexStarving
 				}
+				mutexTrace(m, mutexEvHandoff, old, old+delta)
+				mutexStat(m, mutexStatHandoff)
+				if delta&mutexStarving != 0 { // delta 中减去了 mutexStarving
+					mutexTrace(m, mutexEvExitStarving, old, old+delta)
+				}
 				atomic.AddInt32(&m.state, delta)
 				break
 			}
+			mutexTrace(m, mutexEvWoken, old, old)
 			awoke = true // 标记为唤醒
 			iter = 0
 		} else { // 否则，重新取 m.state 为 old 状态
 			old = m.state
 		}
 	}
+	mutexOwnerAcquire(m)
+
+	if race.Enabled {
+		race.Acquire(unsafe.Pointer(m))
+	}
+}
+
+// TryLock 尝试将 m 上锁，并报告是否成功。
+//
+// 注意：虽然确实存在正确使用 TryLock 的场景，但它们很少见，
+// 使用 TryLock 往往说明对 mutex 的某种用法存在更深层的问题。
+func (m *Mutex) TryLock() bool {
+	old := m.state
+	if old&(mutexLocked|mutexStarving) != 0 { // 已上锁，或处于饥饿状态（拥有权要移交给队首的等待者，不许插队）
+		mutexTrace(m, mutexEvTryLockFail, old, old)
+		return false
+	}
+
+	// 可能有协程在等待这个 mutex，但我们正在运行，可以在那个协程醒来之前抢到它。
+	// 与 Lock 的快路径不同，这里 CAS 的旧值是 old 而不是 0：
+	// 即便队列中有等待者（old>>mutexWaiterShift != 0）或已有协程被唤醒（mutexWoken），只要没上锁也可以抢。
+	// 只尝试一次，失败就返回，不自旋也不排队，所以 TryLock 永远不会阻塞。
+	if !atomic.CompareAndSwapInt32(&m.state, old, old|mutexLocked) {
+		mutexTrace(m, mutexEvTryLockFail, old, old)
+		return false
+	}
+	mutexTrace(m, mutexEvTryLock, old, old|mutexLocked)
+	mutexOwnerAcquire(m)
 
 	if race.Enabled {
 		race.Acquire(unsafe.Pointer(m))
 	}
+	return true
 }
 
 // Unlock 将 m 解锁。
@@ -156,12 +212,14 @@ func (m *Mutex) Unlock() {
 		_ = m.state
 		race.Release(unsafe.Pointer(m))
 	}
+	mutexOwnerRelease(m) // 只在 -tags mutexdebug 时检查是否跨协程解锁
 
 	// 快路径（fast path）：去掉锁定的位。
 	new := atomic.AddInt32(&m.state, -mutexLocked) // 解锁，去除锁定状态
 	if (new+mutexLocked)&mutexLocked == 0 {        // 如果 new 还是上锁状态（如果 m.state 本就未上锁会进入这里）
 		throw("sync: unlock of unlocked mutex") // 异常
 	}
+	mutexTrace(m, mutexEvUnlock, new+mutexLocked, new)
 	if new&mutexStarving == 0 { // 如果 new 不是饥饿状态
 		old := new
 		for {
@@ -175,6 +233,7 @@ func (m *Mutex) Unlock() {
 			// Grab the right to wake someone.
 			new = (old - 1<<mutexWaiterShift) | mutexWoken      // 队列 -1, 并重置 mutexWoken 标志位
 			if atomic.CompareAndSwapInt32(&m.state, old, new) { // 如果 old 状态没变，重置此互斥量的状态为 new
+				mutexTrace(m, mutexEvUnlockWake, old, new)
 				runtime_Semrelease(&m.sema, false)
 				return
 			}
@@ -184,6 +243,7 @@ func (m *Mutex) Unlock() {
 		// 饥饿模式：移交（handoff） mutex 的拥有权给下一个等待者。
 		// 注意： mutexLocked 未被设置，等待者会在唤醒后设置它。
 		// 但如果 mutexStarving 被设置了， mutex 仍然被认为是锁定的，因此新来的协程不会获得（acquire）它。
+		mutexTrace(m, mutexEvUnlockHandoff, new, new)
 		runtime_Semrelease(&m.sema, true)
 	}
 }
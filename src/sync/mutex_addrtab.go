@@ -0,0 +1,32 @@
+package sync
+
+import "sync/atomic"
+
+// 调试构建中按锁记录信息的表（mutex_stats_on.go 的统计表和 mutex_owner.go 的持有者表）都以 Mutex 的地址为键。
+// sync 中不能用 Mutex 保护这些表（记录的正是 Mutex 自己），因此用开放寻址加原子操作，只增不删：
+// 键为 0 的槽是空闲的，用 CAS 占用；锁被回收后地址可能被新锁复用，这时沿用原来的槽即可
+
+// mutexAddrLookup 在有 n 个槽的表中查找键为 addr 的槽并返回其下标，key(i) 返回第 i 个槽的键的地址。
+// 不存在时若 create 为 true 则占用一个空槽；不存在且不创建、或者表已满时返回 -1
+func mutexAddrLookup(addr, n uintptr, key func(i uintptr) *uintptr, create bool) int {
+	h := (addr >> 3) * 0x9e3779b9 // 地址低位总是 0，先去掉再散列
+	for i := uintptr(0); i < n; i++ {
+		j := (h + i) % n
+		k := key(j)
+		switch atomic.LoadUintptr(k) {
+		case addr:
+			return int(j)
+		case 0:
+			if !create {
+				return -1
+			}
+			if atomic.CompareAndSwapUintptr(k, 0, addr) {
+				return int(j)
+			}
+			if atomic.LoadUintptr(k) == addr { // 被另一个协程用同一把锁抢先占用
+				return int(j)
+			}
+		}
+	}
+	return -1
+}
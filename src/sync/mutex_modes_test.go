@@ -0,0 +1,82 @@
+package sync_test
+
+import (
+	"sort"
+	. "sync"
+	"testing"
+	"time"
+)
+
+// lockModes 是对比正常模式与饥饿模式的几种场景。
+// workpool/xsync 中的 BenchmarkLockModes 使用相同的场景和子测试名，
+// 两边的结果可以直接用 benchstat 对比 FairMutex 等其他实现
+var lockModes = []struct {
+	name        string
+	parallelism int           // 每个 P 的协程数，见 b.SetParallelism；0 表示单个协程
+	hold        time.Duration // 临界区的长度
+}{
+	// 没有竞争，只走快路径
+	{"uncontended", 0, 0},
+	// 临界区很短：等待者很少超过 1ms，保持在正常模式，新来的协程可以插队
+	{"normal", 1, time.Microsecond},
+	// 临界区长、等待者多：每个等待者要等前面所有协程的临界区，很快超过 1ms 进入饥饿模式
+	{"starving", 4, 100 * time.Microsecond},
+}
+
+// BenchmarkLockModes 在各场景下报告吞吐以及每次加锁等待时间的 p99 和最大值：
+// 饥饿模式按到达顺序移交，吞吐下降，但最长等待比正常模式中被反复插队时短
+//
+//	go test -run NONE -bench LockModes sync
+func BenchmarkLockModes(b *testing.B) {
+	locks := []struct {
+		name string
+		new  func() Locker
+	}{
+		{"Mutex", func() Locker { return new(Mutex) }},
+		{"RWMutex", func() Locker { return new(RWMutex) }},
+	}
+	for _, l := range locks {
+		for _, m := range lockModes {
+			b.Run(l.name+"/"+m.name, func(b *testing.B) {
+				benchmarkContendedWaits(b, l.new(), m.parallelism, m.hold)
+			})
+		}
+	}
+}
+
+// benchmarkContendedWaits 让 parallelism*GOMAXPROCS 个协程各自反复 Lock、持有 hold、Unlock，
+// 记录每次 Lock 的等待时间，报告 p99-ns 和 max-ns；parallelism 为 0 时只用一个协程
+func benchmarkContendedWaits(b *testing.B, l Locker, parallelism int, hold time.Duration) {
+	var (
+		resMu Mutex
+		waits []time.Duration
+	)
+	worker := func(next func() bool) {
+		local := make([]time.Duration, 0, 1024)
+		for next() {
+			start := time.Now()
+			l.Lock()
+			local = append(local, time.Since(start))
+			for end := time.Now().Add(hold); time.Now().Before(end); {
+				// 忙等而不是 Sleep，模拟占用 CPU 的临界区
+			}
+			l.Unlock()
+		}
+		resMu.Lock()
+		waits = append(waits, local...)
+		resMu.Unlock()
+	}
+	if parallelism == 0 {
+		i := 0
+		worker(func() bool { i++; return i <= b.N })
+	} else {
+		b.SetParallelism(parallelism)
+		b.RunParallel(func(pb *testing.PB) { worker(pb.Next) })
+	}
+	if len(waits) == 0 {
+		return
+	}
+	sort.Slice(waits, func(i, j int) bool { return waits[i] < waits[j] })
+	b.ReportMetric(float64(waits[len(waits)*99/100].Nanoseconds()), "p99-ns")
+	b.ReportMetric(float64(waits[len(waits)-1].Nanoseconds()), "max-ns")
+}
@@ -0,0 +1,114 @@
+//go:build mutexdebug
+// +build mutexdebug
+
+package sync
+
+import (
+	"runtime"
+	"sync/atomic"
+	"unsafe"
+)
+
+// 用 -tags mutexdebug 构建时，记录每把 Mutex 是被哪个协程锁住的，在另一个协程调用 Unlock 时给出警告。
+// Unlock 的注释明确允许这样做，所以只警告不报错，但实际中它多半意味着忘了解锁或重复解锁之类的错误。
+
+// CrossUnlock 描述一次跨协程的解锁
+type CrossUnlock struct {
+	Mutex    uintptr // Mutex 的地址
+	Locker   uint64  // 上锁的协程 id
+	Unlocker uint64  // 解锁的协程 id
+}
+
+// String 返回一行警告，如 "sync: mutex 0xc000012345 locked by goroutine 7, unlocked by goroutine 9"
+func (c CrossUnlock) String() string {
+	return "sync: mutex 0x" + hex(c.Mutex) + " locked by goroutine " + itoa(int64(c.Locker)) +
+		", unlocked by goroutine " + itoa(int64(c.Unlocker))
+}
+
+// crossUnlockHandler 是 *func(CrossUnlock)，为 nil 时使用 defaultCrossUnlock
+var crossUnlockHandler unsafe.Pointer
+
+// SetCrossUnlockHandler 设置跨协程解锁时的处理函数并返回原来的，nil 表示默认行为，即向标准错误输出一行警告。
+// 有意跨协程解锁的锁可以在 f 中按 Mutex 的地址过滤掉；f 在 Unlock 中调用，不能再对同一把锁加锁
+func SetCrossUnlockHandler(f func(CrossUnlock)) func(CrossUnlock) {
+	var p unsafe.Pointer
+	if f != nil {
+		p = unsafe.Pointer(&f)
+	}
+	old := atomic.SwapPointer(&crossUnlockHandler, p)
+	if old == nil {
+		return nil
+	}
+	return *(*func(CrossUnlock))(old)
+}
+
+// defaultCrossUnlock 只能用 print：fmt 和 os 都依赖 sync
+func defaultCrossUnlock(c CrossUnlock) {
+	print(c.String(), "\n")
+}
+
+// mutexOwnersSize 是记录持有者的表的大小，超过这么多把锁后新锁不再检查
+const mutexOwnersSize = 1 << 10
+
+type mutexOwner struct {
+	m    uintptr // 0 表示空闲，用 CAS 占用
+	goid uint64  // 最近一次上锁的协程 id，0 表示未上锁
+}
+
+// 持有者表以 Mutex 的地址为键，见 mutex_addrtab.go
+var mutexOwners [mutexOwnersSize]mutexOwner
+
+// mutexOwnerSlot 返回 m 所在的槽，不存在时占用一个空槽，表满时返回 nil
+func mutexOwnerSlot(m *Mutex) *mutexOwner {
+	i := mutexAddrLookup(uintptr(unsafe.Pointer(m)), mutexOwnersSize, func(i uintptr) *uintptr { return &mutexOwners[i].m }, true)
+	if i < 0 {
+		return nil
+	}
+	return &mutexOwners[i]
+}
+
+// mutexOwnerAcquire 在 Lock 或 TryLock 拿到锁后记录当前协程
+func mutexOwnerAcquire(m *Mutex) {
+	if s := mutexOwnerSlot(m); s != nil {
+		atomic.StoreUint64(&s.goid, goid())
+	}
+}
+
+// mutexOwnerRelease 在 Unlock 释放锁前检查当前协程是否就是上锁的协程
+func mutexOwnerRelease(m *Mutex) {
+	s := mutexOwnerSlot(m)
+	if s == nil {
+		return
+	}
+	locker := atomic.SwapUint64(&s.goid, 0)
+	if locker == 0 { // 表满之后才开始记录，或者是未上锁的锁，后者由 Unlock 自己 throw
+		return
+	}
+	if me := goid(); me != locker {
+		c := CrossUnlock{Mutex: uintptr(unsafe.Pointer(m)), Locker: locker, Unlocker: me}
+		if h := atomic.LoadPointer(&crossUnlockHandler); h != nil {
+			(*(*func(CrossUnlock))(h))(c)
+		} else {
+			defaultCrossUnlock(c)
+		}
+	}
+}
+
+// goid 从当前协程调用栈的首行 "goroutine 18 [running]:" 中解析出协程 id。
+// 很慢，只用于调试构建
+func goid() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	const prefix = "goroutine "
+	if len(b) < len(prefix) || string(b[:len(prefix)]) != prefix {
+		return 0
+	}
+	var id uint64
+	for _, c := range b[len(prefix):] {
+		if c < '0' || c > '9' {
+			break
+		}
+		id = id*10 + uint64(c-'0')
+	}
+	return id
+}
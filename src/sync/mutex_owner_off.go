@@ -0,0 +1,8 @@
+//go:build !mutexdebug
+// +build !mutexdebug
+
+package sync
+
+// 默认构建中不记录 Mutex 的持有者，见 mutex_owner.go
+func mutexOwnerAcquire(m *Mutex) {}
+func mutexOwnerRelease(m *Mutex) {}
@@ -0,0 +1,59 @@
+//go:build mutexdebug
+// +build mutexdebug
+
+package sync_test
+
+import (
+	. "sync"
+	"testing"
+	"unsafe"
+)
+
+// go test -tags mutexdebug -run CrossUnlock sync
+func TestCrossUnlock(t *testing.T) {
+	var got []CrossUnlock
+	old := SetCrossUnlockHandler(func(c CrossUnlock) { got = append(got, c) })
+	defer SetCrossUnlockHandler(old)
+
+	var mu Mutex
+	mu.Lock()
+	mu.Unlock() // 同一个协程，不警告
+	if len(got) != 0 {
+		t.Fatalf("same-goroutine unlock reported: %v", got)
+	}
+
+	mu.Lock()
+	done := make(chan bool)
+	go func() {
+		mu.Unlock()
+		done <- true
+	}()
+	<-done
+	if len(got) != 1 || got[0].Mutex != uintptr(unsafe.Pointer(&mu)) || got[0].Locker == got[0].Unlocker {
+		t.Fatalf("cross-goroutine unlock: got %v", got)
+	}
+}
+
+// 处理函数可以按地址过滤掉有意跨协程解锁的锁
+func TestCrossUnlockFilter(t *testing.T) {
+	var handoff Mutex // 有意在另一个协程解锁
+	var n int
+	old := SetCrossUnlockHandler(func(c CrossUnlock) {
+		if c.Mutex == uintptr(unsafe.Pointer(&handoff)) {
+			return
+		}
+		n++
+	})
+	defer SetCrossUnlockHandler(old)
+
+	handoff.Lock()
+	done := make(chan bool)
+	go func() {
+		handoff.Unlock()
+		done <- true
+	}()
+	<-done
+	if n != 0 {
+		t.Fatalf("filtered mutex reported %d times", n)
+	}
+}
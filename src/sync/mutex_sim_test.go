@@ -0,0 +1,246 @@
+package sync_test
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"sort"
+	"strings"
+	. "sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 争抢模拟：按固定的种子生成每个协程的到达间隔、持锁时间和读写角色，
+// 让它们争抢同一把 Mutex 或 RWMutex，记录拿到锁的顺序和每次的等待时间，
+// 用来重现 mutex.go 注释中关于正常模式（新来的协程插队）和饥饿模式（按到达顺序移交）的描述：
+//
+//	go test -run Simulation -v sync
+//
+// 工作负载由种子完全决定，拿到锁的顺序则取决于调度器，所以同一个种子每次的结果相近但不一定相同。
+
+// simConfig 描述一组争抢的协程
+type simConfig struct {
+	name       string
+	rw         bool          // 争抢 RWMutex 而不是 Mutex
+	goroutines int           // 协程数
+	ops        int           // 每个协程加锁的次数
+	hold       time.Duration // 平均持锁时间，实际在 [hold/2, hold*3/2) 中均匀分布
+	think      time.Duration // 两次加锁之间的平均间隔，即到达率的倒数，分布同 hold
+	readers    float64       // rw 时读锁所占的比例
+	procs      int           // GOMAXPROCS，0 表示不修改
+	seed       int64
+}
+
+// simOp 是一次加锁
+type simOp struct {
+	g, i    int  // 第 g 个协程的第 i 次加锁
+	read    bool // 是读锁
+	seq     int  // 拿到锁的顺序
+	arrive  time.Duration
+	acquire time.Duration
+	hold    time.Duration
+	think   time.Duration
+}
+
+func (op simOp) wait() time.Duration { return op.acquire - op.arrive }
+
+// simResult 是一次模拟的结果，ops 按拿到锁的顺序排列
+type simResult struct {
+	cfg       simConfig
+	ops       []simOp
+	overtakes int // 拿到锁时已有更早到达的协程在等待的次数，即插队的次数
+}
+
+// simSchedule 按种子生成每个协程的操作序列，与调度无关
+func simSchedule(cfg simConfig) [][]simOp {
+	jitter := func(r *rand.Rand, d time.Duration) time.Duration {
+		if d <= 0 {
+			return 0
+		}
+		return d/2 + time.Duration(r.Int63n(int64(d)))
+	}
+	ops := make([][]simOp, cfg.goroutines)
+	for g := range ops {
+		r := rand.New(rand.NewSource(cfg.seed + int64(g)))
+		ops[g] = make([]simOp, cfg.ops)
+		for i := range ops[g] {
+			ops[g][i] = simOp{
+				g:     g,
+				i:     i,
+				read:  cfg.rw && r.Float64() < cfg.readers,
+				hold:  jitter(r, cfg.hold),
+				think: jitter(r, cfg.think),
+			}
+		}
+	}
+	return ops
+}
+
+// busy 忙等 d，模拟占用 CPU 的临界区和到达间隔；Sleep 的精度太低
+func busy(d time.Duration) {
+	for end := time.Now().Add(d); time.Now().Before(end); {
+	}
+}
+
+func simulate(t *testing.T, cfg simConfig) simResult {
+	if cfg.procs > 0 {
+		defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(cfg.procs))
+	}
+	var (
+		mu      Mutex
+		rw      RWMutex
+		seq     int64
+		writers int32 // 持有写锁（或 Mutex）的协程数，只能是 0 或 1
+		readers int32 // 持有读锁的协程数
+	)
+	sched := simSchedule(cfg)
+	start := time.Now()
+	done := make(chan bool)
+	for g := range sched {
+		go func(ops []simOp) {
+			for i := range ops {
+				op := &ops[i]
+				busy(op.think)
+				op.arrive = time.Since(start)
+				switch {
+				case !cfg.rw:
+					mu.Lock()
+				case op.read:
+					rw.RLock()
+				default:
+					rw.Lock()
+				}
+				op.acquire = time.Since(start)
+				op.seq = int(atomic.AddInt64(&seq, 1))
+				if op.read {
+					atomic.AddInt32(&readers, 1)
+					if atomic.LoadInt32(&writers) != 0 {
+						t.Errorf("g%d#%d: reader holds the lock together with a writer", op.g, op.i)
+					}
+				} else {
+					if atomic.AddInt32(&writers, 1) != 1 || atomic.LoadInt32(&readers) != 0 {
+						t.Errorf("g%d#%d: writer does not hold the lock exclusively", op.g, op.i)
+					}
+				}
+				busy(op.hold)
+				if op.read {
+					atomic.AddInt32(&readers, -1)
+				} else {
+					atomic.AddInt32(&writers, -1)
+				}
+				switch {
+				case !cfg.rw:
+					mu.Unlock()
+				case op.read:
+					rw.RUnlock()
+				default:
+					rw.Unlock()
+				}
+			}
+			done <- true
+		}(sched[g])
+	}
+	for range sched {
+		<-done
+	}
+
+	res := simResult{cfg: cfg}
+	for _, ops := range sched {
+		res.ops = append(res.ops, ops...)
+	}
+	sort.Slice(res.ops, func(i, j int) bool { return res.ops[i].seq < res.ops[j].seq })
+	// 逐个检查：在它之前拿到锁的操作中，有没有比它晚到达的
+	latest := time.Duration(-1)
+	for _, op := range res.ops {
+		if op.arrive < latest {
+			res.overtakes++
+		}
+		if op.arrive > latest {
+			latest = op.arrive
+		}
+	}
+	return res
+}
+
+// percentile 返回已排序的 ws 中的第 p 百分位
+func percentile(ws []time.Duration, p int) time.Duration {
+	if len(ws) == 0 {
+		return 0
+	}
+	return ws[(len(ws)-1)*p/100]
+}
+
+// report 返回等待时间的分布和前 n 次拿到锁的顺序
+func (r simResult) report(n int) string {
+	ws := make([]time.Duration, len(r.ops))
+	for i, op := range r.ops {
+		ws[i] = op.wait()
+	}
+	sort.Slice(ws, func(i, j int) bool { return ws[i] < ws[j] })
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %d ops, overtakes=%d (%.1f%%), wait p50=%v p90=%v p99=%v max=%v\n",
+		r.cfg.name, len(r.ops), r.overtakes, 100*float64(r.overtakes)/float64(len(r.ops)),
+		percentile(ws, 50), percentile(ws, 90), percentile(ws, 99), percentile(ws, 100))
+	for _, op := range r.ops[:min(n, len(r.ops))] {
+		kind := "W"
+		if op.read {
+			kind = "R"
+		}
+		fmt.Fprintf(&b, "  %4d %s g%d#%d arrive=%v wait=%v\n", op.seq, kind, op.g, op.i,
+			op.arrive.Round(time.Microsecond), op.wait().Round(time.Microsecond))
+	}
+	return b.String()
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+var simConfigs = []simConfig{
+	// 持锁时间短，到达间隔长：大多走快路径，偶尔自旋
+	{name: "mutex/uncontended", goroutines: 4, ops: 200, hold: time.Microsecond, think: 50 * time.Microsecond, seed: 1},
+	// 持锁时间短但到达频繁：正常模式，新来的协程经常插队
+	{name: "mutex/normal", goroutines: 8, ops: 200, hold: 5 * time.Microsecond, think: 5 * time.Microsecond, seed: 1},
+	// 持锁时间超过 1ms 的饥饿阈值：进入饥饿模式，按到达顺序移交，插队变少但吞吐下降
+	{name: "mutex/starving", goroutines: 8, ops: 10, hold: 2 * time.Millisecond, think: 0, seed: 1},
+	// 只有一个 P 时不会自旋
+	{name: "mutex/procs=1", goroutines: 8, ops: 100, hold: 5 * time.Microsecond, think: 5 * time.Microsecond, procs: 1, seed: 1},
+	// 读多写少
+	{name: "rwmutex/read-mostly", rw: true, readers: 0.9, goroutines: 8, ops: 200, hold: 5 * time.Microsecond, think: 5 * time.Microsecond, seed: 1},
+	// 读写各半：写锁等待期间新来的读锁也要排队，见 rwmutex.go 中的 readerWait
+	{name: "rwmutex/mixed", rw: true, readers: 0.5, goroutines: 8, ops: 200, hold: 5 * time.Microsecond, think: 5 * time.Microsecond, seed: 1},
+}
+
+func TestSimulation(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping contention simulation in short mode")
+	}
+	for _, cfg := range simConfigs {
+		t.Run(cfg.name, func(t *testing.T) {
+			r := simulate(t, cfg)
+			if len(r.ops) != cfg.goroutines*cfg.ops {
+				t.Fatalf("completed %d ops, want %d", len(r.ops), cfg.goroutines*cfg.ops)
+			}
+			t.Log("\n" + r.report(20))
+		})
+	}
+}
+
+// 同一个种子生成的工作负载必须相同，这样不同的锁实现或参数之间才能比较
+func TestSimScheduleDeterministic(t *testing.T) {
+	cfg := simConfig{rw: true, readers: 0.5, goroutines: 3, ops: 50, hold: time.Millisecond, think: time.Millisecond, seed: 42}
+	a, b := simSchedule(cfg), simSchedule(cfg)
+	for g := range a {
+		for i := range a[g] {
+			if a[g][i] != b[g][i] {
+				t.Fatalf("g%d#%d: %+v != %+v", g, i, a[g][i], b[g][i])
+			}
+		}
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !mutexexperiment
+// +build !mutexexperiment
+
+package sync
+
+// canSpin 和 doSpin 是 Lock 自旋时调用的 runtime 钩子，默认构建中直接转发，
+// 用 -tags mutexexperiment 构建时可以调整，见 mutex_spin_exp.go
+func canSpin(iter int) bool {
+	return runtime_canSpin(iter)
+}
+
+func doSpin() {
+	runtime_doSpin()
+}
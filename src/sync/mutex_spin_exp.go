@@ -0,0 +1,49 @@
+//go:build mutexexperiment
+// +build mutexexperiment
+
+package sync
+
+import "sync/atomic"
+
+// 用 -tags mutexexperiment 构建时，可以关闭自旋或改变自旋的次数和长度，
+// 用来在自己的机器上测量 runtime_canSpin 和 runtime_doSpin 对吞吐的影响，
+// 见 mutex_spin_test.go 中的 BenchmarkSpin。与饥饿阈值一样，这只是学习用的开关
+
+// maxSpin 是一次 Lock 最多自旋的轮数，-1 表示由 runtime_canSpin 决定（最多 4 轮）
+var maxSpin int64 = -1
+
+// spinRounds 是每轮自旋调用 runtime_doSpin 的次数，每次是 30 条 PAUSE 指令
+var spinRounds int64 = 1
+
+// SetMaxSpin 设置一次 Lock 最多自旋的轮数并返回原值。
+// 0 表示不自旋，直接排队；-1 恢复 runtime_canSpin 的默认行为。
+// 设为正数时仍要满足 runtime_canSpin 的其他条件：多核、有空闲的 P、当前 P 的本地运行队列为空
+func SetMaxSpin(n int) int {
+	return int(atomic.SwapInt64(&maxSpin, int64(n)))
+}
+
+// SetSpinRounds 设置每轮自旋调用 runtime_doSpin 的次数并返回原值，n 小于 1 时按 1 处理
+func SetSpinRounds(n int) int {
+	if n < 1 {
+		n = 1
+	}
+	return int(atomic.SwapInt64(&spinRounds, int64(n)))
+}
+
+func canSpin(iter int) bool {
+	n := atomic.LoadInt64(&maxSpin)
+	switch {
+	case n < 0:
+		return runtime_canSpin(iter)
+	case int64(iter) >= n:
+		return false
+	}
+	// runtime_canSpin 在 iter 达到 4 时总是返回 false，传 0 只借用它对其他条件的检查
+	return runtime_canSpin(0)
+}
+
+func doSpin() {
+	for i := atomic.LoadInt64(&spinRounds); i > 0; i-- {
+		runtime_doSpin()
+	}
+}
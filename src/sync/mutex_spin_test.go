@@ -0,0 +1,44 @@
+//go:build mutexexperiment
+// +build mutexexperiment
+
+package sync_test
+
+import (
+	. "sync"
+	"testing"
+	"time"
+)
+
+// BenchmarkSpin 在不同的自旋设置下让多个协程争抢同一把锁，报告吞吐以及等待时间的 p99 和最大值。
+// 临界区短时自旋往往能避免一次挂起和唤醒；临界区长时自旋只是白白占用 CPU：
+//
+//	go test -tags mutexexperiment -run NONE -bench Spin sync
+func BenchmarkSpin(b *testing.B) {
+	settings := []struct {
+		name    string
+		maxSpin int
+		rounds  int
+	}{
+		{"off", 0, 1},
+		{"default", -1, 1}, // runtime_canSpin：最多 4 轮，每轮 30 条 PAUSE
+		{"max=16", 16, 1},
+		{"max=4/rounds=4", 4, 4},
+	}
+	holds := []struct {
+		name string
+		d    time.Duration
+	}{
+		{"hold=0", 0},
+		{"hold=1us", time.Microsecond},
+		{"hold=50us", 50 * time.Microsecond},
+	}
+	for _, s := range settings {
+		for _, h := range holds {
+			b.Run(s.name+"/"+h.name, func(b *testing.B) {
+				defer SetMaxSpin(SetMaxSpin(s.maxSpin))
+				defer SetSpinRounds(SetSpinRounds(s.rounds))
+				benchmarkContendedWaits(b, new(Mutex), 1, h.d)
+			})
+		}
+	}
+}
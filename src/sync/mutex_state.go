@@ -0,0 +1,50 @@
+//go:build mutexdebug
+// +build mutexdebug
+
+package sync
+
+import "sync/atomic"
+
+// MutexState 是 Mutex 打包在 state 中的各个位解码后的形式，只在 -tags mutexdebug 时提供，
+// 让测试和教学工具可以直接断言锁的内部状态，而不是靠猜
+type MutexState struct {
+	Locked   bool // mutexLocked：已上锁
+	Woken    bool // mutexWoken：已有协程被唤醒或正在自旋，Unlock 不必再唤醒
+	Starving bool // mutexStarving：饥饿模式，拥有权直接移交给队首的等待者
+	Waiters  int  // state >> mutexWaiterShift：阻塞在信号量上的等待者数量
+}
+
+// StateSnapshot 原子地读取一次 m.state 并解码。
+// 读到的只是某一瞬间的状态，有其他协程在使用这把锁时，返回后它可能已经变了
+func (m *Mutex) StateSnapshot() MutexState {
+	return decodeMutexState(atomic.LoadInt32(&m.state))
+}
+
+func decodeMutexState(state int32) MutexState {
+	return MutexState{
+		Locked:   state&mutexLocked != 0,
+		Woken:    state&mutexWoken != 0,
+		Starving: state&mutexStarving != 0,
+		Waiters:  int(state >> mutexWaiterShift),
+	}
+}
+
+// encode 是 decodeMutexState 的逆操作
+func (s MutexState) encode() int32 {
+	state := int32(s.Waiters) << mutexWaiterShift
+	if s.Locked {
+		state |= mutexLocked
+	}
+	if s.Woken {
+		state |= mutexWoken
+	}
+	if s.Starving {
+		state |= mutexStarving
+	}
+	return state
+}
+
+// String 返回与 MutexTrace 中相同的格式，如 "locked|starving waiters=3"
+func (s MutexState) String() string {
+	return mutexStateString(s.encode())
+}
@@ -0,0 +1,46 @@
+//go:build mutexdebug
+// +build mutexdebug
+
+package sync_test
+
+import (
+	. "sync"
+	"testing"
+	"time"
+)
+
+// go test -tags mutexdebug -run MutexStateSnapshot sync
+func TestMutexStateSnapshot(t *testing.T) {
+	var mu Mutex
+	if s := mu.StateSnapshot(); s != (MutexState{}) || s.String() != "unlocked waiters=0" {
+		t.Fatalf("zero Mutex: %+v (%v)", s, s)
+	}
+
+	mu.Lock()
+	if s := mu.StateSnapshot(); !s.Locked || s.Waiters != 0 {
+		t.Fatalf("after Lock: %v", s)
+	}
+
+	done := make(chan bool)
+	go func() {
+		mu.Lock()
+		mu.Unlock()
+		done <- true
+	}()
+	// 等到第二个协程排进队列
+	for i := 0; mu.StateSnapshot().Waiters == 0; i++ {
+		if i > 1000 {
+			t.Fatalf("waiter never queued: %v", mu.StateSnapshot())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if s := mu.StateSnapshot(); !s.Locked || s.Waiters != 1 {
+		t.Fatalf("with one waiter: %v", s)
+	}
+	mu.Unlock()
+	<-done
+
+	if s := mu.StateSnapshot(); s.Locked || s.Waiters != 0 {
+		t.Fatalf("after Unlock: %v", s)
+	}
+}
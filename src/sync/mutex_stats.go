@@ -0,0 +1,13 @@
+package sync
+
+// mutexStatKind 是 -tags mutexstats 时按锁统计的路径，在默认构建中只用作 mutexStat 的参数
+type mutexStatKind uint8
+
+const (
+	mutexStatFast      mutexStatKind = iota // Lock 的快路径 CAS 直接拿到锁
+	mutexStatSpin                           // 自旋后在慢路径中拿到锁，没有阻塞
+	mutexStatSlow                           // 没有自旋也没有阻塞，在慢路径中拿到锁（快路径 CAS 与 Unlock 恰好交错）
+	mutexStatSemaBlock                      // 阻塞在信号量上一次，一次 Lock 可能阻塞多次
+	mutexStatHandoff                        // 饥饿模式下从 Unlock 直接接手拥有权
+	mutexStatKinds
+)
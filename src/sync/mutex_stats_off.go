@@ -0,0 +1,7 @@
+//go:build !mutexstats
+// +build !mutexstats
+
+package sync
+
+// mutexStat 在默认构建中什么也不做
+func mutexStat(m *Mutex, st mutexStatKind) {}
@@ -0,0 +1,129 @@
+//go:build mutexstats
+// +build mutexstats
+
+package sync
+
+import (
+	"sync/atomic"
+	"unsafe"
+)
+
+// 用 -tags mutexstats 构建时，按锁统计 Lock 走了注释中描述的哪条路径，
+// 用来量化快路径、自旋、阻塞和饥饿移交各自实际发生的频率：
+//
+//	go test -tags mutexstats -run MutexStats sync
+
+// MutexStats 是一把锁的各路径计数
+type MutexStats struct {
+	Mutex     uintptr // Mutex 的地址，溢出的统计为 0
+	Fast      uint64  // 快路径 CAS 拿到锁
+	Spin      uint64  // 自旋后拿到锁，没有阻塞
+	Slow      uint64  // 没有自旋也没有阻塞，在慢路径中拿到锁
+	SemaBlock uint64  // 阻塞在信号量上的次数，被唤醒后拿到锁的 Lock 只计在这里
+	Handoff   uint64  // 饥饿模式下直接移交拥有权的次数
+}
+
+// mutexStatsSize 是统计表的大小，超过这么多把锁后，新锁的统计合并到地址为 0 的溢出项中
+const mutexStatsSize = 1 << 10
+
+type mutexStatsSlot struct {
+	m      uintptr // 0 表示空闲，用 CAS 占用
+	counts [mutexStatKinds]uint64
+}
+
+// 统计表以 Mutex 的地址为键，见 mutex_addrtab.go
+var (
+	mutexStatsTab      [mutexStatsSize]mutexStatsSlot
+	mutexStatsOverflow mutexStatsSlot
+)
+
+// mutexStatsLookup 返回 m 所在的槽，不存在时占用一个空槽，表满时返回溢出项
+func mutexStatsLookup(m uintptr, create bool) *mutexStatsSlot {
+	i := mutexAddrLookup(m, mutexStatsSize, func(i uintptr) *uintptr { return &mutexStatsTab[i].m }, create)
+	switch {
+	case i >= 0:
+		return &mutexStatsTab[i]
+	case !create:
+		return nil
+	}
+	return &mutexStatsOverflow
+}
+
+func mutexStat(m *Mutex, st mutexStatKind) {
+	s := mutexStatsLookup(uintptr(unsafe.Pointer(m)), true)
+	atomic.AddUint64(&s.counts[st], 1)
+}
+
+func (s *mutexStatsSlot) load() MutexStats {
+	return MutexStats{
+		Mutex:     atomic.LoadUintptr(&s.m),
+		Fast:      atomic.LoadUint64(&s.counts[mutexStatFast]),
+		Spin:      atomic.LoadUint64(&s.counts[mutexStatSpin]),
+		Slow:      atomic.LoadUint64(&s.counts[mutexStatSlow]),
+		SemaBlock: atomic.LoadUint64(&s.counts[mutexStatSemaBlock]),
+		Handoff:   atomic.LoadUint64(&s.counts[mutexStatHandoff]),
+	}
+}
+
+// MutexStatsOf 返回 m 的统计，从未上过锁时各项为 0
+func MutexStatsOf(m *Mutex) MutexStats {
+	if s := mutexStatsLookup(uintptr(unsafe.Pointer(m)), false); s != nil {
+		return s.load()
+	}
+	return MutexStats{Mutex: uintptr(unsafe.Pointer(m))}
+}
+
+// AllMutexStats 返回所有被统计过的锁，溢出项有计数时放在最后
+func AllMutexStats() []MutexStats {
+	var all []MutexStats
+	for i := range mutexStatsTab {
+		if atomic.LoadUintptr(&mutexStatsTab[i].m) != 0 {
+			all = append(all, mutexStatsTab[i].load())
+		}
+	}
+	if o := mutexStatsOverflow.load(); o != (MutexStats{}) {
+		all = append(all, o)
+	}
+	return all
+}
+
+// ResetMutexStats 清零所有计数，已占用的槽保留
+func ResetMutexStats() {
+	for i := range mutexStatsTab {
+		for k := range mutexStatsTab[i].counts {
+			atomic.StoreUint64(&mutexStatsTab[i].counts[k], 0)
+		}
+	}
+	for k := range mutexStatsOverflow.counts {
+		atomic.StoreUint64(&mutexStatsOverflow.counts[k], 0)
+	}
+}
+
+// String 返回一行的统计，如 "0xc000012345 fast=10 spin=2 slow=0 block=3 handoff=1"
+func (s MutexStats) String() string {
+	name := "overflow"
+	if s.Mutex != 0 {
+		name = "0x" + hex(s.Mutex)
+	}
+	return name +
+		" fast=" + itoa(int64(s.Fast)) +
+		" spin=" + itoa(int64(s.Spin)) +
+		" slow=" + itoa(int64(s.Slow)) +
+		" block=" + itoa(int64(s.SemaBlock)) +
+		" handoff=" + itoa(int64(s.Handoff))
+}
+
+// MutexStatsReport 把 AllMutexStats 格式化为每把锁一行，最后一行是合计
+func MutexStatsReport() string {
+	var r string
+	var total MutexStats
+	for _, s := range AllMutexStats() {
+		r += s.String() + "\n"
+		total.Fast += s.Fast
+		total.Spin += s.Spin
+		total.Slow += s.Slow
+		total.SemaBlock += s.SemaBlock
+		total.Handoff += s.Handoff
+	}
+	return r + "total" + total.String()[len("overflow"):] + "\n"
+}
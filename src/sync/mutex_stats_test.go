@@ -0,0 +1,46 @@
+//go:build mutexstats
+// +build mutexstats
+
+package sync_test
+
+import (
+	"runtime"
+	. "sync"
+	"testing"
+	"time"
+)
+
+// go test -tags mutexstats -run MutexStats -v sync
+func TestMutexStats(t *testing.T) {
+	var mu Mutex
+	for i := 0; i < 10; i++ {
+		mu.Lock()
+		mu.Unlock()
+	}
+	if s := MutexStatsOf(&mu); s.Fast != 10 || s.SemaBlock != 0 || s.Handoff != 0 {
+		t.Fatalf("uncontended: %v", s)
+	}
+
+	// 持锁时间远大于饥饿阈值，等待者必然阻塞，并最终进入饥饿模式
+	ResetMutexStats()
+	done := make(chan bool)
+	for g := 0; g < 4; g++ {
+		go func() {
+			for i := 0; i < 5; i++ {
+				mu.Lock()
+				time.Sleep(2 * time.Millisecond)
+				mu.Unlock()
+				runtime.Gosched()
+			}
+			done <- true
+		}()
+	}
+	for g := 0; g < 4; g++ {
+		<-done
+	}
+	s := MutexStatsOf(&mu)
+	if s.SemaBlock == 0 || s.Handoff == 0 {
+		t.Errorf("contended: %v, want blocks and handoffs", s)
+	}
+	t.Logf("\n%s", MutexStatsReport())
+}
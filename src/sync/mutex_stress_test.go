@@ -0,0 +1,168 @@
+package sync_test
+
+import (
+	"math/rand"
+	"runtime"
+	. "sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 压力测试：大量协程以随机的持锁时间争抢同一把锁，检查注释中各条路径在合法使用下的不变量：
+//   - 互斥：任意时刻最多一个协程持有 Mutex（或 RWMutex 的写锁），写锁与读锁不共存
+//   - 没有丢失的唤醒：所有协程都能在期限内结束，否则视为有等待者永远没被唤醒
+//   - throw 不可达：mutex.go 中的 throw 会直接终止测试进程，测试能跑完即说明没有触发
+//
+// 用 -race 运行可以同时检查 race.Acquire/race.Release 的标注：
+//
+//	go test -race -run Stress sync
+
+// stressDeadline 是所有协程结束的期限，超过即认为有唤醒丢失
+const stressDeadline = time.Minute
+
+// stressSize 返回协程数和每个协程的加锁次数，-short 时缩小
+func stressSize() (goroutines, iters int) {
+	if testing.Short() {
+		return 200, 50
+	}
+	return 2000, 100
+}
+
+// stressHold 在临界区内停留随机的一段时间：多数很短，少数让出 CPU 或睡眠，
+// 以便覆盖快路径、自旋、阻塞和超过 1ms 阈值进入饥饿模式的情况
+func stressHold(r *rand.Rand) {
+	switch n := r.Intn(100); {
+	case n < 80:
+		for i := r.Intn(100); i > 0; i-- {
+		}
+	case n < 99:
+		runtime.Gosched()
+	default:
+		time.Sleep(time.Duration(r.Intn(2000)) * time.Microsecond)
+	}
+}
+
+// waitAll 等待 n 个协程结束，超过 stressDeadline 时失败
+func waitAll(t *testing.T, done <-chan bool, n int) {
+	t.Helper()
+	timeout := time.After(stressDeadline)
+	for i := 0; i < n; i++ {
+		select {
+		case <-done:
+		case <-timeout:
+			t.Fatalf("%d of %d goroutines still blocked after %v: lost wakeup?", n-i, n, stressDeadline)
+		}
+	}
+}
+
+func TestMutexStress(t *testing.T) {
+	goroutines, iters := stressSize()
+	var (
+		mu      Mutex
+		holders int32
+		count   int // 只在持有锁时修改，-race 会发现未被保护的访问
+	)
+	done := make(chan bool, goroutines)
+	for g := 0; g < goroutines; g++ {
+		go func(seed int64) {
+			r := rand.New(rand.NewSource(seed))
+			for i := 0; i < iters; i++ {
+				if r.Intn(10) == 0 { // 混入 TryLock，它和 Lock 之间也要互斥
+					if !mu.TryLock() {
+						continue
+					}
+				} else {
+					mu.Lock()
+				}
+				if n := atomic.AddInt32(&holders, 1); n != 1 {
+					t.Errorf("%d goroutines hold the mutex", n)
+				}
+				count++
+				stressHold(r)
+				atomic.AddInt32(&holders, -1)
+				mu.Unlock()
+			}
+			done <- true
+		}(int64(g))
+	}
+	waitAll(t, done, goroutines)
+
+	if count == 0 || count > goroutines*iters {
+		t.Errorf("count = %d, want in (0, %d]", count, goroutines*iters)
+	}
+	// 所有协程结束后锁必须是空闲的，没有残留的等待者或标志位
+	if !mu.TryLock() {
+		t.Fatal("mutex still locked after all goroutines finished")
+	}
+	mu.Unlock()
+}
+
+func TestRWMutexStress(t *testing.T) {
+	goroutines, iters := stressSize()
+	var (
+		rw      RWMutex
+		writers int32
+		readers int32
+	)
+	done := make(chan bool, goroutines)
+	for g := 0; g < goroutines; g++ {
+		go func(seed int64) {
+			r := rand.New(rand.NewSource(seed))
+			for i := 0; i < iters; i++ {
+				if r.Intn(4) == 0 {
+					rw.Lock()
+					if n := atomic.AddInt32(&writers, 1); n != 1 {
+						t.Errorf("%d writers hold the lock", n)
+					}
+					if n := atomic.LoadInt32(&readers); n != 0 {
+						t.Errorf("writer holds the lock with %d readers", n)
+					}
+					stressHold(r)
+					atomic.AddInt32(&writers, -1)
+					rw.Unlock()
+				} else {
+					rw.RLock()
+					atomic.AddInt32(&readers, 1)
+					if n := atomic.LoadInt32(&writers); n != 0 {
+						t.Errorf("reader holds the lock with %d writers", n)
+					}
+					stressHold(r)
+					atomic.AddInt32(&readers, -1)
+					rw.RUnlock()
+				}
+			}
+			done <- true
+		}(int64(g))
+	}
+	waitAll(t, done, goroutines)
+
+	if !rw.TryLock() {
+		t.Fatal("rwmutex still locked after all goroutines finished")
+	}
+	rw.Unlock()
+}
+
+// 一个协程加锁、另一个协程解锁是允许的（见 Unlock 的注释），在等待者很多时也不能丢失唤醒
+func TestMutexStressCrossGoroutineUnlock(t *testing.T) {
+	goroutines, iters := stressSize()
+	var mu Mutex
+	handoff := make(chan bool)
+	go func() { // 专门负责解锁的协程
+		for range handoff {
+			mu.Unlock()
+		}
+	}()
+	done := make(chan bool, goroutines)
+	for g := 0; g < goroutines; g++ {
+		go func() {
+			for i := 0; i < iters/10+1; i++ {
+				mu.Lock()
+				handoff <- true
+			}
+			done <- true
+		}()
+	}
+	waitAll(t, done, goroutines)
+	close(handoff)
+}
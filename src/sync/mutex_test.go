@@ -0,0 +1,56 @@
+package sync_test
+
+import (
+	. "sync"
+	"testing"
+)
+
+func TestMutexTryLock(t *testing.T) {
+	m := new(Mutex)
+	m.Lock()
+	if m.TryLock() {
+		t.Fatalf("TryLock succeeded with mutex locked")
+	}
+	m.Unlock()
+	if !m.TryLock() {
+		t.Fatalf("TryLock failed with mutex unlocked")
+	}
+	m.Unlock()
+}
+
+// TryLock 成功的协程之间也必须互斥
+func TestMutexTryLockExclusion(t *testing.T) {
+	const (
+		procs = 8
+		iters = 10000
+	)
+	var (
+		m       Mutex
+		holders int32 // 只在持有锁时修改，不需要原子操作
+		got     int
+	)
+	done := make(chan bool, procs)
+	for p := 0; p < procs; p++ {
+		go func() {
+			for i := 0; i < iters; i++ {
+				if !m.TryLock() {
+					continue
+				}
+				holders++
+				if holders != 1 {
+					panic("TryLock: two holders")
+				}
+				got++
+				holders--
+				m.Unlock()
+			}
+			done <- true
+		}()
+	}
+	for p := 0; p < procs; p++ {
+		<-done
+	}
+	if got == 0 {
+		t.Fatalf("TryLock never succeeded")
+	}
+}
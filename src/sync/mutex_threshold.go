@@ -0,0 +1,12 @@
+//go:build !mutexexperiment
+// +build !mutexexperiment
+
+package sync
+
+// starvationThresholdNs 是等待者转为饥饿状态的阈值，默认构建中固定为 1ms
+const starvationThresholdNs = defaultStarvationThresholdNs
+
+// starvationThreshold 返回饥饿阈值，常量在内联后与直接比较常量没有区别
+func starvationThreshold() int64 {
+	return starvationThresholdNs
+}
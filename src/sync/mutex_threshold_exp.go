@@ -0,0 +1,23 @@
+//go:build mutexexperiment
+// +build mutexexperiment
+
+package sync
+
+import "sync/atomic"
+
+// 用 -tags mutexexperiment 构建时，饥饿阈值是可以调整的变量，用于实验不同的阈值对队尾延迟的影响，
+// 见 mutex_threshold_test.go 中的 BenchmarkStarvationThreshold。这只是学习用的开关，不属于 sync 的 API
+
+// starvationThresholdNs 是等待者转为饥饿状态的阈值，读写都用原子操作，因此可以在运行中修改
+var starvationThresholdNs int64 = defaultStarvationThresholdNs
+
+// starvationThreshold 返回当前的饥饿阈值，Lock 在两种构建下都通过它读取阈值
+func starvationThreshold() int64 {
+	return atomic.LoadInt64(&starvationThresholdNs)
+}
+
+// SetStarvationThreshold 设置饥饿阈值（纳秒）并返回原值。
+// 设为 0 时等待者第一次被唤醒没抢到锁就会转为饥饿模式；设为很大的值则相当于关闭饥饿模式
+func SetStarvationThreshold(ns int64) int64 {
+	return atomic.SwapInt64(&starvationThresholdNs, ns)
+}
@@ -0,0 +1,36 @@
+//go:build mutexexperiment
+// +build mutexexperiment
+
+package sync_test
+
+import (
+	"math"
+	. "sync"
+	"testing"
+	"time"
+)
+
+// BenchmarkStarvationThreshold 在不同的饥饿阈值下让多个协程争抢同一把锁，
+// 报告吞吐以及每次 Lock 等待时间的 p99 和最大值，用来观察 1ms 这个选择对队尾延迟的影响：
+//
+//	go test -tags mutexexperiment -run NONE -bench StarvationThreshold sync
+func BenchmarkStarvationThreshold(b *testing.B) {
+	thresholds := []struct {
+		name string
+		ns   int64
+	}{
+		{"0", 0},
+		{"10us", 10e3},
+		{"100us", 100e3},
+		{"1ms", 1e6}, // 默认值
+		{"10ms", 10e6},
+		{"never", math.MaxInt64}, // 相当于没有饥饿模式
+	}
+	for _, th := range thresholds {
+		b.Run(th.name, func(b *testing.B) {
+			old := SetStarvationThreshold(th.ns)
+			defer SetStarvationThreshold(old)
+			benchmarkContendedWaits(b, new(Mutex), 4, 20*time.Microsecond)
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package sync
+
+// Mutex 状态变化的追踪点。Lock、TryLock 和 Unlock 在注释中描述的每一步都会调用 mutexTrace，
+// 默认构建中它是空函数，内联后没有任何开销；用 -tags mutexdebug 构建时记录到环形缓冲中，
+// 可以用 MutexTrace 取出，把注释里的快路径、自旋、排队、饥饿移交变成看得见的运行时行为
+
+// mutexEvent 是一次状态变化的种类
+type mutexEvent uint8
+
+const (
+	mutexEvFastLock      mutexEvent = iota // Lock 快路径：CAS 0 -> mutexLocked 成功
+	mutexEvSpinWoken                       // 自旋时设置了 mutexWoken，通知 Unlock 不必再唤醒别人
+	mutexEvSlowLock                        // 慢路径中 CAS 成功并拿到锁（锁原本是空闲的）
+	mutexEvQueue                           // 慢路径中 CAS 成功但锁被占用，排到队尾后阻塞在信号量上
+	mutexEvRequeue                         // 被唤醒后又没抢到，排到队首（LIFO）再次阻塞
+	mutexEvWoken                           // 正常模式下从信号量上被唤醒，重新开始争抢
+	mutexEvHandoff                         // 饥饿模式下被唤醒，直接得到了拥有权
+	mutexEvExitStarving                    // 得到拥有权的等待者把 mutex 切回正常模式
+	mutexEvTryLock                         // TryLock 成功
+	mutexEvTryLockFail                     // TryLock 失败
+	mutexEvUnlock                          // Unlock 快路径：去掉 mutexLocked 后无需唤醒
+	mutexEvUnlockWake                      // Unlock 在正常模式下唤醒一个等待者
+	mutexEvUnlockHandoff                   // Unlock 在饥饿模式下把拥有权移交给队首的等待者
+)
+
+var mutexEventNames = [...]string{
+	mutexEvFastLock:      "fast-lock",
+	mutexEvSpinWoken:     "spin-woken",
+	mutexEvSlowLock:      "slow-lock",
+	mutexEvQueue:         "queue",
+	mutexEvRequeue:       "requeue-lifo",
+	mutexEvWoken:         "woken",
+	mutexEvHandoff:       "handoff",
+	mutexEvExitStarving:  "exit-starving",
+	mutexEvTryLock:       "trylock",
+	mutexEvTryLockFail:   "trylock-fail",
+	mutexEvUnlock:        "unlock",
+	mutexEvUnlockWake:    "unlock-wake",
+	mutexEvUnlockHandoff: "unlock-handoff",
+}
+
+func (e mutexEvent) String() string {
+	if int(e) < len(mutexEventNames) {
+		return mutexEventNames[e]
+	}
+	return "unknown"
+}
+
+// mutexStateString 把打包在 state 中的位解码成可读的形式，如 "locked|starving waiters=3"。
+// sync 不能导入 fmt 和 strconv（它们依赖 sync），因此自己拼接
+func mutexStateString(state int32) string {
+	s := ""
+	add := func(flag string) {
+		if s != "" {
+			s += "|"
+		}
+		s += flag
+	}
+	if state&mutexLocked != 0 {
+		add("locked")
+	}
+	if state&mutexWoken != 0 {
+		add("woken")
+	}
+	if state&mutexStarving != 0 {
+		add("starving")
+	}
+	if s == "" {
+		s = "unlocked"
+	}
+	return s + " waiters=" + itoa(int64(state>>mutexWaiterShift))
+}
+
+// itoa 是不依赖 strconv 的十进制格式化
+func itoa(v int64) string {
+	if v == 0 {
+		return "0"
+	}
+	neg := v < 0
+	if neg {
+		v = -v
+	}
+	var buf [20]byte
+	i := len(buf)
+	for v > 0 {
+		i--
+		buf[i] = byte('0' + v%10)
+		v /= 10
+	}
+	if neg {
+		i--
+		buf[i] = '-'
+	}
+	return string(buf[i:])
+}
+
+// hex 是不依赖 strconv 的十六进制格式化，用于打印锁的地址
+func hex(v uintptr) string {
+	const digits = "0123456789abcdef"
+	if v == 0 {
+		return "0"
+	}
+	var buf [16]byte
+	i := len(buf)
+	for v > 0 {
+		i--
+		buf[i] = digits[v&0xf]
+		v >>= 4
+	}
+	return string(buf[i:])
+}
@@ -0,0 +1,7 @@
+//go:build !mutexdebug
+// +build !mutexdebug
+
+package sync
+
+// mutexTrace 在默认构建中什么也不做
+func mutexTrace(m *Mutex, ev mutexEvent, old, new int32) {}
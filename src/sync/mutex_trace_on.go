@@ -0,0 +1,93 @@
+//go:build mutexdebug
+// +build mutexdebug
+
+package sync
+
+import (
+	"sync/atomic"
+	"unsafe"
+)
+
+// mutexTraceSize 是环形缓冲的大小，只保留最近的这么多次状态变化
+const mutexTraceSize = 1 << 12
+
+// MutexEvent 是 -tags mutexdebug 时记录的一次 Mutex 状态变化
+type MutexEvent struct {
+	Seq   uint64  // 全局递增的序号
+	When  int64   // runtime_nanotime 的时间
+	G     uint64  // 引起变化的协程 id
+	Mutex uintptr // Mutex 的地址，用于区分不同的锁
+	Event string  // 变化的种类，如 fast-lock、queue、handoff
+	Old   int32   // 变化前的 state
+	New   int32   // 变化后的 state
+}
+
+// String 返回一行可读的描述，如 "#12 @1234567 g7 0xc000012345 queue: locked waiters=0 -> locked waiters=1"，
+// cmd/mutexviz 按这个格式解析 MutexTraceDump 的输出
+func (e MutexEvent) String() string {
+	return "#" + itoa(int64(e.Seq)) + " @" + itoa(e.When) + " g" + itoa(int64(e.G)) +
+		" 0x" + hex(e.Mutex) + " " + e.Event + ": " +
+		mutexStateString(e.Old) + " -> " + mutexStateString(e.New)
+}
+
+type mutexTraceRecord struct {
+	seq      uint64
+	when     int64
+	g        uint64
+	m        uintptr
+	ev       mutexEvent
+	old, new int32
+}
+
+var (
+	mutexTraceSeq uint64 // 下一个序号，原子操作
+	mutexTraceBuf [mutexTraceSize]mutexTraceRecord
+)
+
+// mutexTrace 把一次状态变化写入环形缓冲。各字段不是原子写入的，
+// 在有锁正在使用时调用 MutexTrace 可能读到写了一半的记录，这对调试来说可以接受。
+// 协程 id 用 goid 从调用栈解析，很慢，会明显改变争抢的时序
+func mutexTrace(m *Mutex, ev mutexEvent, old, new int32) {
+	g := goid()
+	seq := atomic.AddUint64(&mutexTraceSeq, 1)
+	r := &mutexTraceBuf[seq%mutexTraceSize]
+	*r = mutexTraceRecord{seq: seq, when: runtime_nanotime(), g: g, m: uintptr(unsafe.Pointer(m)), ev: ev, old: old, new: new}
+}
+
+// MutexTrace 按发生顺序返回环形缓冲中最近的状态变化，m 不为 nil 时只返回这个锁的
+func MutexTrace(m *Mutex) []MutexEvent {
+	last := atomic.LoadUint64(&mutexTraceSeq)
+	first := uint64(1)
+	if last >= mutexTraceSize {
+		first = last - mutexTraceSize + 1
+	}
+	var events []MutexEvent
+	for seq := first; seq <= last; seq++ {
+		r := mutexTraceBuf[seq%mutexTraceSize]
+		if r.seq != seq { // 已被更新的记录覆盖或还没写完
+			continue
+		}
+		if m != nil && r.m != uintptr(unsafe.Pointer(m)) {
+			continue
+		}
+		events = append(events, MutexEvent{
+			Seq:   r.seq,
+			When:  r.when,
+			G:     r.g,
+			Mutex: r.m,
+			Event: r.ev.String(),
+			Old:   r.old,
+			New:   r.new,
+		})
+	}
+	return events
+}
+
+// MutexTraceDump 把 MutexTrace(m) 格式化为多行文本，便于在测试或调试器中直接打印
+func MutexTraceDump(m *Mutex) string {
+	s := ""
+	for _, e := range MutexTrace(m) {
+		s += e.String() + "\n"
+	}
+	return s
+}
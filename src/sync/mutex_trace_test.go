@@ -0,0 +1,33 @@
+//go:build mutexdebug
+// +build mutexdebug
+
+package sync_test
+
+import (
+	. "sync"
+	"testing"
+)
+
+// go test -tags mutexdebug -run MutexTrace sync
+func TestMutexTrace(t *testing.T) {
+	var mu Mutex
+	mu.Lock()
+	if mu.TryLock() {
+		t.Fatal("TryLock succeeded on a locked mutex")
+	}
+	mu.Unlock()
+
+	var got []string
+	for _, e := range MutexTrace(&mu) {
+		got = append(got, e.Event)
+	}
+	want := []string{"fast-lock", "trylock-fail", "unlock"}
+	if len(got) != len(want) {
+		t.Fatalf("events = %v, want %v\n%s", got, want, MutexTraceDump(&mu))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("events = %v, want %v\n%s", got, want, MutexTraceDump(&mu))
+		}
+	}
+}
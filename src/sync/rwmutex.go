@@ -48,6 +48,35 @@ func (rw *RWMutex) RLock() {
 	}
 }
 
+// TryRLock 尝试给 rw 上读锁，并报告是否成功。
+//
+// 注意：虽然确实存在正确使用 TryRLock 的场景，但它们很少见，
+// 使用 TryRLock 往往说明对 mutex 的某种用法存在更深层的问题。
+func (rw *RWMutex) TryRLock() bool {
+	if race.Enabled {
+		_ = rw.w.state
+		race.Disable()
+	}
+	for {
+		c := atomic.LoadInt32(&rw.readerCount)
+		if c < 0 { // readerCount 为负说明有写者持有锁或正在等待（Lock 中减去了 rwmutexMaxReaders），不能插队
+			if race.Enabled {
+				race.Enable()
+			}
+			return false
+		}
+		// 不能像 RLock 那样直接 AddInt32：加上之后若发现为负，就已经把自己算进了读者，只能去排队。
+		// 所以用 CAS，只在没有写者的前提下加一；CAS 失败说明有其他读者同时进出，重读再试
+		if atomic.CompareAndSwapInt32(&rw.readerCount, c, c+1) {
+			if race.Enabled {
+				race.Enable()
+				race.Acquire(unsafe.Pointer(&rw.readerSem))
+			}
+			return true
+		}
+	}
+}
+
 // RUlock 函数撤销一个单一的 RLock 调用；
 // 它不会影响其他读者。
 // 如果 rw 没有被读锁锁住，在此函数入口会报一个进行时错误。
@@ -100,6 +129,39 @@ func (rw *RWMutex) Lock() {
 	}
 }
 
+// TryLock 尝试给 rw 上写锁，并报告是否成功。
+//
+// 注意：虽然确实存在正确使用 TryLock 的场景，但它们很少见，
+// 使用 TryLock 往往说明对 mutex 的某种用法存在更深层的问题。
+func (rw *RWMutex) TryLock() bool {
+	if race.Enabled {
+		_ = rw.w.state
+		race.Disable()
+	}
+	// 先抢写者之间的锁，失败说明有其他写者持有或在等待
+	if !rw.w.TryLock() {
+		if race.Enabled {
+			race.Enable()
+		}
+		return false
+	}
+	// 再确认没有读者：readerCount 为 0 时一步换成 -rwmutexMaxReaders，通知之后的读者有写者。
+	// 与 Lock 不同，有读者时不等待它们离开（不设置 readerWait），而是放弃并归还 w
+	if !atomic.CompareAndSwapInt32(&rw.readerCount, 0, -rwmutexMaxReaders) {
+		rw.w.Unlock()
+		if race.Enabled {
+			race.Enable()
+		}
+		return false
+	}
+	if race.Enabled {
+		race.Enable()
+		race.Acquire(unsafe.Pointer(&rw.readerSem))
+		race.Acquire(unsafe.Pointer(&rw.writerSem))
+	}
+	return true
+}
+
 // Unlock 将 rw 解锁用于写操作。
 // 如果 rw 未被上写锁，它会在入口报一个进行时的错误。
 //
@@ -129,6 +191,35 @@ func (rw *RWMutex) Unlock() {
 	}
 }
 
+// DowngradeToRLock 把 rw 持有的写锁原子地降级为读锁，之后需要用 RUnlock 释放。
+// 与先 Unlock 再 RLock 不同，中间没有释放锁的窗口，其他写者不能插进来修改数据，
+// 适用于写完之后还要继续读、且读的部分可以与其他读者并行的场景。
+// 如果 rw 没有被上写锁，会报一个进行时错误。
+func (rw *RWMutex) DowngradeToRLock() {
+	if race.Enabled {
+		_ = rw.w.state
+		race.Release(unsafe.Pointer(&rw.readerSem))
+		race.Disable()
+	}
+
+	// 与 Unlock 一样加回 rwmutexMaxReaders 通知读者们写者已不活跃，再多加 1 把自己算作一个读者。
+	// 这之后新来的读者不再阻塞；我们仍持有 w，其他写者还在 w 上排队，拿到 w 后也要等我们 RUnlock
+	r := atomic.AddInt32(&rw.readerCount, rwmutexMaxReaders+1)
+	if r >= rwmutexMaxReaders+1 {
+		race.Enable()
+		throw("sync: DowngradeToRLock of unlocked RWMutex")
+	}
+	// 唤醒在写锁期间阻塞的读者，r 中包含了自己，所以是 r-1 个
+	for i := 0; i < int(r)-1; i++ {
+		runtime_Semrelease(&rw.readerSem, false, 0)
+	}
+	// 允许其他写者进行处理，它们会在 Lock 中等待包括我们在内的读者离开
+	rw.w.Unlock()
+	if race.Enabled {
+		race.Enable()
+	}
+}
+
 // RLocker 返回一个定义了拥有 Lock 和 Unlock 两个方法的 Locker 接口。
 // 通过Lock 和 Unlock 调用 rw.RLock 和 rw.RUnlock 函数。
 func (rw *RWMutex) RLocker() Locker {
@@ -0,0 +1,59 @@
+//go:build mutexdebug
+// +build mutexdebug
+
+package sync
+
+import "sync/atomic"
+
+// RWMutexState 是 RWMutex 内部计数解码后的形式，只在 -tags mutexdebug 时提供，
+// 供测试和争抢模拟（mutex_sim_test.go）断言读者和写者的状态
+type RWMutexState struct {
+	Readers        int  // 持有读锁的读者数
+	Departing      int  // readerWait：写者在等待离开的读者数，没有写者时为 0
+	BlockedReaders int  // 因有写者而阻塞在 readerSem 上的读者数
+	WriterPending  bool // 有写者已通知读者，正在等待 Departing 个读者离开
+	WriterLocked   bool // 有写者持有锁
+	QueuedWriters  int  // 阻塞在 w 上、排在当前写者后面的写者数
+}
+
+// StateSnapshot 读取 rw 的各个计数并解码。
+//
+// 写者在 Lock 中把 readerCount 减去 rwmutexMaxReaders，使它变为负数，以此通知之后的读者：
+// 此时 readerCount+rwmutexMaxReaders 是还没离开的读者加上新来而阻塞的读者，
+// 其中还没离开的那部分记在 readerWait 中。
+// 几个字段不是一次原子读取的，有其他协程在使用这把锁时，得到的状态可能短暂地不一致
+func (rw *RWMutex) StateSnapshot() RWMutexState {
+	rc := atomic.LoadInt32(&rw.readerCount)
+	wait := atomic.LoadInt32(&rw.readerWait)
+	s := RWMutexState{QueuedWriters: rw.w.StateSnapshot().Waiters}
+	if rc >= 0 { // 没有写者
+		s.Readers = int(rc)
+		return s
+	}
+	active := rc + rwmutexMaxReaders // 还没离开的读者 + 阻塞的读者
+	s.Readers = int(wait)
+	s.Departing = int(wait)
+	s.BlockedReaders = int(active - wait)
+	if wait > 0 {
+		s.WriterPending = true
+	} else {
+		s.WriterLocked = true
+	}
+	return s
+}
+
+// String 返回一行可读的描述，如 "readers=2 departing=2 blocked=1 writer=pending queued-writers=0"
+func (s RWMutexState) String() string {
+	writer := "none"
+	switch {
+	case s.WriterLocked:
+		writer = "locked"
+	case s.WriterPending:
+		writer = "pending"
+	}
+	return "readers=" + itoa(int64(s.Readers)) +
+		" departing=" + itoa(int64(s.Departing)) +
+		" blocked=" + itoa(int64(s.BlockedReaders)) +
+		" writer=" + writer +
+		" queued-writers=" + itoa(int64(s.QueuedWriters))
+}
@@ -0,0 +1,72 @@
+//go:build mutexdebug
+// +build mutexdebug
+
+package sync_test
+
+import (
+	. "sync"
+	"testing"
+	"time"
+)
+
+// waitState 轮询 rw 的状态，直到 ok 返回 true
+func waitState(t *testing.T, rw *RWMutex, ok func(RWMutexState) bool) RWMutexState {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		s := rw.StateSnapshot()
+		if ok(s) {
+			return s
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("state never reached: %v", s)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// go test -tags mutexdebug -run RWMutexStateSnapshot sync
+func TestRWMutexStateSnapshot(t *testing.T) {
+	var rw RWMutex
+	if s := rw.StateSnapshot(); s != (RWMutexState{}) {
+		t.Fatalf("zero RWMutex: %v", s)
+	}
+
+	rw.RLock()
+	rw.RLock()
+	if s := rw.StateSnapshot(); s.Readers != 2 || s.WriterPending || s.WriterLocked {
+		t.Fatalf("two readers: %v", s)
+	}
+
+	// 写者到来，等待两个读者离开
+	writerDone := make(chan bool)
+	go func() {
+		rw.Lock()
+		rw.Unlock()
+		writerDone <- true
+	}()
+	waitState(t, &rw, func(s RWMutexState) bool { return s.WriterPending })
+
+	// 新来的读者被写者挡住
+	readerDone := make(chan bool)
+	go func() {
+		rw.RLock()
+		rw.RUnlock()
+		readerDone <- true
+	}()
+	s := waitState(t, &rw, func(s RWMutexState) bool { return s.BlockedReaders == 1 })
+	if s.Readers != 2 || s.Departing != 2 {
+		t.Fatalf("pending writer with a blocked reader: %v", s)
+	}
+
+	rw.RUnlock()
+	if s := rw.StateSnapshot(); s.Departing != 1 || !s.WriterPending {
+		t.Fatalf("one reader departed: %v", s)
+	}
+	rw.RUnlock()
+	<-writerDone
+	<-readerDone
+	if s := rw.StateSnapshot(); s != (RWMutexState{}) {
+		t.Fatalf("after all released: %v", s)
+	}
+}
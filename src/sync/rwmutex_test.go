@@ -0,0 +1,141 @@
+package sync_test
+
+import (
+	. "sync"
+	"testing"
+	"time"
+)
+
+func TestRWMutexTryLock(t *testing.T) {
+	var rw RWMutex
+	rw.Lock()
+	if rw.TryLock() {
+		t.Fatalf("TryLock succeeded with mutex locked")
+	}
+	if rw.TryRLock() {
+		t.Fatalf("TryRLock succeeded with mutex locked")
+	}
+	rw.Unlock()
+
+	if !rw.TryLock() {
+		t.Fatalf("TryLock failed with mutex unlocked")
+	}
+	rw.Unlock()
+
+	if !rw.TryRLock() {
+		t.Fatalf("TryRLock failed with mutex unlocked")
+	}
+	if !rw.TryRLock() {
+		t.Fatalf("TryRLock failed with mutex rlocked")
+	}
+	if rw.TryLock() {
+		t.Fatalf("TryLock succeeded with mutex rlocked")
+	}
+	rw.RUnlock()
+	rw.RUnlock()
+}
+
+// 有写者在等待时，新的读者不能插队，TryRLock 也一样
+func TestRWMutexTryRLockPendingWriter(t *testing.T) {
+	var rw RWMutex
+	rw.RLock()
+
+	locked := make(chan bool)
+	go func() {
+		rw.Lock()
+		locked <- true
+		rw.Unlock()
+	}()
+
+	// 等待写者进入 Lock：此时它持有 w 并已将 readerCount 置为负数
+	deadline := time.Now().Add(5 * time.Second)
+	for rw.TryRLock() {
+		rw.RUnlock()
+		if time.Now().After(deadline) {
+			t.Fatalf("writer never became pending")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if rw.TryLock() {
+		t.Fatalf("TryLock succeeded with a pending writer")
+	}
+
+	rw.RUnlock() // 最后一个读者离开，唤醒写者
+	<-locked
+	if !rw.TryRLock() {
+		t.Fatalf("TryRLock failed after writer released the lock")
+	}
+	rw.RUnlock()
+}
+
+// 降级期间其他写者不能插进来，在写锁期间阻塞的读者会被唤醒
+func TestRWMutexDowngradeToRLock(t *testing.T) {
+	var (
+		rw    RWMutex
+		value int
+	)
+	rw.Lock()
+	value = 1
+
+	// 一个读者和一个写者在写锁期间到来，都被阻塞
+	readerSaw := make(chan int)
+	go func() {
+		rw.RLock()
+		readerSaw <- value
+		rw.RUnlock()
+	}()
+	writerDone := make(chan bool)
+	go func() {
+		rw.Lock()
+		value = 2
+		rw.Unlock()
+		writerDone <- true
+	}()
+	time.Sleep(10 * time.Millisecond) // 让两者进入等待
+
+	rw.DowngradeToRLock()
+	// 被阻塞的读者与我们同时持有读锁，看到的是降级前写入的值
+	select {
+	case v := <-readerSaw:
+		if v != 1 {
+			t.Fatalf("blocked reader saw %d, want 1", v)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("reader blocked during the write lock was not woken by DowngradeToRLock")
+	}
+	if rw.TryLock() {
+		t.Fatal("TryLock succeeded while holding the downgraded read lock")
+	}
+	time.Sleep(10 * time.Millisecond)
+	if value != 1 {
+		t.Fatalf("writer modified value while the downgraded read lock was held")
+	}
+	select {
+	case <-writerDone:
+		t.Fatal("writer acquired the lock during the downgrade")
+	default:
+	}
+
+	rw.RUnlock()
+	<-writerDone
+	rw.RLock()
+	if value != 2 {
+		t.Fatalf("value = %d after the writer, want 2", value)
+	}
+	rw.RUnlock()
+}
+
+func TestRWMutexDowngradeUncontended(t *testing.T) {
+	var rw RWMutex
+	rw.Lock()
+	rw.DowngradeToRLock()
+	if !rw.TryRLock() {
+		t.Fatal("TryRLock failed after DowngradeToRLock")
+	}
+	rw.RUnlock()
+	rw.RUnlock()
+	if !rw.TryLock() {
+		t.Fatal("TryLock failed after releasing the downgraded read lock")
+	}
+	rw.Unlock()
+}
@@ -0,0 +1,105 @@
+package workpool
+
+import (
+	stdsync "sync"
+	"sync/atomic"
+	"time"
+)
+
+// AdaptiveConfig 是自适应并发上限的参数，零值的字段取默认值，见 WithAdaptiveLimit
+type AdaptiveConfig struct {
+	MinWorkers int           // 上限最低降到多少，默认 1
+	Latency    time.Duration // 任务执行时间超过它视为过载；为 0 时以最近观察到的最短执行时间的 Tolerance 倍为准
+	Tolerance  float64       // Latency 为 0 时允许的执行时间相对最短执行时间的倍数，默认 2
+	Backoff    float64       // 过载时上限乘以的系数，取值 (0, 1)，默认 0.9
+	Window     int           // 每多少个样本重新统计一次最短执行时间，使基准能跟随下游的变化，默认 100
+}
+
+// WithAdaptiveLimit 使协程数上限随任务的执行时间和错误自动调整（AIMD，与 Netflix concurrency-limits 的 AIMD 相同）：
+//   - 任务出错（实现了 IErrWorkload 的任务执行完后 Err 不为 nil，例如 SubmitErrFunc 的函数重试后仍返回错误）或执行时间超过阈值时，说明下游已经过载，上限乘以 Backoff，
+//     在降低之前就已开始执行的任务不会再次触发降低，一轮过载只降一次
+//   - 否则若正在执行的任务数达到上限的一半，说明上限可能限制了吞吐，上限加 1
+//
+// 上限在 MinWorkers 与 NewWorkerpool 的 n（或 ApplyConfig 的 Workers）之间，从 n 开始，
+// MaxWorkers 返回当前的上限。降低后多出的协程在执行完手头的任务后退出，与 ApplyConfig 降低上限相同
+func WithAdaptiveLimit(c AdaptiveConfig) Option {
+	return func(p *Workerpool) {
+		if c.MinWorkers <= 0 {
+			c.MinWorkers = 1
+		}
+		if c.Tolerance <= 1 {
+			c.Tolerance = 2
+		}
+		if c.Backoff <= 0 || c.Backoff >= 1 {
+			c.Backoff = 0.9
+		}
+		if c.Window <= 0 {
+			c.Window = 100
+		}
+		p.adaptive = &adaptiveLimit{conf: c, ceiling: p.MaxWorkers()}
+	}
+}
+
+// adaptiveLimit 维护自适应的协程数上限，结果写入 Workerpool.workerCount
+type adaptiveLimit struct {
+	conf      AdaptiveConfig
+	mu        stdsync.Mutex
+	ceiling   int           // 上限的上限
+	lastCut   time.Time     // 上一次降低的时间
+	baseline  time.Duration // 上一个窗口中最短的执行时间，为 0 时还没有完整的窗口
+	windowMin time.Duration // 当前窗口中最短的执行时间
+	samples   int           // 当前窗口中的样本数
+}
+
+// setCeiling 修改上限的上限，当前上限超过它时降到它，由 ApplyConfig 调用
+func (a *adaptiveLimit) setCeiling(p *Workerpool, n int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.ceiling = n
+	if p.MaxWorkers() > n {
+		atomic.StoreInt64(&p.workerCount, int64(n))
+	}
+}
+
+// sample 记录一个开始于 start、执行了 d 的任务，failed 为任务是否出错，并据此调整上限
+func (a *adaptiveLimit) sample(p *Workerpool, start time.Time, d time.Duration, failed bool) {
+	a.mu.Lock()
+	threshold := a.threshold(d)
+	limit := p.MaxWorkers()
+	old := limit
+	switch {
+	case failed || (threshold > 0 && d > threshold):
+		if start.Before(a.lastCut) { // 降低之前就已开始执行，这轮过载已经处理过
+			break
+		}
+		if limit = int(float64(limit) * a.conf.Backoff); limit < a.conf.MinWorkers {
+			limit = a.conf.MinWorkers
+		}
+		a.lastCut = time.Now()
+	case p.RunningWorkers()*2 >= limit && limit < a.ceiling:
+		limit++
+	}
+	if limit > a.ceiling { // MinWorkers 大于 ceiling 时
+		limit = a.ceiling
+	}
+	atomic.StoreInt64(&p.workerCount, int64(limit))
+	a.mu.Unlock()
+
+	if limit > old {
+		p.spawnForBacklog()
+	}
+}
+
+// threshold 把 d 计入最短执行时间的统计，返回判断过载的执行时间阈值，还不能判断时返回 0，调用方需持有 mu
+func (a *adaptiveLimit) threshold(d time.Duration) time.Duration {
+	if a.samples == 0 || d < a.windowMin {
+		a.windowMin = d
+	}
+	if a.samples++; a.samples >= a.conf.Window {
+		a.baseline, a.samples = a.windowMin, 0
+	}
+	if a.conf.Latency > 0 {
+		return a.conf.Latency
+	}
+	return time.Duration(float64(a.baseline) * a.conf.Tolerance)
+}
@@ -0,0 +1,128 @@
+package workpool
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/RobinTsai/tearUpGo/workpool/sync"
+)
+
+func TestAdaptiveSample(t *testing.T) {
+	pool := NewWorkerpool(8, WithAdaptiveLimit(AdaptiveConfig{MinWorkers: 2, Latency: 10 * time.Millisecond}))
+	a := pool.adaptive
+	expectLimit := func(want int) {
+		t.Helper()
+		if n := pool.MaxWorkers(); n != want {
+			t.Fatalf("MaxWorkers = %d, want %d", n, want)
+		}
+	}
+
+	a.sample(pool, time.Now(), time.Millisecond, true) // 出错，乘性降低
+	expectLimit(7)
+	a.sample(pool, time.Now().Add(-time.Second), time.Millisecond, true) // 降低之前开始的任务不再降低
+	expectLimit(7)
+	a.sample(pool, time.Now(), 20*time.Millisecond, false) // 执行时间超过 Latency
+	expectLimit(6)
+
+	a.sample(pool, time.Now(), time.Millisecond, false) // 没有任务在执行，上限没有限制吞吐
+	expectLimit(6)
+	atomic.StoreInt64(&pool.running, 4)
+	for want := 7; want <= 8; want++ { // 加性增加，不超过 n
+		a.sample(pool, time.Now(), time.Millisecond, false)
+		expectLimit(want)
+	}
+	a.sample(pool, time.Now(), time.Millisecond, false)
+	expectLimit(8)
+	atomic.StoreInt64(&pool.running, 0)
+
+	for i := 0; i < 10; i++ {
+		a.sample(pool, time.Now(), time.Millisecond, true)
+	}
+	expectLimit(2) // 不低于 MinWorkers
+
+	if err := pool.ApplyConfig(Config{Workers: 1}); err != nil {
+		t.Fatal(err)
+	}
+	expectLimit(1) // ApplyConfig 的 Workers 是上限的上限
+}
+
+// Latency 为 0 时以上一个窗口中最短的执行时间为基准
+func TestAdaptiveBaseline(t *testing.T) {
+	pool := NewWorkerpool(4, WithAdaptiveLimit(AdaptiveConfig{Window: 3}))
+	a := pool.adaptive
+	for i := 0; i < 3; i++ {
+		a.sample(pool, time.Now(), time.Hour, false) // 还没有基准，不判断过载
+	}
+	if n := pool.MaxWorkers(); n != 4 {
+		t.Fatalf("MaxWorkers = %d, want 4", n)
+	}
+	a.sample(pool, time.Now(), time.Millisecond, false)
+	a.sample(pool, time.Now(), time.Hour, false) // 基准仍是第一个窗口的 1h
+	if n := pool.MaxWorkers(); n != 4 {
+		t.Fatalf("MaxWorkers = %d, want 4", n)
+	}
+	a.sample(pool, time.Now(), time.Millisecond, false)   // 第二个窗口结束，基准变为 1ms
+	a.sample(pool, time.Now(), 3*time.Millisecond, false) // 超过 1ms 的 2 倍
+	if n := pool.MaxWorkers(); n != 3 {
+		t.Errorf("MaxWorkers = %d, want 3", n)
+	}
+}
+
+// 持续出错时上限降到 MinWorkers，恢复后随负载回升
+func TestAdaptiveLimit(t *testing.T) {
+	defer sync.VerifyNone(t)
+	pool := NewWorkerpool(8,
+		WithAdaptiveLimit(AdaptiveConfig{Latency: time.Second}),
+		WithErrorHandler(func(error) {}),
+	)
+	pool.Start()
+	fail := errors.New("downstream overloaded")
+	for i := 0; i < 100; i++ {
+		pool.SubmitErrFunc(func() error {
+			time.Sleep(100 * time.Microsecond)
+			return fail
+		})
+	}
+	waitFor(t, func() bool { return pool.PendingCount() == 0 && pool.RunningWorkers() == 0 })
+	if n := pool.MaxWorkers(); n != 1 {
+		t.Fatalf("MaxWorkers after errors = %d, want 1", n)
+	}
+
+	for i := 0; i < 100; i++ {
+		pool.SubmitFunc(func() { time.Sleep(100 * time.Microsecond) })
+	}
+	pool.Shutdown()
+	pool.Wait()
+	if n := pool.MaxWorkers(); n <= 1 {
+		t.Errorf("MaxWorkers after recovery = %d, want > 1", n)
+	}
+}
+
+// failingWorkload 以 IErrWorkload 报告失败，不经过 SubmitErrFunc
+type failingWorkload struct {
+	err error
+}
+
+func (w *failingWorkload) Work() {
+	time.Sleep(100 * time.Microsecond)
+	w.err = errors.New("downstream overloaded")
+}
+
+func (w *failingWorkload) Err() error { return w.err }
+
+// 自定义任务通过 IErrWorkload 报告的错误同样使上限降低
+func TestAdaptiveLimitErrWorkload(t *testing.T) {
+	defer sync.VerifyNone(t)
+	pool := NewWorkerpool(8, WithAdaptiveLimit(AdaptiveConfig{Latency: time.Second}))
+	pool.Start()
+	for i := 0; i < 100; i++ {
+		pool.AddTask(&failingWorkload{})
+	}
+	pool.Shutdown()
+	pool.Wait()
+	if n := pool.MaxWorkers(); n != 1 {
+		t.Errorf("MaxWorkers after errors = %d, want 1", n)
+	}
+}
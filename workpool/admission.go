@@ -0,0 +1,187 @@
+package workpool
+
+import "sync/atomic"
+
+// 准入控制：决定提交任务时是否启动新协程、空闲的协程能否退出。
+//
+// 以前 AddTask 分别检查协程数是否为 0、尝试直接写入 Out、再用 TryAddCapped 增加协程数，
+// 而空闲的协程在另一个协程中独立地决定退出，几步之间都有窗口：
+//   - 协程数为 1 时任务被写入 Out，同时这唯一的协程空闲超时退出，任务留在 Out 中却没有协程执行，
+//     直到下一次提交才会启动新协程
+//   - 协程数已达上限、提交方放弃启动的同时，有协程正在退出，结果同样是有任务却没有协程
+//   - 空闲的协程还没来得及取走 Out 中的任务，提交方就判断“所有协程都忙”而多启动协程
+//
+// 现在用一个原子变量同时记录两个数，每次修改都是一次 CAS：
+//
+//	workers 存活的协程数（含已决定启动、还没开始运行的）
+//	credits 等待任务的协程数 - 已提交还没有被协程取走的任务数
+//
+// credits > 0 说明有协程在等待，新任务一定会被它取走，不需要启动协程；
+// credits <= 0 说明任务多于等待的协程，需要时在同一次 CAS 中增加 workers。
+// 空闲的协程只有在 credits > 0（它不被任何任务需要）时才能退出，并在同一次 CAS 中减少 workers 和 credits，
+// 所以只要有任务没有被取走，就一定还有协程存活或正在启动；也不会在有协程等待时启动新协程。
+//
+// credits 以偏移 1<<31 存在低 32 位，workers 存在高 32 位，无条件的增减可以直接用 atomic.AddUint64，
+// 排队的任务数需小于 1<<31
+
+const (
+	creditBias = 1 << 31
+	oneWorker  = 1 << 32
+)
+
+// admState 是打包后的 workers 和 credits
+type admState uint64
+
+func makeAdmState(workers, credits int) admState {
+	return admState(uint64(workers)<<32 | uint64(credits+creditBias))
+}
+
+func (s admState) workers() int { return int(s >> 32) }
+func (s admState) credits() int { return int(uint32(s)) - creditBias }
+
+func (p *Workerpool) loadAdm() admState {
+	return admState(atomic.LoadUint64(&p.adm))
+}
+
+func (p *Workerpool) casAdm(old, new admState) bool {
+	return atomic.CompareAndSwapUint64(&p.adm, uint64(old), uint64(new))
+}
+
+// admit 为一个新提交的任务扣除一个 credit，没有等待的协程时按 scheduler 和上限决定是否同时增加一个协程。
+// 返回是否需要启动协程，以及给钩子的决策和状态
+func (p *Workerpool) admit() (spawn bool, d spawnDecision, st SchedState) {
+	asked, allow := false, false // scheduler 在重试 CAS 时只询问一次
+	for {
+		old := p.loadAdm()
+		w, c := old.workers(), old.credits()
+		st = SchedState{Workers: w, Running: p.RunningWorkers(), MaxWorkers: p.MaxWorkers(), Pending: p.PendingCount()}
+		spawn = false
+		switch {
+		case c > 0:
+			d = spawnMatched
+		case w >= st.MaxWorkers:
+			d = spawnAtCap
+		case w == 0:
+			d, spawn = spawnFirst, true
+		default:
+			if !asked {
+				asked, allow = true, p.scheduler.ShouldSpawn(st)
+			}
+			d, spawn = spawnDeclined, allow
+			if spawn {
+				d = spawnScheduled
+			}
+		}
+		new := makeAdmState(w, c-1)
+		if spawn {
+			new = makeAdmState(w+1, c-1)
+		}
+		if p.casAdm(old, new) {
+			return spawn, d, st
+		}
+	}
+}
+
+// admitBounded 与 admit 相同，但只在任务能立即被执行（有等待的协程，或协程数未达上限，不询问 scheduler），
+// 或者没有协程认领的任务数（-credits）少于 maxQueue 时接受。
+// 否则不做任何修改并返回 ok 为 false，供不能无限排队的调用方使用，见 TryAddTask
+func (p *Workerpool) admitBounded(maxQueue int) (spawn bool, d spawnDecision, st SchedState, ok bool) {
+	for {
+		old := p.loadAdm()
+		w, c := old.workers(), old.credits()
+		st = SchedState{Workers: w, Running: p.RunningWorkers(), MaxWorkers: p.MaxWorkers(), Pending: p.PendingCount()}
+		switch {
+		case c > 0:
+			d, spawn = spawnMatched, false
+		case w < st.MaxWorkers && w == 0:
+			d, spawn = spawnFirst, true
+		case w < st.MaxWorkers:
+			d, spawn = spawnScheduled, true
+		case -c < maxQueue:
+			d, spawn = spawnAtCap, false
+		default:
+			return false, spawnAtCap, st, false
+		}
+		new := makeAdmState(w, c-1)
+		if spawn {
+			new = makeAdmState(w+1, c-1)
+		}
+		if p.casAdm(old, new) {
+			return spawn, d, st, true
+		}
+	}
+}
+
+// unadmit 撤销提交失败的任务的 admit
+func (p *Workerpool) unadmit(spawned bool) {
+	delta := uint64(1)
+	if spawned {
+		delta -= oneWorker
+	}
+	atomic.AddUint64(&p.adm, delta)
+}
+
+// reserveWorkers 无条件增加 n 个协程，用于 Start。这些协程一开始就算作在等待任务，
+// 不必等它们运行起来，Start 之后立即提交的任务也不会被当作没有空闲协程
+func (p *Workerpool) reserveWorkers(n int) {
+	atomic.AddUint64(&p.adm, uint64(n)*(oneWorker+1))
+}
+
+// startWaiting 在协程开始等待任务时调用
+func (p *Workerpool) startWaiting() {
+	atomic.AddUint64(&p.adm, 1)
+}
+
+// stopWaiting 在等待中的协程因关闭而退出时调用
+func (p *Workerpool) stopWaiting() {
+	atomic.AddUint64(&p.adm, ^uint64(oneWorker)) // -oneWorker-1
+}
+
+// releaseWorker 在还没开始等待的协程退出时调用（初始化失败）
+func (p *Workerpool) releaseWorker() {
+	atomic.AddUint64(&p.adm, ^uint64(oneWorker-1)) // -oneWorker
+}
+
+// retireIdle 在等待超时的协程想退出时调用：只有 credits > 0、没有任务需要它时才退出
+func (p *Workerpool) retireIdle() bool {
+	for {
+		old := p.loadAdm()
+		if old.credits() <= 0 {
+			return false
+		}
+		if p.casAdm(old, makeAdmState(old.workers()-1, old.credits()-1)) {
+			return true
+		}
+	}
+}
+
+// retireExcess 在协程执行完任务、开始等待之前调用，协程数超过上限（ApplyConfig 降低了上限）时退出并返回 true。
+// 上限至少为 1，退出后仍有协程存活，排队的任务不会无人执行
+func (p *Workerpool) retireExcess() bool {
+	for {
+		old := p.loadAdm()
+		if old.workers() <= p.MaxWorkers() {
+			return false
+		}
+		if p.casAdm(old, makeAdmState(old.workers()-1, old.credits())) {
+			return true
+		}
+	}
+}
+
+// reserveForBacklog 在上限提高后为多出等待协程的任务增加协程，返回增加的个数
+func (p *Workerpool) reserveForBacklog() int {
+	for {
+		old := p.loadAdm()
+		n := -old.credits()
+		if room := p.MaxWorkers() - old.workers(); n > room {
+			n = room
+		}
+		if n <= 0 {
+			return 0
+		}
+		if p.casAdm(old, makeAdmState(old.workers()+n, old.credits())) {
+			return n
+		}
+	}
+}
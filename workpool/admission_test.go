@@ -0,0 +1,97 @@
+package workpool
+
+import (
+	"math/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/RobinTsai/tearUpGo/workpool/sync"
+)
+
+// 下界：只有一个协程且空闲超时极短，提交的时机与协程退出反复交错，每个任务都必须很快被执行，
+// 不能出现任务留在队列中而唯一的协程已经退出的情况
+func TestAdmissionNoStrandedTask(t *testing.T) {
+	defer sync.VerifyNone(t)
+	pool := NewWorkerpool(1, WithIdleTimeout(50*time.Microsecond))
+	pool.Start()
+	n := 2000
+	if testing.Short() {
+		n = 200
+	}
+	done := make(chan struct{}, 1)
+	for i := 0; i < n; i++ {
+		time.Sleep(time.Duration(rand.Intn(100)) * time.Microsecond) // 空闲超时前后提交
+		pool.SubmitFunc(func() { done <- struct{}{} })
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			s := pool.loadAdm()
+			t.Fatalf("task %d not run: workers=%d credits=%d pending=%d", i, s.workers(), s.credits(), pool.PendingCount())
+		}
+	}
+	pool.Shutdown()
+	pool.Wait()
+}
+
+// 上界：多个提交方并发提交，存活的协程数和同时执行的任务数都不超过上限
+func TestAdmissionWorkerCap(t *testing.T) {
+	defer sync.VerifyNone(t)
+	const max = 4
+	pool := NewWorkerpool(max, WithIdleTimeout(time.Millisecond))
+	pool.Start()
+
+	var running, peak int32
+	submitters := make(chan struct{})
+	for g := 0; g < 8; g++ {
+		go func() {
+			defer func() { submitters <- struct{}{} }()
+			for i := 0; i < 200; i++ {
+				pool.SubmitFunc(func() {
+					if r := atomic.AddInt32(&running, 1); r > atomic.LoadInt32(&peak) {
+						atomic.StoreInt32(&peak, r) // 近似值，只用于检查上限
+					}
+					if w := pool.loadAdm().workers(); w > max {
+						t.Errorf("workers = %d, want <= %d", w, max)
+					}
+					time.Sleep(time.Duration(rand.Intn(50)) * time.Microsecond)
+					atomic.AddInt32(&running, -1)
+				})
+			}
+		}()
+	}
+	for g := 0; g < 8; g++ {
+		<-submitters
+	}
+	waitFor(t, func() bool {
+		return pool.loadAdm().credits() >= 0 && pool.PendingCount() == 0 && pool.RunningWorkers() == 0
+	})
+	if p := atomic.LoadInt32(&peak); p > max {
+		t.Errorf("peak running = %d, want <= %d", p, max)
+	}
+	pool.Shutdown()
+	pool.Wait()
+	if s := pool.loadAdm(); s.workers() != 0 || s.credits() != 0 {
+		t.Errorf("after Wait workers=%d credits=%d, want 0 0", s.workers(), s.credits())
+	}
+}
+
+// 有协程在等待时提交任务不会启动新协程，即使任务还没有被取走
+func TestAdmissionNoSpawnWhileWaiting(t *testing.T) {
+	defer sync.VerifyNone(t)
+	rec, hooks := newRecorder()
+	pool := NewWorkerpool(4, hooks)
+	pool.Start()
+	for i := 0; i < 100; i++ {
+		waitFor(t, func() bool { return pool.loadAdm().credits() == 1 }) // 上一个任务的协程重新开始等待
+		done := make(chan struct{})
+		pool.SubmitFunc(func() { close(done) })
+		expect(t, rec.spawns, spawnMatched)
+		<-done
+	}
+	if w := pool.loadAdm().workers(); w != 1 {
+		t.Errorf("workers = %d, want 1", w)
+	}
+	pool.Shutdown()
+	pool.Wait()
+}
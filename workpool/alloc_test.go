@@ -0,0 +1,42 @@
+package workpool
+
+import (
+	"testing"
+
+	"github.com/RobinTsai/tearUpGo/workpool/sync"
+)
+
+// AllocsPerRun 统计的是整个进程的分配次数，包括执行任务的协程和弹性队列的协程，
+// 所以这里度量的是提交、分发和执行一个任务的全部分配，而不只是 AddTask 本身。
+// 任务事先转换为 IWorkload，避免把调用方装箱的分配算进来
+func TestAddTaskAllocs(t *testing.T) {
+	defer sync.VerifyNone(t)
+	if testing.Short() {
+		t.Skip("skipping allocation test in short mode")
+	}
+	pool := NewWorkerpool(4)
+	pool.Start()
+	var w IWorkload = nopWorkload{}
+	for i := 0; i < 1000; i++ { // 预热：启动协程，让队列和 task 的空闲列表达到稳定大小
+		pool.AddTask(w)
+	}
+	if n := testing.AllocsPerRun(10000, func() { pool.AddTask(w) }); n != 0 {
+		t.Errorf("AddTask allocates %v times per task, want 0", n)
+	}
+	pool.Shutdown()
+	pool.Wait()
+}
+
+func BenchmarkAddTask(b *testing.B) {
+	pool := NewWorkerpool(4)
+	pool.Start()
+	var w IWorkload = nopWorkload{}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pool.AddTask(w)
+	}
+	b.StopTimer()
+	pool.Shutdown()
+	pool.Wait()
+}
@@ -0,0 +1,151 @@
+package workpool
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RobinTsai/tearUpGo/workpool/xsync"
+)
+
+// 下面的基准测试把工作池与几种常见的“限制并发数”写法放在一起比较，
+// 度量同样的任务交给不同实现时每个任务的开销（ns/op）和分配次数。
+//
+// 模块没有依赖 golang.org/x/sync，x/sync/semaphore 和 errgroup.SetLimit 用等价的写法代替：
+// semaphore.Weighted 每次取一个令牌时与 xsync.Semaphore 相同（FIFO 排队、Release 直接交给队首），
+// errgroup 的 SetLimit 就是一个带缓冲的令牌通道加 WaitGroup，并记录第一个错误，见 limitGroup
+
+// limiter 以最多 n 个并发执行提交的函数，submit 可能阻塞，close 在所有任务完成后调用
+type limiter struct {
+	submit func(f func())
+	close  func()
+}
+
+var limiters = []struct {
+	name string
+	new  func(n int) limiter
+}{
+	{"workpool", func(n int) limiter {
+		p := NewWorkerpool(n)
+		p.Start()
+		return limiter{
+			submit: func(f func()) { p.SubmitFunc(f) },
+			close:  func() { p.Shutdown(); p.Wait() },
+		}
+	}},
+	{"workpool-sharded", func(n int) limiter {
+		p := NewShardedPool(n)
+		p.Start()
+		return limiter{
+			submit: func(f func()) { p.SubmitFunc(f) },
+			close:  func() { p.Shutdown(); p.Wait() },
+		}
+	}},
+	{"chan-semaphore", func(n int) limiter {
+		sem := make(chan struct{}, n)
+		return limiter{
+			submit: func(f func()) {
+				sem <- struct{}{}
+				go func() {
+					defer func() { <-sem }()
+					f()
+				}()
+			},
+			close: func() {},
+		}
+	}},
+	{"weighted-semaphore", func(n int) limiter {
+		sem := xsync.NewSemaphore(n, false)
+		return limiter{
+			submit: func(f func()) {
+				sem.Acquire()
+				go func() {
+					defer sem.Release()
+					f()
+				}()
+			},
+			close: func() {},
+		}
+	}},
+	{"errgroup-limit", func(n int) limiter {
+		g := newLimitGroup(n)
+		return limiter{
+			submit: func(f func()) { g.Go(func() error { f(); return nil }) },
+			close:  func() { g.Wait() },
+		}
+	}},
+}
+
+// limitGroup 是 errgroup.Group 在 SetLimit 之后的等价实现
+type limitGroup struct {
+	wg      sync.WaitGroup
+	sem     chan struct{}
+	errOnce sync.Once
+	err     error
+}
+
+func newLimitGroup(n int) *limitGroup {
+	return &limitGroup{sem: make(chan struct{}, n)}
+}
+
+func (g *limitGroup) Go(f func() error) {
+	g.sem <- struct{}{}
+	g.wg.Add(1)
+	go func() {
+		defer func() {
+			<-g.sem
+			g.wg.Done()
+		}()
+		if err := f(); err != nil {
+			g.errOnce.Do(func() { g.err = err })
+		}
+	}()
+}
+
+func (g *limitGroup) Wait() error {
+	g.wg.Wait()
+	return g.err
+}
+
+// spin 忙等 d，模拟 CPU 密集的任务；d 为 0 时是空任务，只剩调度本身的开销
+func spin(d time.Duration) {
+	for end := time.Now().Add(d); time.Now().Before(end); {
+	}
+}
+
+// BenchmarkLimiters 在不同的任务大小和提交方式下比较各实现，并发上限都是 8：
+//   - steady：一次提交全部 b.N 个任务，最后等它们完成
+//   - burst：每次提交 256 个任务后等这一批全部完成再提交下一批，反映空闲后突发到来时的开销
+func BenchmarkLimiters(b *testing.B) {
+	const (
+		workers = 8
+		burst   = 256
+	)
+	for _, size := range []time.Duration{0, time.Microsecond, 10 * time.Microsecond} {
+		for _, pattern := range []string{"steady", "burst"} {
+			for _, l := range limiters {
+				b.Run(fmt.Sprintf("task=%v/%s/%s", size, pattern, l.name), func(b *testing.B) {
+					b.ReportAllocs()
+					lim := l.new(workers)
+					var wg sync.WaitGroup
+					task := func() {
+						spin(size)
+						wg.Done()
+					}
+					b.ResetTimer()
+					for i := 0; i < b.N; i++ {
+						wg.Add(1)
+						lim.submit(task)
+						if pattern == "burst" && (i+1)%burst == 0 {
+							wg.Wait()
+						}
+					}
+					wg.Wait()
+					b.StopTimer()
+					lim.close()
+				})
+			}
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package workpool
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Call 用 TryAddTask 在工作池中执行 f 并等待它结束，f 中的 panic 在调用方的协程中重新抛出，
+// 使工作池可以直接作为服务的并发限制器，见 HTTPLimiter 和 grpcpool。
+// 没有空位时返回 ErrPoolFull；f 开始执行之前 ctx 结束时返回 ctx.Err()，工作池被 Down 时返回 ErrPoolClosed，
+// 这几种情况下 f 都不会执行。f 开始执行之后总是等它结束，因为 f 用到的请求资源通常只在调用方返回前有效
+func (p *Workerpool) Call(ctx context.Context, maxQueue int, f func()) error {
+	c := &call{f: f, done: make(chan struct{})}
+	if err := p.TryAddTask(ctx, funcWorkload(c.run), maxQueue); err != nil {
+		return err
+	}
+	return c.wait(ctx, p.ctx.Done())
+}
+
+// call 是在工作池中执行、由提交方等待的一次调用，提交方可以在调用开始之前放弃它
+type call struct {
+	f        func()
+	state    int32 // callPending、callStarted 或 callAbandoned，原子操作
+	done     chan struct{}
+	panicVal interface{}
+}
+
+const (
+	callPending int32 = iota
+	callStarted
+	callAbandoned
+)
+
+// run 在协程中执行 f，提交方已经放弃时直接返回
+func (c *call) run() {
+	if !atomic.CompareAndSwapInt32(&c.state, callPending, callStarted) {
+		return
+	}
+	defer close(c.done)
+	defer func() {
+		c.panicVal = recover() // 交还给提交方所在协程
+	}()
+	c.f()
+}
+
+// wait 等待 f 执行结束并重新抛出其中的 panic。
+// f 开始之前 ctx 结束或 down 关闭时放弃调用，返回 ctx.Err() 或 ErrPoolClosed；f 已经开始时总是等它结束
+func (c *call) wait(ctx context.Context, down <-chan struct{}) error {
+	select {
+	case <-c.done:
+	case <-ctx.Done():
+	case <-down:
+	}
+	if atomic.CompareAndSwapInt32(&c.state, callPending, callAbandoned) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return ErrPoolClosed
+	}
+	<-c.done
+	if c.panicVal != nil {
+		panic(c.panicVal)
+	}
+	return nil
+}
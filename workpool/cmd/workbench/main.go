@@ -0,0 +1,186 @@
+// workbench 用可配置的到达速率和任务时长分布驱动工作池，运行结束后打印吞吐量、
+// 排队等待和端到端延迟的分位数以及协程数的峰值，用数据来调整协程数、队列上限等参数。
+//
+// 用法：
+//
+//	workbench [-workers 8] [-rate 1000] [-arrival poisson] [-dist exp] [-mean 5ms] [-duration 10s] [-queue 0]
+//
+// 例如比较协程数：
+//
+//	for w in 4 8 16; do workbench -workers $w -rate 2000 -mean 5ms; done
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/RobinTsai/tearUpGo/workpool"
+	"github.com/RobinTsai/tearUpGo/workpool/examples"
+)
+
+// config 是一次压测的参数
+type config struct {
+	Workers  int           // 工作池的协程数上限
+	Rate     float64       // 每秒到达的任务数
+	Arrival  string        // 到达模式：constant 或 poisson
+	Dist     string        // 任务时长分布：fixed、uniform（[0, 2*Mean)）或 exp
+	Mean     time.Duration // 任务时长的均值
+	CPU      bool          // 任务忙等（CPU 密集）而不是睡眠（IO 密集）
+	Duration time.Duration // 提交任务的总时长
+	Queue    int           // 排队任务数上限，见 workpool.WithQueueBound，0 表示不限
+	Seed     int64
+}
+
+// report 是一次压测的结果
+type report struct {
+	Submitted, Completed int
+	Elapsed              time.Duration   // 从第一个任务提交到最后一个任务完成
+	Wait, Latency        []time.Duration // 每个任务的排队等待时间和端到端延迟，已排序
+	PeakWorkers          int
+}
+
+// benchWorkload 记录自己的提交、开始和结束时间
+type benchWorkload struct {
+	d                  time.Duration
+	cpu                bool
+	submit, start, end time.Time
+	pool               interface{ GetWaitCount() uint64 }
+	peak               *int32
+}
+
+func (w *benchWorkload) Work() {
+	w.start = time.Now()
+	for n := int32(w.pool.GetWaitCount()); ; {
+		p := atomic.LoadInt32(w.peak)
+		if n <= p || atomic.CompareAndSwapInt32(w.peak, p, n) {
+			break
+		}
+	}
+	if w.cpu {
+		for end := w.start.Add(w.d); time.Now().Before(end); {
+		}
+	} else {
+		time.Sleep(w.d)
+	}
+	w.end = time.Now()
+}
+
+func run(cfg config) (*report, error) {
+	var opts []workpool.Option
+	if cfg.Queue > 0 {
+		opts = append(opts, workpool.WithQueueBound(cfg.Queue))
+	}
+	pool := workpool.NewWorkerpool(cfg.Workers, opts...)
+	if pool == nil {
+		return nil, fmt.Errorf("invalid worker count %d", cfg.Workers)
+	}
+	if err := pool.Start(); err != nil {
+		return nil, err
+	}
+
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	var dur func() time.Duration
+	switch cfg.Dist {
+	case "fixed":
+		dur = func() time.Duration { return cfg.Mean }
+	case "uniform":
+		dur = func() time.Duration { return time.Duration(rng.Int63n(2*int64(cfg.Mean) + 1)) }
+	case "exp":
+		dur = func() time.Duration { return time.Duration(rng.ExpFloat64() * float64(cfg.Mean)) }
+	default:
+		return nil, fmt.Errorf("unknown duration distribution %q", cfg.Dist)
+	}
+
+	n := int(cfg.Rate * cfg.Duration.Seconds())
+	works := make([]*benchWorkload, n)
+	var peak int32
+	newWork := func(i int) workpool.IWorkload {
+		works[i] = &benchWorkload{d: dur(), cpu: cfg.CPU, submit: time.Now(), pool: pool, peak: &peak}
+		return works[i]
+	}
+	var producer *examples.Arrivals
+	switch cfg.Arrival {
+	case "constant":
+		producer = examples.ConstantRate(n, cfg.Rate, newWork)
+	case "poisson":
+		producer = examples.Poisson(n, cfg.Rate, cfg.Seed, newWork)
+	default:
+		return nil, fmt.Errorf("unknown arrival pattern %q", cfg.Arrival)
+	}
+
+	start := time.Now()
+	for w := producer.Produce(); w != nil; w = producer.Produce() {
+		if err := pool.AddTask(w); err != nil {
+			return nil, err
+		}
+	}
+	pool.Shutdown()
+	pool.Wait()
+
+	r := &report{Submitted: n, Elapsed: time.Since(start), PeakWorkers: int(peak)}
+	for _, w := range works {
+		if w.end.IsZero() {
+			continue
+		}
+		r.Completed++
+		r.Wait = append(r.Wait, w.start.Sub(w.submit))
+		r.Latency = append(r.Latency, w.end.Sub(w.submit))
+	}
+	sort.Slice(r.Wait, func(i, j int) bool { return r.Wait[i] < r.Wait[j] })
+	sort.Slice(r.Latency, func(i, j int) bool { return r.Latency[i] < r.Latency[j] })
+	return r, nil
+}
+
+// percentile 返回已排序的 ds 中的 p 分位数
+func percentile(ds []time.Duration, p float64) time.Duration {
+	if len(ds) == 0 {
+		return 0
+	}
+	i := int(p / 100 * float64(len(ds)))
+	if i >= len(ds) {
+		i = len(ds) - 1
+	}
+	return ds[i].Round(time.Microsecond)
+}
+
+func (r *report) write(w io.Writer) {
+	fmt.Fprintf(w, "submitted %d, completed %d in %v: %.1f tasks/s, peak workers %d\n",
+		r.Submitted, r.Completed, r.Elapsed.Round(time.Millisecond),
+		float64(r.Completed)/r.Elapsed.Seconds(), r.PeakWorkers)
+	for _, row := range []struct {
+		name string
+		ds   []time.Duration
+	}{{"queue wait", r.Wait}, {"latency", r.Latency}} {
+		fmt.Fprintf(w, "%-10s  p50 %-12v p90 %-12v p99 %-12v max %v\n", row.name,
+			percentile(row.ds, 50), percentile(row.ds, 90), percentile(row.ds, 99), percentile(row.ds, 100))
+	}
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("workbench: ")
+	var cfg config
+	flag.IntVar(&cfg.Workers, "workers", 8, "maximum number of workers")
+	flag.Float64Var(&cfg.Rate, "rate", 1000, "tasks arriving per second")
+	flag.StringVar(&cfg.Arrival, "arrival", "poisson", "arrival pattern: constant or poisson")
+	flag.StringVar(&cfg.Dist, "dist", "exp", "task duration distribution: fixed, uniform or exp")
+	flag.DurationVar(&cfg.Mean, "mean", 5*time.Millisecond, "mean task duration")
+	flag.BoolVar(&cfg.CPU, "cpu", false, "busy-wait in tasks instead of sleeping")
+	flag.DurationVar(&cfg.Duration, "duration", 10*time.Second, "how long to keep submitting tasks")
+	flag.IntVar(&cfg.Queue, "queue", 0, "bound on queued tasks, 0 for unbounded")
+	flag.Int64Var(&cfg.Seed, "seed", 1, "seed of the arrival and duration randomness")
+	flag.Parse()
+
+	r, err := run(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	r.write(os.Stdout)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	for _, arrival := range []string{"constant", "poisson"} {
+		for _, dist := range []string{"fixed", "uniform", "exp"} {
+			r, err := run(config{
+				Workers: 4, Rate: 2000, Arrival: arrival, Dist: dist,
+				Mean: 500 * time.Microsecond, Duration: 50 * time.Millisecond, Seed: 1,
+			})
+			if err != nil {
+				t.Fatalf("%s/%s: %v", arrival, dist, err)
+			}
+			if r.Submitted != 100 || r.Completed != r.Submitted {
+				t.Errorf("%s/%s: submitted %d, completed %d, want 100", arrival, dist, r.Submitted, r.Completed)
+			}
+			if r.PeakWorkers < 1 || r.PeakWorkers > 4 {
+				t.Errorf("%s/%s: peak workers = %d, want 1..4", arrival, dist, r.PeakWorkers)
+			}
+			for i := 1; i < len(r.Latency); i++ {
+				if r.Latency[i] < r.Latency[i-1] || r.Wait[i] < r.Wait[i-1] {
+					t.Fatalf("%s/%s: durations are not sorted", arrival, dist)
+				}
+			}
+			var buf bytes.Buffer
+			r.write(&buf)
+			if !strings.Contains(buf.String(), "completed 100") {
+				t.Errorf("%s/%s: report = %q", arrival, dist, buf.String())
+			}
+		}
+	}
+	if _, err := run(config{Workers: 1, Rate: 1, Arrival: "bursty", Dist: "fixed", Duration: time.Second}); err == nil {
+		t.Error("run accepted an unknown arrival pattern")
+	}
+}
@@ -0,0 +1,218 @@
+package workpool
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config 是工作池的声明式配置，可以从 JSON/YAML 文件（LoadConfig）或环境变量（FromEnv）读取，
+// 再用 New 创建工作池。字段名以 json 标签为准，YAML 和环境变量使用相同的名字
+type Config struct {
+	Name        string   `json:"name"`         // 工作池名称，见 WithName
+	Workers     int      `json:"workers"`      // 最大协程数，必须大于 0
+	QueueBound  int      `json:"queue_bound"`  // 排队任务数上限，0 为不限，见 WithQueueBound
+	IdleTimeout Duration `json:"idle_timeout"` // 协程空闲多久后退出，如 "500ms"，0 为默认值，见 WithIdleTimeout
+	Retries     int      `json:"retries"`      // 出错的函数最多重试的次数，见 WithRetries
+	RateLimit   float64  `json:"rate_limit"`   // 每秒开始执行的任务数，0 为不限，见 WithRateLimit
+	RateBurst   int      `json:"rate_burst"`   // 限速时最多积攒的令牌数，0 时为 1
+	Prefork     bool     `json:"prefork"`      // 见 WithPrefork
+}
+
+// Duration 是以 "1.5s" 这样的字符串表示的 time.Duration，用于配置文件
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(b []byte) error {
+	v, err := time.ParseDuration(string(b))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// envPrefix 是 FromEnv 读取的环境变量的前缀
+const envPrefix = "WORKPOOL_"
+
+// LoadConfig 读取配置文件，按扩展名解析：.json 为 JSON，.yaml/.yml 为 YAML。
+// YAML 只支持 `key: value` 形式的单层映射和 # 注释，足够表达 Config；未知的字段名会报错
+func LoadConfig(path string) (Config, error) {
+	var c Config
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return c, err
+	}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&c)
+	case ".yaml", ".yml":
+		err = c.parseYAML(b)
+	default:
+		err = fmt.Errorf("unknown config format %q", ext)
+	}
+	if err == nil {
+		err = c.Validate()
+	}
+	if err != nil {
+		return c, fmt.Errorf("workpool: load config %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// FromEnv 从环境变量读取配置，变量名为 WORKPOOL_ 加上大写的字段名，
+// 如 WORKPOOL_WORKERS=8、WORKPOOL_IDLE_TIMEOUT=1s，未设置的字段保持零值
+func FromEnv() (Config, error) {
+	var c Config
+	err := c.eachField(func(key string, _ reflect.Value) error {
+		if v, ok := os.LookupEnv(envPrefix + strings.ToUpper(key)); ok {
+			return c.set(key, v)
+		}
+		return nil
+	})
+	if err == nil {
+		err = c.Validate()
+	}
+	if err != nil {
+		return c, fmt.Errorf("workpool: config from env: %w", err)
+	}
+	return c, nil
+}
+
+// parseYAML 解析单层的 `key: value` 映射
+func (c *Config) parseYAML(b []byte) error {
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	for line := 1; sc.Scan(); line++ {
+		text := sc.Text()
+		if i := strings.Index(text, " #"); i >= 0 {
+			text = text[:i]
+		}
+		text = strings.TrimRight(text, " \t")
+		if text == "" || strings.HasPrefix(strings.TrimSpace(text), "#") || text == "---" {
+			continue
+		}
+		if text[0] == ' ' || text[0] == '\t' {
+			return fmt.Errorf("line %d: nested values are not supported", line)
+		}
+		key, value, ok := strings.Cut(text, ":")
+		if !ok {
+			return fmt.Errorf("line %d: want `key: value`", line)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		if err := c.set(strings.TrimSpace(key), value); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+	return sc.Err()
+}
+
+// eachField 按声明顺序对每个字段调用 f，key 为其 json 标签
+func (c *Config) eachField(f func(key string, v reflect.Value) error) error {
+	v := reflect.ValueOf(c).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if err := f(v.Type().Field(i).Tag.Get("json"), v.Field(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var durationType = reflect.TypeOf(Duration(0))
+
+// set 把字符串形式的 value 解析后赋给名为 key 的字段
+func (c *Config) set(key, value string) error {
+	found := false
+	err := c.eachField(func(k string, v reflect.Value) error {
+		if k != key {
+			return nil
+		}
+		found = true
+		var err error
+		switch {
+		case v.Type() == durationType:
+			err = v.Addr().Interface().(*Duration).UnmarshalText([]byte(value))
+		case v.Kind() == reflect.String:
+			v.SetString(value)
+		case v.Kind() == reflect.Int:
+			var n int64
+			n, err = strconv.ParseInt(value, 10, 0)
+			v.SetInt(n)
+		case v.Kind() == reflect.Float64:
+			var f float64
+			f, err = strconv.ParseFloat(value, 64)
+			v.SetFloat(f)
+		case v.Kind() == reflect.Bool:
+			var b bool
+			b, err = strconv.ParseBool(value)
+			v.SetBool(b)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		return nil
+	})
+	if err == nil && !found {
+		err = fmt.Errorf("unknown field %q", key)
+	}
+	return err
+}
+
+// Validate 检查配置的取值范围
+func (c Config) Validate() error {
+	switch {
+	case c.Workers <= 0:
+		return fmt.Errorf("workers must be positive, got %d", c.Workers)
+	case c.QueueBound < 0, c.Retries < 0, c.RateBurst < 0:
+		return fmt.Errorf("queue_bound, retries and rate_burst must not be negative")
+	case c.IdleTimeout < 0 || c.RateLimit < 0:
+		return fmt.Errorf("idle_timeout and rate_limit must not be negative")
+	}
+	return nil
+}
+
+// Options 返回与配置对应的 Option，Workers 作为 NewWorkerpool 的参数，不在其中
+func (c Config) Options() []Option {
+	var opts []Option
+	if c.Name != "" {
+		opts = append(opts, WithName(c.Name))
+	}
+	if c.QueueBound > 0 {
+		opts = append(opts, WithQueueBound(c.QueueBound))
+	}
+	if c.IdleTimeout > 0 {
+		opts = append(opts, WithIdleTimeout(time.Duration(c.IdleTimeout)))
+	}
+	if c.Retries > 0 {
+		opts = append(opts, WithRetries(c.Retries))
+	}
+	if c.RateLimit > 0 {
+		opts = append(opts, WithRateLimit(c.RateLimit, c.RateBurst))
+	}
+	if c.Prefork {
+		opts = append(opts, WithPrefork())
+	}
+	return opts
+}
+
+// New 按配置创建工作池，opts 在配置之后应用，用于补充无法写在配置中的选项（如错误处理函数）
+func (c Config) New(opts ...Option) (*Workerpool, error) {
+	if err := c.Validate(); err != nil {
+		return nil, fmt.Errorf("workpool: %w", err)
+	}
+	return NewWorkerpool(c.Workers, append(c.Options(), opts...)...), nil
+}
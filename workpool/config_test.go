@@ -0,0 +1,133 @@
+package workpool
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RobinTsai/tearUpGo/workpool/sync"
+)
+
+var wantConfig = Config{
+	Name:        "api",
+	Workers:     8,
+	QueueBound:  100,
+	IdleTimeout: Duration(500 * time.Millisecond),
+	Retries:     2,
+	RateLimit:   50,
+	RateBurst:   5,
+	Prefork:     true,
+}
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	for name, content := range map[string]string{
+		"pool.json": `{"name": "api", "workers": 8, "queue_bound": 100, "idle_timeout": "500ms",
+			"retries": 2, "rate_limit": 50, "rate_burst": 5, "prefork": true}`,
+		"pool.yaml": `# 接口服务的工作池
+name: "api"
+workers: 8
+queue_bound: 100
+idle_timeout: 500ms # 比默认的 3s 更快收缩
+retries: 2
+rate_limit: 50
+rate_burst: 5
+prefork: true
+`,
+	} {
+		c, err := LoadConfig(writeConfig(t, name, content))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+		} else if c != wantConfig {
+			t.Errorf("%s: got %+v, want %+v", name, c, wantConfig)
+		}
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	for name, content := range map[string]string{
+		"unknown.json": `{"workers": 1, "threads": 2}`,
+		"unknown.yaml": "workers: 1\nthreads: 2\n",
+		"nested.yaml":  "workers: 1\nlimits:\n  rate: 2\n",
+		"value.yaml":   "workers: many\n",
+		"zero.yaml":    "queue_bound: 10\n",
+		"pool.toml":    "workers = 1\n",
+	} {
+		if _, err := LoadConfig(writeConfig(t, name, content)); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	for k, v := range map[string]string{
+		"WORKPOOL_NAME":         "api",
+		"WORKPOOL_WORKERS":      "8",
+		"WORKPOOL_QUEUE_BOUND":  "100",
+		"WORKPOOL_IDLE_TIMEOUT": "500ms",
+		"WORKPOOL_RETRIES":      "2",
+		"WORKPOOL_RATE_LIMIT":   "50",
+		"WORKPOOL_RATE_BURST":   "5",
+		"WORKPOOL_PREFORK":      "true",
+	} {
+		t.Setenv(k, v)
+	}
+	c, err := FromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c != wantConfig {
+		t.Errorf("got %+v, want %+v", c, wantConfig)
+	}
+
+	t.Setenv("WORKPOOL_RETRIES", "-")
+	if _, err := FromEnv(); err == nil || !strings.Contains(err.Error(), "retries") {
+		t.Errorf("FromEnv with a bad value = %v, want an error naming retries", err)
+	}
+}
+
+// 配置中的重试次数和空闲超时在创建的工作池中生效
+func TestConfigNew(t *testing.T) {
+	defer sync.VerifyNone(t)
+	c := Config{Workers: 1, Retries: 2, IdleTimeout: Duration(10 * time.Millisecond)}
+	errc := make(chan error, 1)
+	pool, err := c.New(WithErrorHandler(func(err error) { errc <- err }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.Start()
+
+	var calls int
+	pool.SubmitErrFunc(func() error {
+		calls++
+		if calls < 3 {
+			return os.ErrDeadlineExceeded
+		}
+		return nil
+	})
+	waitFor(t, func() bool { return pool.GetWaitCount() == 0 }) // 空闲 10ms 后协程退出
+	pool.Shutdown()
+	pool.Wait()
+	if calls != 3 {
+		t.Errorf("function called %d times, want 3", calls)
+	}
+	select {
+	case err := <-errc:
+		t.Errorf("error handler called with %v after a successful retry", err)
+	default:
+	}
+
+	if _, err := (Config{}).New(); err == nil {
+		t.Error("New with zero workers succeeded")
+	}
+}
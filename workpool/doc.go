@@ -0,0 +1,12 @@
+// Package workpool 是一个按需伸缩协程数的工作池，导入路径为 github.com/RobinTsai/tearUpGo/workpool。
+//
+// 模块中可单独使用的部分：
+//
+//	github.com/RobinTsai/tearUpGo/workpool/sync   ElasticBuf 等无界缓冲和队列
+//	github.com/RobinTsai/tearUpGo/workpool/xsync  WaitGroup、Semaphore、Limiter 等同步原语
+//	github.com/RobinTsai/tearUpGo/workpool/grpcpool  gRPC 拦截器，单独的模块
+//
+// 版本按语义化版本以 git 标签 workpool/vX.Y.Z 发布（grpcpool 为 workpool/grpcpool/vX.Y.Z）。
+// v1 之前次版本号的升级可能包含不兼容的修改，修订号只修复问题；
+// examples 和 cmd 中的代码以及注释中标明“实验性”的 API 不在兼容性保证之内
+package workpool
@@ -0,0 +1,42 @@
+package workpool
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrDeadlineUnreachable 表示开启 WithEDF 时任务的截止时间已过，或剩余时间不足以执行完任务，任务不会被执行
+var ErrDeadlineUnreachable = errors.New("workpool: task cannot finish before its deadline")
+
+// WithEDF 开启最早截止时间优先（Earliest Deadline First）调度，适合有延迟 SLO 的请求类任务：
+//   - 排队的任务按截止时间出队，截止时间越早越先执行；没有截止时间的任务排在所有有截止时间的任务之后，
+//     截止时间相同或都没有截止时间的任务之间先进先出
+//   - 提交时截止时间距现在不足 cost（任务预计的执行时间）的任务直接返回 ErrDeadlineUnreachable，
+//     不占用队列；cost 为 0 时只拒绝截止时间已过的任务
+//   - 出队时同样检查，排队期间变得来不及的任务被跳过并计入 ExpiredCount，不浪费协程
+//
+// 截止时间取自提交时的 context（AddTaskContext 的 ctx，或 IContextWorkload 的 Context）。
+// 与 WithPriority 一样只影响在队列中等待的任务，有空闲协程时任务仍会被立即执行；
+// 同时设置 WithPriority 或 WithLIFO 时以 EDF 为准
+func WithEDF(cost time.Duration) Option {
+	return func(p *Workerpool) {
+		p.edf = true
+		p.edfCost = cost
+	}
+}
+
+// edfLess 是 EDF 的出队顺序，见 WithEDF
+func edfLess(a, b *task) bool {
+	switch {
+	case a.deadline.IsZero() != b.deadline.IsZero():
+		return b.deadline.IsZero()
+	case !a.deadline.Equal(b.deadline):
+		return a.deadline.Before(b.deadline)
+	}
+	return a.seq < b.seq
+}
+
+// hopeless 返回 t 是否已经来不及在截止时间前执行完
+func (p *Workerpool) hopeless(t *task) bool {
+	return p.edf && !t.deadline.IsZero() && time.Until(t.deadline) < p.edfCost
+}
@@ -0,0 +1,90 @@
+package workpool
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/RobinTsai/tearUpGo/workpool/sync"
+)
+
+// 排队的任务按截止时间出队，没有截止时间的任务最后按提交顺序执行
+func TestEDFOrder(t *testing.T) {
+	defer sync.VerifyNone(t)
+	pool := NewWorkerpool(1, WithEDF(0), WithChanSize(0, 0))
+	pool.Start()
+	release := make(chan struct{})
+	f, started := blockingTask(release)
+	pool.SubmitFunc(f)
+	<-started
+
+	now := time.Now()
+	var order []int
+	submit := func(id int, after time.Duration) {
+		ctx := context.Background()
+		if after > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithDeadline(ctx, now.Add(after))
+			t.Cleanup(cancel)
+		}
+		if err := pool.SubmitFuncContext(ctx, func(context.Context) { order = append(order, id) }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	submit(5, 0)
+	submit(3, 3*time.Hour)
+	submit(1, time.Hour)
+	submit(6, 0)
+	submit(2, 2*time.Hour)
+	submit(4, 3*time.Hour)
+	waitFor(t, func() bool { return pool.PendingCount() == 6 })
+	close(release)
+	pool.Shutdown()
+	pool.Wait()
+
+	if want := []int{1, 2, 3, 4, 5, 6}; !reflect.DeepEqual(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+}
+
+// 来不及执行完的任务在提交时被拒绝，排队期间变得来不及的任务被跳过
+func TestEDFHopeless(t *testing.T) {
+	defer sync.VerifyNone(t)
+	pool := NewWorkerpool(1, WithEDF(20*time.Millisecond), WithChanSize(0, 0))
+	pool.Start()
+	nop := func(context.Context) {}
+
+	past, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	if err := pool.SubmitFuncContext(past, nop); err != ErrDeadlineUnreachable {
+		t.Errorf("deadline passed: err = %v, want ErrDeadlineUnreachable", err)
+	}
+	soon, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if err := pool.SubmitFuncContext(soon, nop); err != ErrDeadlineUnreachable {
+		t.Errorf("deadline within cost: err = %v, want ErrDeadlineUnreachable", err)
+	}
+
+	release := make(chan struct{})
+	f, started := blockingTask(release)
+	pool.SubmitFunc(f)
+	<-started
+	tight, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	ran := false
+	if err := pool.SubmitFuncContext(tight, func(context.Context) { ran = true }); err != nil {
+		t.Fatalf("deadline after cost: err = %v, want nil", err)
+	}
+	time.Sleep(40 * time.Millisecond) // 剩余时间不足 cost
+	close(release)
+	pool.Shutdown()
+	pool.Wait()
+
+	if ran {
+		t.Error("task whose deadline became unreachable while queued was run")
+	}
+	if n := pool.ExpiredCount(); n != 1 {
+		t.Errorf("ExpiredCount = %d, want 1", n)
+	}
+}
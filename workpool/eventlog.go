@@ -0,0 +1,63 @@
+package workpool
+
+import (
+	"encoding/json"
+	"io"
+	stdsync "sync"
+	"sync/atomic"
+	"time"
+)
+
+// EventRecord 是 WithEventLog 写出的一行 JSON
+type EventRecord struct {
+	Time    time.Time `json:"time"`
+	Event   string    `json:"event"`            // submit、start、finish、expired、fail、spawn、retire、shutdown、drained
+	Task    uint64    `json:"task,omitempty"`   // 任务编号，按提交顺序从 1 开始
+	Worker  uint64    `json:"worker,omitempty"` // 协程编号，按启动顺序从 1 开始
+	Workers int       `json:"workers"`          // 事件发生时的协程数
+	Error   string    `json:"error,omitempty"`  // fail 事件的错误
+}
+
+// eventLog 把事件按 JSON Lines 格式写入 w
+type eventLog struct {
+	mu    stdsync.Mutex // 串行化写入，保证每行完整
+	enc   *json.Encoder
+	err   error  // 第一次写入失败的错误，之后不再写入
+	tasks uint64 // 已分配的任务编号，原子操作
+}
+
+// WithEventLog 把工作池的每个生命周期事件和任务事件作为一行 JSON（见 EventRecord）写入 w：
+// 任务的提交、开始、结束、过期和失败，协程的启动和退出，以及关闭和排空。
+// 与 Events 通道不同，事件不会被丢弃，可以作为审计日志，或交给外部工具回放和绘图；
+// 代价是每个事件都要在锁内编码和写入，w 较慢时会拖慢提交和执行，需要时可以套一层 bufio.Writer。
+// 写入出错后不再写入
+func WithEventLog(w io.Writer) Option {
+	return func(p *Workerpool) {
+		p.eventLog = &eventLog{enc: json.NewEncoder(w)}
+	}
+}
+
+// nextTask 分配任务编号，未开启事件日志时返回 0
+func (l *eventLog) nextTask() uint64 {
+	if l == nil {
+		return 0
+	}
+	return atomic.AddUint64(&l.tasks, 1)
+}
+
+// logEvent 写出一个事件，未开启事件日志时什么都不做
+func (p *Workerpool) logEvent(event string, task, worker uint64, err error) {
+	l := p.eventLog
+	if l == nil {
+		return
+	}
+	rec := EventRecord{Time: time.Now(), Event: event, Task: task, Worker: worker, Workers: int(p.GetWaitCount())}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err == nil {
+		l.err = l.enc.Encode(rec)
+	}
+}
@@ -0,0 +1,91 @@
+package workpool
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/RobinTsai/tearUpGo/workpool/sync"
+)
+
+// recordWriter 把每次 Write（即一行 JSON）解码后送入 c
+type recordWriter struct {
+	t *testing.T
+	c chan EventRecord
+}
+
+func (w recordWriter) Write(b []byte) (int, error) {
+	var rec EventRecord
+	if err := json.Unmarshal(b, &rec); err != nil {
+		w.t.Errorf("invalid event line %q: %v", b, err)
+	}
+	w.c <- rec
+	return len(b), nil
+}
+
+func TestEventLog(t *testing.T) {
+	defer sync.VerifyNone(t)
+	w := recordWriter{t: t, c: make(chan EventRecord, 1000)}
+	pool := NewWorkerpool(2, WithEventLog(w), WithErrorHandler(func(error) {}))
+	pool.Start()
+
+	const n = 10
+	for i := 0; i < n; i++ {
+		if err := pool.AddTask(nopWorkload{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := pool.SubmitErrFunc(func() error { return errors.New("boom") }); err != nil {
+		t.Fatal(err)
+	}
+	pool.Shutdown()
+
+	var recs []EventRecord
+	for timeout := time.After(5 * time.Second); len(recs) == 0 || recs[len(recs)-1].Event != "drained"; {
+		select {
+		case rec := <-w.c:
+			recs = append(recs, rec)
+		case <-timeout:
+			t.Fatalf("no drained event, got %+v", recs)
+		}
+	}
+
+	// 每个任务依次 submit、start、finish，协程先 spawn 后 retire
+	state := map[uint64]string{}
+	workers := map[uint64]string{}
+	count := map[string]int{}
+	for _, rec := range recs {
+		count[rec.Event]++
+		if rec.Time.IsZero() {
+			t.Errorf("%s event without time", rec.Event)
+		}
+		switch rec.Event {
+		case "submit", "start", "finish":
+			prev := map[string]string{"submit": "", "start": "submit", "finish": "start"}[rec.Event]
+			if state[rec.Task] != prev {
+				t.Errorf("task %d: %s after %q", rec.Task, rec.Event, state[rec.Task])
+			}
+			state[rec.Task] = rec.Event
+		case "spawn", "retire":
+			prev := map[string]string{"spawn": "", "retire": "spawn"}[rec.Event]
+			if workers[rec.Worker] != prev {
+				t.Errorf("worker %d: %s after %q", rec.Worker, rec.Event, workers[rec.Worker])
+			}
+			workers[rec.Worker] = rec.Event
+		case "fail":
+			if rec.Error != "boom" {
+				t.Errorf("fail event error = %q, want boom", rec.Error)
+			}
+		}
+	}
+	if count["submit"] != n+1 || count["finish"] != n+1 {
+		t.Errorf("submit/finish = %d/%d, want %d", count["submit"], count["finish"], n+1)
+	}
+	if count["fail"] != 1 || count["shutdown"] != 1 {
+		t.Errorf("fail/shutdown = %d/%d, want 1/1", count["fail"], count["shutdown"])
+	}
+	if count["spawn"] == 0 || count["spawn"] != count["retire"] {
+		t.Errorf("spawn/retire = %d/%d", count["spawn"], count["retire"])
+	}
+}
@@ -0,0 +1,93 @@
+package workpool
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// EventType 工作池生命周期事件的类型
+type EventType int
+
+const (
+	WorkerSpawned EventType = iota // 启动了一个新协程
+	WorkerRetired                  // 一个协程退出（空闲收缩或工作池关闭）
+	TaskStarted                    // 任务开始执行
+	TaskFinished                   // 任务执行结束
+	QueueFull                      // 协程数已达上限，任务只能在队列中等待
+	ShutdownBegan                  // 开始关闭（Shutdown 或 Down）
+	Drained                        // 关闭后所有协程都已退出
+	TaskExpired                    // 任务的 context 在执行前已结束，被跳过
+)
+
+var eventTypeNames = [...]string{
+	WorkerSpawned: "WorkerSpawned",
+	WorkerRetired: "WorkerRetired",
+	TaskStarted:   "TaskStarted",
+	TaskFinished:  "TaskFinished",
+	QueueFull:     "QueueFull",
+	ShutdownBegan: "ShutdownBegan",
+	Drained:       "Drained",
+	TaskExpired:   "TaskExpired",
+}
+
+func (t EventType) String() string {
+	if t >= 0 && int(t) < len(eventTypeNames) {
+		return eventTypeNames[t]
+	}
+	return fmt.Sprintf("EventType(%d)", int(t))
+}
+
+// Event 是工作池发出的结构化事件
+type Event struct {
+	Type    EventType
+	Time    time.Time
+	Workers int       // 事件发生时的协程数目
+	Task    IWorkload // 任务相关的事件携带该任务，其余为 nil
+}
+
+const (
+	eventsBufSize = 64
+)
+
+// Events 返回工作池的事件通道，供外部监控使用而无需轮询
+// 通道满时新的事件会被丢弃，保证工作池不会因为无人读取而阻塞；通道不会被关闭
+func (p *Workerpool) Events() <-chan Event {
+	return p.events
+}
+
+// emit 非阻塞地发送一个事件
+func (p *Workerpool) emit(typ EventType, task IWorkload) {
+	select {
+	case p.events <- Event{Type: typ, Time: time.Now(), Workers: int(p.GetWaitCount()), Task: task}:
+	default:
+	}
+}
+
+// drained 在关闭后所有协程退出时调用，只发出一次 Drained 事件并关闭结果通道
+func (p *Workerpool) drained() {
+	if !p.drainedEv.Set() {
+		return
+	}
+	p.dropQueued()
+	if p.group != nil && !p.group.shardDrained() { // 分片只在最后一个排空时代表整个 ShardedPool 发出
+		return
+	}
+	if p.results != nil {
+		p.results.close(p.ctx)
+	}
+	p.emit(Drained, nil)
+	p.logEvent("drained", 0, 0, nil)
+}
+
+// Drained 返回一个通道，工作池关闭且所有协程都已退出后被关闭，与 WaitDrained 相同，适合在 select 中使用。
+// Down 时排队的任务被丢弃，通道关闭后它们不会再执行
+func (p *Workerpool) Drained() <-chan struct{} {
+	return p.drainedEv.Done()
+}
+
+// WaitDrained 阻塞直到工作池关闭且所有任务处理完，ctx 先结束时返回 ctx.Err()
+// 协程全部空闲退出时 Wait 也会返回，而 WaitDrained 只在关闭之后返回，可被多个协程同时等待
+func (p *Workerpool) WaitDrained(ctx context.Context) error {
+	return p.drainedEv.Wait(ctx)
+}
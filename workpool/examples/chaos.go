@@ -0,0 +1,120 @@
+package examples
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/RobinTsai/tearUpGo/workpool"
+)
+
+// ErrChaos 是 Chaos 注入的错误
+var ErrChaos = errors.New("examples: injected failure")
+
+// ErrTimeout 是 Resilient 中看门狗放弃等待一次执行时的错误
+var ErrTimeout = errors.New("examples: attempt timed out")
+
+// PanicError 包装 Resilient 中 recover 得到的值
+type PanicError struct {
+	Value interface{}
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("examples: task panicked: %v", e.Value)
+}
+
+// Chaos 按配置的概率向任务注入故障：panic、返回 ErrChaos，或者挂起 Hang 之后再正常执行。
+// 每次 Do 都重新抽签，所以故障是暂时性的，重试有机会成功。Rate 需在首次 Do 之前设置
+type Chaos struct {
+	PanicRate float64
+	ErrorRate float64
+	HangRate  float64
+	Hang      time.Duration
+
+	mu                    sync.Mutex
+	rng                   *rand.Rand
+	panics, errors, hangs int
+}
+
+// NewChaos 创建以 seed 抽签的 Chaos，不设置 Rate 时不注入任何故障
+func NewChaos(seed int64) *Chaos {
+	return &Chaos{rng: rand.New(rand.NewSource(seed))}
+}
+
+// Do 抽签决定是否注入故障，然后执行 f
+func (c *Chaos) Do(f func() error) error {
+	c.mu.Lock()
+	x := c.rng.Float64()
+	switch {
+	case x < c.PanicRate:
+		c.panics++
+		c.mu.Unlock()
+		panic(ErrChaos)
+	case x < c.PanicRate+c.ErrorRate:
+		c.errors++
+		c.mu.Unlock()
+		return ErrChaos
+	case x < c.PanicRate+c.ErrorRate+c.HangRate:
+		c.hangs++
+		c.mu.Unlock()
+		time.Sleep(c.Hang)
+	default:
+		c.mu.Unlock()
+	}
+	return f()
+}
+
+// Injected 返回已注入的各类故障次数
+func (c *Chaos) Injected() (panics, errors, hangs int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.panics, c.errors, c.hangs
+}
+
+// Resilient 把可能失败的 f 包装成 IWorkload。
+// 工作池本身不恢复任务中的 panic（会使整个进程退出）、也不限制任务的执行时间，
+// 所以在任务这一层处理：每次执行都在新协程中进行并 recover，超过 timeout 时看门狗放弃等待
+// （挂起的协程会在 f 返回后自行退出），失败后最多重试 retries 次，最后一次的结果交给 done
+func Resilient(f func() error, timeout time.Duration, retries int, done func(err error)) workpool.IWorkload {
+	return resilientWorkload{f: f, timeout: timeout, retries: retries, done: done}
+}
+
+type resilientWorkload struct {
+	f       func() error
+	timeout time.Duration
+	retries int
+	done    func(err error)
+}
+
+func (w resilientWorkload) Work() {
+	var err error
+	for i := 0; i <= w.retries; i++ {
+		if err = w.attempt(); err == nil {
+			break
+		}
+	}
+	w.done(err)
+}
+
+// attempt 执行一次 f，把 panic 和超时都转成错误
+func (w resilientWorkload) attempt() error {
+	ch := make(chan error, 1) // 有缓冲，超时后被放弃的协程也能发送并退出
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				ch <- &PanicError{Value: r}
+			}
+		}()
+		ch <- w.f()
+	}()
+	t := time.NewTimer(w.timeout)
+	defer t.Stop()
+	select {
+	case err := <-ch:
+		return err
+	case <-t.C:
+		return ErrTimeout
+	}
+}
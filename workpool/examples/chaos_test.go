@@ -0,0 +1,78 @@
+package examples
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/RobinTsai/tearUpGo/workpool"
+)
+
+// 200 个任务中约三成的执行会 panic、出错或挂起，经过 Resilient 的恢复、看门狗和重试后全部成功，
+// 工作池也不会因为 panic 而退出
+func TestChaos(t *testing.T) {
+	chaos := NewChaos(1)
+	chaos.PanicRate, chaos.ErrorRate, chaos.HangRate = 0.1, 0.1, 0.1
+	chaos.Hang = 50 * time.Millisecond
+
+	const n = 200
+	var ran, failed int64
+	pool := workpool.NewWorkerpool(5)
+	pool.Start()
+	for i := 0; i < n; i++ {
+		work := Resilient(func() error {
+			return chaos.Do(func() error {
+				atomic.AddInt64(&ran, 1)
+				return nil
+			})
+		}, 10*time.Millisecond, 10, func(err error) {
+			if err != nil {
+				atomic.AddInt64(&failed, 1)
+			}
+		})
+		if err := pool.AddTask(work); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pool.Shutdown()
+	pool.Wait()
+
+	panics, errs, hangs := chaos.Injected()
+	if panics == 0 || errs == 0 || hangs == 0 {
+		t.Errorf("injected panics=%d errors=%d hangs=%d, want all > 0", panics, errs, hangs)
+	}
+	if failed != 0 {
+		t.Errorf("%d tasks failed after retries", failed)
+	}
+	// 超时被放弃的执行在挂起结束后仍会运行 f，所以 ran 至少是 n
+	if ran := atomic.LoadInt64(&ran); ran < n {
+		t.Errorf("f succeeded %d times, want >= %d", ran, n)
+	}
+}
+
+func TestResilientErrors(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		f    func() error
+		want func(err error) bool
+	}{
+		{"panic", func() error { panic("boom") }, func(err error) bool {
+			var pe *PanicError
+			return errors.As(err, &pe) && pe.Value == "boom"
+		}},
+		{"hang", func() error { time.Sleep(time.Second); return nil }, func(err error) bool { return err == ErrTimeout }},
+		{"error", func() error { return ErrChaos }, func(err error) bool { return err == ErrChaos }},
+	} {
+		c := c             // 超时被放弃的执行在下一轮循环时仍可能读取 c
+		var attempts int32 // 超时被放弃的执行可能与下一次并发
+		var got error
+		Resilient(func() error { atomic.AddInt32(&attempts, 1); return c.f() }, 10*time.Millisecond, 2, func(err error) { got = err }).Work()
+		if !c.want(got) {
+			t.Errorf("%s: err = %v", c.name, got)
+		}
+		if n := atomic.LoadInt32(&attempts); n != 3 {
+			t.Errorf("%s: %d attempts, want 3", c.name, n)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package examples
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/RobinTsai/tearUpGo/workpool"
+)
+
+// CrawlOptions 是 Crawl 的参数，零值字段使用默认值
+type CrawlOptions struct {
+	Concurrency int           // 同时进行的请求数，默认 5
+	Timeout     time.Duration // 每次请求的超时，默认 10s
+	Retries     int           // 网络错误或 5xx 时的重试次数
+	Backoff     time.Duration // 第 n 次重试前等待 n*Backoff
+	Client      *http.Client  // 默认 http.DefaultClient
+	MaxBody     int64         // 最多读取的响应体字节数，默认 1MB
+}
+
+// CrawlResult 是一个 URL 的抓取结果
+type CrawlResult struct {
+	URL      string
+	Status   int    // 最后一次请求的状态码，请求失败时为 0
+	Body     []byte // 最多 MaxBody 字节
+	Attempts int    // 实际请求的次数，因 ctx 结束被跳过时为 0
+	Err      error  // 最后一次请求的错误，状态码不是 2xx 时也为非 nil
+}
+
+// Crawl 通过工作池以有限的并发抓取 urls，按 urls 的顺序返回结果，展示工作池在 IO 密集场景下的用法：
+//   - 每个 URL 是一个任务，工作池的协程数即并发请求数的上限
+//   - 任务实现 IContextWorkload，ctx 结束后还未开始的任务被工作池直接跳过
+//   - 任务实现 IResultWorkload，结果经 WithOrderedResults 的通道按提交顺序返回；
+//     结果无人读取时 worker 会阻塞，所以边提交边收集
+func Crawl(ctx context.Context, urls []string, opts CrawlOptions) []CrawlResult {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 5
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+	if opts.MaxBody <= 0 {
+		opts.MaxBody = 1 << 20
+	}
+
+	results := make([]CrawlResult, len(urls))
+	pool := workpool.NewWorkerpool(opts.Concurrency, workpool.WithOrderedResults())
+	pool.Start()
+	collected := make(chan struct{})
+	go func() {
+		defer close(collected)
+		for v := range pool.Results() {
+			r := v.(*fetchWorkload)
+			results[r.index] = r.result
+		}
+	}()
+
+	for i, u := range urls {
+		pool.AddTask(&fetchWorkload{ctx: ctx, opts: &opts, index: i, result: CrawlResult{URL: u}})
+	}
+	pool.Shutdown()
+	pool.Wait()
+	<-collected
+	for i := range results { // 因 ctx 结束被跳过的任务没有结果
+		if results[i].Attempts == 0 {
+			results[i] = CrawlResult{URL: urls[i], Err: ctx.Err()}
+		}
+	}
+	return results
+}
+
+// fetchWorkload 抓取一个 URL，失败时按 opts 重试
+type fetchWorkload struct {
+	ctx    context.Context
+	opts   *CrawlOptions
+	index  int
+	result CrawlResult
+}
+
+func (w *fetchWorkload) Context() context.Context { return w.ctx }
+func (w *fetchWorkload) Result() interface{}      { return w }
+
+func (w *fetchWorkload) Work() {
+	for attempt := 0; attempt <= w.opts.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(time.Duration(attempt) * w.opts.Backoff):
+			case <-w.ctx.Done():
+				w.result.Err = w.ctx.Err()
+				return
+			}
+		}
+		w.result.Attempts++
+		if retry := w.fetch(); !retry {
+			return
+		}
+	}
+}
+
+// fetch 请求一次，返回是否值得重试
+func (w *fetchWorkload) fetch() (retry bool) {
+	ctx, cancel := context.WithTimeout(w.ctx, w.opts.Timeout)
+	defer cancel()
+	r := &w.result
+	r.Status, r.Body = 0, nil
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.URL, nil)
+	if err != nil {
+		r.Err = err
+		return false
+	}
+	resp, err := w.opts.Client.Do(req)
+	if err != nil {
+		r.Err = err
+		return w.ctx.Err() == nil // 超时或连接失败时重试，整体取消时不再重试
+	}
+	defer resp.Body.Close()
+	r.Status = resp.StatusCode
+	if r.Body, err = io.ReadAll(io.LimitReader(resp.Body, w.opts.MaxBody)); err != nil {
+		r.Err = err
+		return w.ctx.Err() == nil
+	}
+	if resp.StatusCode/100 != 2 {
+		r.Err = fmt.Errorf("examples: GET %s: %s", r.URL, resp.Status)
+		return resp.StatusCode >= 500
+	}
+	r.Err = nil
+	return false
+}
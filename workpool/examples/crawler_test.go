@@ -0,0 +1,89 @@
+package examples
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCrawl(t *testing.T) {
+	var flaky, inFlight, peak int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("ok")) })
+	mux.HandleFunc("/flaky", func(w http.ResponseWriter, r *http.Request) { // 前两次 503
+		if atomic.AddInt32(&flaky, 1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("flaky"))
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for p := atomic.LoadInt32(&peak); n > p && !atomic.CompareAndSwapInt32(&peak, p, n); p = atomic.LoadInt32(&peak) {
+		}
+		time.Sleep(5 * time.Millisecond)
+		mux.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	urls := []string{srv.URL + "/flaky", srv.URL + "/missing", srv.URL + "/slow"}
+	for i := 0; i < 10; i++ {
+		urls = append(urls, srv.URL+"/ok")
+	}
+	results := Crawl(context.Background(), urls, CrawlOptions{
+		Concurrency: 3, Timeout: 50 * time.Millisecond, Retries: 2, Backoff: time.Millisecond,
+	})
+
+	if len(results) != len(urls) {
+		t.Fatalf("got %d results, want %d", len(results), len(urls))
+	}
+	for i, r := range results {
+		if r.URL != urls[i] {
+			t.Errorf("result %d is for %s, want %s", i, r.URL, urls[i])
+		}
+	}
+	if r := results[0]; r.Err != nil || string(r.Body) != "flaky" || r.Attempts != 3 {
+		t.Errorf("flaky: %+v, want success on the 3rd attempt", r)
+	}
+	if r := results[1]; r.Status != http.StatusNotFound || r.Err == nil || r.Attempts != 1 {
+		t.Errorf("missing: %+v, want a 404 without retries", r)
+	}
+	if r := results[2]; r.Err == nil || r.Attempts != 3 {
+		t.Errorf("slow: %+v, want a timeout after 3 attempts", r)
+	}
+	for _, r := range results[3:] {
+		if r.Err != nil || string(r.Body) != "ok" {
+			t.Errorf("ok: %+v", r)
+		}
+	}
+	if p := atomic.LoadInt32(&peak); p > 3 {
+		t.Errorf("%d requests in flight, want <= 3", p)
+	}
+}
+
+func TestCrawlCancel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+	}))
+	defer srv.Close()
+	urls := make([]string, 20)
+	for i := range urls {
+		urls[i] = srv.URL
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	results := Crawl(ctx, urls, CrawlOptions{Concurrency: 1})
+	if r := results[len(results)-1]; r.Attempts != 0 || r.Err != context.DeadlineExceeded {
+		t.Errorf("last result = %+v, want skipped with DeadlineExceeded", r)
+	}
+}
@@ -3,10 +3,16 @@ package examples
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"time"
-	"workpool"
+
+	"github.com/RobinTsai/tearUpGo/workpool"
 )
 
+// output 是 Question2 打印的去处，测试中替换为缓冲区以便与 golden 文件比较
+var output io.Writer = os.Stdout
+
 /*
 // IWorkload 请勿修改接口
 type IWorkload interface {
@@ -44,21 +50,33 @@ type IProducer interface {
 //   功能点：
 //     1. 优雅关闭工作池（会等待所有任务执行完）
 //     2. 立即关闭工作池
-//     3. 动态伸缩协程个数，最少 0 个，最多 workerpool.workerCount 个协程
+//     3. 动态伸缩协程个数，最少 0 个，最多 Workerpool.workerCount 个协程
 //     4. 弹性池保存任务列表
 //     5. 集成并扩展 WaitGroup，等待所有任务任务处理结束，执行期间可查看 WaitGroup 中存在个数
 func Question2(producer workpool.IProducer) {
 	pool := workpool.NewWorkerpool(5)
 	pool.Start()
 
-	go func() { // 测试代码：定时查看协程个数
+	stop, stopped := make(chan struct{}), make(chan struct{})
+	go func() { // 测试代码：定时查看协程个数，Question2 返回前退出
+		defer close(stopped)
 		t := time.NewTicker(time.Second)
 		defer t.Stop()
 
-		for range t.C {
-			fmt.Println("cur worker count:", pool.GetWaitCount())
+		for {
+			select {
+			case <-t.C:
+				fmt.Fprintln(output, "cur worker count:", pool.GetWaitCount(),
+					"running:", pool.RunningWorkers(), "idle:", pool.IdleWorkers())
+			case <-stop:
+				return
+			}
 		}
 	}()
+	defer func() {
+		close(stop)
+		<-stopped
+	}()
 
 	taskCount := 0
 
@@ -68,11 +86,11 @@ func Question2(producer workpool.IProducer) {
 		pool.AddTask(workload)
 		workload = producer.Produce()
 	}
-	fmt.Println("total work count:", taskCount)
+	fmt.Fprintln(output, "total work count:", taskCount)
 
 	pool.Shutdown()
 	pool.Wait()
 
-	fmt.Println("worker count at the end:", pool.GetWaitCount())
+	fmt.Fprintln(output, "worker count at the end:", pool.GetWaitCount())
 	// fmt.Println("pool buf len at the end:", pool.elasticJobBuf.Len()) // 测试用
 }
@@ -1,56 +1,90 @@
 package examples
 
 import (
+	"flag"
 	"fmt"
+	"io"
 	"math/rand"
+	"os"
 	"sort"
 	"sync"
 	"testing"
 	"time"
-	"workpool"
+
+	"github.com/RobinTsai/tearUpGo/workpool"
 )
 
-type sleepWorkProducer int
-type sleepWorkload int
+// sleepWorkload 睡眠 ms 毫秒，并把始末相对时间打印到 p.out、发送给 p.collector
+type sleepWorkload struct {
+	ms int
+	p  *sleepWorkProducer
+}
 
-var uptime int64
-var collector chan [2]int64
+// sleepWorkProducer 产生 n 个 sleepWorkload。profile 非空时依次循环取其中的时长（毫秒），
+// 否则用 rng 产生 [0, 200) 毫秒的随机时长；rng 由显式的种子创建，同一种子每次运行的任务序列相同
+type sleepWorkProducer struct {
+	n       int
+	rng     *rand.Rand
+	profile []int
+	i       int
 
-func init() {
-	uptime = time.Now().UnixNano() / 1e6
-	rand.Seed(time.Now().Unix())
-	collector = make(chan [2]int64, 100) // 用于收集各 work 执行的始末相对时间便于观测结果
+	uptime    int64            // 相对时间的起点（毫秒）
+	out       io.Writer        // 任务打印的去处
+	collector chan<- [2]int64 // 非 nil 时收集各 work 执行的始末相对时间便于观测结果
+}
+
+// newSleepWorkProducer 创建产生 n 个随机时长任务的 sleepWorkProducer，任务打印到标准输出
+func newSleepWorkProducer(n int, seed int64) *sleepWorkProducer {
+	return &sleepWorkProducer{
+		n:      n,
+		rng:    rand.New(rand.NewSource(seed)),
+		uptime: time.Now().UnixNano() / 1e6,
+		out:    os.Stdout,
+	}
 }
 
+var timelineFile = flag.String("timeline", "", "write the Gantt chart of TestQuestion2 to this HTML file")
+var seed = flag.Int64("seed", 1, "seed of the sleep durations in TestQuestion2")
+
 func (w *sleepWorkload) Work() {
-	start := time.Now().UnixNano()/1e6 - uptime
-	time.Sleep(time.Duration(*w) * time.Millisecond)
-	end := time.Now().UnixNano()/1e6 - uptime
+	start := time.Now().UnixNano()/1e6 - w.p.uptime
+	time.Sleep(time.Duration(w.ms) * time.Millisecond)
+	end := time.Now().UnixNano()/1e6 - w.p.uptime
 
-	collector <- [2]int64{start, end}
-	fmt.Printf("sleep %3dms, relative time: %5d to %5d\n", *w, start, end)
+	if w.p.collector != nil {
+		w.p.collector <- [2]int64{start, end}
+	}
+	fmt.Fprintf(w.p.out, "sleep %3dms, relative time: %5d to %5d\n", w.ms, start, end)
 }
 func (w *sleepWorkProducer) Produce() workpool.IWorkload {
-	if *w <= 0 {
+	if w.i >= w.n {
 		return nil
 	}
-	*w--
-	n := sleepWorkload(rand.Intn(200)) // 产生睡眠 200ms 内的 work
+	n := sleepWorkload{p: w}
+	if len(w.profile) > 0 {
+		n.ms = w.profile[w.i%len(w.profile)]
+	} else {
+		n.ms = w.rng.Intn(200) // 产生睡眠 200ms 内的 work
+	}
+	w.i++
 	return &n
 }
 
 // 测试方案：
 //   用 sleepWorkload 来实现 IWorkload 接口，这个任务只用来做 sleep 任务并记录起始和结束的相对时间以及将这些信息发给收集器 collector
 //   用 sleepWorkProducer 实现 IProducer 接口，用于生产固定数量的 sleepWorkload 任务
-//   通过 collector 收集所有任务执行的起止时间（相对时间），用于观察。（可以通过绘图或者类似动态窗口的处理方式将这些数据做处理来观察结果，但未实现）
+//   通过 collector 收集所有任务执行的起止时间（相对时间），用于观察。
+//   用 -timeline 参数可以把它们画成甘特图：go test -run TestQuestion2 -timeline timeline.html
 //   在程序函数中也有部分测试代码，如定时获取并发执行的任务数等。
+//   最后用扫描线验证任意时刻执行中的任务不超过 5 个。
 func TestQuestion2(t *testing.T) {
-	producer := new(sleepWorkProducer)
-	*producer = 100
+	producer := newSleepWorkProducer(100, *seed)
+	collector := make(chan [2]int64, 100)
+	producer.collector = collector
 	// 开启收集执行信息任务
 	wg := sync.WaitGroup{}
 	wg.Add(1)
-	collectTimeInfo := make([][2]int64, 0, *producer)
+	collectTimeInfo := make([][2]int64, 0, producer.n)
 	// 启动收集执行时间信息
 	go func() {
 		for v := range collector {
@@ -75,6 +109,91 @@ func TestQuestion2(t *testing.T) {
 	for _, v := range collectTimeInfo {
 		fmt.Println(v)
 	}
-	// TODO: 类似滑动窗口验证 collectTimeInfo (以毫秒精度记录的时间，有临界重复的问题)
-	// 时间关系不再验证，参考一个输出案例 output.txt
+	if *timelineFile != "" {
+		f, err := os.Create(*timelineFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if err := WriteTimeline(f, collectTimeInfo); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 一个输出案例见 output.txt
+	if peak := peakConcurrency(collectTimeInfo); peak > 5 {
+		t.Errorf("peak concurrency = %d, want <= 5 (the pool size of Question2)", peak)
+	}
+}
+
+// peakConcurrency 用扫描线求 intervals 中同时执行的区间数的最大值：
+// 把每个区间拆成开始（+1）和结束（-1）两个事件按时间排序后累加。
+// 时间以毫秒精度记录，一个 worker 上前一个任务的结束和下一个任务的开始常落在同一毫秒，
+// 所以同一时刻先处理结束事件，即 end == start 视为不重叠；
+// 截断到毫秒是单调的，真实不重叠的两个区间截断后仍满足 end <= start，不会误报
+func peakConcurrency(intervals [][2]int64) int {
+	type event struct {
+		at    int64
+		delta int
+	}
+	events := make([]event, 0, 2*len(intervals))
+	for _, iv := range intervals {
+		events = append(events, event{iv[0], 1}, event{iv[1], -1})
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].at != events[j].at {
+			return events[i].at < events[j].at
+		}
+		return events[i].delta < events[j].delta
+	})
+	cur, peak := 0, 0
+	for _, e := range events {
+		cur += e.delta
+		if cur > peak {
+			peak = cur
+		}
+	}
+	return peak
+}
+
+func TestSleepWorkProducerDeterministic(t *testing.T) {
+	drain := func(p *sleepWorkProducer) (ds []int) {
+		for w := p.Produce(); w != nil; w = p.Produce() {
+			ds = append(ds, w.(*sleepWorkload).ms)
+		}
+		return ds
+	}
+	a, b := drain(newSleepWorkProducer(50, 42)), drain(newSleepWorkProducer(50, 42))
+	if len(a) != 50 {
+		t.Fatalf("produced %d workloads, want 50", len(a))
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("same seed produced different durations at %d: %d != %d", i, a[i], b[i])
+		}
+	}
+
+	p := &sleepWorkProducer{n: 5, profile: []int{10, 20}}
+	got := drain(p)
+	want := []int{10, 20, 10, 20, 10}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("profile durations = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestPeakConcurrency(t *testing.T) {
+	for _, c := range []struct {
+		intervals [][2]int64
+		want      int
+	}{
+		{nil, 0},
+		{[][2]int64{{0, 10}, {10, 20}, {20, 30}}, 1}, // 首尾相接
+		{[][2]int64{{0, 10}, {5, 15}, {9, 10}}, 3},
+		{[][2]int64{{0, 10}, {0, 10}, {10, 10}}, 2}, // 0ms 任务
+	} {
+		if got := peakConcurrency(c.intervals); got != c.want {
+			t.Errorf("peakConcurrency(%v) = %d, want %d", c.intervals, got, c.want)
+		}
+	}
 }
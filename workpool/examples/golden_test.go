@@ -0,0 +1,85 @@
+package examples
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// checkGolden 比较 got 与 testdata/name 的内容，-update 时改为写入
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s (run go test -update if the change is intended)\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+// lockedBuffer 是可以被多个协程同时写入的缓冲区
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+var relativeTime = regexp.MustCompile(`relative time: +\d+ to +\d+`)
+
+// normalize 去掉输出中与时间有关的部分：定时打印的协程数被删除，相对时间被替换为 T，
+// 各任务完成的先后不确定，所以最后把所有行排序
+func normalize(out string) []byte {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if strings.HasPrefix(line, "cur worker count:") {
+			continue
+		}
+		lines = append(lines, relativeTime.ReplaceAllString(line, "relative time: T to T"))
+	}
+	sort.Strings(lines)
+	return []byte(strings.Join(lines, "\n") + "\n")
+}
+
+func TestQuestion2Golden(t *testing.T) {
+	var buf lockedBuffer
+	defer func(w interface{ Write([]byte) (int, error) }) { output = w }(output)
+	output = &buf
+
+	producer := newSleepWorkProducer(12, 1)
+	producer.profile = []int{30, 10, 20, 5}
+	producer.out = &buf
+	Question2(producer)
+	checkGolden(t, "question2.golden", normalize(buf.buf.String()))
+}
+
+func TestTimelineGolden(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteTimeline(&buf, [][2]int64{{0, 30}, {0, 10}, {10, 30}, {12, 14}, {30, 40}}); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "timeline.golden", buf.Bytes())
+}
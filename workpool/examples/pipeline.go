@@ -0,0 +1,101 @@
+package examples
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/RobinTsai/tearUpGo/workpool"
+)
+
+// Record 是流水线最后一步存储的数据
+type Record struct {
+	Source string
+	Words  int
+}
+
+// PipelineStages 是流水线三个阶段的处理函数，由调用方提供（例如 Crawl 中的抓取）
+type PipelineStages struct {
+	Fetch func(ctx context.Context, src string) (string, error)
+	Parse func(doc string) Record
+	Store func(r Record) error
+}
+
+// PipelineOptions 是流水线每个阶段的协程数和排队上限
+type PipelineOptions struct {
+	FetchWorkers, ParseWorkers, StoreWorkers int
+	Queue                                    int // 每个阶段排队任务数的上限，见 workpool.WithQueueBound
+}
+
+// RunPipeline 用三个工作池组成 fetch → parse → store 的流水线处理 sources，返回各阶段的错误。
+//
+// 背压：每个阶段的工作池都设置了 WithQueueBound，下游积压满时上游任务在 AddTask 中阻塞，
+// 占住上游的协程，上游的队列随之被填满，最终阻塞 RunPipeline 的提交，内存占用有上限。
+//
+// 关闭：按阶段顺序 Shutdown 再 Wait，上一阶段的任务全部完成（已经提交给下一阶段）之后才关闭下一阶段，
+// 所以不会有任务被提交给已关闭的工作池。ctx 结束时不再提交新的源，已提交的任务照常流过流水线，
+// Fetch 可以自行根据 ctx 放弃
+func RunPipeline(ctx context.Context, sources []string, stages PipelineStages, opts PipelineOptions) []error {
+	if opts.FetchWorkers <= 0 {
+		opts.FetchWorkers = 1
+	}
+	if opts.ParseWorkers <= 0 {
+		opts.ParseWorkers = 1
+	}
+	if opts.StoreWorkers <= 0 {
+		opts.StoreWorkers = 1
+	}
+	bound := workpool.WithQueueBound(opts.Queue)
+	fetchPool := workpool.NewWorkerpool(opts.FetchWorkers, bound)
+	parsePool := workpool.NewWorkerpool(opts.ParseWorkers, bound)
+	storePool := workpool.NewWorkerpool(opts.StoreWorkers, bound)
+	for _, p := range []interface{ Start() error }{fetchPool, parsePool, storePool} {
+		p.Start()
+	}
+
+	var mu sync.Mutex
+	var errs []error
+	fail := func(err error) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+	}
+
+	for _, src := range sources {
+		if ctx.Err() != nil {
+			fail(ctx.Err())
+			break
+		}
+		src := src
+		fetchPool.SubmitFunc(func() {
+			doc, err := stages.Fetch(ctx, src)
+			if err != nil {
+				fail(err)
+				return
+			}
+			parsePool.SubmitFunc(func() {
+				r := stages.Parse(doc)
+				r.Source = src
+				storePool.SubmitFunc(func() {
+					if err := stages.Store(r); err != nil {
+						fail(err)
+					}
+				})
+			})
+		})
+	}
+
+	// 按阶段顺序关闭
+	fetchPool.Shutdown()
+	fetchPool.Wait()
+	parsePool.Shutdown()
+	parsePool.Wait()
+	storePool.Shutdown()
+	storePool.Wait()
+	return errs
+}
+
+// CountWords 是一个 Parse 的示例
+func CountWords(doc string) Record {
+	return Record{Words: len(strings.Fields(doc))}
+}
@@ -0,0 +1,66 @@
+package examples
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPipeline(t *testing.T) {
+	sources := make([]string, 50)
+	for i := range sources {
+		sources[i] = fmt.Sprint(i)
+	}
+	var (
+		mu         sync.Mutex
+		stored     = make(map[string]int)
+		maxBacklog int
+	)
+	var fetched, inFlight, peak int32
+	errs := RunPipeline(context.Background(), sources, PipelineStages{
+		Fetch: func(ctx context.Context, src string) (string, error) {
+			atomic.AddInt32(&fetched, 1)
+			if src == "13" {
+				return "", errors.New("fetch 13 failed")
+			}
+			return "a b c " + src, nil
+		},
+		Parse: CountWords,
+		Store: func(r Record) error {
+			c := atomic.AddInt32(&inFlight, 1)
+			for p := atomic.LoadInt32(&peak); c > p && !atomic.CompareAndSwapInt32(&peak, p, c); p = atomic.LoadInt32(&peak) {
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&inFlight, -1)
+			mu.Lock()
+			stored[r.Source] = r.Words
+			// 存储很慢而抓取很快，有背压时已抓取但未存储的文档数有上限
+			if n := int(atomic.LoadInt32(&fetched)) - len(stored); n > maxBacklog {
+				maxBacklog = n
+			}
+			mu.Unlock()
+			return nil
+		},
+	}, PipelineOptions{FetchWorkers: 4, ParseWorkers: 2, StoreWorkers: 1, Queue: 2})
+
+	if len(errs) != 1 || errs[0].Error() != "fetch 13 failed" {
+		t.Errorf("errs = %v, want the fetch error of 13", errs)
+	}
+	if len(stored) != 49 {
+		t.Errorf("stored %d records, want 49", len(stored))
+	}
+	if w := stored["7"]; w != 4 {
+		t.Errorf("record 7 has %d words, want 4", w)
+	}
+	if maxBacklog >= 30 {
+		t.Errorf("%d documents fetched but not stored, want the upstream to be held back", maxBacklog)
+	}
+	t.Logf("max backlog between fetch and store: %d", maxBacklog)
+	if p := atomic.LoadInt32(&peak); p != 1 {
+		t.Errorf("%d stores ran concurrently, want 1", p)
+	}
+}
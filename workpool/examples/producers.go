@@ -0,0 +1,79 @@
+package examples
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/RobinTsai/tearUpGo/workpool"
+)
+
+// Arrivals 是按到达间隔产生任务的 IProducer：第 i 次 Produce 在距第一次调用
+// Gap(0)+...+Gap(i) 之后返回 New(i)，共产生 N 个任务后返回 nil。
+// 到达时间按累计间隔计算而不是每次睡 Gap(i)，提交任务本身的耗时不会让节奏越来越慢；
+// 提交跟不上时（如 AddTask 因队列有界而阻塞）会立即返回，体现为一次突发。
+//
+// 下面的构造函数给出了常见的到达模式，用于观察工作池在接近真实负载时的表现
+type Arrivals struct {
+	N   int                            // 任务总数
+	Gap func(i int) time.Duration      // 第 i 个任务与上一个任务（i 为 0 时与第一次调用）的间隔
+	New func(i int) workpool.IWorkload // 生成第 i 个任务
+
+	i     int
+	start time.Time
+	due   time.Duration // 第 i 个任务的到达时间，相对 start
+}
+
+// Produce 等到下一个任务的到达时间并返回它，产生完 N 个后返回 nil
+func (a *Arrivals) Produce() workpool.IWorkload {
+	if a.i >= a.N {
+		return nil
+	}
+	if a.i == 0 {
+		a.start = time.Now()
+	}
+	a.due += a.Gap(a.i)
+	if d := a.due - time.Since(a.start); d > 0 {
+		time.Sleep(d)
+	}
+	w := a.New(a.i)
+	a.i++
+	return w
+}
+
+// ConstantRate 以每秒 rate 个的固定速率产生 n 个任务
+func ConstantRate(n int, rate float64, newWork func(i int) workpool.IWorkload) *Arrivals {
+	gap := time.Duration(float64(time.Second) / rate)
+	return &Arrivals{N: n, New: newWork, Gap: func(int) time.Duration { return gap }}
+}
+
+// Poisson 产生 n 个平均每秒 rate 个的泊松到达的任务，即到达间隔服从均值为 1/rate 的指数分布，
+// 是大量独立用户请求叠加的典型模型。seed 相同时间隔序列相同
+func Poisson(n int, rate float64, seed int64, newWork func(i int) workpool.IWorkload) *Arrivals {
+	rng := rand.New(rand.NewSource(seed))
+	return &Arrivals{N: n, New: newWork, Gap: func(int) time.Duration {
+		return time.Duration(rng.ExpFloat64() / rate * float64(time.Second))
+	}}
+}
+
+// Bursts 每隔 gap 一次性到达 size 个任务，共 n 个，第一批立即到达。
+// 用于观察协程从 0 扩张、空闲回收后再次扩张的过程
+func Bursts(n, size int, gap time.Duration, newWork func(i int) workpool.IWorkload) *Arrivals {
+	return &Arrivals{N: n, New: newWork, Gap: func(i int) time.Duration {
+		if i > 0 && i%size == 0 {
+			return gap
+		}
+		return 0
+	}}
+}
+
+// Ramp 产生 n 个任务，速率从每秒 from 个线性变化到每秒 to 个，
+// 用于观察负载逐渐上升（或下降）时协程数的变化
+func Ramp(n int, from, to float64, newWork func(i int) workpool.IWorkload) *Arrivals {
+	return &Arrivals{N: n, New: newWork, Gap: func(i int) time.Duration {
+		rate := from
+		if n > 1 {
+			rate += (to - from) * float64(i) / float64(n-1)
+		}
+		return time.Duration(float64(time.Second) / rate)
+	}}
+}
@@ -0,0 +1,72 @@
+package examples
+
+import (
+	"testing"
+	"time"
+
+	"github.com/RobinTsai/tearUpGo/workpool"
+)
+
+type nopWorkload int
+
+func (nopWorkload) Work() {}
+
+func newNop(i int) workpool.IWorkload { return nopWorkload(i) }
+
+func gaps(a *Arrivals) []time.Duration {
+	gs := make([]time.Duration, a.N)
+	for i := range gs {
+		gs[i] = a.Gap(i)
+	}
+	return gs
+}
+
+func TestArrivalPatterns(t *testing.T) {
+	for _, g := range gaps(ConstantRate(5, 1000, newNop)) {
+		if g != time.Millisecond {
+			t.Errorf("ConstantRate gap = %v, want 1ms", g)
+		}
+	}
+
+	bursts := gaps(Bursts(7, 3, time.Second, newNop))
+	want := []time.Duration{0, 0, 0, time.Second, 0, 0, time.Second}
+	for i := range want {
+		if bursts[i] != want[i] {
+			t.Fatalf("Bursts gaps = %v, want %v", bursts, want)
+		}
+	}
+
+	ramp := gaps(Ramp(3, 10, 1000, newNop))
+	if ramp[0] != 100*time.Millisecond || ramp[2] != time.Millisecond || ramp[1] >= ramp[0] || ramp[1] <= ramp[2] {
+		t.Errorf("Ramp gaps = %v, want decreasing from 100ms to 1ms", ramp)
+	}
+
+	const n = 10000
+	var sum time.Duration
+	p1, p2 := gaps(Poisson(n, 1000, 1, newNop)), gaps(Poisson(n, 1000, 1, newNop))
+	for i := range p1 {
+		if p1[i] != p2[i] {
+			t.Fatal("Poisson gaps differ with the same seed")
+		}
+		sum += p1[i]
+	}
+	if mean := sum / n; mean < 900*time.Microsecond || mean > 1100*time.Microsecond {
+		t.Errorf("Poisson mean gap = %v, want about 1ms", mean)
+	}
+}
+
+func TestArrivalsProduce(t *testing.T) {
+	p := ConstantRate(20, 2000, newNop) // 每 0.5ms 一个，共 10ms
+	start := time.Now()
+	for i := 0; i < 20; i++ {
+		if w := p.Produce(); w != nopWorkload(i) {
+			t.Fatalf("Produce #%d = %v, want %d", i, w, i)
+		}
+	}
+	if w := p.Produce(); w != nil {
+		t.Errorf("Produce after n tasks = %v, want nil", w)
+	}
+	if d := time.Since(start); d < 10*time.Millisecond {
+		t.Errorf("20 tasks at 2000/s took %v, want >= 10ms", d)
+	}
+}
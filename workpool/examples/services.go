@@ -0,0 +1,37 @@
+package examples
+
+import (
+	"github.com/RobinTsai/tearUpGo/workpool"
+)
+
+// Services 演示用 Manager 管理一个服务中的多个工作池：各组件在字段中保存从 Manager 按名字取得的工作池，
+// 退出时调用 Manager.Shutdown 按依赖顺序关闭
+type Services struct {
+	Manager *workpool.Manager
+	Orders  workpool.Pool // 处理订单，每个订单处理完后向 Emails 提交一个任务
+	Emails  workpool.Pool // 发送邮件，提交量大，按 P 分片
+}
+
+// NewServices 创建并启动 emails（ShardedPool）和 orders（Workerpool）两个工作池，orders 依赖 emails
+func NewServices(workers int) (*Services, error) {
+	m := workpool.NewManager()
+	if err := m.Add("emails", workpool.NewShardedPool(workers)); err != nil {
+		return nil, err
+	}
+	if err := m.Add("orders", workpool.NewWorkerpool(workers), "emails"); err != nil {
+		return nil, err
+	}
+	s := &Services{Manager: m, Orders: m.Pool("orders"), Emails: m.Pool("emails")}
+	s.Emails.Start()
+	s.Orders.Start()
+	return s, nil
+}
+
+// PlaceOrder 提交订单 id，处理完后发送邮件，邮件发出后调用 sent
+func (s *Services) PlaceOrder(id int, sent func(id int)) error {
+	return s.Orders.SubmitFunc(func() {
+		if err := s.Emails.SubmitFunc(func() { sent(id) }); err != nil {
+			panic(err) // 依赖顺序保证 orders 排空之前 emails 不会关闭
+		}
+	})
+}
@@ -0,0 +1,27 @@
+package examples
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+)
+
+// 先关闭并排空 orders，排空期间提交给 emails 的邮件都会发出
+func TestServicesShutdown(t *testing.T) {
+	s, err := NewServices(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sent int32
+	for i := 0; i < 20; i++ {
+		if err := s.PlaceOrder(i, func(int) { atomic.AddInt32(&sent, 1) }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Manager.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&sent); n != 20 {
+		t.Errorf("sent %d emails, want 20", n)
+	}
+}
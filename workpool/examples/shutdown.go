@@ -0,0 +1,81 @@
+package examples
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/RobinTsai/tearUpGo/workpool"
+)
+
+// ShutdownReport 是 ServeTasks 退出时的统计
+type ShutdownReport struct {
+	Accepted  int  // 提交给工作池的任务数
+	Completed int  // 执行完的任务数
+	Running   int  // 关闭超时被放弃时仍在执行的任务数，它们会在后台继续执行完
+	Abandoned int  // 关闭超时后被丢弃、没有开始执行的任务数
+	TimedOut  bool // 是否在 drainTimeout 内没能处理完
+}
+
+func (r ShutdownReport) String() string {
+	return fmt.Sprintf("accepted %d, completed %d, running %d, abandoned %d, timed out %v",
+		r.Accepted, r.Completed, r.Running, r.Abandoned, r.TimedOut)
+}
+
+// ServeTasksUntilSignal 与 ServeTasks 相同，收到 SIGINT 或 SIGTERM 时开始关闭
+func ServeTasksUntilSignal(tasks <-chan func(), workers int, drainTimeout time.Duration) ShutdownReport {
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(stop) // 之后再收到信号时恢复默认行为，第二次 Ctrl-C 可以直接退出
+	return ServeTasks(stop, tasks, workers, drainTimeout)
+}
+
+// ServeTasks 把从 tasks 收到的任务交给工作池执行，直到 stop 收到信号或 tasks 被关闭，
+// 然后按推荐的顺序关闭：
+//  1. 停止接收新任务：不再读取 tasks，调用 Shutdown，此后的 AddTask 返回 ErrPoolClosed
+//  2. 在 drainTimeout 内等待已接收的任务全部执行完（WaitDrained）
+//  3. 超时则调用 Down 丢弃还在排队的任务，并报告丢弃和仍在执行的任务数；
+//     正在执行的任务无法被强行终止，需要它们自行检查 context 才能尽快退出
+func ServeTasks(stop <-chan os.Signal, tasks <-chan func(), workers int, drainTimeout time.Duration) ShutdownReport {
+	pool := workpool.NewWorkerpool(workers)
+	pool.Start()
+
+	var accepted, started, completed int64
+	func() {
+		for {
+			select {
+			case <-stop:
+				return
+			case f, ok := <-tasks:
+				if !ok {
+					return
+				}
+				err := pool.SubmitFunc(func() {
+					atomic.AddInt64(&started, 1)
+					defer atomic.AddInt64(&completed, 1)
+					f()
+				})
+				if err == nil {
+					accepted++
+				}
+			}
+		}
+	}()
+
+	pool.Shutdown()
+	ctx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+	r := ShutdownReport{Accepted: int(accepted)}
+	if pool.WaitDrained(ctx) != nil {
+		r.TimedOut = true
+		pool.Down()
+	}
+	// Down 之后排队的任务不会再开始，已开始的任务数不再增加
+	s, c := atomic.LoadInt64(&started), atomic.LoadInt64(&completed)
+	r.Completed, r.Running, r.Abandoned = int(c), int(s-c), int(accepted-s)
+	return r
+}
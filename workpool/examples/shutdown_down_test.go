@@ -0,0 +1,136 @@
+package examples
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/RobinTsai/tearUpGo/workpool"
+)
+
+// runLog 记录哪些任务开始、哪些执行完
+type runLog struct {
+	mu                 sync.Mutex
+	started, completed []int
+}
+
+func (l *runLog) add(list *[]int, i int) {
+	l.mu.Lock()
+	*list = append(*list, i)
+	l.mu.Unlock()
+}
+
+func (l *runLog) sorted(list []int) []int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	s := append([]int(nil), list...)
+	sort.Ints(s)
+	return s
+}
+
+// ctxTask 是带 context 的任务：context 结束后还未开始的会被工作池跳过
+type ctxTask struct {
+	ctx context.Context
+	f   func()
+}
+
+func (t ctxTask) Work()                    { t.f() }
+func (t ctxTask) Context() context.Context { return t.ctx }
+
+// closer 是 Shutdown 和 Down 所在的工作池
+type closer interface {
+	Shutdown()
+	Down()
+}
+
+// runClose 用 2 个协程的工作池提交 6 个任务，前两个阻塞到 release 关闭，
+// 在它们执行期间调用 closeFn，之后放行并等待工作池退出。withCtx 时任务共享一个 context，closeFn 之前取消
+func runClose(closeFn func(p closer), withCtx bool) (*runLog, error) {
+	pool := workpool.NewWorkerpool(2)
+	pool.Start()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	log := &runLog{}
+	release := make(chan struct{})
+	var running sync.WaitGroup
+	running.Add(2)
+	for i := 0; i < 6; i++ {
+		i := i
+		f := func() {
+			log.add(&log.started, i)
+			if i < 2 {
+				running.Done()
+				<-release
+			}
+			log.add(&log.completed, i)
+		}
+		if withCtx {
+			pool.AddTask(ctxTask{ctx: ctx, f: f})
+		} else {
+			pool.SubmitFunc(f)
+		}
+	}
+	running.Wait() // 前两个任务占住了两个协程，其余四个在排队
+	if withCtx {
+		cancel()
+	}
+	closeFn(pool)
+	err := pool.SubmitFunc(func() {})
+	close(release)
+	pool.Wait()
+	return log, err
+}
+
+func shutdown(p closer) {
+	p.Shutdown()
+}
+
+func down(p closer) {
+	p.Down()
+}
+
+// Shutdown 和 Down 都立即拒绝新任务（AddTask 返回 ErrPoolClosed），区别在于已接受的任务：
+//   - Shutdown：排队的任务照常执行，Wait 返回时全部完成
+//   - Down：正在执行的任务无法被打断，会执行完；排队的任务被放弃，
+//     但协程执行完手头的任务时，若队列中恰好有已分发的任务，可能还会再取走一些，所以具体放弃哪些不确定
+//   - 任务实现 IContextWorkload 并在 Down 之前取消其 context 时，被取走的排队任务也会被跳过，
+//     结果是确定的：只有正在执行的任务完成
+func TestShutdownVsDown(t *testing.T) {
+	log, err := runClose(shutdown, false)
+	if err != workpool.ErrPoolClosed {
+		t.Errorf("Shutdown: AddTask after close = %v, want ErrPoolClosed", err)
+	}
+	if got := log.sorted(log.completed); fmt.Sprint(got) != "[0 1 2 3 4 5]" {
+		t.Errorf("Shutdown: completed %v, want all 6", got)
+	}
+
+	log, err = runClose(down, false)
+	if err != workpool.ErrPoolClosed {
+		t.Errorf("Down: AddTask after close = %v, want ErrPoolClosed", err)
+	}
+	completed := log.sorted(log.completed)
+	if len(completed) < 2 || completed[0] != 0 || completed[1] != 1 {
+		t.Errorf("Down: completed %v, want at least the 2 running tasks", completed)
+	}
+	if started := log.sorted(log.started); len(started) != len(completed) {
+		t.Errorf("Down: started %v but completed %v", started, completed)
+	}
+	t.Logf("Down: completed %v, abandoned %d", completed, 6-len(completed))
+
+	log, _ = runClose(down, true)
+	if got := log.sorted(log.completed); fmt.Sprint(got) != "[0 1]" {
+		t.Errorf("Down with cancelled contexts: completed %v, want [0 1]", got)
+	}
+}
+
+func Example_shutdownVsDown() {
+	log, _ := runClose(shutdown, false)
+	fmt.Println("Shutdown: completed", log.sorted(log.completed))
+	log, _ = runClose(down, true)
+	fmt.Println("Down with cancelled contexts: completed", log.sorted(log.completed))
+	// Output:
+	// Shutdown: completed [0 1 2 3 4 5]
+	// Down with cancelled contexts: completed [0 1]
+}
@@ -0,0 +1,61 @@
+package examples
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestServeTasksDrain(t *testing.T) {
+	stop := make(chan os.Signal, 1)
+	tasks := make(chan func())
+	done := make(chan ShutdownReport)
+	go func() { done <- ServeTasks(stop, tasks, 2, time.Second) }()
+	for i := 0; i < 10; i++ {
+		tasks <- func() { time.Sleep(5 * time.Millisecond) }
+	}
+	stop <- os.Interrupt
+	r := <-done
+	if r.TimedOut || r.Accepted != 10 || r.Completed != 10 || r.Abandoned != 0 || r.Running != 0 {
+		t.Errorf("report = %v, want all 10 tasks completed", r)
+	}
+}
+
+func TestServeTasksTimeout(t *testing.T) {
+	stop := make(chan os.Signal, 1)
+	tasks := make(chan func(), 10)
+	release := make(chan struct{})
+	defer close(release)
+	for i := 0; i < 10; i++ {
+		tasks <- func() { <-release }
+	}
+	close(tasks)
+	r := ServeTasks(stop, tasks, 2, 20*time.Millisecond)
+	if !r.TimedOut || r.Accepted != 10 || r.Completed != 0 || r.Running != 2 || r.Abandoned != 8 {
+		t.Errorf("report = %v, want 2 running and 8 abandoned", r)
+	}
+}
+
+func TestServeTasksUntilSignal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("cannot send SIGTERM to self on windows")
+	}
+	tasks := make(chan func())
+	done := make(chan ShutdownReport)
+	go func() { done <- ServeTasksUntilSignal(tasks, 2, time.Second) }()
+	tasks <- func() {} // 收到第一个任务说明信号处理已经安装
+	p, _ := os.FindProcess(os.Getpid())
+	if err := p.Signal(syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case r := <-done:
+		if r.Accepted != 1 || r.Completed != 1 {
+			t.Errorf("report = %v, want 1 completed", r)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ServeTasksUntilSignal did not stop on SIGTERM")
+	}
+}
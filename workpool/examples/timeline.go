@@ -0,0 +1,83 @@
+package examples
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// assignLanes 把任务的执行区间分配到泳道上：按开始时间依次放入第一条已空闲的泳道，
+// 同一泳道上的区间互不重叠，因此泳道数就是同时执行的任务数的上限。
+// 区间以毫秒记录，一个任务的结束与另一个的开始落在同一毫秒时视为不重叠。
+// 返回每个区间（按 intervals 的下标）所在的泳道和泳道总数
+func assignLanes(intervals [][2]int64) (lanes []int, n int) {
+	idx := make([]int, len(intervals))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return intervals[idx[a]][0] < intervals[idx[b]][0] })
+
+	lanes = make([]int, len(intervals))
+	var laneEnd []int64 // 每条泳道上最后一个区间的结束时间
+	for _, i := range idx {
+		lane := -1
+		for l, end := range laneEnd {
+			if end <= intervals[i][0] {
+				lane = l
+				break
+			}
+		}
+		if lane < 0 {
+			lane = len(laneEnd)
+			laneEnd = append(laneEnd, 0)
+		}
+		laneEnd[lane] = intervals[i][1]
+		lanes[i] = lane
+	}
+	return lanes, len(laneEnd)
+}
+
+// WriteTimeline 把 collector 收集的任务起止时间画成 HTML 中的甘特图：
+// 每条泳道是一行，每个任务是一个横条，泳道数即为观察到的最大并发数
+func WriteTimeline(w io.Writer, intervals [][2]int64) error {
+	const (
+		width  = 1000
+		left   = 50
+		laneH  = 20
+		axisH  = 20
+		bottom = 10
+	)
+	lanes, n := assignLanes(intervals)
+	var end int64 = 1
+	for _, iv := range intervals {
+		if iv[1] > end {
+			end = iv[1]
+		}
+	}
+	x := func(ms int64) float64 { return left + float64(ms)*float64(width-left)/float64(end) }
+	height := axisH + n*laneH + bottom
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "<!DOCTYPE html>\n<html><head><meta charset=\"utf-8\"><title>workpool timeline</title></head><body>\n")
+	fmt.Fprintf(bw, "<p>%d tasks, %d lanes (peak concurrency), %dms</p>\n", len(intervals), n, end)
+	fmt.Fprintf(bw, "<svg width=\"%d\" height=\"%d\" xmlns=\"http://www.w3.org/2000/svg\" font-family=\"sans-serif\" font-size=\"11\">\n", width, height)
+	for i := int64(0); i <= 10; i++ { // 时间轴
+		t := end * i / 10
+		fmt.Fprintf(bw, "<line x1=\"%.1f\" y1=\"%d\" x2=\"%.1f\" y2=\"%d\" stroke=\"#eee\"/><text x=\"%.1f\" y=\"12\" text-anchor=\"middle\">%dms</text>\n",
+			x(t), axisH, x(t), height-bottom, x(t), t)
+	}
+	for l := 0; l < n; l++ {
+		fmt.Fprintf(bw, "<text x=\"4\" y=\"%d\">lane %d</text>\n", axisH+l*laneH+14, l)
+	}
+	for i, iv := range intervals {
+		wd := x(iv[1]) - x(iv[0])
+		if wd < 1 {
+			wd = 1
+		}
+		fmt.Fprintf(bw, "<rect x=\"%.1f\" y=\"%d\" width=\"%.1f\" height=\"%d\" fill=\"hsl(%d,60%%,55%%)\" stroke=\"#fff\"><title>%dms to %dms (%dms)</title></rect>\n",
+			x(iv[0]), axisH+lanes[i]*laneH+2, wd, laneH-4, (i*47)%360, iv[0], iv[1], iv[1]-iv[0])
+	}
+	fmt.Fprint(bw, "</svg>\n</body></html>\n")
+	return bw.Flush()
+}
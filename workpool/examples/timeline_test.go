@@ -0,0 +1,34 @@
+package examples
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestAssignLanes(t *testing.T) {
+	intervals := [][2]int64{
+		{0, 10},
+		{5, 15},
+		{10, 20}, // 与第一个在 10ms 处相接，复用其泳道
+		{12, 14},
+	}
+	lanes, n := assignLanes(intervals)
+	if n != 3 {
+		t.Errorf("lanes = %d, want 3", n)
+	}
+	want := []int{0, 1, 0, 2}
+	for i := range want {
+		if lanes[i] != want[i] {
+			t.Fatalf("lanes = %v, want %v", lanes, want)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTimeline(&buf, intervals); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(buf.String(), "<rect"); got != len(intervals) {
+		t.Errorf("timeline has %d bars, want %d", got, len(intervals))
+	}
+}
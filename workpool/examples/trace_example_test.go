@@ -0,0 +1,47 @@
+package examples
+
+import (
+	"fmt"
+	"os"
+	"runtime/trace"
+	"time"
+
+	"github.com/RobinTsai/tearUpGo/workpool"
+)
+
+// 用 runtime/trace 观察工作池：开启 trace 后每个任务都是一个 "workpool.task" trace 任务，
+// 执行部分是其中的 "workpool.execute" region。生成 trace 后运行
+//
+//	go tool trace trace.out
+//
+// 在 User-defined tasks 页面中按 workpool.task 查看每个任务的排队等待（创建到 execute 开始）
+// 和执行时间的分布，在 Goroutine analysis 中查看协程的启动和空闲回收。
+// 测试中也可以直接用 go test -run TestQuestion2 -trace trace.out 得到同样的信息
+func Example_trace() {
+	f, err := os.CreateTemp("", "workpool-trace-*.out")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	if err := trace.Start(f); err != nil { // 已经在 go test -trace 下运行时会失败
+		fmt.Println("traced 20 tasks")
+		return
+	}
+
+	pool := workpool.NewWorkerpool(5)
+	pool.Start()
+	for i := 0; i < 20; i++ { // 两批各 10 个任务，每批中有一半需要排队
+		d := time.Duration(i%5) * time.Millisecond
+		pool.SubmitFunc(func() { time.Sleep(d) })
+		if i == 9 {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	pool.Shutdown()
+	pool.Wait()
+	trace.Stop()
+	fmt.Println("traced 20 tasks")
+	// Output: traced 20 tasks
+}
@@ -1,3 +1,3 @@
-module workpool
+module github.com/RobinTsai/tearUpGo/workpool
 
-go 1.16
+go 1.18
@@ -0,0 +1,88 @@
+// grpcpool 提供 gRPC 服务端拦截器，通过工作池限制 handler 的并发数
+// 单独作为一个 module，避免 workpool 本身依赖 gRPC
+package grpcpool
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/RobinTsai/tearUpGo/workpool"
+)
+
+// ErrExhausted 工作池和等待队列都已满时返回给客户端，状态码为 RESOURCE_EXHAUSTED
+var ErrExhausted = status.Error(codes.ResourceExhausted, "grpcpool: worker pool exhausted")
+
+// Pool 是拦截器需要的工作池方法，workpool.Workerpool 和 workpool.ShardedPool 都满足该接口，见 workpool.Workerpool.Call
+type Pool interface {
+	Call(ctx context.Context, maxQueue int, f func()) error
+}
+
+// Option 配置拦截器
+type Option func(l *limiter)
+
+// WithMaxQueue 设置没有空闲协程时最多允许排队的请求数，默认为 0，即没有空闲协程时立即拒绝
+func WithMaxQueue(n int) Option {
+	return func(l *limiter) {
+		if n >= 0 {
+			l.maxQueue = n
+		}
+	}
+}
+
+type limiter struct {
+	pool     Pool
+	maxQueue int
+}
+
+func newLimiter(p Pool, opts []Option) *limiter {
+	l := &limiter{pool: p}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// run 在工作池中执行 f 并等待其结束，f 中的 panic 会在调用方协程中重新抛出。
+// 没有空闲协程且排队已满时返回 ErrExhausted；f 开始执行之前 ctx 结束时返回 ctx 对应的状态
+// （Canceled 或 DeadlineExceeded），工作池已关闭或被 Down 而丢弃了请求时返回 Unavailable，
+// 这几种情况下 f 都不会执行；f 开始执行之后总是等它结束
+func (l *limiter) run(ctx context.Context, f func()) error {
+	err := l.pool.Call(ctx, l.maxQueue, f)
+	switch {
+	case err == nil:
+		return nil
+	case err == workpool.ErrPoolFull:
+		return ErrExhausted
+	case err == ctx.Err():
+		return status.FromContextError(err).Err()
+	}
+	return status.Error(codes.Unavailable, "grpcpool: "+err.Error())
+}
+
+// UnaryServerInterceptor 返回限制一元调用并发数的拦截器
+func UnaryServerInterceptor(p Pool, opts ...Option) grpc.UnaryServerInterceptor {
+	l := newLimiter(p, opts)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		var resp interface{}
+		var err error
+		if e := l.run(ctx, func() { resp, err = handler(ctx, req) }); e != nil {
+			return nil, e
+		}
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor 返回限制流式调用并发数的拦截器，整个流的处理占用一个协程
+func StreamServerInterceptor(p Pool, opts ...Option) grpc.StreamServerInterceptor {
+	l := newLimiter(p, opts)
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		var err error
+		if e := l.run(ss.Context(), func() { err = handler(srv, ss) }); e != nil {
+			return e
+		}
+		return err
+	}
+}
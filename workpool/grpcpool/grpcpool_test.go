@@ -0,0 +1,187 @@
+package grpcpool
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/RobinTsai/tearUpGo/workpool"
+)
+
+var unaryInfo = &grpc.UnaryServerInfo{FullMethod: "/test.Service/Method"}
+
+// waitFor 轮询直到 cond 成立
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); !cond(); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within 5s")
+		}
+	}
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	pool := workpool.NewWorkerpool(1)
+	pool.Start()
+	defer func() { pool.Shutdown(); pool.Wait() }()
+	intercept := UnaryServerInterceptor(pool)
+
+	resp, err := intercept(context.Background(), "ping", unaryInfo, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return req.(string) + "-pong", nil
+	})
+	if resp != "ping-pong" || err != nil {
+		t.Fatalf("resp, err = %v, %v, want ping-pong, nil", resp, err)
+	}
+
+	entered, release := make(chan struct{}), make(chan struct{})
+	first := make(chan error)
+	go func() {
+		_, err := intercept(context.Background(), nil, unaryInfo, func(context.Context, interface{}) (interface{}, error) {
+			close(entered)
+			<-release
+			return nil, nil
+		})
+		first <- err
+	}()
+	<-entered
+	_, err = intercept(context.Background(), nil, unaryInfo, func(context.Context, interface{}) (interface{}, error) {
+		t.Error("handler ran while the pool was exhausted")
+		return nil, nil
+	})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("exhausted: err = %v, want ResourceExhausted", err)
+	}
+	close(release)
+	if err := <-first; err != nil {
+		t.Errorf("first call: err = %v", err)
+	}
+}
+
+// 同时到达的请求一起抢占时，接受的恰好是能立即执行的加上 WithMaxQueue 个，其余都是 RESOURCE_EXHAUSTED
+func TestUnaryInterceptorConcurrentAdmission(t *testing.T) {
+	for _, maxQueue := range []int{0, 2} {
+		pool := workpool.NewWorkerpool(1)
+		pool.Start()
+		intercept := UnaryServerInterceptor(pool, WithMaxQueue(maxQueue))
+		release := make(chan struct{})
+		var wg sync.WaitGroup
+		var ok, exhausted int32
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := intercept(context.Background(), nil, unaryInfo, func(context.Context, interface{}) (interface{}, error) {
+					<-release
+					return nil, nil
+				})
+				switch status.Code(err) {
+				case codes.OK:
+					atomic.AddInt32(&ok, 1)
+				case codes.ResourceExhausted:
+					atomic.AddInt32(&exhausted, 1)
+				default:
+					t.Errorf("err = %v", err)
+				}
+			}()
+		}
+		waitFor(t, func() bool { return atomic.LoadInt32(&exhausted) == int32(19-maxQueue) })
+		close(release)
+		wg.Wait()
+		if n := atomic.LoadInt32(&ok); n != int32(1+maxQueue) {
+			t.Errorf("maxQueue %d: %d calls ran, want %d", maxQueue, n, 1+maxQueue)
+		}
+		pool.Shutdown()
+		pool.Wait()
+	}
+}
+
+func TestUnaryInterceptorPanic(t *testing.T) {
+	pool := workpool.NewWorkerpool(1)
+	pool.Start()
+	defer func() { pool.Shutdown(); pool.Wait() }()
+	intercept := UnaryServerInterceptor(pool)
+	defer func() {
+		if v := recover(); v != "boom" {
+			t.Errorf("recovered %v, want boom", v)
+		}
+	}()
+	intercept(context.Background(), nil, unaryInfo, func(context.Context, interface{}) (interface{}, error) {
+		panic("boom")
+	})
+	t.Error("interceptor did not panic")
+}
+
+// 请求因限速还没有开始执行时，RPC 被取消或工作池被 Down 都使拦截器返回，handler 不再执行
+func TestUnaryInterceptorAbandoned(t *testing.T) {
+	for _, c := range []struct {
+		name  string
+		abort func(pool interface{ Down() }, cancel context.CancelFunc)
+		want  codes.Code
+	}{
+		{"Cancelled", func(_ interface{ Down() }, cancel context.CancelFunc) { cancel() }, codes.Canceled},
+		{"Down", func(pool interface{ Down() }, _ context.CancelFunc) { pool.Down() }, codes.Unavailable},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			pool := workpool.NewWorkerpool(1, workpool.WithRateLimit(0.001, 1)) // 只有第一个请求能拿到令牌
+			pool.Start()
+			intercept := UnaryServerInterceptor(pool)
+			ran := 0
+			handler := func(context.Context, interface{}) (interface{}, error) { ran++; return nil, nil }
+			if _, err := intercept(context.Background(), nil, unaryInfo, handler); err != nil {
+				t.Fatal(err)
+			}
+			waitFor(t, func() bool { return pool.IdleWorkers() == 1 })
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			second := make(chan error)
+			go func() {
+				_, err := intercept(ctx, nil, unaryInfo, handler)
+				second <- err
+			}()
+			time.Sleep(10 * time.Millisecond) // 无论请求还在排队还是已被协程取走，都不应再执行
+			c.abort(pool, cancel)
+			select {
+			case err := <-second:
+				if status.Code(err) != c.want {
+					t.Errorf("err = %v, want %v", err, c.want)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("interceptor did not return")
+			}
+			pool.Down()
+			pool.Wait()
+			if ran != 1 {
+				t.Errorf("handler ran %d times, want 1", ran)
+			}
+		})
+	}
+}
+
+type testStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s testStream) Context() context.Context { return s.ctx }
+
+func TestStreamInterceptor(t *testing.T) {
+	pool := workpool.NewWorkerpool(1)
+	pool.Start()
+	defer func() { pool.Shutdown(); pool.Wait() }()
+	intercept := StreamServerInterceptor(pool)
+	ran := false
+	err := intercept(nil, testStream{ctx: context.Background()}, &grpc.StreamServerInfo{}, func(interface{}, grpc.ServerStream) error {
+		ran = true
+		return status.Error(codes.NotFound, "missing")
+	})
+	if !ran || status.Code(err) != codes.NotFound {
+		t.Errorf("ran, err = %v, %v, want true, NotFound", ran, err)
+	}
+}
@@ -0,0 +1,68 @@
+package workpool
+
+// 下面是只供测试使用的调度决策钩子。Events 是给外部监控用的，通道满时会丢弃事件，
+// 也看不到“为什么”没有启动协程；钩子在做出决策的协程中同步调用，测试可以据此确定地断言行为
+// （例如“抢占 Out 失败时启动了新协程”），而不必 sleep 之后再观察。
+// 钩子不导出，只能通过测试中的 withHooks 设置，未设置时只多一次 nil 判断
+
+// spawnDecision 是提交任务时关于是否启动新协程的决策
+type spawnDecision int
+
+const (
+	spawnFirst     spawnDecision = iota // 没有存活的协程，直接启动一个
+	spawnScheduled                      // scheduler 同意，启动了新协程
+	spawnDeclined                       // scheduler 拒绝，任务留在队列中
+	spawnAtCap                          // 协程数已达上限，任务留在队列中
+	spawnMatched                        // 有等待任务的协程，不需要启动新协程
+)
+
+// retireReason 是协程退出的原因
+type retireReason int
+
+const (
+	retireIdle       retireReason = iota // 空闲超过 idleTimeout
+	retireClosed                         // 工作池关闭且任务已取完
+	retireCancelled                      // Down
+	retireInitFailed                     // WithWorkerInit 的初始化失败
+	retireExcess                         // ApplyConfig 降低了协程数上限
+)
+
+// dispatchPath 是任务进入工作池的路径
+type dispatchPath int
+
+const (
+	dispatchDirect dispatchPath = iota // 直接写入 Out，被空闲的协程取走
+	dispatchQueued                     // 放入弹性队列
+)
+
+// poolHooks 是调度决策的钩子，任一字段可为 nil
+type poolHooks struct {
+	spawn    func(d spawnDecision, st SchedState)
+	retire   func(r retireReason)
+	dispatch func(d dispatchPath)
+}
+
+// withHooks 设置调度决策钩子，只供测试使用
+func withHooks(h *poolHooks) Option {
+	return func(p *Workerpool) {
+		p.hooks = h
+	}
+}
+
+func (p *Workerpool) hookSpawn(d spawnDecision, st SchedState) {
+	if p.hooks != nil && p.hooks.spawn != nil {
+		p.hooks.spawn(d, st)
+	}
+}
+
+func (p *Workerpool) hookRetire(r retireReason) {
+	if p.hooks != nil && p.hooks.retire != nil {
+		p.hooks.retire(r)
+	}
+}
+
+func (p *Workerpool) hookDispatch(d dispatchPath) {
+	if p.hooks != nil && p.hooks.dispatch != nil {
+		p.hooks.dispatch(d)
+	}
+}
@@ -0,0 +1,120 @@
+package workpool
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+// recorder 把钩子的调用记录到通道中，测试按顺序读取
+type recorder struct {
+	spawns   chan spawnDecision
+	retires  chan retireReason
+	dispatch chan dispatchPath
+}
+
+func newRecorder() (*recorder, Option) {
+	r := &recorder{
+		spawns:   make(chan spawnDecision, 100),
+		retires:  make(chan retireReason, 100),
+		dispatch: make(chan dispatchPath, 100),
+	}
+	return r, withHooks(&poolHooks{
+		spawn:    func(d spawnDecision, st SchedState) { r.spawns <- d },
+		retire:   func(rr retireReason) { r.retires <- rr },
+		dispatch: func(d dispatchPath) { r.dispatch <- d },
+	})
+}
+
+// blockingTask 返回一个任务：开始执行时关闭 started，直到 release 关闭才结束
+func blockingTask(release <-chan struct{}) (func(), <-chan struct{}) {
+	started := make(chan struct{})
+	return func() {
+		close(started)
+		<-release
+	}, started
+}
+
+func expect[T comparable](t *testing.T, ch <-chan T, want T) {
+	t.Helper()
+	if got := <-ch; got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+// occupyAll 不断提交阻塞的任务，直到所有已启动的协程都在执行任务，并丢弃这期间的钩子记录
+func occupyAll(pool *Workerpool, rec *recorder, release <-chan struct{}) {
+	for {
+		f, started := blockingTask(release)
+		pool.SubmitFunc(f)
+		<-started
+		for len(rec.spawns) > 0 || len(rec.dispatch) > 0 { // AddTask 返回前钩子已调用完
+			select {
+			case <-rec.spawns:
+			case <-rec.dispatch:
+			}
+		}
+		if pool.RunningWorkers() == int(pool.GetWaitCount()) {
+			return
+		}
+	}
+}
+
+// Out 没有缓冲，所有协程都忙时抢占 Out 必然失败，任务进入队列，再由 scheduler 和上限决定是否启动新协程
+func TestHookSpawnWhenOutFull(t *testing.T) {
+	for _, c := range []struct {
+		name      string
+		workers   int
+		scheduler Scheduler
+		want      spawnDecision
+	}{
+		{"default", 4, DefaultScheduler, spawnScheduled},
+		{"lazy", 4, LazyScheduler(5), spawnDeclined},
+		{"at cap", 1, DefaultScheduler, spawnAtCap},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			rec, hook := newRecorder()
+			var setup int32 = 1 // 准备阶段不启动新协程，只占满 Start 启动的那一个
+			gate := SchedulerFunc(func(st SchedState) bool {
+				return atomic.LoadInt32(&setup) == 0 && c.scheduler.ShouldSpawn(st)
+			})
+			pool := NewWorkerpool(c.workers, WithScheduler(gate), WithChanSize(0, 0), hook)
+			pool.Start()
+			release := make(chan struct{})
+			occupyAll(pool, rec, release)
+			atomic.StoreInt32(&setup, 0)
+
+			pool.SubmitFunc(func() {})
+			expect(t, rec.dispatch, dispatchQueued)
+			expect(t, rec.spawns, c.want)
+
+			close(release)
+			pool.Shutdown()
+			pool.Wait()
+		})
+	}
+}
+
+func TestHookRetire(t *testing.T) {
+	for _, c := range []struct {
+		name    string
+		closeFn func(p *Workerpool)
+		want    retireReason
+	}{
+		{"Shutdown", (*Workerpool).Shutdown, retireClosed},
+		// Down 同时取消 ctx 并关闭 In，弹性队列可能先看到 In 关闭而关闭 Out，所以两种原因都可能
+		{"Down", (*Workerpool).Down, retireCancelled},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			rec, hook := newRecorder()
+			pool := NewWorkerpool(2, WithPrefork(), hook)
+			pool.Start()
+			c.closeFn(pool)
+			pool.Wait()
+			for i := 0; i < 2; i++ {
+				if r := <-rec.retires; r != c.want && !(c.name == "Down" && r == retireClosed) {
+					t.Errorf("worker %d retired with %v, want %v", i, r, c.want)
+				}
+			}
+		})
+	}
+}
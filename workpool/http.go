@@ -0,0 +1,18 @@
+package workpool
+
+import (
+	"net/http"
+)
+
+// HTTPLimiter 返回一个限制并发的 http.Handler：每个请求的 next.ServeHTTP 都经 Call 在工作池中执行，
+// 没有能立即执行它的协程时（或工作池已关闭）直接返回 503，请求不会在队列中等待，使工作池可以直接作为服务的并发限制器。
+// next 开始执行之前请求被取消或工作池被 Down 时同样返回 503，next 不会再执行；
+// next 开始执行之后会一直等到它返回，因为 ResponseWriter 只在 ServeHTTP 返回前有效。
+// next 中的 panic 在请求所在的协程中重新抛出，由 net/http 按原有方式处理
+func HTTPLimiter(p Pool, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if p.Call(r.Context(), 0, func() { next.ServeHTTP(w, r) }) != nil {
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		}
+	})
+}
@@ -0,0 +1,118 @@
+package workpool
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/RobinTsai/tearUpGo/workpool/sync"
+)
+
+// serve 调用 h 处理一个请求，返回响应码
+func serve(h http.Handler, ctx context.Context) int {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil).WithContext(ctx))
+	return rec.Code
+}
+
+// 没有能立即执行请求的协程时返回 503，请求不排队
+func TestHTTPLimiterSaturated(t *testing.T) {
+	defer sync.VerifyNone(t)
+	pool := NewWorkerpool(1)
+	pool.Start()
+	entered, release := make(chan struct{}), make(chan struct{})
+	h := HTTPLimiter(pool, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("block") != "" {
+			close(entered)
+			<-release
+		}
+	}))
+
+	first := make(chan int)
+	go func() {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", "/?block=1", nil))
+		first <- rec.Code
+	}()
+	<-entered
+	if code := serve(h, context.Background()); code != http.StatusServiceUnavailable {
+		t.Errorf("saturated: code = %d, want 503", code)
+	}
+	if n := pool.PendingCount(); n != 0 {
+		t.Errorf("PendingCount = %d, want 0", n)
+	}
+	close(release)
+	if code := <-first; code != http.StatusOK {
+		t.Errorf("first request: code = %d, want 200", code)
+	}
+	waitFor(t, func() bool { return pool.loadAdm().credits() == 1 })
+	if code := serve(h, context.Background()); code != http.StatusOK {
+		t.Errorf("idle: code = %d, want 200", code)
+	}
+
+	pool.Shutdown()
+	pool.Wait()
+	if code := serve(h, context.Background()); code != http.StatusServiceUnavailable {
+		t.Errorf("closed: code = %d, want 503", code)
+	}
+}
+
+// handler 中的 panic 在请求所在协程中重新抛出
+func TestHTTPLimiterPanic(t *testing.T) {
+	defer sync.VerifyNone(t)
+	pool := NewWorkerpool(1)
+	pool.Start()
+	h := HTTPLimiter(pool, http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	}))
+	func() {
+		defer func() {
+			if v := recover(); v != "boom" {
+				t.Errorf("recovered %v, want boom", v)
+			}
+		}()
+		serve(h, context.Background())
+		t.Error("ServeHTTP did not panic")
+	}()
+	pool.Shutdown()
+	pool.Wait()
+}
+
+// 请求已被协程取走、但因限速还没有开始执行时，Down 或请求被取消都使其返回 503，handler 不再执行
+func TestHTTPLimiterAbandoned(t *testing.T) {
+	for _, c := range []struct {
+		name   string
+		cancel func(pool *Workerpool, cancelReq context.CancelFunc)
+	}{
+		{"Down", func(pool *Workerpool, _ context.CancelFunc) { pool.Down() }},
+		{"RequestCancelled", func(_ *Workerpool, cancelReq context.CancelFunc) { cancelReq() }},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			defer sync.VerifyNone(t)
+			pool := NewWorkerpool(1, WithRateLimit(0.001, 1)) // 只有第一个请求能拿到令牌
+			pool.Start()
+			var ran int
+			h := HTTPLimiter(pool, http.HandlerFunc(func(http.ResponseWriter, *http.Request) { ran++ }))
+			if code := serve(h, context.Background()); code != http.StatusOK {
+				t.Fatalf("first request: code = %d, want 200", code)
+			}
+			waitFor(t, func() bool { return pool.loadAdm().credits() == 1 })
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			second := make(chan int)
+			go func() { second <- serve(h, ctx) }()
+			waitFor(t, func() bool { return pool.loadAdm().credits() == 0 }) // 已被接受
+			c.cancel(pool, cancel)
+			if code := <-second; code != http.StatusServiceUnavailable {
+				t.Errorf("code = %d, want 503", code)
+			}
+			pool.Down()
+			pool.Wait()
+			if ran != 1 {
+				t.Errorf("handler ran %d times, want 1", ran)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package workpool
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"runtime/pprof"
+	"sort"
+	"strconv"
+	stdsync "sync"
+	"time"
+
+	"github.com/RobinTsai/tearUpGo/workpool/xsync"
+)
+
+// 协程标识：用 WithName 命名的工作池，其协程带有 pprof 标签 workpool=<名称>、worker=<编号>，
+// 执行任务期间再加上 task=<任务名>，在 goroutine profile（/debug/pprof/goroutine?debug=1）和
+// CPU profile 中可以按标签区分各个工作池和任务。
+//
+// panic 和 SIGQUIT 打印的调用栈中不包含 pprof 标签，所以协程同时登记在全局的注册表中：
+// Workers/DumpWorkers 列出每个协程的协程 id，与转储中的 "goroutine N" 对照，
+// 就能知道一段调用栈属于哪个工作池的第几个协程、正在执行什么任务、执行了多久。
+// 未命名的工作池不设置标签也不登记，只多一次 nil 判断
+
+// WithName 为工作池命名并开启协程标识，见 Workers
+func WithName(name string) Option {
+	return func(p *Workerpool) {
+		p.name = name
+	}
+}
+
+// INamedWorkload 是有名字的任务，名字用作 pprof 标签 task 和 WorkerInfo.Task，
+// 未实现时使用任务的类型名
+type INamedWorkload interface {
+	Name() string
+}
+
+// WorkerInfo 是一个协程的标识和当前状态
+type WorkerInfo struct {
+	Pool      string    // 工作池名称
+	Worker    uint64    // 协程编号，按启动顺序从 1 开始，与事件日志中的一致
+	Goroutine uint64    // 协程 id，即调用栈转储中 "goroutine N" 的 N
+	Task      string    // 正在执行的任务名，空闲时为空
+	Since     time.Time // 开始执行当前任务的时间，空闲时为零值
+}
+
+// workerSlot 是一个协程在注册表中的登记项
+type workerSlot struct {
+	ctx  context.Context // 带有 workpool 和 worker 标签，任务结束后恢复为它
+	mu   stdsync.Mutex
+	info WorkerInfo
+}
+
+var workerRegistry struct {
+	mu    stdsync.Mutex
+	slots map[*workerSlot]struct{}
+}
+
+// registerWorker 为当前协程设置 pprof 标签并登记，工作池未命名时返回 nil
+func (p *Workerpool) registerWorker(worker uint64) *workerSlot {
+	if p.name == "" {
+		return nil
+	}
+	s := &workerSlot{
+		ctx:  pprof.WithLabels(context.Background(), pprof.Labels("workpool", p.name, "worker", strconv.FormatUint(worker, 10))),
+		info: WorkerInfo{Pool: p.name, Worker: worker, Goroutine: xsync.GoroutineID()},
+	}
+	pprof.SetGoroutineLabels(s.ctx)
+	workerRegistry.mu.Lock()
+	if workerRegistry.slots == nil {
+		workerRegistry.slots = make(map[*workerSlot]struct{})
+	}
+	workerRegistry.slots[s] = struct{}{}
+	workerRegistry.mu.Unlock()
+	return s
+}
+
+// unregister 在协程退出时注销并清除标签
+func (s *workerSlot) unregister() {
+	if s == nil {
+		return
+	}
+	workerRegistry.mu.Lock()
+	delete(workerRegistry.slots, s)
+	workerRegistry.mu.Unlock()
+	pprof.SetGoroutineLabels(context.Background())
+}
+
+// begin 和 end 标记任务的开始和结束，执行期间协程带有 task 标签
+func (s *workerSlot) begin(job interface{}) {
+	if s == nil {
+		return
+	}
+	name := taskName(job)
+	pprof.SetGoroutineLabels(pprof.WithLabels(s.ctx, pprof.Labels("task", name)))
+	s.mu.Lock()
+	s.info.Task, s.info.Since = name, time.Now()
+	s.mu.Unlock()
+}
+
+func (s *workerSlot) end() {
+	if s == nil {
+		return
+	}
+	pprof.SetGoroutineLabels(s.ctx)
+	s.mu.Lock()
+	s.info.Task, s.info.Since = "", time.Time{}
+	s.mu.Unlock()
+}
+
+// taskName 返回队列元素中任务的名字
+func taskName(job interface{}) string {
+	t, ok := job.(*task)
+	if !ok {
+		return reflect.TypeOf(job).String()
+	}
+	if nw, ok := t.work.(INamedWorkload); ok {
+		return nw.Name()
+	}
+	return reflect.TypeOf(t.work).String()
+}
+
+// Workers 返回所有命名工作池中存活协程的快照，按工作池名称和协程编号排序
+func Workers() []WorkerInfo {
+	workerRegistry.mu.Lock()
+	infos := make([]WorkerInfo, 0, len(workerRegistry.slots))
+	for s := range workerRegistry.slots {
+		s.mu.Lock()
+		infos = append(infos, s.info)
+		s.mu.Unlock()
+	}
+	workerRegistry.mu.Unlock()
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Pool != infos[j].Pool {
+			return infos[i].Pool < infos[j].Pool
+		}
+		return infos[i].Worker < infos[j].Worker
+	})
+	return infos
+}
+
+// DumpWorkers 把 Workers 的结果每个协程一行写入 w，例如
+//
+//	goroutine 42: pool=crawler worker=3 task=*main.fetch running 1.5s
+//	goroutine 43: pool=crawler worker=4 idle
+//
+// 可以在收到 SIGQUIT 之类的信号时与调用栈一起输出
+func DumpWorkers(w io.Writer) error {
+	now := time.Now()
+	for _, info := range Workers() {
+		var err error
+		if info.Task == "" {
+			_, err = fmt.Fprintf(w, "goroutine %d: pool=%s worker=%d idle\n", info.Goroutine, info.Pool, info.Worker)
+		} else {
+			_, err = fmt.Fprintf(w, "goroutine %d: pool=%s worker=%d task=%s running %v\n",
+				info.Goroutine, info.Pool, info.Worker, info.Task, now.Sub(info.Since).Round(time.Millisecond))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package workpool
+
+import (
+	"bytes"
+	"runtime/pprof"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RobinTsai/tearUpGo/workpool/sync"
+)
+
+type namedWorkload struct{ release chan struct{} }
+
+func (w namedWorkload) Work()        { <-w.release }
+func (w namedWorkload) Name() string { return "blocker" }
+
+// waitFor 轮询直到 cond 成立，5s 内不成立时测试失败
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); !cond(); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within 5s")
+		}
+	}
+}
+
+func poolWorkers(pool string) []WorkerInfo {
+	var infos []WorkerInfo
+	for _, info := range Workers() {
+		if info.Pool == pool {
+			infos = append(infos, info)
+		}
+	}
+	return infos
+}
+
+func TestWorkerLabels(t *testing.T) {
+	defer sync.VerifyNone(t)
+	pool := NewWorkerpool(1, WithName("labels-test"))
+	pool.Start()
+	w := namedWorkload{release: make(chan struct{})}
+	pool.AddTask(w)
+
+	var infos []WorkerInfo
+	waitFor(t, func() bool {
+		infos = poolWorkers("labels-test")
+		return len(infos) == 1 && infos[0].Task == "blocker"
+	})
+	if infos[0].Worker != 1 || infos[0].Goroutine == 0 || infos[0].Since.IsZero() {
+		t.Errorf("worker info = %+v", infos[0])
+	}
+
+	var prof bytes.Buffer
+	pprof.Lookup("goroutine").WriteTo(&prof, 1)
+	if want := `"task":"blocker", "worker":"1", "workpool":"labels-test"`; !strings.Contains(prof.String(), want) {
+		t.Errorf("goroutine profile has no labels %s", want)
+	}
+	var dump bytes.Buffer
+	DumpWorkers(&dump)
+	if want := "pool=labels-test worker=1 task=blocker running"; !strings.Contains(dump.String(), want) {
+		t.Errorf("DumpWorkers = %q, want a line containing %q", dump.String(), want)
+	}
+
+	close(w.release)
+	waitFor(t, func() bool {
+		infos = poolWorkers("labels-test")
+		return len(infos) == 1 && infos[0].Task == ""
+	})
+	pool.Shutdown()
+	pool.Wait()
+	if infos = poolWorkers("labels-test"); len(infos) != 0 {
+		t.Errorf("workers after Wait = %+v, want none", infos)
+	}
+}
+
+// 未命名的工作池不登记
+func TestWorkersUnnamed(t *testing.T) {
+	defer sync.VerifyNone(t)
+	pool := NewWorkerpool(1)
+	pool.Start()
+	w := namedWorkload{release: make(chan struct{})}
+	pool.AddTask(w)
+	if infos := poolWorkers(""); len(infos) != 0 {
+		t.Errorf("unnamed pool registered workers %+v", infos)
+	}
+	close(w.release)
+	pool.Shutdown()
+	pool.Wait()
+}
@@ -0,0 +1,171 @@
+package workpool
+
+import (
+	"context"
+	"fmt"
+	stdsync "sync"
+)
+
+// Manager 管理一个服务中的多个命名工作池（Workerpool 或 ShardedPool）：按名字查找、汇总统计，以及在退出时按依赖顺序关闭。
+//
+// 依赖指的是“谁给谁提交任务”：若 orders 的任务会向 emails 提交任务，则 orders 依赖 emails，
+// 关闭时先关闭并排空 orders，再关闭 emails，这样 orders 排空期间提交给 emails 的任务不会因 emails 已关闭而失败。
+// 依赖的工作池必须先于依赖它的工作池登记，所以不会出现循环依赖
+type Manager struct {
+	mu    stdsync.Mutex
+	pools map[string]*managedPool
+	order []string // 登记顺序
+}
+
+type managedPool struct {
+	pool Pool
+	deps []string // 本工作池会向其提交任务的工作池
+}
+
+// PoolStats 是一个工作池某一时刻的状态
+type PoolStats struct {
+	Name       string
+	Workers    int    // 存活的协程数
+	MaxWorkers int    // 协程数上限
+	Running    int    // 正在执行任务的协程数
+	Pending    int    // 排队等待的任务数
+	Expired    uint64 // 因 context 已结束而跳过的任务数
+}
+
+// ManagerStats 是所有工作池的状态，Total 为各项之和（Name 为空）
+type ManagerStats struct {
+	Pools []PoolStats // 按登记顺序
+	Total PoolStats
+}
+
+func NewManager() *Manager {
+	return &Manager{pools: make(map[string]*managedPool)}
+}
+
+// Add 以 name 登记工作池 p，dependsOn 为 p 的任务会向其提交任务的工作池，必须已经登记。
+// 名字重复或依赖未登记时返回错误
+func (m *Manager) Add(name string, p Pool, dependsOn ...string) error {
+	if isNilPool(p) {
+		return fmt.Errorf("workpool: manager: nil pool %q", name)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.pools[name]; ok {
+		return fmt.Errorf("workpool: manager: pool %q already added", name)
+	}
+	for _, dep := range dependsOn {
+		if _, ok := m.pools[dep]; !ok {
+			return fmt.Errorf("workpool: manager: pool %q depends on unknown pool %q", name, dep)
+		}
+	}
+	m.pools[name] = &managedPool{pool: p, deps: append([]string(nil), dependsOn...)}
+	m.order = append(m.order, name)
+	return nil
+}
+
+// isNilPool 判断 p 是否为 nil，包括 n <= 0 时 NewWorkerpool 和 NewShardedPool 返回的 nil 指针
+func isNilPool(p Pool) bool {
+	switch p := p.(type) {
+	case nil:
+		return true
+	case *Workerpool:
+		return p == nil
+	case *ShardedPool:
+		return p == nil
+	}
+	return false
+}
+
+// Pool 返回名为 name 的工作池，不存在时返回 nil
+func (m *Manager) Pool(name string) Pool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if mp, ok := m.pools[name]; ok {
+		return mp.pool
+	}
+	return nil
+}
+
+// Names 按登记顺序返回所有工作池的名字
+func (m *Manager) Names() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.order...)
+}
+
+// Stats 返回所有工作池的状态及其汇总，各工作池的状态不是同时读取的
+func (m *Manager) Stats() ManagerStats {
+	var s ManagerStats
+	for _, name := range m.Names() {
+		p := m.Pool(name)
+		ps := PoolStats{
+			Name:       name,
+			Workers:    int(p.GetWaitCount()),
+			MaxWorkers: p.MaxWorkers(),
+			Running:    p.RunningWorkers(),
+			Pending:    p.PendingCount(),
+			Expired:    p.ExpiredCount(),
+		}
+		s.Pools = append(s.Pools, ps)
+		s.Total.Workers += ps.Workers
+		s.Total.MaxWorkers += ps.MaxWorkers
+		s.Total.Running += ps.Running
+		s.Total.Pending += ps.Pending
+		s.Total.Expired += ps.Expired
+	}
+	return s
+}
+
+// Shutdown 按依赖顺序关闭所有工作池：每一轮同时 Shutdown 所有不再被其他未关闭的工作池依赖的工作池，
+// 等它们排空后再进行下一轮。ctx 先结束时对还没有排空的所有工作池调用 Down 并返回 ctx.Err()，
+// Down 不会中断正在执行的任务，需要时可以再对各工作池调用 Wait。
+// 通常在收到退出信号后调用，例如
+//
+//	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM)
+//	<-ctx.Done()
+//	stop()
+//	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+//	defer cancel()
+//	m.Shutdown(shutdownCtx)
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	remaining := make(map[string]*managedPool, len(m.pools))
+	for name, mp := range m.pools {
+		remaining[name] = mp
+	}
+	order := append([]string(nil), m.order...)
+	m.mu.Unlock()
+
+	for len(remaining) > 0 {
+		// 本轮关闭没有被剩余工作池依赖的工作池
+		depended := make(map[string]bool)
+		for _, mp := range remaining {
+			for _, dep := range mp.deps {
+				depended[dep] = true
+			}
+		}
+		var round []Pool
+		for _, name := range order {
+			if mp, ok := remaining[name]; ok && !depended[name] {
+				round = append(round, mp.pool)
+				delete(remaining, name)
+			}
+		}
+
+		for _, p := range round {
+			p.Shutdown()
+		}
+		for _, p := range round {
+			if err := p.WaitDrained(ctx); err != nil {
+				for _, p := range round {
+					p.Down()
+				}
+				for _, mp := range remaining {
+					mp.pool.Down()
+				}
+				return err
+			}
+		}
+	}
+	return nil
+}
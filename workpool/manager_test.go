@@ -0,0 +1,89 @@
+package workpool
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/RobinTsai/tearUpGo/workpool/sync"
+)
+
+func TestManagerAdd(t *testing.T) {
+	m := NewManager()
+	emails := NewWorkerpool(1)
+	if err := m.Add("emails", emails); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Add("emails", NewWorkerpool(1)); err == nil {
+		t.Error("duplicate name accepted")
+	}
+	if err := m.Add("orders", NewWorkerpool(1), "billing"); err == nil {
+		t.Error("unknown dependency accepted")
+	}
+	if p := m.Pool("emails"); p != emails {
+		t.Errorf("Pool(emails) = %p, want %p", p, emails)
+	}
+	if p := m.Pool("billing"); p != nil {
+		t.Errorf("Pool(billing) = %p, want nil", p)
+	}
+}
+
+// orders 的任务在排空期间向 emails 提交任务，按依赖顺序关闭时这些提交都能成功
+func TestManagerShutdownOrder(t *testing.T) {
+	defer sync.VerifyNone(t)
+	m := NewManager()
+	emails, orders := NewWorkerpool(2), NewWorkerpool(2)
+	m.Add("emails", emails)
+	m.Add("orders", orders, "emails")
+	emails.Start()
+	orders.Start()
+
+	var sent, failed int32
+	for i := 0; i < 10; i++ {
+		orders.SubmitFunc(func() {
+			time.Sleep(5 * time.Millisecond)
+			if err := emails.SubmitFunc(func() { atomic.AddInt32(&sent, 1) }); err != nil {
+				atomic.AddInt32(&failed, 1)
+			}
+		})
+	}
+	if s := m.Stats(); len(s.Pools) != 2 || s.Total.MaxWorkers != 4 || s.Pools[1].Name != "orders" {
+		t.Errorf("Stats = %+v", s)
+	}
+
+	if err := m.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if sent, failed := atomic.LoadInt32(&sent), atomic.LoadInt32(&failed); sent != 10 || failed != 0 {
+		t.Errorf("sent/failed = %d/%d, want 10/0", sent, failed)
+	}
+	if s := m.Stats(); s.Total.Workers != 0 || s.Total.Pending != 0 {
+		t.Errorf("Stats after Shutdown = %+v", s.Total)
+	}
+}
+
+// ctx 结束时剩下的工作池被 Down
+func TestManagerShutdownTimeout(t *testing.T) {
+	defer sync.VerifyNone(t)
+	m := NewManager()
+	slow, downstream := NewWorkerpool(1), NewWorkerpool(1)
+	m.Add("downstream", downstream)
+	m.Add("slow", slow, "downstream")
+	slow.Start()
+	downstream.Start()
+	release := make(chan struct{})
+	slow.SubmitFunc(func() { <-release })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := m.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Shutdown = %v, want DeadlineExceeded", err)
+	}
+	if err := downstream.AddTask(nopWorkload{}); err != ErrPoolClosed {
+		t.Errorf("downstream AddTask after Shutdown timeout = %v, want ErrPoolClosed", err)
+	}
+	close(release)
+	slow.Wait()
+	downstream.Wait()
+}
@@ -0,0 +1,132 @@
+package workpool
+
+import (
+	"io"
+	"time"
+
+	"github.com/RobinTsai/tearUpGo/workpool/sync"
+)
+
+// Option 是 NewWorkerpool 的可选配置项
+type Option func(p *Workerpool)
+
+// WithScheduler 设置决定何时启动新协程的策略，为 nil 时使用 DefaultScheduler
+func WithScheduler(s Scheduler) Option {
+	return func(p *Workerpool) {
+		if s != nil {
+			p.scheduler = s
+		}
+	}
+}
+
+// WithErrorHandler 设置任务错误的处理函数，默认打印日志
+// 处理函数在执行任务的协程中被调用，需要自行保证并发安全
+func WithErrorHandler(h func(err error)) Option {
+	return func(p *Workerpool) {
+		p.errHandler = h
+	}
+}
+
+// WithPrefork 在 Start 时就启动全部 workerCount 个协程，并且空闲时不再收缩，
+// 用少量常驻的空闲协程换取第一波任务到来时没有启动协程的延迟
+func WithPrefork() Option {
+	return func(p *Workerpool) {
+		p.prefork = true
+	}
+}
+
+// WithWorkerInit 设置协程启动时的初始化函数，用于为每个协程准备一次昂贵的私有状态（数据库连接、缓冲区等）
+// 初始化失败时错误交给错误处理函数，该协程直接退出，不会领取任务
+func WithWorkerInit(init func() (interface{}, error)) Option {
+	return func(p *Workerpool) {
+		p.workerInit = init
+	}
+}
+
+// WithWorkerTeardown 设置协程退出时（空闲收缩或工作池关闭）释放私有状态的函数，
+// 参数为 WithWorkerInit 返回的状态，未设置初始化函数时为 nil
+func WithWorkerTeardown(teardown func(state interface{})) Option {
+	return func(p *Workerpool) {
+		p.workerTeardown = teardown
+	}
+}
+
+// WithResults 开启 Results 通道，IResultWorkload 的结果按完成顺序发送
+func WithResults() Option {
+	return func(p *Workerpool) {
+		p.results = newResultBuf(p.MaxWorkers(), false)
+	}
+}
+
+// WithOrderedResults 开启 Results 通道，任务并行执行，但结果按提交顺序发送
+func WithOrderedResults() Option {
+	return func(p *Workerpool) {
+		p.results = newResultBuf(p.MaxWorkers(), true)
+	}
+}
+
+// WithQueueBound 限制排队任务数最多为 n（不含 In/Out 通道中的少量缓冲），
+// 队列满时 AddTask 会阻塞直到有空间或工作池 Down，使工作池的内存占用有上限
+func WithQueueBound(n int) Option {
+	return func(p *Workerpool) {
+		p.queueBound = n
+	}
+}
+
+// WithPriority 使排队的任务按优先级执行：less(a, b) 为 true 时 a 先于 b 执行
+// 只影响在队列中等待的任务，有空闲协程时任务仍会被立即执行
+func WithPriority(less func(a, b IWorkload) bool) Option {
+	return func(p *Workerpool) {
+		p.less = less
+	}
+}
+
+// WithLIFO 使排队的任务后进先出，最新提交的任务最先执行；同时设置 WithPriority 时以优先级为准
+func WithLIFO() Option {
+	return func(p *Workerpool) {
+		p.lifo = true
+	}
+}
+
+// WithChanSize 设置任务队列 In 和 Out 通道的缓冲大小（默认均为 2），小于 0 时保持默认值。
+// 任务多且执行快时，较大的 Out 缓冲可以减少协程等待分发的次数
+func WithChanSize(in, out int) Option {
+	return func(p *Workerpool) {
+		p.bufOpts = append(p.bufOpts, func(eb *sync.ElasticBuf) {
+			eb.SetChanSize(in, out)
+		})
+	}
+}
+
+// WithWaitDebug 开启阻塞诊断：Wait 阻塞超过 d 时，把仍在运行的协程的调用栈写入 out（为 nil 时写到标准错误），
+// 便于找出卡住的任务，见 xsync.ExtWaitGroup.SetWaitDebug
+func WithWaitDebug(d time.Duration, out io.Writer) Option {
+	return func(p *Workerpool) {
+		p.SetWaitDebug(d, out)
+	}
+}
+
+// WithIdleTimeout 设置协程空闲多久后退出（默认 3s），d <= 0 时保持默认值
+func WithIdleTimeout(d time.Duration) Option {
+	return func(p *Workerpool) {
+		if d > 0 {
+			p.idleTimeout = int64(d)
+		}
+	}
+}
+
+// WithRetries 使 SubmitErrFunc 提交的函数返回错误后立即重试，最多再执行 n 次，
+// 全部失败时只把最后一次的错误交给错误处理函数。重试在同一个协程中进行，不会重新排队
+func WithRetries(n int) Option {
+	return func(p *Workerpool) {
+		p.retries = int32(n)
+	}
+}
+
+// WithRateLimit 限制每秒开始执行的任务数：令牌以每秒 rate 个的速度生成，最多积攒 burst 个，
+// 协程取到任务后先等待令牌再执行，见 xsync.Limiter。rate <= 0 时不限速
+func WithRateLimit(rate float64, burst int) Option {
+	return func(p *Workerpool) {
+		p.setRateLimit(rate, burst)
+	}
+}
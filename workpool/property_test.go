@@ -0,0 +1,132 @@
+package workpool
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"testing/quick"
+	"time"
+)
+
+// schedule 是一次随机的运行：工作池的配置、每个任务的执行时长以及提交前的间隔
+type schedule struct {
+	Workers    int
+	Opts       []string
+	Durations  []time.Duration
+	SubmitGaps []time.Duration
+}
+
+func (s schedule) String() string {
+	return fmt.Sprintf("workers=%d opts=%v tasks=%d", s.Workers, s.Opts, len(s.Durations))
+}
+
+// Generate 实现 quick.Generator
+func (schedule) Generate(rng *rand.Rand, size int) reflect.Value {
+	s := schedule{Workers: 1 + rng.Intn(8)}
+	for _, opt := range []string{"prefork", "lifo", "lazy", "bound", "priority"} {
+		if rng.Intn(3) == 0 {
+			s.Opts = append(s.Opts, opt)
+		}
+	}
+	n := rng.Intn(4 * size)
+	for i := 0; i < n; i++ {
+		s.Durations = append(s.Durations, time.Duration(rng.Intn(300))*time.Microsecond)
+		var gap time.Duration
+		if rng.Intn(4) == 0 { // 大部分任务连续提交，偶尔停顿，让协程有机会空闲
+			gap = time.Duration(rng.Intn(500)) * time.Microsecond
+		}
+		s.SubmitGaps = append(s.SubmitGaps, gap)
+	}
+	return reflect.ValueOf(s)
+}
+
+func (s schedule) options() []Option {
+	var opts []Option
+	for _, opt := range s.Opts {
+		switch opt {
+		case "prefork":
+			opts = append(opts, WithPrefork())
+		case "lifo":
+			opts = append(opts, WithLIFO())
+		case "lazy":
+			opts = append(opts, WithScheduler(LazyScheduler(2)))
+		case "bound":
+			opts = append(opts, WithQueueBound(4))
+		case "priority":
+			opts = append(opts, WithPriority(func(a, b IWorkload) bool {
+				return a.(*propWorkload).d < b.(*propWorkload).d
+			}))
+		}
+	}
+	return opts
+}
+
+// propWorkload 记录自己的执行次数和执行时的并发数
+type propWorkload struct {
+	d       time.Duration
+	runs    int32
+	running *int32
+	peak    *int32
+}
+
+func (w *propWorkload) Work() {
+	atomic.AddInt32(&w.runs, 1)
+	n := atomic.AddInt32(w.running, 1)
+	for {
+		p := atomic.LoadInt32(w.peak)
+		if n <= p || atomic.CompareAndSwapInt32(w.peak, p, n) {
+			break
+		}
+	}
+	time.Sleep(w.d)
+	atomic.AddInt32(w.running, -1)
+}
+
+// TestPoolInvariants 在随机的配置和提交节奏下检查优雅关闭时工作池的不变量：
+//   - 每个提交的任务的 Work 恰好执行一次
+//   - 同时执行的任务数不超过 workerCount
+//   - Wait 返回后 GetWaitCount 为 0
+func TestPoolInvariants(t *testing.T) {
+	maxCount := 50
+	if testing.Short() {
+		maxCount = 20
+	}
+	prop := func(s schedule) bool {
+		pool := NewWorkerpool(s.Workers, s.options()...)
+		pool.Start()
+		var running, peak int32
+		works := make([]*propWorkload, len(s.Durations))
+		for i, d := range s.Durations {
+			time.Sleep(s.SubmitGaps[i])
+			works[i] = &propWorkload{d: d, running: &running, peak: &peak}
+			if err := pool.AddTask(works[i]); err != nil {
+				t.Logf("%v: AddTask #%d = %v", s, i, err)
+				return false
+			}
+		}
+		pool.Shutdown()
+		pool.Wait()
+
+		ok := true
+		for i, w := range works {
+			if runs := atomic.LoadInt32(&w.runs); runs != 1 {
+				t.Logf("%v: task #%d ran %d times", s, i, runs)
+				ok = false
+			}
+		}
+		if p := int(atomic.LoadInt32(&peak)); p > s.Workers {
+			t.Logf("%v: %d tasks ran concurrently", s, p)
+			ok = false
+		}
+		if n := pool.GetWaitCount(); n != 0 {
+			t.Logf("%v: GetWaitCount = %d after Wait", s, n)
+			ok = false
+		}
+		return ok
+	}
+	if err := quick.Check(prop, &quick.Config{MaxCount: maxCount}); err != nil {
+		t.Error(err)
+	}
+}
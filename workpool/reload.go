@@ -0,0 +1,127 @@
+package workpool
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/RobinTsai/tearUpGo/workpool/xsync"
+)
+
+// ApplyConfig 在运行中调整工作池的参数，不需要重启：
+//   - Workers：协程数上限。提高后若有积压的任务，立即启动新协程；降低后多出的协程在执行完手头的任务后退出，
+//     不会中断正在执行的任务。开启 WithAdaptiveLimit 时是自适应上限的上限
+//   - RateLimit/RateBurst：限速，保留令牌桶中已有的令牌，设为 0 时取消限速
+//   - IdleTimeout：空闲超时，设为 0 时恢复默认值，正在空闲计时的协程在下一次计时时生效
+//   - QueueBound：排队任务数上限，降低后已积压的任务不会被丢弃，只是在降到上限以下之前提交会阻塞
+//   - Retries：之后开始执行的任务生效
+//
+// Name 和 Prefork 只在创建时生效，这里忽略。c 不合法时返回错误，不做任何修改
+func (p *Workerpool) ApplyConfig(c Config) error {
+	if err := c.Validate(); err != nil {
+		return fmt.Errorf("workpool: %w", err)
+	}
+	p.reloadMu.Lock() // 串行化并发的 ApplyConfig
+	defer p.reloadMu.Unlock()
+	old := p.MaxWorkers()
+	if p.adaptive != nil { // Workers 是自适应上限的上限
+		p.adaptive.setCeiling(p, c.Workers)
+	} else {
+		atomic.StoreInt64(&p.workerCount, int64(c.Workers))
+	}
+	idle := time.Duration(c.IdleTimeout)
+	if idle <= 0 {
+		idle = maxIdleDuration
+	}
+	atomic.StoreInt64(&p.idleTimeout, int64(idle))
+	atomic.StoreInt32(&p.retries, int32(c.Retries))
+	p.setRateLimit(c.RateLimit, c.RateBurst)
+	p.elasticJobBuf.SetCap(c.QueueBound)
+	if p.MaxWorkers() > old {
+		p.spawnForBacklog()
+	}
+	return nil
+}
+
+// spawnForBacklog 在上限提高后为没有协程等待的积压任务启动协程，最多到新的上限
+func (p *Workerpool) spawnForBacklog() {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if !p.started || p.isDown() {
+		return
+	}
+	n := p.reserveForBacklog()
+	if n == 0 {
+		return
+	}
+	p.hookSpawn(spawnScheduled, SchedState{Workers: p.loadAdm().workers(), MaxWorkers: p.MaxWorkers(), Pending: p.PendingCount()})
+	p.Add(n)
+	for i := 0; i < n; i++ {
+		go p.spawnOneWorker(false)
+	}
+}
+
+// rateLimiter 返回当前的限速器，未限速时为 nil
+func (p *Workerpool) rateLimiter() *xsync.Limiter {
+	l, _ := p.limiter.Load().(*xsync.Limiter)
+	return l
+}
+
+// setRateLimit 设置限速，rate <= 0 时取消限速，burst <= 0 时为 1
+func (p *Workerpool) setRateLimit(rate float64, burst int) {
+	if rate <= 0 {
+		p.limiter.Store((*xsync.Limiter)(nil))
+		return
+	}
+	if burst <= 0 {
+		burst = 1
+	}
+	if l := p.rateLimiter(); l != nil {
+		l.SetLimit(rate, burst)
+		return
+	}
+	p.limiter.Store(xsync.NewLimiter(rate, burst))
+}
+
+// WatchConfig 每隔 interval 检查一次配置文件 path，修改时间或大小变化后用 LoadConfig 读取并 ApplyConfig，
+// 读取或应用失败的错误交给错误处理函数，工作池保持原来的参数。第一次检查时总会读取并应用文件的当前内容。
+// 阻塞直到 ctx 结束，返回 ctx.Err()，通常在单独的协程中调用
+func (p *Workerpool) WatchConfig(ctx context.Context, path string, interval time.Duration) error {
+	return watchConfig(ctx, path, interval, p.ApplyConfig, p.handleError)
+}
+
+// watchConfig 实现 WatchConfig，配置文件变化后交给 apply，读取或应用失败的错误交给 handleError
+func watchConfig(ctx context.Context, path string, interval time.Duration, apply func(Config) error, handleError func(error)) error {
+	stat := func() (time.Time, int64) {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, -1
+		}
+		return fi.ModTime(), fi.Size()
+	}
+	var mtime time.Time
+	size := int64(-2) // 与任何 stat 的结果都不同，第一次检查时总会读取
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		m, s := stat()
+		if m.Equal(mtime) && s == size {
+			continue
+		}
+		mtime, size = m, s
+		c, err := LoadConfig(path)
+		if err == nil {
+			err = apply(c)
+		}
+		if err != nil {
+			handleError(err)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package workpool
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/RobinTsai/tearUpGo/workpool/sync"
+)
+
+func TestApplyConfigWorkers(t *testing.T) {
+	defer sync.VerifyNone(t)
+	pool := NewWorkerpool(1)
+	pool.Start()
+	release := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		pool.SubmitFunc(func() { <-release })
+	}
+	waitFor(t, func() bool { return pool.RunningWorkers() == 1 })
+
+	// 提高上限后积压的任务立即得到协程
+	if err := pool.ApplyConfig(Config{Workers: 4}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return pool.RunningWorkers() == 4 })
+
+	// 降低上限后多出的协程执行完手头的任务就退出，不必等空闲超时
+	if err := pool.ApplyConfig(Config{Workers: 1}); err != nil {
+		t.Fatal(err)
+	}
+	close(release)
+	waitFor(t, func() bool { return pool.GetWaitCount() == 1 })
+
+	if err := pool.ApplyConfig(Config{Workers: 0}); err == nil {
+		t.Error("ApplyConfig with zero workers succeeded")
+	}
+	if n := pool.MaxWorkers(); n != 1 {
+		t.Errorf("MaxWorkers after a rejected config = %d, want 1", n)
+	}
+	pool.Shutdown()
+	pool.Wait()
+}
+
+func TestWatchConfig(t *testing.T) {
+	defer sync.VerifyNone(t)
+	path := writeConfig(t, "pool.yaml", "workers: 1\n")
+	errc := make(chan error, 1)
+	pool := NewWorkerpool(1, WithErrorHandler(func(err error) { errc <- err }))
+	pool.Start()
+	ctx, cancel := context.WithCancel(context.Background())
+	watched := make(chan error)
+	go func() { watched <- pool.WatchConfig(ctx, path, 5*time.Millisecond) }()
+
+	if err := os.WriteFile(path, []byte("workers: 3\nrate_limit: 100\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return pool.MaxWorkers() == 3 && pool.rateLimiter() != nil })
+
+	// 不合法的配置报告给错误处理函数，参数保持不变
+	if err := os.WriteFile(path, []byte("workers: -1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errc; err == nil {
+		t.Error("no error for an invalid config")
+	}
+	if n := pool.MaxWorkers(); n != 3 {
+		t.Errorf("MaxWorkers after an invalid config = %d, want 3", n)
+	}
+
+	cancel()
+	if err := <-watched; err != context.Canceled {
+		t.Errorf("WatchConfig = %v, want Canceled", err)
+	}
+	pool.Shutdown()
+	pool.Wait()
+}
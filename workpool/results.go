@@ -0,0 +1,93 @@
+package workpool
+
+import (
+	"context"
+	stdsync "sync"
+)
+
+// IResultWorkload 是会产生结果的任务，Work 执行完后 Result 的返回值会发送到 Results 通道
+type IResultWorkload interface {
+	IWorkload
+	Result() interface{}
+}
+
+// Results 返回任务结果通道，需要通过 WithResults 或 WithOrderedResults 开启，否则为 nil
+// 工作池关闭且所有协程退出后通道被关闭；结果无人读取时执行任务的协程会阻塞，起到背压的作用
+func (p *Workerpool) Results() <-chan interface{} {
+	if p.results == nil {
+		return nil
+	}
+	return p.results.out
+}
+
+// resultBuf 收集任务结果，有序模式下按提交顺序重排后再发送
+type resultBuf struct {
+	ordered bool
+	out     chan interface{}
+
+	mu      stdsync.Mutex
+	next    uint64            // 下一个应该发送的任务序号
+	pending map[uint64]result // 已完成但还轮不到发送的结果
+}
+
+type result struct {
+	val interface{}
+	ok  bool // 为 false 时该序号没有结果（非 IResultWorkload、被跳过等），只占位
+}
+
+func newResultBuf(size int, ordered bool) *resultBuf {
+	return &resultBuf{
+		ordered: ordered,
+		out:     make(chan interface{}, size),
+		pending: make(map[uint64]result),
+	}
+}
+
+// complete 记录序号为 seq 的任务的结果，ctx 结束后丢弃结果以免阻塞协程退出
+func (r *resultBuf) complete(ctx context.Context, seq uint64, res result) {
+	if !r.ordered {
+		if res.ok {
+			r.send(ctx, res.val)
+		}
+		return
+	}
+
+	// 持锁发送，保证多个协程间的发送顺序
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pending[seq] = res
+	for {
+		res, found := r.pending[r.next]
+		if !found {
+			return
+		}
+		delete(r.pending, r.next)
+		r.next++
+		if res.ok {
+			r.send(ctx, res.val)
+		}
+	}
+}
+
+func (r *resultBuf) send(ctx context.Context, val interface{}) {
+	select {
+	case r.out <- val:
+	case <-ctx.Done():
+	}
+}
+
+// close 在所有协程退出后调用：按序号发送剩余的结果（Down 后可能存在空缺），然后关闭通道
+func (r *resultBuf) close(ctx context.Context) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for len(r.pending) > 0 {
+		if res, found := r.pending[r.next]; found {
+			delete(r.pending, r.next)
+			if res.ok {
+				r.send(ctx, res.val)
+			}
+		}
+		r.next++
+	}
+	close(r.out)
+}
@@ -0,0 +1,36 @@
+package workpool
+
+// SchedState 是调度器做决定时看到的工作池状态
+type SchedState struct {
+	Workers    int // 当前协程数
+	Running    int // 正在执行任务的协程数
+	MaxWorkers int // 协程数上限
+	Pending    int // 排队等待的任务数
+}
+
+// Scheduler 决定任务入队后是否再启动一个协程
+// 只有在已有协程存活、没有等待任务的协程且协程数未达上限时才会被询问，
+// 没有协程时工作池总会启动一个，所以实现不需要处理这两个边界。
+type Scheduler interface {
+	ShouldSpawn(st SchedState) bool
+}
+
+// SchedulerFunc 将普通函数适配为 Scheduler
+type SchedulerFunc func(st SchedState) bool
+
+func (f SchedulerFunc) ShouldSpawn(st SchedState) bool {
+	return f(st)
+}
+
+// DefaultScheduler 是默认策略：没有等待任务的协程说明已有协程都忙，只要未达上限就启动新协程
+var DefaultScheduler Scheduler = SchedulerFunc(func(SchedState) bool {
+	return true
+})
+
+// LazyScheduler 只有排队任务数超过 backlog 时才启动新协程，
+// 适合任务很短、希望协程数尽量少的场景
+func LazyScheduler(backlog int) Scheduler {
+	return SchedulerFunc(func(st SchedState) bool {
+		return st.Pending > backlog
+	})
+}
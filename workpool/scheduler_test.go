@@ -0,0 +1,61 @@
+package workpool
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/RobinTsai/tearUpGo/workpool/sync"
+)
+
+// 所有协程都忙时每个入队的任务都询问一次 scheduler：否决时任务留在队列中，允许时启动新协程取走排队的任务
+func TestCustomScheduler(t *testing.T) {
+	for _, c := range []struct {
+		name    string
+		backlog int // 排队任务数达到 backlog 时允许启动新协程
+		allowed []bool
+		running int
+	}{
+		{"Veto", 100, []bool{false, false, false}, 1},
+		{"Allow", 2, []bool{false, false, true}, 2},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			defer sync.VerifyNone(t)
+			var asked []SchedState
+			var allowed []bool
+			sched := SchedulerFunc(func(st SchedState) bool {
+				asked = append(asked, st) // 在提交方的协程中调用
+				allowed = append(allowed, st.Pending >= c.backlog)
+				return st.Pending >= c.backlog
+			})
+			pool := NewWorkerpool(4, WithScheduler(sched), WithChanSize(0, 0))
+			pool.Start()
+			release := make(chan struct{})
+			f, started := blockingTask(release)
+			pool.SubmitFunc(f) // 由 Start 启动的协程执行，不询问 scheduler
+			<-started
+			waitFor(t, func() bool { return pool.PendingCount() == 0 }) // 经队列转交时，任务开始后才从队列中移除
+
+			for i := 1; i <= 3; i++ {
+				f, _ := blockingTask(release)
+				pool.SubmitFunc(f)
+				waitFor(t, func() bool { return pool.RunningWorkers()+pool.PendingCount() == 1+i })
+			}
+			waitFor(t, func() bool { return pool.RunningWorkers() == c.running })
+			if n := pool.PendingCount(); n != 4-c.running {
+				t.Errorf("PendingCount = %d, want %d", n, 4-c.running)
+			}
+			if !reflect.DeepEqual(allowed, c.allowed) {
+				t.Errorf("decisions = %v, want %v", allowed, c.allowed)
+			}
+			for i, st := range asked {
+				if want := (SchedState{Workers: 1, Running: 1, MaxWorkers: 4, Pending: i}); st != want {
+					t.Errorf("ask %d: state = %+v, want %+v", i, st, want)
+				}
+			}
+
+			close(release)
+			pool.Shutdown()
+			pool.Wait()
+		})
+	}
+}
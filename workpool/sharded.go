@@ -0,0 +1,309 @@
+package workpool
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	stdsync "sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/RobinTsai/tearUpGo/workpool/xsync"
+)
+
+// ShardedPool 是按 P（GOMAXPROCS）分片的工作池：每个分片是一个独立的 Workerpool，有自己的队列、协程和锁，
+// 提交时按提交方所在的 P 或者 key 选择分片，提交量极大时各个核不再争用同一个队列。
+// 代价是分片之间不互相借用协程：一个分片积压时，其他分片的空闲协程不会帮它执行。
+//
+// 方法与 Workerpool 相同，各项统计为所有分片之和。Events 和 Results 是所有分片共用的一个通道，
+// 有序结果按整个池的提交顺序发送；Drained 事件、Drained 和 WaitDrained 在所有分片都排空后才发出或返回
+type ShardedPool struct {
+	shards  []*Workerpool
+	group   *shardGroup
+	events  chan Event
+	results *resultBuf
+	hints   stdsync.Pool // *shardHint，sync.Pool 的本地缓存按 P 划分，同一个 P 上取到的通常是同一个分片，见 local
+	next    uint32       // 分配给新 shardHint 的下一个分片，原子操作
+}
+
+// shardGroup 是同一个 ShardedPool 的分片共用的状态
+type shardGroup struct {
+	seq       uint64 // 下一个任务的提交序号，原子操作，放在首位保证 64 位对齐，见 nextSeq
+	remaining int32  // 还没有排空的分片数，原子操作
+	drainedEv xsync.Event
+}
+
+// shardDrained 在一个分片排空时调用，返回它是否是最后一个排空的分片
+func (g *shardGroup) shardDrained() bool {
+	if atomic.AddInt32(&g.remaining, -1) != 0 {
+		return false
+	}
+	g.drainedEv.Set()
+	return true
+}
+
+// shardHint 是缓存在 P 上的分片编号
+type shardHint struct {
+	shard int
+}
+
+// NewShardedPool 创建 GOMAXPROCS 个分片，n 个协程的上限平均分给各分片，n 小于 GOMAXPROCS 时只创建 n 个分片，
+// 分片数此后不再改变。opts 应用于每个分片，与 ApplyConfig 一样，数量类选项是整个池的总量：
+// WithQueueBound 和 WithRateLimit 的速度与容量按分片个数平均分配，每个分片至少 1；
+// WithName 的名字加上分片编号作为各分片的名字，例如 "orders-0"
+func NewShardedPool(n int, opts ...Option) *ShardedPool {
+	if n <= 0 {
+		return nil
+	}
+	count := runtime.GOMAXPROCS(0)
+	if n < count {
+		count = n
+	}
+	sp := &ShardedPool{
+		shards: make([]*Workerpool, count),
+		group:  &shardGroup{remaining: int32(count)},
+		events: make(chan Event, eventsBufSize),
+	}
+	for i := range sp.shards {
+		i := i
+		sp.shards[i] = newWorkerpool(splitWorkers(n, count, i), opts, func(p *Workerpool) {
+			sp.setupShard(p, n, i)
+		})
+	}
+	sp.hints.New = func() interface{} {
+		return &shardHint{shard: int(atomic.AddUint32(&sp.next, 1)-1) % len(sp.shards)}
+	}
+	return sp
+}
+
+// setupShard 在应用 opts 之后调整第 i 个分片：按分片拆分总量，并接入共用的状态
+func (sp *ShardedPool) setupShard(p *Workerpool, n, i int) {
+	count := len(sp.shards)
+	if p.name != "" {
+		p.name = fmt.Sprintf("%s-%d", p.name, i)
+	}
+	p.queueBound = splitLimit(p.queueBound, count, i)
+	if l := p.rateLimiter(); l != nil {
+		rate, burst := l.Limit()
+		p.setRateLimit(rate/float64(count), splitLimit(burst, count, i))
+	}
+	p.group = sp.group
+	p.events = sp.events
+	if p.results != nil {
+		if sp.results == nil {
+			sp.results = newResultBuf(n, p.results.ordered)
+		}
+		p.results = sp.results
+	}
+}
+
+// splitWorkers 返回 n 平均分成 count 份后第 i 份的大小，余数分给前面的分片；n 小于 count 时后面的分片为 0
+func splitWorkers(n, count, i int) int {
+	size := n / count
+	if i < n%count {
+		size++
+	}
+	return size
+}
+
+// splitLimit 与 splitWorkers 相同，但 n 大于 0 时每份至少为 1，以免分到 0 的分片变成不限
+func splitLimit(n, count, i int) int {
+	size := splitWorkers(n, count, i)
+	if n > 0 && size == 0 {
+		size = 1
+	}
+	return size
+}
+
+// Shards 返回分片的个数
+func (sp *ShardedPool) Shards() int {
+	return len(sp.shards)
+}
+
+// local 返回当前 P 对应的分片
+func (sp *ShardedPool) local() *Workerpool {
+	h := sp.hints.Get().(*shardHint)
+	p := sp.shards[h.shard]
+	sp.hints.Put(h)
+	return p
+}
+
+// shardFor 返回 key 对应的分片，同一个 key 总是落在同一个分片上
+func (sp *ShardedPool) shardFor(key string) *Workerpool {
+	h := uint32(2166136261) // FNV-1a，不需要分配内存
+	for i := 0; i < len(key); i++ {
+		h ^= uint32(key[i])
+		h *= 16777619
+	}
+	return sp.shards[h%uint32(len(sp.shards))]
+}
+
+// Start 启动所有分片，返回第一个错误
+func (sp *ShardedPool) Start() error {
+	var first error
+	for _, p := range sp.shards {
+		if err := p.Start(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Shutdown 优雅关闭所有分片
+func (sp *ShardedPool) Shutdown() {
+	for _, p := range sp.shards {
+		p.Shutdown()
+	}
+}
+
+// Down 立即下线所有分片
+func (sp *ShardedPool) Down() {
+	for _, p := range sp.shards {
+		p.Down()
+	}
+}
+
+// Wait 等待所有分片的协程退出，返回第一个错误
+func (sp *ShardedPool) Wait() error {
+	var first error
+	for _, p := range sp.shards {
+		if err := p.Wait(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Drained 返回一个通道，所有分片都排空后被关闭，见 Workerpool.Drained
+func (sp *ShardedPool) Drained() <-chan struct{} {
+	return sp.group.drainedEv.Done()
+}
+
+// WaitDrained 等待所有分片排空，见 Workerpool.WaitDrained
+func (sp *ShardedPool) WaitDrained(ctx context.Context) error {
+	return sp.group.drainedEv.Wait(ctx)
+}
+
+// Events 返回所有分片共用的事件通道，见 Workerpool.Events。
+// 事件的 Workers 是发出事件的分片的协程数，Drained 事件只在最后一个分片排空时发出一次
+func (sp *ShardedPool) Events() <-chan Event {
+	return sp.events
+}
+
+// Results 返回所有分片共用的结果通道，见 Workerpool.Results，所有分片都排空后被关闭
+func (sp *ShardedPool) Results() <-chan interface{} {
+	if sp.results == nil {
+		return nil
+	}
+	return sp.results.out
+}
+
+// AddTask 把任务提交给当前 P 对应的分片
+func (sp *ShardedPool) AddTask(work IWorkload) error {
+	return sp.local().AddTask(work)
+}
+
+// AddTaskContext 把任务提交给当前 P 对应的分片，见 Workerpool.AddTaskContext
+func (sp *ShardedPool) AddTaskContext(ctx context.Context, work IWorkload) error {
+	return sp.local().AddTaskContext(ctx, work)
+}
+
+// TryAddTask 把任务提交给当前 P 对应的分片，见 Workerpool.TryAddTask。
+// 只看这一个分片是否有空闲协程和排队的空间，不会转交给其他分片
+func (sp *ShardedPool) TryAddTask(ctx context.Context, work IWorkload, maxQueue int) error {
+	return sp.local().TryAddTask(ctx, work, maxQueue)
+}
+
+// Call 在当前 P 对应的分片中执行 f 并等待它返回，见 Workerpool.Call
+func (sp *ShardedPool) Call(ctx context.Context, maxQueue int, f func()) error {
+	return sp.local().Call(ctx, maxQueue, f)
+}
+
+// AddTaskKey 把任务提交给 key 对应的分片，同一个 key 的任务总在同一个分片中执行，
+// 适合同一个 key 的任务共享分片内的缓存或连接等场景
+func (sp *ShardedPool) AddTaskKey(key string, work IWorkload) error {
+	return sp.shardFor(key).AddTask(work)
+}
+
+func (sp *ShardedPool) SubmitFunc(f func()) error {
+	return sp.local().SubmitFunc(f)
+}
+
+func (sp *ShardedPool) SubmitFuncContext(ctx context.Context, f func(ctx context.Context)) error {
+	return sp.local().SubmitFuncContext(ctx, f)
+}
+
+func (sp *ShardedPool) SubmitErrFunc(f func() error) error {
+	return sp.local().SubmitErrFunc(f)
+}
+
+// ApplyConfig 调整所有分片的参数，见 Workerpool.ApplyConfig。与 NewShardedPool 一样，
+// c 中的数量是整个池的总量：Workers、QueueBound、RateLimit 和 RateBurst 按分片个数平均分配，其余参数每个分片相同。
+// 分片数不随配置改变，每个分片至少要有 1 个协程，Workers 少于 Shards() 时返回错误；
+// QueueBound 不为 0 以及限速时的 RateBurst 每个分片至少为 1，少于 Shards() 时实际的总量为 Shards()
+func (sp *ShardedPool) ApplyConfig(c Config) error {
+	if err := c.Validate(); err != nil {
+		return fmt.Errorf("workpool: %w", err)
+	}
+	n := len(sp.shards)
+	if c.Workers < n {
+		return fmt.Errorf("workpool: workers %d is less than the %d shards", c.Workers, n)
+	}
+	for i, p := range sp.shards {
+		sc := c
+		sc.Workers = splitWorkers(c.Workers, n, i)
+		sc.QueueBound = splitLimit(c.QueueBound, n, i)
+		sc.RateLimit = c.RateLimit / float64(n)
+		sc.RateBurst = splitLimit(c.RateBurst, n, i)
+		if err := p.ApplyConfig(sc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WatchConfig 监视配置文件并以 ApplyConfig 应用到所有分片，见 Workerpool.WatchConfig
+func (sp *ShardedPool) WatchConfig(ctx context.Context, path string, interval time.Duration) error {
+	return watchConfig(ctx, path, interval, sp.ApplyConfig, sp.shards[0].handleError)
+}
+
+// sum 返回 f 在所有分片上的值之和
+func (sp *ShardedPool) sum(f func(p *Workerpool) int) int {
+	n := 0
+	for _, p := range sp.shards {
+		n += f(p)
+	}
+	return n
+}
+
+func (sp *ShardedPool) PendingCount() int {
+	return sp.sum((*Workerpool).PendingCount)
+}
+
+func (sp *ShardedPool) RunningWorkers() int {
+	return sp.sum((*Workerpool).RunningWorkers)
+}
+
+func (sp *ShardedPool) MaxWorkers() int {
+	return sp.sum((*Workerpool).MaxWorkers)
+}
+
+func (sp *ShardedPool) IdleWorkers() int {
+	return sp.sum((*Workerpool).IdleWorkers)
+}
+
+func (sp *ShardedPool) GetWaitCount() uint64 {
+	var n uint64
+	for _, p := range sp.shards {
+		n += p.GetWaitCount()
+	}
+	return n
+}
+
+func (sp *ShardedPool) ExpiredCount() uint64 {
+	var n uint64
+	for _, p := range sp.shards {
+		n += p.ExpiredCount()
+	}
+	return n
+}
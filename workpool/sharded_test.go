@@ -0,0 +1,170 @@
+package workpool
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/RobinTsai/tearUpGo/workpool/sync"
+)
+
+func TestShardedPool(t *testing.T) {
+	defer sync.VerifyNone(t)
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	sp := NewShardedPool(10)
+	if n := sp.Shards(); n != 4 {
+		t.Fatalf("Shards = %d, want 4", n)
+	}
+	if n := sp.MaxWorkers(); n != 10 {
+		t.Errorf("MaxWorkers = %d, want 10", n)
+	}
+	if err := sp.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	var ran int32
+	done := make(chan struct{})
+	for g := 0; g < 8; g++ {
+		go func() {
+			defer func() { done <- struct{}{} }()
+			for i := 0; i < 500; i++ {
+				sp.SubmitFunc(func() { atomic.AddInt32(&ran, 1) })
+			}
+		}()
+	}
+	for g := 0; g < 8; g++ {
+		<-done
+	}
+	sp.Shutdown()
+	sp.Wait()
+	if n := atomic.LoadInt32(&ran); n != 8*500 {
+		t.Errorf("ran %d tasks, want %d", n, 8*500)
+	}
+	if err := sp.SubmitFunc(func() {}); err != ErrPoolClosed {
+		t.Errorf("SubmitFunc after Shutdown = %v, want ErrPoolClosed", err)
+	}
+}
+
+// 同一个 key 的任务总是落在同一个分片
+func TestShardedPoolKey(t *testing.T) {
+	defer sync.VerifyNone(t)
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	sp := NewShardedPool(4) // 每个分片一个协程
+	sp.Start()
+	shard := sp.shardFor("user-42")
+	release := make(chan struct{})
+	for i := 0; i < 6; i++ {
+		f, _ := blockingTask(release)
+		sp.AddTaskKey("user-42", funcWorkload(f))
+	}
+	waitFor(t, func() bool { return shard.RunningWorkers() == 1 && shard.PendingCount() == 5 })
+	if r, n := sp.RunningWorkers(), sp.PendingCount(); r != 1 || n != 5 {
+		t.Errorf("running/pending = %d/%d, want 1/5 all on the key's shard", r, n)
+	}
+	close(release)
+	sp.Shutdown()
+	sp.Wait()
+}
+
+// Config 中的数量是整个池的总量，按分片平均分配；Workers 少于分片数时拒绝，各分片保持原来的参数
+func TestShardedPoolApplyConfig(t *testing.T) {
+	defer sync.VerifyNone(t)
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	sp := NewShardedPool(8)
+	for _, c := range []Config{{}, {Workers: 2}} {
+		if err := sp.ApplyConfig(c); err == nil {
+			t.Errorf("ApplyConfig(%+v) accepted", c)
+		}
+	}
+	if n := sp.MaxWorkers(); n != 8 {
+		t.Errorf("MaxWorkers after rejected configs = %d, want 8", n)
+	}
+
+	if err := sp.ApplyConfig(Config{Workers: 6, QueueBound: 3, RateLimit: 100, RateBurst: 6}); err != nil {
+		t.Fatal(err)
+	}
+	if n := sp.MaxWorkers(); n != 6 {
+		t.Errorf("MaxWorkers = %d, want 6", n)
+	}
+	for i, want := range []int{2, 2, 1, 1} {
+		p := sp.shards[i]
+		if n := p.MaxWorkers(); n != want {
+			t.Errorf("shard %d: MaxWorkers = %d, want %d", i, n, want)
+		}
+		if b := int(p.rateLimiter().Tokens()); b != want { // 新建的限速器积满了令牌
+			t.Errorf("shard %d: burst = %d, want %d", i, b, want)
+		}
+	}
+}
+
+// 构造时的数量类选项与 ApplyConfig 一样是总量，名字加上分片编号
+func TestShardedPoolOptions(t *testing.T) {
+	defer sync.VerifyNone(t)
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	sp := NewShardedPool(8, WithName("orders"), WithQueueBound(6), WithRateLimit(100, 6))
+	for i, want := range []int{2, 2, 1, 1} {
+		p := sp.shards[i]
+		if name := fmt.Sprintf("orders-%d", i); p.name != name {
+			t.Errorf("shard %d: name = %q, want %q", i, p.name, name)
+		}
+		if n := p.queueBound; n != want {
+			t.Errorf("shard %d: queue bound = %d, want %d", i, n, want)
+		}
+		if rate, burst := p.rateLimiter().Limit(); rate != 25 || burst != want {
+			t.Errorf("shard %d: rate limit = %v, %d, want 25, %d", i, rate, burst, want)
+		}
+	}
+}
+
+// Events 和 Results 是分片共用的通道：有序结果按整个池的提交顺序发送，所有分片排空后才发出一次 Drained
+func TestShardedPoolDrained(t *testing.T) {
+	defer sync.VerifyNone(t)
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	const n = 50
+	sp := NewShardedPool(8, WithOrderedResults())
+	drained := make(chan struct{})
+	go func() { // 事件比通道的缓冲多，边执行边读取
+		for ev := range sp.Events() {
+			if ev.Type == Drained {
+				close(drained)
+				return
+			}
+		}
+	}()
+	sp.Start()
+	go func() {
+		for i := 0; i < n; i++ {
+			sp.AddTaskKey(strconv.Itoa(i), &resultWorkload{i: i}) // 分散到各个分片
+		}
+		sp.Shutdown()
+	}()
+
+	i := 0
+	for v := range sp.Results() {
+		if v != i*i {
+			t.Fatalf("result %d = %v, want %d", i, v, i*i)
+		}
+		i++
+	}
+	if i != n {
+		t.Errorf("got %d results, want %d", i, n)
+	}
+	select {
+	case <-sp.Drained():
+	default:
+		t.Error("Results closed before Drained")
+	}
+	if err := sp.WaitDrained(context.Background()); err != nil {
+		t.Error(err)
+	}
+	<-drained
+	for len(sp.Events()) > 0 {
+		if ev := <-sp.Events(); ev.Type == Drained {
+			t.Error("Drained sent more than once")
+		}
+	}
+	sp.Wait()
+}
@@ -0,0 +1,38 @@
+package workpool
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// DrainOnSignal 阻塞直到收到 signals 中的一个信号（未指定时为 SIGINT 和 SIGTERM），然后按正确的顺序关闭 p：
+//  1. 恢复这些信号的默认处理，再收到一次时进程直接退出，运维可以用第二次 Ctrl-C 强行结束
+//  2. Shutdown：不再接受新任务，已提交的任务继续执行
+//  3. 在 timeout 内等待已提交的任务全部执行完（WaitDrained）
+//  4. 超时则 Down，丢弃还在排队的任务，并返回 context.DeadlineExceeded；正在执行的任务不会被中断
+//
+// 排空时返回 nil。等待信号期间 p 因其他原因关闭并排空时也直接返回 nil。
+// 通常在 main 中启动工作池后调用，返回后进程即可退出
+func DrainOnSignal(p *Workerpool, timeout time.Duration, signals ...os.Signal) error {
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), signals...)
+	defer stop()
+	if p.WaitDrained(ctx) == nil {
+		return nil
+	}
+	stop()
+
+	p.Shutdown()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := p.WaitDrained(ctx); err != nil {
+		p.Down()
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package workpool
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/RobinTsai/tearUpGo/workpool/sync"
+)
+
+// drainOnSigterm 在协程中调用 DrainOnSignal，并不断给自己发送 SIGTERM 直到它返回。
+// 测试自己也订阅了 SIGTERM，DrainOnSignal 安装处理之前和恢复默认处理之后收到的信号不会结束进程
+func drainOnSigterm(t *testing.T, p *Workerpool, timeout time.Duration) error {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("cannot send SIGTERM to self on windows")
+	}
+	guard := make(chan os.Signal, 1)
+	signal.Notify(guard, syscall.SIGTERM)
+	defer signal.Stop(guard)
+
+	done := make(chan error, 1)
+	go func() { done <- DrainOnSignal(p, timeout) }()
+	self, _ := os.FindProcess(os.Getpid())
+	ticker := time.NewTicker(5 * time.Millisecond)
+	defer ticker.Stop()
+	for deadline := time.After(5 * time.Second); ; {
+		select {
+		case err := <-done:
+			return err
+		case <-ticker.C:
+			self.Signal(syscall.SIGTERM)
+		case <-deadline:
+			t.Fatal("DrainOnSignal did not return after SIGTERM")
+		}
+	}
+}
+
+func TestDrainOnSignal(t *testing.T) {
+	defer sync.VerifyNone(t)
+	pool := NewWorkerpool(1)
+	pool.Start()
+	finished := make(chan struct{})
+	pool.SubmitFunc(func() {
+		time.Sleep(20 * time.Millisecond)
+		close(finished)
+	})
+	if err := drainOnSigterm(t, pool, 5*time.Second); err != nil {
+		t.Errorf("DrainOnSignal = %v, want nil", err)
+	}
+	select {
+	case <-finished:
+	default:
+		t.Error("DrainOnSignal returned before the submitted task finished")
+	}
+	if err := pool.AddTask(nopWorkload{}); err != ErrPoolClosed {
+		t.Errorf("AddTask after DrainOnSignal = %v, want ErrPoolClosed", err)
+	}
+}
+
+// 超时后 Down，DrainOnSignal 不等待还在执行的任务
+func TestDrainOnSignalTimeout(t *testing.T) {
+	defer sync.VerifyNone(t)
+	pool := NewWorkerpool(1)
+	pool.Start()
+	release := make(chan struct{})
+	pool.SubmitFunc(func() { <-release })
+	if err := drainOnSigterm(t, pool, 20*time.Millisecond); err != context.DeadlineExceeded {
+		t.Errorf("DrainOnSignal = %v, want DeadlineExceeded", err)
+	}
+	if err := pool.AddTask(nopWorkload{}); err != ErrPoolClosed {
+		t.Errorf("AddTask after DrainOnSignal = %v, want ErrPoolClosed", err)
+	}
+	close(release)
+	pool.Wait()
+}
+
+// 工作池在等待信号期间被关闭时直接返回
+func TestDrainOnSignalClosed(t *testing.T) {
+	defer sync.VerifyNone(t)
+	pool := NewWorkerpool(1)
+	pool.Start()
+	time.AfterFunc(10*time.Millisecond, pool.Shutdown)
+	if err := DrainOnSignal(pool, time.Second, syscall.SIGTERM); err != nil {
+		t.Errorf("DrainOnSignal = %v, want nil", err)
+	}
+}
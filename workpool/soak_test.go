@@ -0,0 +1,106 @@
+package workpool
+
+import (
+	"flag"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var soak = flag.Duration("soak", 0, "run TestSoak for this long, such as -soak 5m")
+
+// TestSoak 长时间持续提交任务（突发和平缓的提交交替进行），定期采样协程数和 GC 后的堆大小，
+// 结束时检查：
+//   - 停止提交、超过空闲回收时间后，协程全部退出，进程的协程数回到开始时的水平
+//   - 堆大小没有在整个运行期间单调增长（ElasticBuf 等的内存在积压消化后没有被释放）
+//
+// 默认跳过，用 go test -run TestSoak -soak 5m 运行
+func TestSoak(t *testing.T) {
+	if *soak <= 0 {
+		t.Skip("use -soak to run the soak test")
+	}
+	const sampleEvery = time.Second
+	baseGoroutines := runtime.NumGoroutine()
+
+	pool := NewWorkerpool(16)
+	pool.Start()
+	var submitted, done int64
+	stop := make(chan struct{})
+	submitterDone := make(chan struct{})
+	go func() {
+		defer close(submitterDone)
+		submit := func() {
+			pool.SubmitFunc(func() {
+				time.Sleep(50 * time.Microsecond)
+				atomic.AddInt64(&done, 1)
+			})
+			atomic.AddInt64(&submitted, 1)
+		}
+		for {
+			// 每轮先突发提交 5000 个任务使积压和协程数增长，再以每毫秒一个的平缓速率提交 2s，
+			// 平均速率低于工作池的处理能力，积压会被消化
+			for i := 0; i < 5000; i++ {
+				submit()
+			}
+			for end := time.Now().Add(2 * time.Second); time.Now().Before(end); {
+				select {
+				case <-stop:
+					return
+				case <-time.After(time.Millisecond):
+				}
+				submit()
+			}
+		}
+	}()
+
+	var heaps []uint64
+	deadline := time.Now().Add(*soak)
+	for time.Now().Before(deadline) {
+		time.Sleep(sampleEvery)
+		var ms runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&ms)
+		heaps = append(heaps, ms.HeapAlloc)
+		t.Logf("submitted %d, done %d, pending %d, workers %d, goroutines %d, heap %d KB",
+			atomic.LoadInt64(&submitted), atomic.LoadInt64(&done), pool.PendingCount(),
+			pool.GetWaitCount(), runtime.NumGoroutine(), ms.HeapAlloc>>10)
+	}
+	close(stop)
+	<-submitterDone
+
+	// 等积压消化完、协程空闲回收
+	shrinkDeadline := time.Now().Add(time.Minute)
+	for pool.GetWaitCount() != 0 {
+		if time.Now().After(shrinkDeadline) {
+			t.Fatalf("%d workers still alive long after the pool became idle (pending %d)",
+				pool.GetWaitCount(), pool.PendingCount())
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if s, d := atomic.LoadInt64(&submitted), atomic.LoadInt64(&done); s != d {
+		t.Errorf("submitted %d tasks, %d done", s, d)
+	}
+	// 协程退出后还需要一点时间从 NumGoroutine 中消失；ElasticBuf 的协程常驻，所以允许多 1 个
+	for i := 0; runtime.NumGoroutine() > baseGoroutines+1; i++ {
+		if i == 50 {
+			t.Errorf("goroutines = %d after the pool became idle, want <= %d", runtime.NumGoroutine(), baseGoroutines+1)
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if n := len(heaps); n >= 4 {
+		increasing := true
+		for i := n / 2; i < n; i++ {
+			if heaps[i] <= heaps[i-1] {
+				increasing = false
+			}
+		}
+		if increasing && heaps[n-1] > heaps[0]*3/2 {
+			t.Errorf("heap grew monotonically from %d KB to %d KB", heaps[0]>>10, heaps[n-1]>>10)
+		}
+	}
+	pool.Shutdown()
+	pool.Wait()
+}
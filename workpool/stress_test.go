@@ -0,0 +1,98 @@
+package workpool
+
+import (
+	"context"
+	stdsync "sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/RobinTsai/tearUpGo/workpool/sync"
+)
+
+// TestStressAddTaskClose 让多个协程同时 AddTask、Shutdown 和 Down，用 -race 运行以检查工作池的并发约定：
+//   - AddTask 只返回 nil 或 ErrPoolClosed，不会向已关闭的队列发送而 panic
+//   - 只有 Shutdown 时，每个被接受（AddTask 返回 nil）的任务都恰好执行一次
+//   - 有 Down 时，被接受的任务可能被丢弃，但不会执行多次，也不会执行未被接受的任务
+//   - 之后 Wait 和 WaitDrained 都会返回
+func TestStressAddTaskClose(t *testing.T) {
+	defer sync.VerifyNone(t)
+	rounds, submitters, perSubmitter := 50, 16, 200
+	if testing.Short() {
+		rounds = 10
+	}
+	for _, mode := range []string{"Shutdown", "Down", "mixed"} {
+		t.Run(mode, func(t *testing.T) {
+			for r := 0; r < rounds; r++ {
+				stressRound(t, mode, submitters, perSubmitter)
+			}
+		})
+	}
+}
+
+func stressRound(t *testing.T, mode string, submitters, perSubmitter int) {
+	pool := NewWorkerpool(4)
+	pool.Start()
+
+	var accepted, executed int64
+	start := make(chan struct{})
+	var wg stdsync.WaitGroup
+	for s := 0; s < submitters; s++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			for i := 0; i < perSubmitter; i++ {
+				switch err := pool.SubmitFunc(func() { atomic.AddInt64(&executed, 1) }); err {
+				case nil:
+					atomic.AddInt64(&accepted, 1)
+				case ErrPoolClosed:
+					return
+				default:
+					t.Errorf("AddTask = %v, want nil or ErrPoolClosed", err)
+					return
+				}
+			}
+		}()
+	}
+	closers := map[string][]func(){
+		"Shutdown": {pool.Shutdown, pool.Shutdown},
+		"Down":     {pool.Down, pool.Down},
+		"mixed":    {pool.Shutdown, pool.Down, pool.Shutdown, pool.Down},
+	}[mode]
+	for i, closeFn := range closers {
+		wg.Add(1)
+		go func(i int, closeFn func()) {
+			defer wg.Done()
+			<-start
+			time.Sleep(time.Duration(i*50) * time.Microsecond) // 在提交进行到一半时关闭
+			closeFn()
+		}(i, closeFn)
+	}
+	close(start)
+	wg.Wait()
+
+	done := make(chan struct{})
+	go func() {
+		pool.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("%s: Wait did not return", mode)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := pool.WaitDrained(ctx); err != nil {
+		t.Fatalf("%s: WaitDrained = %v", mode, err)
+	}
+
+	a, e := atomic.LoadInt64(&accepted), atomic.LoadInt64(&executed)
+	if mode == "Shutdown" && e != a {
+		t.Fatalf("Shutdown: executed %d tasks, accepted %d", e, a)
+	}
+	if e > a {
+		t.Fatalf("%s: executed %d tasks, more than the %d accepted", mode, e, a)
+	}
+}
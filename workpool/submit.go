@@ -0,0 +1,83 @@
+package workpool
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+
+	"github.com/RobinTsai/tearUpGo/workpool/xsync"
+)
+
+// funcWorkload 将普通函数适配为 IWorkload
+type funcWorkload func()
+
+func (f funcWorkload) Work() {
+	f()
+}
+
+// ctxFuncWorkload 将接收 context 的函数适配为 IWorkloadWithContext
+type ctxFuncWorkload func(ctx context.Context)
+
+func (f ctxFuncWorkload) Work() {
+	f(context.Background())
+}
+
+func (f ctxFuncWorkload) WorkContext(ctx context.Context) {
+	f(ctx)
+}
+
+// errFuncWorkload 将返回 error 的函数适配为 IErrWorkload，错误交给工作池的错误处理函数
+type errFuncWorkload struct {
+	f   func() error
+	p   *Workerpool
+	err error // 重试后最终的错误，见 Err
+}
+
+func (w *errFuncWorkload) Work() {
+	err := w.f()
+	for i, n := 0, int(atomic.LoadInt32(&w.p.retries)); err != nil && i < n; i++ {
+		err = w.f()
+	}
+	if w.err = err; err != nil {
+		w.p.handleError(err)
+	}
+}
+
+func (w *errFuncWorkload) Err() error {
+	return w.err
+}
+
+// SubmitFunc 提交一个普通函数，无需为其定义 IWorkload 类型
+func (p *Workerpool) SubmitFunc(f func()) error {
+	return p.AddTask(funcWorkload(f))
+}
+
+// SubmitFuncContext 以 AddTaskContext 提交 f，执行时 f 收到的是提交时的 ctx
+func (p *Workerpool) SubmitFuncContext(ctx context.Context, f func(ctx context.Context)) error {
+	return p.AddTaskContext(ctx, ctxFuncWorkload(f))
+}
+
+// SubmitErrFunc 提交一个返回 error 的函数，非 nil 的错误交给 WithErrorHandler 设置的函数处理（设置了 WithRetries 时先重试）
+func (p *Workerpool) SubmitErrFunc(f func() error) error {
+	return p.AddTask(&errFuncWorkload{f: f, p: p})
+}
+
+// handleError 处理任务返回的错误，默认打印日志
+func (p *Workerpool) handleError(err error) {
+	p.logEvent("fail", 0, 0, err)
+	if p.errHandler != nil {
+		p.errHandler(err)
+		return
+	}
+	log.Printf("Error: task failed: %v\n", err)
+}
+
+// SubmitFuture 提交一个有返回值的函数，返回的 Future 在函数执行完后得到其结果。
+// 方法不能带类型参数，因此以工作池为第一个参数的函数形式提供
+func SubmitFuture[T any](p *Workerpool, f func() (T, error)) (*xsync.Future[T], error) {
+	promise, future := xsync.NewPromise[T]()
+	if err := p.SubmitFunc(func() { promise.Set(f()) }); err != nil {
+		return nil, err
+	}
+	return future, nil
+}
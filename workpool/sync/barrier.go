@@ -0,0 +1,120 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrBrokenBarrier 屏障已损坏时 Await 返回，见 Barrier
+var ErrBrokenBarrier = errors.New("sync: broken barrier")
+
+// Barrier 是可重复使用的屏障：n 个参与者各自调用 Await，最后一个到达的参与者先执行 action（可为 nil），
+// 然后所有参与者一起继续，屏障随即进入下一轮，适合在工作池上分阶段处理批量任务。
+//
+// 某个等待中的参与者的 ctx 结束时屏障损坏：该参与者返回 ctx.Err()，
+// 本轮其他等待者以及之后的 Await 都返回 ErrBrokenBarrier，直到调用 Reset
+type Barrier struct {
+	mu      sync.Mutex
+	n       int    // 每轮的参与者数目
+	action  func() // 每轮最后一个参与者到达时执行
+	arrived int    // 本轮已到达的参与者数目
+	gen     *generation
+}
+
+// generation 是屏障的一轮
+type generation struct {
+	release chan struct{} // 本轮结束（放行或损坏）时关闭
+	broken  bool
+}
+
+func NewBarrier(n int) *Barrier {
+	return NewCyclicBarrier(n, nil)
+}
+
+// NewCyclicBarrier 创建每轮有 parties 个参与者的屏障，每轮最后一个到达的参与者在放行其他参与者之前执行 action
+func NewCyclicBarrier(parties int, action func()) *Barrier {
+	if parties < 1 {
+		parties = 1
+	}
+	return &Barrier{n: parties, action: action, gen: &generation{release: make(chan struct{})}}
+}
+
+// Await 阻塞直到本轮 n 个参与者都已调用 Await，与 AwaitContext(context.Background()) 相同
+func (b *Barrier) Await() error {
+	return b.AwaitContext(context.Background())
+}
+
+// AwaitContext 阻塞直到本轮 n 个参与者都已到达，ctx 先结束时使屏障损坏并返回 ctx.Err()
+func (b *Barrier) AwaitContext(ctx context.Context) error {
+	b.mu.Lock()
+	g := b.gen
+	if g.broken {
+		b.mu.Unlock()
+		return ErrBrokenBarrier
+	}
+	b.arrived++
+	if b.arrived == b.n { // 最后一个到达，放行本轮并开始下一轮
+		if b.action != nil {
+			b.action()
+		}
+		b.nextLocked()
+		b.mu.Unlock()
+		return nil
+	}
+	b.mu.Unlock()
+
+	select {
+	case <-g.release:
+	case <-ctx.Done():
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if g == b.gen && !g.broken { // 本轮还未结束，由当前参与者使其损坏
+			b.breakLocked()
+			return ctx.Err()
+		}
+	}
+	if g.broken {
+		return ErrBrokenBarrier
+	}
+	return nil
+}
+
+// Reset 使屏障回到初始状态；本轮还有参与者在等待时，它们返回 ErrBrokenBarrier
+func (b *Barrier) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.gen.broken && b.arrived > 0 {
+		b.breakLocked()
+	}
+	b.nextLocked()
+}
+
+// IsBroken 返回屏障是否已损坏
+func (b *Barrier) IsBroken() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.gen.broken
+}
+
+// Waiting 返回本轮正在等待的参与者数目
+func (b *Barrier) Waiting() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.arrived
+}
+
+func (b *Barrier) breakLocked() {
+	b.gen.broken = true
+	close(b.gen.release)
+	b.arrived = 0
+}
+
+// nextLocked 放行本轮（已损坏的除外）并开始新的一轮
+func (b *Barrier) nextLocked() {
+	if !b.gen.broken {
+		close(b.gen.release)
+	}
+	b.gen = &generation{release: make(chan struct{})}
+	b.arrived = 0
+}
@@ -0,0 +1,83 @@
+package sync
+
+import (
+	"context"
+	stdsync "sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBarrier(t *testing.T) {
+	const parties, rounds = 4, 50
+	b := NewBarrier(parties)
+	var phase int64
+	var wg stdsync.WaitGroup
+	for i := 0; i < parties; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := int64(0); r < rounds; r++ {
+				if p := atomic.LoadInt64(&phase); p/parties != r {
+					t.Errorf("round %d: participant ahead of barrier, phase %d", r, p)
+				}
+				b.Await()
+				atomic.AddInt64(&phase, 1)
+				b.Await() // 所有参与者都记录完本轮之后再进入下一轮
+			}
+		}()
+	}
+	wg.Wait()
+	if phase != parties*rounds {
+		t.Errorf("phase = %d, want %d", phase, parties*rounds)
+	}
+}
+
+func TestCyclicBarrier(t *testing.T) {
+	const parties, rounds = 3, 20
+	var actions int
+	b := NewCyclicBarrier(parties, func() { actions++ })
+	var wg stdsync.WaitGroup
+	for i := 0; i < parties; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := 0; r < rounds; r++ {
+				if err := b.AwaitContext(context.Background()); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if actions != rounds {
+		t.Errorf("action ran %d times, want %d", actions, rounds)
+	}
+}
+
+func TestBarrierBroken(t *testing.T) {
+	b := NewBarrier(3)
+	errc := make(chan error, 1)
+	go func() { errc <- b.Await() }()
+	for b.Waiting() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := b.AwaitContext(ctx); err != context.DeadlineExceeded {
+		t.Errorf("canceled Await = %v, want DeadlineExceeded", err)
+	}
+	if err := <-errc; err != ErrBrokenBarrier {
+		t.Errorf("waiting Await = %v, want ErrBrokenBarrier", err)
+	}
+	if err := b.Await(); err != ErrBrokenBarrier {
+		t.Errorf("Await on broken barrier = %v, want ErrBrokenBarrier", err)
+	}
+
+	b.Reset()
+	if b.IsBroken() {
+		t.Error("barrier still broken after Reset")
+	}
+}
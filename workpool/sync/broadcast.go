@@ -0,0 +1,91 @@
+package sync
+
+import (
+	"context"
+	"sync"
+)
+
+// Broadcaster 是基于 ElasticBuf 的缓冲发布订阅：写入 In 的每个元素都会分发给所有订阅者，
+// 而不是只交给其中一个。每个订阅者有独立的 ElasticBuf 作为缓冲，慢订阅者不会阻塞其他订阅者。
+// 关闭 In 后，各订阅者取完已缓冲的元素，其通道随即关闭
+type Broadcaster struct {
+	In chan interface{}
+
+	mu     sync.Mutex // 保护 subs 和 closed，分发期间持有
+	subs   map[<-chan interface{}]*ElasticBuf
+	closed bool // In 已关闭或 ctx 已结束
+}
+
+func NewBroadcaster() *Broadcaster {
+	return &Broadcaster{
+		In:   make(chan interface{}, defaultChanSize),
+		subs: make(map[<-chan interface{}]*ElasticBuf),
+	}
+}
+
+// Subscribe 注册一个订阅者，返回的通道会收到此后写入 In 的每个元素。
+// Broadcaster 已结束时返回已关闭的通道
+func (b *Broadcaster) Subscribe() <-chan interface{} {
+	eb := NewElasticBuf()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		eb.Close()
+		return eb.Out
+	}
+	eb.Run(context.Background())
+	b.subs[eb.Out] = eb
+	return eb.Out
+}
+
+// Unsubscribe 注销订阅者并关闭其通道，还未取走的元素被丢弃
+func (b *Broadcaster) Unsubscribe(ch <-chan interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if eb, ok := b.subs[ch]; ok {
+		delete(b.subs, ch)
+		eb.Close()
+	}
+}
+
+// Run 启动分发的协程，ctx 结束时立即关闭所有订阅者，丢弃还未取走的元素
+func (b *Broadcaster) Run(ctx context.Context) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	go func() {
+		for {
+			select {
+			case e, ok := <-b.In:
+				if !ok {
+					b.shutdown(func(eb *ElasticBuf) { close(eb.In) })
+					return
+				}
+				b.publish(e)
+			case <-ctx.Done():
+				b.shutdown((*ElasticBuf).Close)
+				return
+			}
+		}
+	}()
+}
+
+// publish 把 e 放入每个订阅者的缓冲，订阅者的缓冲不限容量，不会长时间阻塞
+func (b *Broadcaster) publish(e interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, eb := range b.subs {
+		eb.In <- e
+	}
+}
+
+// shutdown 对每个订阅者执行 stop 并清空订阅，之后 Subscribe 返回已关闭的通道
+func (b *Broadcaster) shutdown(stop func(eb *ElasticBuf)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for ch, eb := range b.subs {
+		delete(b.subs, ch)
+		stop(eb)
+	}
+}
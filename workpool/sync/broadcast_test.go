@@ -0,0 +1,36 @@
+package sync
+
+import (
+	"context"
+	"testing"
+)
+
+func TestBroadcaster(t *testing.T) {
+	b := NewBroadcaster()
+	b.Run(context.Background())
+	subs := []<-chan interface{}{b.Subscribe(), b.Subscribe()}
+	dropped := b.Subscribe()
+	b.Unsubscribe(dropped)
+
+	const n = 10
+	for i := 0; i < n; i++ {
+		b.In <- i
+	}
+	close(b.In)
+
+	for k, ch := range subs {
+		i := 0
+		for e := range ch {
+			if e != i {
+				t.Fatalf("subscriber %d got %v, want %d", k, e, i)
+			}
+			i++
+		}
+		if i != n {
+			t.Errorf("subscriber %d got %d items, want %d", k, i, n)
+		}
+	}
+	if _, ok := <-dropped; ok {
+		t.Error("unsubscribed channel is not closed")
+	}
+}
@@ -0,0 +1,94 @@
+package sync
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrDequeClosed 向已关闭的 Deque 放入元素时返回
+var ErrDequeClosed = errors.New("sync: Deque is closed")
+
+// Deque 是互斥锁保护的双端队列，可作为 ElasticBuf 的轻量替代：
+// 没有 Run 的协程，元素也不经过 In/Out 两次通道拷贝，生产者和消费者直接调用 Push/Pop。
+// 代价是不能和其他通道一起 select
+type Deque struct {
+	mu       sync.Mutex
+	nonEmpty *sync.Cond // 有新元素或关闭时通知等待的 PopFront
+	r        ring
+	closed   bool
+}
+
+func NewDeque() *Deque {
+	d := &Deque{}
+	d.nonEmpty = sync.NewCond(&d.mu)
+	return d
+}
+
+// PushBack 在队尾放入元素，关闭后返回 ErrDequeClosed
+func (d *Deque) PushBack(e interface{}) error {
+	return d.push(e, (*ring).push)
+}
+
+// PushFront 在队首放入元素，使其最先被取出，关闭后返回 ErrDequeClosed
+func (d *Deque) PushFront(e interface{}) error {
+	return d.push(e, (*ring).pushFront)
+}
+
+func (d *Deque) push(e interface{}, push func(r *ring, e interface{})) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return ErrDequeClosed
+	}
+	push(&d.r, e)
+	d.nonEmpty.Signal()
+	return nil
+}
+
+// PopFront 取出队首元素，队列为空时阻塞；关闭且已取空时返回 false
+func (d *Deque) PopFront() (interface{}, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for d.r.len() == 0 && !d.closed {
+		d.nonEmpty.Wait()
+	}
+	if d.r.len() == 0 {
+		return nil, false
+	}
+	return d.r.pop(), true
+}
+
+// TryPopFront 非阻塞地取出队首元素，队列为空时返回 false
+func (d *Deque) TryPopFront() (interface{}, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.r.len() == 0 {
+		return nil, false
+	}
+	return d.r.pop(), true
+}
+
+// TryPopBack 非阻塞地取出队尾元素，队列为空时返回 false
+func (d *Deque) TryPopBack() (interface{}, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.r.len() == 0 {
+		return nil, false
+	}
+	return d.r.popBack(), true
+}
+
+// Len 返回队列中的元素个数
+func (d *Deque) Len() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.r.len()
+}
+
+// Close 关闭队列，之后不能再放入元素，已有的元素仍可取出；可重复调用
+func (d *Deque) Close() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.closed = true
+	d.nonEmpty.Broadcast()
+}
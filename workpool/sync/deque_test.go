@@ -0,0 +1,42 @@
+package sync
+
+import "testing"
+
+func TestDeque(t *testing.T) {
+	d := NewDeque()
+	for i := 1; i <= 3; i++ {
+		d.PushBack(i)
+	}
+	d.PushFront(0)
+	if e, _ := d.TryPopBack(); e != 3 {
+		t.Errorf("TryPopBack = %v, want 3", e)
+	}
+	d.Close()
+	if err := d.PushBack(4); err != ErrDequeClosed {
+		t.Errorf("PushBack after Close = %v, want ErrDequeClosed", err)
+	}
+	for want := 0; want < 3; want++ {
+		if e, ok := d.PopFront(); !ok || e != want {
+			t.Fatalf("PopFront = %v, %v, want %d", e, ok, want)
+		}
+	}
+	if _, ok := d.PopFront(); ok {
+		t.Error("PopFront on closed empty deque returned ok")
+	}
+}
+
+// BenchmarkDeque 与 BenchmarkElasticBuf 对照，一个生产者一个消费者
+func BenchmarkDeque(b *testing.B) {
+	d := NewDeque()
+	var e interface{} = 1
+	b.ReportAllocs()
+	b.ResetTimer()
+	go func() {
+		for i := 0; i < b.N; i++ {
+			d.PushBack(e)
+		}
+	}()
+	for i := 0; i < b.N; i++ {
+		d.PopFront()
+	}
+}
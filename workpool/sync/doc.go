@@ -0,0 +1,12 @@
+// Package sync 提供工作池所用的缓冲和队列：ElasticBuf（无界的弹性通道缓冲）、Deque、
+// Broadcaster、ErrGroup 等，独立的无锁队列 MPSC，以及测试中检查协程泄漏的 VerifyNone。
+// 包名与标准库相同，与标准库同时使用时需要给其中一个起别名，本模块的惯例是
+//
+//	import (
+//		stdsync "sync"
+//
+//		"github.com/RobinTsai/tearUpGo/workpool/sync"
+//	)
+//
+// 兼容性保证见 workpool 包的文档
+package sync
@@ -0,0 +1,531 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultChanSize = 2
+)
+
+var (
+	// ErrBufStarted 重复调用 Run 时返回
+	ErrBufStarted = errors.New("sync: ElasticBuf already started")
+	// ErrBufClosed 在 Close 之后调用 Run 时返回，ElasticBuf 不支持关闭后重新启动
+	ErrBufClosed = errors.New("sync: ElasticBuf is closed")
+)
+
+// OverflowPolicy 是 buf 达到容量上限后对新元素的处理策略
+type OverflowPolicy int
+
+const (
+	Block      OverflowPolicy = iota // 阻塞发送方，直到有空间（默认）
+	DropOldest                       // 丢弃 buf 中最早的元素，为新元素腾出空间；按优先级出队时丢弃优先级最低的元素，后进先出时丢弃栈底的元素
+	DropNewest                       // 丢弃新到达的元素
+)
+
+// Expirable 是带过期时间的元素，已过期的元素不会再写给 Out，ExpiresAt 为零值时永不过期
+type Expirable interface {
+	ExpiresAt() time.Time
+}
+
+// Expiring 把任意元素包装为 Expirable，Out 输出的仍是 Expiring 本身
+type Expiring struct {
+	Value    interface{}
+	Deadline time.Time
+}
+
+// ExpiresAt 实现 Expirable
+func (e Expiring) ExpiresAt() time.Time {
+	return e.Deadline
+}
+
+type ElasticBuf struct {
+	_ noCopy
+
+	n       int64 // buf 的长度，由 Run 的协程原子更新，供 Len 并发读取
+	In, Out chan interface{}
+	buf     store
+	max     int // buf 的容量上限，0 表示不限
+
+	policy  OverflowPolicy    // buf 满时的处理策略
+	onDrop  func(interface{}) // 元素被丢弃时的回调
+	dropped uint64            // 被丢弃的元素个数，原子操作
+
+	onExpire func(interface{}) // 元素过期时的回调
+	expired  uint64            // 过期的元素个数，原子操作
+
+	highMark, lowMark int    // 水位线，见 OnHighWater 和 OnLowWater
+	onHigh, onLow     func() // 越过水位线时的回调
+	high              bool   // 已触发 onHigh，且还未回落到低水位
+
+	key     func(interface{}) interface{} // 去重用的键，见 SetDedup
+	keys    map[interface{}]struct{}      // buf 中元素的键
+	deduped uint64                        // 因重复而被合并的元素个数，原子操作
+
+	mu        sync.Mutex    // 串行化 do，并保护 started 和 closed
+	started   bool          // 是否已调用 Run
+	closed    bool          // 是否已调用 Close
+	ops       chan func()   // 交给 Run 的协程执行的操作，见 do
+	done      chan struct{} // Run 的协程退出时关闭
+	stopped   bool          // 由 ops 中的操作设置，使 Run 的协程退出
+	outClosed bool          // Out 是否已关闭
+
+	stats     BufStats  // 运行统计，只在 Run 的协程中更新，见 Stats
+	zeroSince time.Time // buf 最近一次变为空的时间，buf 非空时为零值
+}
+
+// BufStats 是 ElasticBuf 的累计运行统计，用于容量规划
+type BufStats struct {
+	MaxLen   int           // buf 积压的最大长度
+	TotalIn  uint64        // 从 In 读取的元素总数，包括因溢出被丢弃的
+	TotalOut uint64        // 从 buf 写给 Out 的元素总数
+	IdleTime time.Duration // buf 为空的累计时间
+}
+
+func NewElasticBuf() *ElasticBuf {
+	return &ElasticBuf{
+		In:   make(chan interface{}, defaultChanSize),
+		Out:  make(chan interface{}, defaultChanSize),
+		buf:  &ring{},
+		ops:  make(chan func()),
+		done: make(chan struct{}),
+	}
+}
+
+// NewElasticBufCap 创建 buf 最多缓存 max 个元素的 ElasticBuf
+// buf 满时不再从 In 读取，向 In 发送会阻塞直到有空间，max <= 0 时不限容量
+func NewElasticBufCap(max int) *ElasticBuf {
+	eb := NewElasticBuf()
+	eb.SetCap(max)
+	return eb
+}
+
+// SetCap 设置 buf 的容量上限，max <= 0 时不限容量，可在运行中调用。
+// 缩小上限时已经积压的元素不会被丢弃，只是在降到上限以下之前不再从 In 读取
+func (eb *ElasticBuf) SetCap(max int) {
+	if max < 0 {
+		max = 0
+	}
+	eb.do(func() { eb.max = max })
+}
+
+// NewElasticBufOrdered 创建按优先级出队的 ElasticBuf，内部用堆维护 buf，
+// Out 总是先输出 less 意义下最小的元素；相同优先级的元素之间不保证先后顺序
+func NewElasticBufOrdered(less func(a, b interface{}) bool) *ElasticBuf {
+	eb := NewElasticBuf()
+	eb.buf = &heapStore{less: less}
+	return eb
+}
+
+// NewElasticBufLIFO 创建后进先出的 ElasticBuf，Out 总是先输出最新放入 buf 的元素，
+// 适合越新的任务缓存越热、截止时间越宽裕的场景；积压严重时旧元素可能长期得不到输出
+func NewElasticBufLIFO() *ElasticBuf {
+	eb := NewElasticBuf()
+	eb.buf = &stack{}
+	return eb
+}
+
+// SetOverflow 设置 buf 满时的处理策略以及元素被丢弃时的回调（可为 nil），
+// 只对设置了容量的 ElasticBuf 有效，需在 Run 之前调用。
+// 回调在 Run 的协程中执行，不应阻塞
+func (eb *ElasticBuf) SetOverflow(policy OverflowPolicy, onDrop func(e interface{})) {
+	eb.policy = policy
+	eb.onDrop = onDrop
+}
+
+// SetSpill 开启磁盘溢出：buf 中超过 conf.Threshold 的元素经 conf.Codec 编码后写入临时文件，
+// 积压减少时再按顺序读回，从而在限制内存的同时保持 FIFO 顺序。
+// 只支持 FIFO 的 ElasticBuf，需在 Run 之前调用；容量上限 SetCap 仍然按全部积压计算
+func (eb *ElasticBuf) SetSpill(conf SpillConfig) error {
+	if _, ok := eb.buf.(*ring); !ok {
+		return ErrSpillUnsupported
+	}
+	eb.buf = newSpillStore(conf, eb.drop)
+	return nil
+}
+
+// SetChanSize 重新设置 In 和 Out 的缓冲大小，小于 0 时保持默认值，需在 Run 之前、向 In 发送之前调用。
+// 吞吐量大时较大的 Out 缓冲可以减少 worker 等待 Run 的协程分发的次数
+func (eb *ElasticBuf) SetChanSize(in, out int) {
+	if in >= 0 {
+		eb.In = make(chan interface{}, in)
+	}
+	if out >= 0 {
+		eb.Out = make(chan interface{}, out)
+	}
+}
+
+// SetExpire 设置元素过期时的回调，需在 Run 之前调用，回调在 Run 的协程中执行，不应阻塞。
+// 只检查 buf 中的元素，In/Out 通道缓冲中的少量元素不检查
+func (eb *ElasticBuf) SetExpire(onExpire func(e interface{})) {
+	eb.onExpire = onExpire
+}
+
+// Expired 返回因过期而被丢弃的元素个数
+func (eb *ElasticBuf) Expired() uint64 {
+	return atomic.LoadUint64(&eb.expired)
+}
+
+// expiresAt 返回 e 的过期时间，不会过期时返回零值
+func expiresAt(e interface{}) time.Time {
+	if x, ok := e.(Expirable); ok {
+		return x.ExpiresAt()
+	}
+	return time.Time{}
+}
+
+// dropExpired 丢弃 buf 头部已过期的元素
+func (eb *ElasticBuf) dropExpired() {
+	now := time.Now()
+	for eb.buf.len() > 0 {
+		deadline := expiresAt(eb.buf.peek())
+		if deadline.IsZero() || now.Before(deadline) {
+			return
+		}
+		e := eb.pop()
+		atomic.AddUint64(&eb.expired, 1)
+		if eb.onExpire != nil {
+			eb.onExpire(e)
+		}
+	}
+}
+
+// SetDedup 开启去重：从 In 读到的元素若与 buf 中某个元素的键相同，则直接丢弃，
+// 元素被写给 Out 之后同样的键可以再次进入 buf。key 的返回值需可作为 map 的键，需在 Run 之前调用。
+// 适合“刷新缓存项 X”这类重复排队毫无意义的任务
+func (eb *ElasticBuf) SetDedup(key func(e interface{}) interface{}) {
+	eb.key = key
+	eb.keys = make(map[interface{}]struct{})
+}
+
+// Deduped 返回因重复而被合并的元素个数
+func (eb *ElasticBuf) Deduped() uint64 {
+	return atomic.LoadUint64(&eb.deduped)
+}
+
+// duplicate 判断 e 的键是否已在 buf 中
+func (eb *ElasticBuf) duplicate(e interface{}) bool {
+	if eb.key == nil {
+		return false
+	}
+	if _, ok := eb.keys[eb.key(e)]; ok {
+		atomic.AddUint64(&eb.deduped, 1)
+		return true
+	}
+	return false
+}
+
+// OnHighWater 设置 buf 长度上升到 n 时的回调，
+// OnLowWater 设置 buf 长度从高于 n 下降到 n 时的回调。
+// 同时设置时两者交替触发：高水位触发后，要先回落到低水位才会再次触发，反之亦然，
+// 配合起来可让生产者按积压情况暂停和恢复，而不必轮询 Len。
+// 需在 Run 之前调用，回调在 Run 的协程中执行，不应阻塞
+func (eb *ElasticBuf) OnHighWater(n int, f func()) {
+	eb.highMark, eb.onHigh = n, f
+}
+
+// OnLowWater 见 OnHighWater
+func (eb *ElasticBuf) OnLowWater(n int, f func()) {
+	eb.lowMark, eb.onLow = n, f
+}
+
+// watermark 在 buf 长度从 prev 变为 n 时触发越过的水位线回调
+func (eb *ElasticBuf) watermark(prev, n int) {
+	if eb.onHigh != nil && !eb.high && n >= eb.highMark {
+		eb.high = true
+		eb.onHigh()
+	}
+	if prev > eb.lowMark && n <= eb.lowMark && (eb.onHigh == nil || eb.high) {
+		eb.high = false // 只设置了 OnHighWater 时，低水位默认为 0，即 buf 清空后可再次触发
+		if eb.onLow != nil {
+			eb.onLow()
+		}
+	}
+}
+
+// Dropped 返回因溢出（或磁盘溢出失败，见 SpillConfig）而被丢弃的元素个数
+func (eb *ElasticBuf) Dropped() uint64 {
+	return atomic.LoadUint64(&eb.dropped)
+}
+
+func (eb *ElasticBuf) drop(e interface{}) {
+	atomic.AddUint64(&eb.dropped, 1)
+	if eb.onDrop != nil {
+		eb.onDrop(e)
+	}
+}
+
+// overflow 在 buf 已满时按策略放入新元素 e
+func (eb *ElasticBuf) overflow(e interface{}) {
+	switch eb.policy {
+	case DropOldest:
+		ev, ok := eb.buf.(evicter)
+		if !ok { // FIFO：队首就是最早的元素
+			oldest := eb.pop()
+			eb.push(e)
+			eb.drop(oldest)
+			return
+		}
+		dropped, kept := ev.evict(e) // 元素个数不变，不需要更新长度和统计
+		if kept && eb.key != nil {
+			delete(eb.keys, eb.key(dropped))
+			eb.keys[eb.key(e)] = struct{}{}
+		}
+		eb.drop(dropped)
+	default:
+		eb.drop(e)
+	}
+}
+
+// full 判断 buf 是否已达容量上限
+func (eb *ElasticBuf) full() bool {
+	return eb.max > 0 && eb.buf.len() >= eb.max
+}
+
+func (eb *ElasticBuf) push(e interface{}) {
+	eb.buf.push(e)
+	if eb.key != nil {
+		eb.keys[eb.key(e)] = struct{}{}
+	}
+	n := eb.buf.len()
+	prev := int(atomic.SwapInt64(&eb.n, int64(n)))
+	eb.watermark(prev, n)
+	if n > eb.stats.MaxLen {
+		eb.stats.MaxLen = n
+	}
+	if !eb.zeroSince.IsZero() {
+		eb.stats.IdleTime += time.Since(eb.zeroSince)
+		eb.zeroSince = time.Time{}
+	}
+}
+
+func (eb *ElasticBuf) pop() interface{} {
+	e := eb.buf.pop()
+	if eb.key != nil {
+		delete(eb.keys, eb.key(e))
+	}
+	n := eb.buf.len() // 溢出到磁盘失败时 buf 可能少于预期，直接读取长度
+	prev := int(atomic.SwapInt64(&eb.n, int64(n)))
+	eb.watermark(prev, n)
+	if n == 0 {
+		eb.zeroSince = time.Now()
+	}
+	return e
+}
+
+// Stats 返回累计运行统计，可并发调用
+func (eb *ElasticBuf) Stats() BufStats {
+	var s BufStats
+	eb.do(func() {
+		s = eb.stats
+		if !eb.zeroSince.IsZero() {
+			s.IdleTime += time.Since(eb.zeroSince)
+		}
+	})
+	return s
+}
+
+// Len 返回还未被取走的元素个数，包括 buf 中的积压以及 In/Out 两个通道中缓冲的元素，可并发调用。
+// 三者不是同时读取的，元素在其间流转时结果可能短暂偏差一个
+func (eb *ElasticBuf) Len() int {
+	return int(atomic.LoadInt64(&eb.n)) + len(eb.In) + len(eb.Out)
+}
+
+// Run 启动 eb 的处理协程，只能调用一次，重复调用返回 ErrBufStarted，Close 之后调用返回 ErrBufClosed。
+// ctx 用于立即关闭 eb 的处理
+// 关闭 eb.In 时为优雅关闭——会将所有存在 buf 中的信息都从 Out 中读走再结束 eb
+func (eb *ElasticBuf) Run(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background() // 永远不会主动结束
+	}
+
+	in := eb.In // 使用局部变量，关闭后置为 nil 时不会与外部读取 eb.In 竞争
+	run := func() {
+		defer close(eb.done)
+		for {
+			eb.dropExpired()
+			var out chan interface{} // buf 为空时为 nil，不会被 select 选中
+			var head interface{}
+			var timer *time.Timer // head 等待 Out 期间到期时唤醒，以便将其丢弃
+			var expire <-chan time.Time
+			if eb.buf.len() > 0 {
+				out, head = eb.Out, eb.buf.peek()
+				if deadline := expiresAt(head); !deadline.IsZero() {
+					timer = time.NewTimer(time.Until(deadline))
+					expire = timer.C
+				}
+			} else if in == nil { // In 已关闭且 buf 已全部写给 Out，关闭 Out
+				eb.closeOut()
+				return
+			}
+			inCh := in
+			if eb.full() && eb.policy == Block { // buf 已满，暂停读取 In，使发送方阻塞
+				inCh = nil
+			}
+
+			select {
+			case e, ok := <-inCh:
+				if !ok { // In 关闭，将 in 设置为 nil，即永久阻塞，以便将所有数据都写给 Out
+					in = nil
+					break
+				}
+				eb.stats.TotalIn++
+				if eb.duplicate(e) {
+					break
+				}
+				if eb.full() {
+					eb.overflow(e)
+					break
+				}
+				eb.push(e)
+			case out <- head:
+				eb.pop()
+				eb.stats.TotalOut++
+			case op := <-eb.ops:
+				op()
+				if eb.stopped {
+					return
+				}
+			case <-expire:
+			case <-ctx.Done():
+				return
+			}
+			if timer != nil {
+				timer.Stop()
+			}
+		}
+	}
+
+	eb.mu.Lock()
+	defer eb.mu.Unlock()
+	if eb.closed {
+		return ErrBufClosed
+	}
+	if eb.started {
+		return ErrBufStarted
+	}
+	eb.started = true
+	if eb.buf.len() == 0 {
+		eb.zeroSince = time.Now()
+	}
+	go run()
+	return nil
+}
+
+// do 在拥有 buf 的协程中执行 f 并等待其完成；Run 未启动或已退出时直接在当前协程执行
+func (eb *ElasticBuf) do(f func()) {
+	eb.mu.Lock()
+	defer eb.mu.Unlock()
+	if eb.started {
+		finished := make(chan struct{})
+		select {
+		case eb.ops <- func() { f(); close(finished) }:
+			<-finished
+			return
+		case <-eb.done:
+		}
+	}
+	f()
+}
+
+func (eb *ElasticBuf) closeOut() {
+	if !eb.outClosed {
+		eb.outClosed = true
+		close(eb.Out)
+	}
+}
+
+// BufSnapshot 是 ElasticBuf 某一时刻的状态，用于调试
+type BufSnapshot struct {
+	Items  []interface{} // buf 中积压的元素的拷贝，按出队顺序
+	InLen  int           // In 通道中缓冲的元素个数
+	OutLen int           // Out 通道中缓冲的元素个数
+}
+
+// Snapshot 返回当前状态的拷贝，可并发调用，不影响元素的流转
+func (eb *ElasticBuf) Snapshot() BufSnapshot {
+	var s BufSnapshot
+	eb.do(func() {
+		s.Items = make([]interface{}, 0, eb.buf.len())
+		eb.buf.each(func(e interface{}) bool {
+			s.Items = append(s.Items, e)
+			return true
+		})
+		s.InLen, s.OutLen = len(eb.In), len(eb.Out)
+	})
+	return s
+}
+
+// Range 按出队顺序遍历 buf 中积压元素的一份一致快照，f 返回 false 时停止。
+// f 在调用方的协程中执行，期间元素照常流转，遍历到的元素可能已被取走
+func (eb *ElasticBuf) Range(f func(e interface{}) bool) {
+	for _, e := range eb.Snapshot().Items {
+		if !f(e) {
+			return
+		}
+	}
+}
+
+// Drain 同 CloseAndDrain
+func (eb *ElasticBuf) Drain() []interface{} {
+	return eb.CloseAndDrain()
+}
+
+// Close 立即停止 eb 并关闭 Out，丢弃所有还未被取走的元素并删除溢出文件。
+// 可重复调用，Run 之前调用也有效；之后 In 不再被读取，调用方需保证此后不再向 In 发送
+func (eb *ElasticBuf) Close() {
+	eb.CloseAndDrain()
+}
+
+// CloseAndDrain 与 Close 相同，但按先后顺序返回所有还未被取走的元素：
+// Out 和 In 通道中缓冲的，以及 buf 中积压的。重复调用返回空
+func (eb *ElasticBuf) CloseAndDrain() []interface{} {
+	var items []interface{}
+	eb.do(func() {
+		if eb.closed {
+			return
+		}
+		items = eb.drain()
+		if r, ok := eb.buf.(interface{ release() }); ok {
+			r.release()
+		}
+		eb.stopped = true
+		eb.closed = true
+	})
+	return items
+}
+
+// drain 取出全部元素并关闭 Out，需在拥有 buf 的协程中调用
+func (eb *ElasticBuf) drain() []interface{} {
+	items := make([]interface{}, 0, eb.buf.len()+len(eb.Out)+len(eb.In))
+	for !eb.outClosed {
+		select {
+		case e := <-eb.Out:
+			items = append(items, e)
+			continue
+		default:
+		}
+		break
+	}
+	for eb.buf.len() > 0 {
+		items = append(items, eb.pop())
+	}
+	for {
+		select {
+		case e, ok := <-eb.In:
+			if ok {
+				items = append(items, e)
+				continue
+			}
+		default:
+		}
+		break
+	}
+	eb.closeOut()
+	return items
+}
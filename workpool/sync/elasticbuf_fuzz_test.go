@@ -0,0 +1,102 @@
+package sync
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// FuzzElasticBuf 按 ops 交替执行向 In 发送、从 Out 接收、关闭 In、Close 和取消 ctx，
+// 检查每个发送的元素恰好被取走一次（从 Out 接收或由 CloseAndDrain 返回）且保持 FIFO 顺序，
+// 并且 Run 的协程最终退出。
+//
+// ops 的第一个字节决定 In 和 Out 的缓冲大小，其余每个字节低 3 位为一个操作：
+// 0-3 发送，4-5 接收，6 关闭 In 或 Close，7 取消 ctx；关闭或取消之后结束
+func FuzzElasticBuf(f *testing.F) {
+	f.Add([]byte{0, 0, 0, 0, 4, 4, 4})
+	f.Add([]byte{1, 0, 1, 2, 4, 6})
+	f.Add([]byte{2, 0, 0, 4, 0, 7})
+	f.Add([]byte{0x13, 0, 0, 0, 0, 0, 14})
+	f.Add([]byte{3, 0, 4, 0, 4, 0, 4})
+	f.Fuzz(fuzzOne)
+}
+
+func fuzzOne(t *testing.T, ops []byte) {
+	if len(ops) == 0 {
+		return
+	}
+	eb := NewElasticBuf()
+	eb.SetChanSize(int(ops[0]&3), int(ops[0]>>2&3))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := eb.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	sent := 0
+	var got []interface{}
+	recv := func() (interface{}, bool) {
+		select {
+		case e, ok := <-eb.Out:
+			return e, ok
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no element from Out after %d sent, %d received", sent, len(got))
+			return nil, false
+		}
+	}
+
+	end := func() { got = append(got, eb.CloseAndDrain()...) } // 没有关闭操作时最后用 Close 收尾
+loop:
+	for _, op := range ops[1:] {
+		switch op & 7 {
+		case 0, 1, 2, 3:
+			eb.In <- sent
+			sent++
+		case 4, 5:
+			if len(got) < sent { // 还有在途的元素，最终一定会到达 Out
+				e, _ := recv()
+				got = append(got, e)
+			}
+		case 6:
+			if op&8 != 0 {
+				end = func() {
+					close(eb.In) // 优雅关闭：所有元素都从 Out 输出后 Out 被关闭
+					for {
+						e, ok := recv()
+						if !ok {
+							return
+						}
+						got = append(got, e)
+					}
+				}
+			}
+			break loop
+		case 7:
+			end = func() {
+				cancel() // 立即停止后元素仍留在 buf 和通道中，可以由 CloseAndDrain 取回
+				select {
+				case <-eb.done:
+				case <-time.After(5 * time.Second):
+					t.Fatal("Run did not return after ctx was cancelled")
+				}
+				got = append(got, eb.CloseAndDrain()...)
+			}
+			break loop
+		}
+	}
+	end()
+
+	if len(got) != sent {
+		t.Fatalf("got %d elements, sent %d: %v", len(got), sent, got)
+	}
+	for i, e := range got {
+		if e != i {
+			t.Fatalf("element %d = %v, want %d (lost, duplicated or reordered): %v", i, e, i, got)
+		}
+	}
+	select {
+	case <-eb.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run goroutine leaked")
+	}
+}
@@ -0,0 +1,653 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRingWrapAndGrow(t *testing.T) {
+	var r ring
+	next, want := 0, 0
+	// 交替入队出队使 head 绕回，并在中途触发扩容
+	for round := 0; round < 10; round++ {
+		for i := 0; i < 7*round+3; i++ {
+			r.push(next)
+			next++
+		}
+		for i := 0; i < 5*round+2; i++ {
+			if got := r.pop(); got != want {
+				t.Fatalf("pop = %v, want %d", got, want)
+			}
+			want++
+		}
+	}
+	for r.len() > 0 {
+		if got := r.pop(); got != want {
+			t.Fatalf("pop = %v, want %d", got, want)
+		}
+		want++
+	}
+	if want != next {
+		t.Fatalf("popped %d elements, pushed %d", want, next)
+	}
+}
+
+func TestRingSteadyStateNoGrowth(t *testing.T) {
+	var r ring
+	for i := 0; i < 100; i++ {
+		r.push(i)
+	}
+	size := len(r.buf)
+	for i := 0; i < 100000; i++ {
+		r.push(i)
+		r.pop()
+	}
+	if len(r.buf) != size {
+		t.Errorf("ring grew from %d to %d in steady state", size, len(r.buf))
+	}
+}
+
+func TestRingShrinksAfterBurst(t *testing.T) {
+	var r ring
+	for i := 0; i < 10000; i++ {
+		r.push(i)
+	}
+	peak := len(r.buf)
+	for r.len() > 0 {
+		r.pop()
+	}
+	if len(r.buf) >= peak || len(r.buf) > minRingSize {
+		t.Errorf("ring size %d after draining a burst (peak %d)", len(r.buf), peak)
+	}
+}
+
+func TestElasticBufFIFO(t *testing.T) {
+	defer VerifyNone(t)
+	eb := NewElasticBuf()
+	eb.Run(context.Background())
+	const n = 1000
+	go func() {
+		for i := 0; i < n; i++ {
+			eb.In <- i
+		}
+		close(eb.In)
+	}()
+	want := 0
+	for e := range eb.Out {
+		if e != want {
+			t.Fatalf("got %v, want %d", e, want)
+		}
+		want++
+	}
+	if want != n {
+		t.Fatalf("got %d elements, want %d", want, n)
+	}
+}
+
+// BenchmarkRingSteadyState 入队出队交替进行，稳态下应当没有内存分配
+func BenchmarkRingSteadyState(b *testing.B) {
+	var r ring
+	for i := 0; i < 64; i++ {
+		r.push(i)
+	}
+	var e interface{} = 1
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.push(e)
+		r.pop()
+	}
+}
+
+// BenchmarkSliceSteadyState 是原先 buf = buf[1:] 的出队方式，作为对照
+func BenchmarkSliceSteadyState(b *testing.B) {
+	buf := make([]interface{}, 0, 64)
+	for i := 0; i < 64; i++ {
+		buf = append(buf, i)
+	}
+	var e interface{} = 1
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf = append(buf, e)
+		buf = buf[1:]
+	}
+}
+
+func BenchmarkElasticBuf(b *testing.B) {
+	eb := NewElasticBuf()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	eb.Run(ctx)
+	var e interface{} = 1
+	b.ReportAllocs()
+	b.ResetTimer()
+	go func() {
+		for i := 0; i < b.N; i++ {
+			eb.In <- e
+		}
+	}()
+	for i := 0; i < b.N; i++ {
+		<-eb.Out
+	}
+}
+
+func TestElasticBufDrain(t *testing.T) {
+	defer VerifyNone(t)
+	eb := NewElasticBuf()
+	eb.Run(context.Background())
+	const n = 10
+	for i := 0; i < n; i++ {
+		eb.In <- i
+	}
+
+	items := eb.Drain()
+	if len(items) != n {
+		t.Fatalf("Drain returned %d items, want %d", len(items), n)
+	}
+	for i, e := range items {
+		if e != i {
+			t.Fatalf("items[%d] = %v, want %d", i, e, i)
+		}
+	}
+	if _, ok := <-eb.Out; ok {
+		t.Error("Out is not closed after Drain")
+	}
+}
+
+type intCodec struct{}
+
+func (intCodec) Encode(e interface{}) ([]byte, error) { return []byte(strconv.Itoa(e.(int))), nil }
+func (intCodec) Decode(b []byte) (interface{}, error) { return strconv.Atoi(string(b)) }
+
+func TestElasticBufSpill(t *testing.T) {
+	defer VerifyNone(t)
+	dir := t.TempDir()
+	eb := NewElasticBuf()
+	if err := eb.SetSpill(SpillConfig{Threshold: 4, Dir: dir, Codec: intCodec{}}); err != nil {
+		t.Fatal(err)
+	}
+	eb.Run(context.Background())
+	const n = 100
+	for i := 0; i < n; i++ {
+		eb.In <- i
+	}
+	close(eb.In)
+
+	i := 0
+	for e := range eb.Out {
+		if e != i {
+			t.Fatalf("got %v, want %d", e, i)
+		}
+		i++
+	}
+	if i != n {
+		t.Errorf("got %d items, want %d", i, n)
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 0 {
+		t.Errorf("spill file not removed: %v", files)
+	}
+	if err := NewElasticBufLIFO().SetSpill(SpillConfig{Codec: intCodec{}}); err != ErrSpillUnsupported {
+		t.Errorf("SetSpill on LIFO = %v, want ErrSpillUnsupported", err)
+	}
+}
+
+// badCodec 无法解码 bad
+type badCodec struct {
+	intCodec
+	bad int
+}
+
+func (c badCodec) Decode(b []byte) (interface{}, error) {
+	e, err := c.intCodec.Decode(b)
+	if err == nil && e == c.bad {
+		return nil, errors.New("corrupt record")
+	}
+	return e, err
+}
+
+// 解码失败的记录被跳过并计为丢弃，后面的元素照常读回
+func TestElasticBufSpillDecodeError(t *testing.T) {
+	defer VerifyNone(t)
+	eb := NewElasticBuf()
+	var dropped []interface{}
+	var errs int
+	eb.SetCap(100)
+	eb.SetOverflow(DropNewest, func(e interface{}) { dropped = append(dropped, e) })
+	eb.SetSpill(SpillConfig{Threshold: 1, Dir: t.TempDir(), Codec: badCodec{bad: 3}, OnError: func(error) { errs++ }})
+	for i := 0; i < 6; i++ {
+		eb.push(i)
+	}
+	var got []interface{}
+	for eb.buf.len() > 0 {
+		got = append(got, eb.pop())
+	}
+	if want := []interface{}{0, 1, 2, 4, 5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if eb.Dropped() != 1 || len(dropped) != 1 || dropped[0] != nil || errs != 1 {
+		t.Errorf("Dropped = %d, callback %v, errors %d, want 1, [<nil>], 1", eb.Dropped(), dropped, errs)
+	}
+}
+
+// 文件不可读时剩下的元素全部计为丢弃
+func TestElasticBufSpillReadError(t *testing.T) {
+	defer VerifyNone(t)
+	eb := NewElasticBuf()
+	drops := 0
+	eb.SetOverflow(DropNewest, func(interface{}) { drops++ })
+	eb.SetSpill(SpillConfig{Threshold: 1, Dir: t.TempDir(), Codec: intCodec{}})
+	for i := 0; i < 5; i++ {
+		eb.push(i)
+	}
+	eb.buf.(*spillStore).file.Close() // 模拟读文件失败
+	if e := eb.pop(); e != 0 {
+		t.Errorf("pop = %v, want 0", e)
+	}
+	if n := eb.buf.len(); n != 0 {
+		t.Errorf("len = %d, want 0", n)
+	}
+	if eb.Dropped() != 4 || drops != 4 {
+		t.Errorf("Dropped = %d, callback calls %d, want 4, 4", eb.Dropped(), drops)
+	}
+}
+
+func TestElasticBufStats(t *testing.T) {
+	defer VerifyNone(t)
+	eb := NewElasticBuf()
+	eb.Run(context.Background())
+	const n = 10
+	for i := 0; i < n; i++ {
+		eb.In <- i
+	}
+	close(eb.In)
+	for range eb.Out {
+	}
+
+	s := eb.Stats()
+	if s.TotalIn != n {
+		t.Errorf("TotalIn = %d, want %d", s.TotalIn, n)
+	}
+	if s.TotalOut > n || s.MaxLen > n {
+		t.Errorf("TotalOut/MaxLen = %d/%d, want <= %d", s.TotalOut, s.MaxLen, n)
+	}
+	if s.IdleTime <= 0 {
+		t.Errorf("IdleTime = %v, want > 0", s.IdleTime)
+	}
+}
+
+func TestElasticBufClose(t *testing.T) {
+	defer VerifyNone(t)
+	eb := NewElasticBuf()
+	if err := eb.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := eb.Run(context.Background()); err != ErrBufStarted {
+		t.Errorf("second Run = %v, want ErrBufStarted", err)
+	}
+	eb.In <- 1
+	eb.In <- 2
+	if items := eb.CloseAndDrain(); len(items) != 2 {
+		t.Errorf("CloseAndDrain returned %v, want 2 items", items)
+	}
+	eb.Close() // 可重复调用
+	if items := eb.CloseAndDrain(); len(items) != 0 {
+		t.Errorf("CloseAndDrain after Close returned %v, want none", items)
+	}
+	if _, ok := <-eb.Out; ok {
+		t.Error("Out is not closed after Close")
+	}
+
+	eb = NewElasticBuf()
+	eb.Close()
+	if err := eb.Run(context.Background()); err != ErrBufClosed {
+		t.Errorf("Run after Close = %v, want ErrBufClosed", err)
+	}
+}
+
+func TestElasticBufChanSize(t *testing.T) {
+	defer VerifyNone(t)
+	eb := NewElasticBuf()
+	eb.SetChanSize(-1, 64)
+	if cap(eb.In) != defaultChanSize || cap(eb.Out) != 64 {
+		t.Fatalf("cap(In)/cap(Out) = %d/%d, want %d/64", cap(eb.In), cap(eb.Out), defaultChanSize)
+	}
+	eb.Run(context.Background())
+	for i := 0; i < 10; i++ {
+		eb.In <- i
+	}
+	close(eb.In)
+	i := 0
+	for e := range eb.Out {
+		if e != i {
+			t.Fatalf("got %v, want %d", e, i)
+		}
+		i++
+	}
+}
+
+func TestElasticBufExpire(t *testing.T) {
+	defer VerifyNone(t)
+	var expired []interface{}
+	eb := NewElasticBuf()
+	eb.SetExpire(func(e interface{}) { expired = append(expired, e) })
+	eb.Run(context.Background())
+
+	past, future := time.Now().Add(-time.Second), time.Now().Add(time.Hour)
+	eb.In <- Expiring{Value: 0, Deadline: past}
+	eb.In <- Expiring{Value: 1, Deadline: future}
+	eb.In <- Expiring{Value: 2, Deadline: past}
+	eb.In <- 3
+	close(eb.In)
+
+	var got []interface{}
+	for e := range eb.Out {
+		if x, ok := e.(Expiring); ok {
+			e = x.Value
+		}
+		got = append(got, e)
+	}
+	if len(got) != 2 || got[0] != 1 || got[1] != 3 {
+		t.Errorf("got %v, want [1 3]", got)
+	}
+	if len(expired) != 2 || eb.Expired() != 2 {
+		t.Errorf("expired %v (count %d), want 2 items", expired, eb.Expired())
+	}
+}
+
+func TestElasticBufDedup(t *testing.T) {
+	defer VerifyNone(t)
+	eb := NewElasticBuf()
+	eb.SetChanSize(-1, 0) // Out 无缓冲，使元素都停留在 buf 中
+	eb.SetDedup(func(e interface{}) interface{} { return e.(int) % 3 })
+	eb.Run(context.Background())
+	for i := 0; i < 9; i++ {
+		eb.In <- i
+	}
+	close(eb.In)
+
+	var got []interface{}
+	for e := range eb.Out {
+		got = append(got, e)
+	}
+	if len(got) != 3 || got[0] != 0 || got[1] != 1 || got[2] != 2 {
+		t.Errorf("got %v, want [0 1 2]", got)
+	}
+	if n := eb.Deduped(); n != 6 {
+		t.Errorf("Deduped = %d, want 6", n)
+	}
+}
+
+func TestElasticBufWatermark(t *testing.T) {
+	defer VerifyNone(t)
+	var events []string
+	eb := NewElasticBuf()
+	eb.SetChanSize(-1, 0)
+	eb.OnHighWater(4, func() { events = append(events, "high") })
+	eb.OnLowWater(1, func() { events = append(events, "low") })
+	eb.Run(context.Background())
+	for i := 0; i < 6; i++ {
+		eb.In <- i
+	}
+	close(eb.In)
+	for range eb.Out {
+	}
+	if len(events) != 2 || events[0] != "high" || events[1] != "low" {
+		t.Errorf("events = %v, want [high low]", events)
+	}
+}
+
+func TestElasticBufLen(t *testing.T) {
+	defer VerifyNone(t)
+	eb := NewElasticBuf()
+	eb.Run(context.Background())
+	const n = 10
+	for i := 0; i < n; i++ {
+		eb.In <- i
+	}
+	for deadline := time.Now().Add(time.Second); eb.Len() != n; {
+		if time.Now().After(deadline) {
+			t.Fatalf("Len = %d, want %d", eb.Len(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	go func() {
+		for range eb.Out {
+		}
+	}()
+	for i := 0; i < 100; i++ { // 与 Run 的协程并发读取，供 -race 检查
+		eb.In <- i
+		eb.Len()
+	}
+	close(eb.In)
+}
+
+func TestElasticBufRange(t *testing.T) {
+	defer VerifyNone(t)
+	eb := NewElasticBuf()
+	eb.SetChanSize(-1, 0)
+	eb.Run(context.Background())
+	for i := 0; i < 5; i++ {
+		eb.In <- i
+	}
+	for len(eb.Snapshot().Items) != 5 {
+		time.Sleep(time.Millisecond)
+	}
+
+	var got []interface{}
+	eb.Range(func(e interface{}) bool {
+		got = append(got, e)
+		return e != 2
+	})
+	if len(got) != 3 || got[0] != 0 || got[2] != 2 {
+		t.Errorf("Range visited %v, want [0 1 2]", got)
+	}
+	eb.Close()
+}
+
+func TestHeapStoreOrder(t *testing.T) {
+	h := &heapStore{less: func(a, b interface{}) bool { return a.(int) < b.(int) }}
+	for _, e := range []int{5, 1, 4, 2, 3, 0} {
+		h.push(e)
+	}
+	var each []interface{}
+	h.each(func(e interface{}) bool {
+		each = append(each, e)
+		return true
+	})
+	if want := []interface{}{0, 1, 2, 3, 4, 5}; !reflect.DeepEqual(each, want) {
+		t.Errorf("each = %v, want %v", each, want)
+	}
+	if e, kept := h.evict(9); e != 9 || kept {
+		t.Errorf("evict(9) = %v, %v; want 9, false", e, kept)
+	}
+	if e, kept := h.evict(-1); e != 5 || !kept {
+		t.Errorf("evict(-1) = %v, %v; want 5, true", e, kept)
+	}
+	var got []interface{}
+	for h.len() > 0 {
+		got = append(got, h.pop())
+	}
+	if want := []interface{}{-1, 0, 1, 2, 3, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("pop order = %v, want %v", got, want)
+	}
+}
+
+// 按优先级出队的 buf 满时 DropOldest 丢弃优先级最低的元素，而不是下一个要输出的元素
+func TestElasticBufOrderedDropOldest(t *testing.T) {
+	defer VerifyNone(t)
+	var dropped []interface{}
+	eb := NewElasticBufOrdered(func(a, b interface{}) bool { return a.(int) < b.(int) })
+	eb.SetCap(3)
+	eb.SetChanSize(-1, 0)
+	eb.SetOverflow(DropOldest, func(e interface{}) { dropped = append(dropped, e) })
+	eb.Run(context.Background())
+	for _, e := range []int{5, 1, 4, 2, 9, 3} {
+		eb.In <- e
+	}
+	for eb.Dropped() != 3 {
+		time.Sleep(time.Millisecond)
+	}
+	items := eb.CloseAndDrain()
+	if want := []interface{}{1, 2, 3}; !reflect.DeepEqual(items, want) {
+		t.Errorf("items = %v, want %v", items, want)
+	}
+	if want := []interface{}{5, 9, 4}; !reflect.DeepEqual(dropped, want) {
+		t.Errorf("dropped = %v, want %v", dropped, want)
+	}
+}
+
+func TestElasticBufLIFO(t *testing.T) {
+	defer VerifyNone(t)
+	eb := NewElasticBufLIFO()
+	eb.SetChanSize(-1, 0)
+	eb.Run(context.Background())
+	for i := 0; i < 5; i++ {
+		eb.In <- i
+	}
+	for len(eb.Snapshot().Items) != 5 {
+		time.Sleep(time.Millisecond)
+	}
+	close(eb.In)
+	var got []interface{}
+	for e := range eb.Out {
+		got = append(got, e)
+	}
+	if want := []interface{}{4, 3, 2, 1, 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// 后进先出的 buf 满时 DropOldest 丢弃栈底最早放入的元素，而不是刚放入的元素
+func TestElasticBufLIFODropOldest(t *testing.T) {
+	defer VerifyNone(t)
+	var dropped []interface{}
+	eb := NewElasticBufLIFO()
+	eb.SetCap(3)
+	eb.SetChanSize(-1, 0)
+	eb.SetOverflow(DropOldest, func(e interface{}) { dropped = append(dropped, e) })
+	eb.Run(context.Background())
+	for i := 0; i < 5; i++ {
+		eb.In <- i
+	}
+	for eb.Dropped() != 2 {
+		time.Sleep(time.Millisecond)
+	}
+	items := eb.CloseAndDrain()
+	if want := []interface{}{4, 3, 2}; !reflect.DeepEqual(items, want) {
+		t.Errorf("items = %v, want %v", items, want)
+	}
+	if want := []interface{}{0, 1}; !reflect.DeepEqual(dropped, want) {
+		t.Errorf("dropped = %v, want %v", dropped, want)
+	}
+}
+
+// buf 满时 DropOldest 丢弃最早的元素，DropNewest 丢弃新元素，被丢弃的元素计入 Dropped 并交给回调
+func TestElasticBufOverflow(t *testing.T) {
+	defer VerifyNone(t)
+	for _, c := range []struct {
+		policy        OverflowPolicy
+		kept, dropped []interface{}
+	}{
+		{DropOldest, []interface{}{2, 3, 4}, []interface{}{0, 1}},
+		{DropNewest, []interface{}{0, 1, 2}, []interface{}{3, 4}},
+	} {
+		var dropped []interface{}
+		eb := NewElasticBufCap(3)
+		eb.SetChanSize(-1, 0)
+		eb.SetOverflow(c.policy, func(e interface{}) { dropped = append(dropped, e) })
+		eb.Run(context.Background())
+		for i := 0; i < 5; i++ {
+			eb.In <- i
+		}
+		for eb.Dropped() != 2 {
+			time.Sleep(time.Millisecond)
+		}
+		if items := eb.CloseAndDrain(); !reflect.DeepEqual(items, c.kept) {
+			t.Errorf("policy %d: items = %v, want %v", c.policy, items, c.kept)
+		}
+		if !reflect.DeepEqual(dropped, c.dropped) {
+			t.Errorf("policy %d: dropped = %v, want %v", c.policy, dropped, c.dropped)
+		}
+	}
+}
+
+// In 关闭后 Out 要等 buf 中积压的元素全部输出后才关闭
+func TestElasticBufCloseInDrainsFirst(t *testing.T) {
+	defer VerifyNone(t)
+	eb := NewElasticBuf()
+	eb.SetChanSize(-1, 0)
+	eb.Run(context.Background())
+	for i := 0; i < 3; i++ {
+		eb.In <- i
+	}
+	close(eb.In)
+	for i := 0; i < 3; i++ {
+		time.Sleep(time.Millisecond) // 给 Run 的协程提前关闭 Out 的机会
+		if e, ok := <-eb.Out; !ok || e != i {
+			t.Fatalf("receive %d = %v, %v; want %d, true", i, e, ok, i)
+		}
+	}
+	if _, ok := <-eb.Out; ok {
+		t.Error("Out is not closed after the backlog drained")
+	}
+}
+
+// Snapshot 按出队顺序返回 buf 中的元素，不取走元素，之后 Out 的输出顺序与之相同
+func TestElasticBufSnapshot(t *testing.T) {
+	defer VerifyNone(t)
+	for _, c := range []struct {
+		name string
+		eb   *ElasticBuf
+		want []interface{}
+	}{
+		{"FIFO", NewElasticBuf(), []interface{}{3, 1, 4, 0, 2}},
+		{"LIFO", NewElasticBufLIFO(), []interface{}{2, 0, 4, 1, 3}},
+		{"Ordered", NewElasticBufOrdered(func(a, b interface{}) bool { return a.(int) < b.(int) }), []interface{}{0, 1, 2, 3, 4}},
+	} {
+		eb := c.eb
+		eb.SetChanSize(-1, 0)
+		eb.Run(context.Background())
+		for _, e := range []int{3, 1, 4, 0, 2} {
+			eb.In <- e
+		}
+		for len(eb.Snapshot().Items) != 5 {
+			time.Sleep(time.Millisecond)
+		}
+		s := eb.Snapshot()
+		if !reflect.DeepEqual(s.Items, c.want) || s.InLen != 0 || s.OutLen != 0 {
+			t.Errorf("%s: Snapshot = %+v, want items %v and empty channels", c.name, s, c.want)
+		}
+		if again := eb.Snapshot(); !reflect.DeepEqual(again, s) {
+			t.Errorf("%s: second Snapshot = %+v, want %+v", c.name, again, s)
+		}
+		close(eb.In)
+		var got []interface{}
+		for e := range eb.Out {
+			got = append(got, e)
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: Out = %v, want %v", c.name, got, c.want)
+		}
+	}
+
+	// Out 有缓冲时已经进入 Out 的元素只计入 OutLen
+	eb := NewElasticBuf()
+	eb.SetChanSize(-1, 2)
+	eb.Run(context.Background())
+	for i := 0; i < 5; i++ {
+		eb.In <- i
+	}
+	for s := eb.Snapshot(); len(s.Items) != 3 || s.InLen != 0; s = eb.Snapshot() {
+		time.Sleep(time.Millisecond)
+	}
+	if s := eb.Snapshot(); !reflect.DeepEqual(s.Items, []interface{}{2, 3, 4}) || s.OutLen != 2 {
+		t.Errorf("Snapshot = %+v, want items [2 3 4] and OutLen 2", s)
+	}
+	eb.Close()
+}
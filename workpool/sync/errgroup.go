@@ -0,0 +1,110 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// PanicError 是 ErrGroup 中协程 panic 时记录的错误
+type PanicError struct {
+	Value interface{} // recover 得到的值
+	Stack []byte      // panic 时的调用栈
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("sync: goroutine panicked: %v\n%s", e.Value, e.Stack)
+}
+
+// ErrGroup 启动一组协程并等待它们结束，返回第一个错误，功能与 x/sync/errgroup 相同：
+// 可用 SetLimit 限制同时运行的协程数，由 WithContext 创建时第一个错误会取消 ctx，
+// 协程 panic 时被捕获并转为 *PanicError 返回，不会使进程崩溃。零值可直接使用，不限制并发也不取消
+type ErrGroup struct {
+	cancel func()
+	wg     sync.WaitGroup
+	sem    chan struct{} // 限制并发数，为 nil 时不限制
+
+	errOnce sync.Once
+	err     error
+}
+
+// WithContext 返回新的 ErrGroup 以及派生的 ctx，ctx 在第一个协程返回错误或 Wait 返回时被取消
+func WithContext(ctx context.Context) (*ErrGroup, context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	return &ErrGroup{cancel: cancel}, ctx
+}
+
+// SetLimit 限制同时运行的协程数最多为 n，n < 0 时不限制。不能在有协程运行时调用
+func (g *ErrGroup) SetLimit(n int) {
+	if n < 0 {
+		g.sem = nil
+		return
+	}
+	if len(g.sem) != 0 {
+		panic("sync: ErrGroup.SetLimit called while goroutines are active")
+	}
+	g.sem = make(chan struct{}, n)
+}
+
+// Go 在新协程中执行 f，达到并发上限时阻塞直到有协程结束
+func (g *ErrGroup) Go(f func() error) {
+	if g.sem != nil {
+		g.sem <- struct{}{}
+	}
+	g.start(f)
+}
+
+// TryGo 未达到并发上限时在新协程中执行 f 并返回 true，否则立即返回 false
+func (g *ErrGroup) TryGo(f func() error) bool {
+	if g.sem != nil {
+		select {
+		case g.sem <- struct{}{}:
+		default:
+			return false
+		}
+	}
+	g.start(f)
+	return true
+}
+
+func (g *ErrGroup) start(f func() error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.done()
+		defer func() {
+			if r := recover(); r != nil {
+				buf := make([]byte, 64<<10)
+				g.setErr(&PanicError{Value: r, Stack: buf[:runtime.Stack(buf, false)]})
+			}
+		}()
+		if err := f(); err != nil {
+			g.setErr(err)
+		}
+	}()
+}
+
+func (g *ErrGroup) done() {
+	if g.sem != nil {
+		<-g.sem
+	}
+	g.wg.Done()
+}
+
+func (g *ErrGroup) setErr(err error) {
+	g.errOnce.Do(func() {
+		g.err = err
+		if g.cancel != nil {
+			g.cancel()
+		}
+	})
+}
+
+// Wait 等待所有协程结束，返回第一个错误
+func (g *ErrGroup) Wait() error {
+	g.wg.Wait()
+	if g.cancel != nil {
+		g.cancel()
+	}
+	return g.err
+}
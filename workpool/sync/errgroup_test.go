@@ -0,0 +1,55 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+func TestErrGroupCancel(t *testing.T) {
+	g, ctx := WithContext(context.Background())
+	boom := errors.New("boom")
+	g.Go(func() error { return boom })
+	g.Go(func() error {
+		<-ctx.Done() // 第一个错误取消 ctx
+		return ctx.Err()
+	})
+	if err := g.Wait(); err != boom {
+		t.Errorf("Wait = %v, want boom", err)
+	}
+}
+
+func TestErrGroupLimit(t *testing.T) {
+	var g ErrGroup
+	g.SetLimit(2)
+	var cur, peak int32
+	for i := 0; i < 20; i++ {
+		g.Go(func() error {
+			n := atomic.AddInt32(&cur, 1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+			atomic.AddInt32(&cur, -1)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if peak > 2 {
+		t.Errorf("peak concurrency = %d, want <= 2", peak)
+	}
+}
+
+func TestErrGroupPanic(t *testing.T) {
+	var g ErrGroup
+	g.Go(func() error { panic("oops") })
+	var pe *PanicError
+	if err := g.Wait(); !errors.As(err, &pe) || pe.Value != "oops" {
+		t.Errorf("Wait = %v, want *PanicError(oops)", err)
+	}
+}
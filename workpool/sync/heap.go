@@ -0,0 +1,74 @@
+package sync
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// heapStore 是按 less 排序的二叉堆，队首总是优先级最高（最“小”）的元素
+type heapStore struct {
+	items []interface{}
+	less  func(a, b interface{}) bool
+}
+
+// 以下五个方法实现 heap.Interface，仅供 container/heap 使用
+func (h *heapStore) Len() int           { return len(h.items) }
+func (h *heapStore) Less(i, j int) bool { return h.less(h.items[i], h.items[j]) }
+func (h *heapStore) Swap(i, j int)      { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *heapStore) Push(e interface{}) { h.items = append(h.items, e) }
+func (h *heapStore) Pop() interface{} {
+	n := len(h.items) - 1
+	e := h.items[n]
+	h.items[n] = nil // 释放引用
+	h.items = h.items[:n]
+	return e
+}
+
+func (h *heapStore) len() int {
+	return len(h.items)
+}
+
+func (h *heapStore) push(e interface{}) {
+	heap.Push(h, e)
+}
+
+func (h *heapStore) peek() interface{} {
+	return h.items[0]
+}
+
+func (h *heapStore) pop() interface{} {
+	return heap.Pop(h)
+}
+
+// evict 丢弃优先级最低的元素：e 的优先级不高于堆中最低的元素时丢弃 e，否则用 e 替换该元素。
+// 优先级最低的元素一定是叶子，只需在后一半中查找
+func (h *heapStore) evict(e interface{}) (interface{}, bool) {
+	n := len(h.items)
+	if n == 0 {
+		return e, false
+	}
+	worst := n / 2
+	for i := worst + 1; i < n; i++ {
+		if h.less(h.items[worst], h.items[i]) {
+			worst = i
+		}
+	}
+	if !h.less(e, h.items[worst]) {
+		return e, false
+	}
+	dropped := h.items[worst]
+	h.items[worst] = e
+	heap.Fix(h, worst)
+	return dropped, true
+}
+
+// each 按出队顺序遍历元素，需要先复制并排序
+func (h *heapStore) each(f func(e interface{}) bool) {
+	items := append([]interface{}(nil), h.items...)
+	sort.SliceStable(items, func(i, j int) bool { return h.less(items[i], items[j]) })
+	for _, e := range items {
+		if !f(e) {
+			return
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package sync
+
+import (
+	"runtime"
+	"strings"
+	"time"
+)
+
+// TB 是 VerifyNone 需要的 testing.TB 的子集，避免非测试代码依赖 testing 包
+type TB interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// 测试框架和运行时自身的协程，总是忽略
+var leakIgnores = []string{
+	"testing.(*T).Run(",
+	"testing.(*M).",
+	"testing.runTests",
+	"testing.runFuzzing",
+	"testing.tRunner.func1",
+	"testing.(*F).Fuzz",
+	"os/signal.signal_recv",
+	"os/signal.loop",
+	"runtime.ensureSigM",
+	"runtime.goexit1",
+	"runtime/trace.Start.func1",
+}
+
+// VerifyNone 检查除当前协程之外是否还有其他协程存活，有则让测试失败并打印它们的栈，
+// 类似 go.uber.org/goleak 的 VerifyNone，在测试的末尾用 defer 调用：
+//
+//	defer sync.VerifyNone(t)
+//
+// 协程退出需要一点时间，所以会重试到 2s 后才判定为泄漏。
+// 栈中包含 ignores 中任一字符串（如函数名 "net/http.(*persistConn).readLoop"）的协程被忽略。
+// 测试需是串行的：并行运行的其他测试的协程也会被当作泄漏
+func VerifyNone(t TB, ignores ...string) {
+	t.Helper()
+	var leaked []string
+	for deadline := time.Now().Add(2 * time.Second); ; {
+		leaked = leakedGoroutines(ignores)
+		if len(leaked) == 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(leaked) > 0 {
+		t.Errorf("found %d leaked goroutines:\n\n%s", len(leaked), strings.Join(leaked, "\n\n"))
+	}
+}
+
+// leakedGoroutines 返回除当前协程和被忽略的协程之外所有协程的栈
+func leakedGoroutines(ignores []string) []string {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+	stacks := strings.Split(strings.TrimSpace(string(buf)), "\n\n")
+	var leaked []string
+	for _, stack := range stacks[1:] { // 第一个是当前协程
+		if !ignored(stack, leakIgnores) && !ignored(stack, ignores) {
+			leaked = append(leaked, stack)
+		}
+	}
+	return leaked
+}
+
+func ignored(stack string, ignores []string) bool {
+	for _, s := range ignores {
+		if strings.Contains(stack, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package sync
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// fakeTB 记录 VerifyNone 报告的错误
+type fakeTB struct{ errs []string }
+
+func (*fakeTB) Helper() {}
+func (f *fakeTB) Errorf(format string, args ...interface{}) {
+	f.errs = append(f.errs, fmt.Sprintf(format, args...))
+}
+
+func leakyFunction(stop chan struct{}) { <-stop }
+
+func TestVerifyNone(t *testing.T) {
+	VerifyNone(t)
+
+	stop := make(chan struct{})
+	go leakyFunction(stop)
+	var tb fakeTB
+	VerifyNone(&tb)
+	if len(tb.errs) != 1 || !strings.Contains(tb.errs[0], "leakyFunction") {
+		t.Errorf("VerifyNone reported %q, want the leaked goroutine", tb.errs)
+	}
+
+	tb.errs = nil
+	VerifyNone(&tb, "sync.leakyFunction")
+	if len(tb.errs) != 0 {
+		t.Errorf("VerifyNone reported an ignored goroutine: %q", tb.errs)
+	}
+	close(stop)
+	VerifyNone(t)
+}
@@ -0,0 +1,79 @@
+package sync
+
+import (
+	"sync/atomic"
+	"unsafe"
+)
+
+// MPSCNode 是 MPSC 队列的节点，由调用方分配并可重复使用，使入队本身不产生分配
+type MPSCNode struct {
+	next  unsafe.Pointer // *MPSCNode
+	Value interface{}
+}
+
+// MPSC 是无锁的多生产者单消费者队列（Vyukov 算法）：
+// 任意多个协程可以并发 Push，只能有一个协程调用 Pop，入队只需一次原子交换。零值可直接使用。
+//
+// 它是独立的原语，工作池本身没有用到：ElasticBuf 的分发协程要在 select 中同时等待提交、ops 和 ctx，
+// 队列满时还要让提交方阻塞，这些都依赖通道，换成需要轮询的 Pop 并不划算
+type MPSC struct {
+	_ noCopy
+
+	head unsafe.Pointer // *MPSCNode，最近入队的节点，生产者之间通过原子交换竞争；为 nil 时还未初始化，见 init
+	tail *MPSCNode      // 下一个出队的节点，只由消费者访问；为 nil 时还未初始化
+	stub MPSCNode       // 哨兵节点，队列为空时 head 和 tail 都指向它
+}
+
+// NewMPSC 返回一个空队列，与零值相同
+func NewMPSC() *MPSC {
+	return new(MPSC)
+}
+
+// init 使零值的 head 指向哨兵，可被多个生产者并发调用，只有第一个生效
+func (q *MPSC) init() {
+	if atomic.LoadPointer(&q.head) == nil {
+		atomic.CompareAndSwapPointer(&q.head, nil, unsafe.Pointer(&q.stub))
+	}
+}
+
+// Push 将节点 n 入队，可并发调用；n 在出队之前不能再次入队
+func (q *MPSC) Push(n *MPSCNode) {
+	q.init()
+	atomic.StorePointer(&n.next, nil)
+	prev := (*MPSCNode)(atomic.SwapPointer(&q.head, unsafe.Pointer(n)))
+	// 交换和链接之间存在短暂的窗口，此时 Pop 看不到 n 及其之后的节点
+	atomic.StorePointer(&prev.next, unsafe.Pointer(n))
+}
+
+// Pop 取出队首节点，只能由单个消费者调用。
+// 队列为空，或某个生产者正处在 Push 的中间状态时返回 nil，调用方稍后重试即可
+func (q *MPSC) Pop() *MPSCNode {
+	if q.tail == nil {
+		q.tail = &q.stub
+	}
+	tail := q.tail
+	next := (*MPSCNode)(atomic.LoadPointer(&tail.next))
+	if tail == &q.stub { // 跳过哨兵
+		if next == nil {
+			return nil
+		}
+		q.tail = next
+		tail = next
+		next = (*MPSCNode)(atomic.LoadPointer(&tail.next))
+	}
+	if next != nil {
+		q.tail = next
+		return tail
+	}
+	if tail != (*MPSCNode)(atomic.LoadPointer(&q.head)) { // 生产者还未完成链接
+		return nil
+	}
+	// tail 是最后一个节点，放回哨兵后才能取出它，否则队列会断开
+	q.Push(&q.stub)
+	next = (*MPSCNode)(atomic.LoadPointer(&tail.next))
+	if next != nil {
+		q.tail = next
+		return tail
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package sync
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestMPSC(t *testing.T) {
+	const producers, per = 8, 1000
+	q := NewMPSC()
+	for p := 0; p < producers; p++ {
+		go func(p int) {
+			for i := 0; i < per; i++ {
+				q.Push(&MPSCNode{Value: [2]int{p, i}})
+			}
+		}(p)
+	}
+
+	next := make([]int, producers) // 每个生产者下一个应收到的序号
+	for got := 0; got < producers*per; {
+		n := q.Pop()
+		if n == nil {
+			runtime.Gosched()
+			continue
+		}
+		v := n.Value.([2]int)
+		if v[1] != next[v[0]] {
+			t.Fatalf("producer %d: got %d, want %d", v[0], v[1], next[v[0]])
+		}
+		next[v[0]]++
+		got++
+	}
+	if n := q.Pop(); n != nil {
+		t.Errorf("Pop on empty queue = %v", n.Value)
+	}
+}
+
+// 零值的队列不经 NewMPSC 也能直接使用
+func TestMPSCZeroValue(t *testing.T) {
+	var q MPSC
+	if n := q.Pop(); n != nil {
+		t.Fatalf("Pop on zero queue = %v", n.Value)
+	}
+	for i := 0; i < 3; i++ {
+		q.Push(&MPSCNode{Value: i})
+	}
+	for i := 0; i < 3; i++ {
+		if n := q.Pop(); n == nil || n.Value != i {
+			t.Fatalf("Pop %d = %v", i, n)
+		}
+	}
+	if n := q.Pop(); n != nil {
+		t.Errorf("Pop on drained queue = %v", n.Value)
+	}
+}
+
+// BenchmarkMPSC 与 BenchmarkElasticBuf、BenchmarkDeque 对照，节点预先分配并重复使用
+func BenchmarkMPSC(b *testing.B) {
+	q := NewMPSC()
+	nodes := make([]MPSCNode, 1024)
+	free := make(chan *MPSCNode, len(nodes))
+	for i := range nodes {
+		free <- &nodes[i]
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	go func() {
+		for i := 0; i < b.N; i++ {
+			q.Push(<-free)
+		}
+	}()
+	for i := 0; i < b.N; {
+		if n := q.Pop(); n != nil {
+			free <- n
+			i++
+		} else {
+			runtime.Gosched()
+		}
+	}
+}
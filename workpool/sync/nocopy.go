@@ -0,0 +1,11 @@
+package sync
+
+// noCopy 嵌入到首次使用后不能被复制的结构体中（字段名为 _），
+// go vet 的 copylocks 检查会把它当作锁，在值被复制时报告，
+// 而不是等到运行时出现莫名其妙的行为。它的大小为 0，不影响其他字段的对齐。
+// 见 https://golang.org/issues/8005#issuecomment-190753527
+type noCopy struct{}
+
+// Lock 和 Unlock 只用于让 copylocks 检查识别 noCopy，什么也不做
+func (*noCopy) Lock()   {}
+func (*noCopy) Unlock() {}
@@ -0,0 +1,44 @@
+package sync
+
+import (
+	"reflect"
+	"testing"
+)
+
+// containsLock 与 go vet 的 copylocks 检查的判断相同，见 xsync/nocopy_test.go
+func containsLock(t reflect.Type) bool {
+	pt := reflect.PtrTo(t)
+	_, lock := pt.MethodByName("Lock")
+	_, unlock := pt.MethodByName("Unlock")
+	if lock && unlock {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if containsLock(t.Field(i).Type) {
+				return true
+			}
+		}
+	case reflect.Array:
+		return containsLock(t.Elem())
+	}
+	return false
+}
+
+// 首次使用后不能复制的类型都要能被 copylocks 检查发现
+func TestNoCopy(t *testing.T) {
+	for _, typ := range []reflect.Type{
+		reflect.TypeOf((*ElasticBuf)(nil)).Elem(),
+		reflect.TypeOf((*MPSC)(nil)).Elem(),
+		reflect.TypeOf((*TreiberStack)(nil)).Elem(),
+		reflect.TypeOf((*Deque)(nil)).Elem(),
+		reflect.TypeOf((*Barrier)(nil)).Elem(),
+		reflect.TypeOf((*Broadcaster)(nil)).Elem(),
+		reflect.TypeOf((*ErrGroup)(nil)).Elem(),
+	} {
+		if !containsLock(typ) {
+			t.Errorf("%v can be copied without a vet copylocks warning", typ)
+		}
+	}
+}
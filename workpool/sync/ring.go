@@ -0,0 +1,93 @@
+package sync
+
+const (
+	minRingSize = 16
+)
+
+// ring 是按需扩缩容的环形队列
+// 出队只移动 head，不会像 buf = buf[1:] 那样让底层数组头部的内存无法释放，
+// 稳态下（入队出队速率相当）也不会反复扩容
+type ring struct {
+	buf  []interface{}
+	head int // 队首下标
+	n    int // 元素个数
+}
+
+func (r *ring) len() int {
+	return r.n
+}
+
+func (r *ring) push(e interface{}) {
+	if r.n == len(r.buf) {
+		r.resize(2 * len(r.buf))
+	}
+	r.buf[(r.head+r.n)%len(r.buf)] = e
+	r.n++
+}
+
+// peek 返回队首元素，调用方需保证队列非空
+func (r *ring) peek() interface{} {
+	return r.buf[r.head]
+}
+
+// pop 移除并返回队首元素，调用方需保证队列非空
+// 占用率低于 1/4 时容量减半，使突发流量过后的内存能够归还
+func (r *ring) pop() interface{} {
+	e := r.buf[r.head]
+	r.buf[r.head] = nil // 释放引用
+	r.head = (r.head + 1) % len(r.buf)
+	r.n--
+	if len(r.buf) > minRingSize && r.n < len(r.buf)/4 {
+		r.resize(len(r.buf) / 2)
+	}
+	return e
+}
+
+// resize 将元素按顺序搬到容量为 size 的新数组中
+func (r *ring) resize(size int) {
+	if size < minRingSize {
+		size = minRingSize
+	}
+	buf := make([]interface{}, size)
+	if r.n > 0 {
+		if r.head+r.n <= len(r.buf) {
+			copy(buf, r.buf[r.head:r.head+r.n])
+		} else {
+			k := copy(buf, r.buf[r.head:])
+			copy(buf[k:], r.buf[:r.n-k])
+		}
+	}
+	r.buf = buf
+	r.head = 0
+}
+
+// each 按出队顺序遍历元素，f 返回 false 时停止
+func (r *ring) each(f func(e interface{}) bool) {
+	for i := 0; i < r.n; i++ {
+		if !f(r.buf[(r.head+i)%len(r.buf)]) {
+			return
+		}
+	}
+}
+
+// pushFront 在队首插入元素
+func (r *ring) pushFront(e interface{}) {
+	if r.n == len(r.buf) {
+		r.resize(2 * len(r.buf))
+	}
+	r.head = (r.head - 1 + len(r.buf)) % len(r.buf)
+	r.buf[r.head] = e
+	r.n++
+}
+
+// popBack 移除并返回队尾元素，调用方需保证队列非空
+func (r *ring) popBack() interface{} {
+	i := (r.head + r.n - 1) % len(r.buf)
+	e := r.buf[i]
+	r.buf[i] = nil // 释放引用
+	r.n--
+	if len(r.buf) > minRingSize && r.n < len(r.buf)/4 {
+		r.resize(len(r.buf) / 2)
+	}
+	return e
+}
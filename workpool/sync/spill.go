@@ -0,0 +1,184 @@
+package sync
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+)
+
+// Codec 负责元素与字节之间的转换，用于把溢出的元素写入磁盘
+type Codec interface {
+	Encode(e interface{}) ([]byte, error)
+	Decode(b []byte) (interface{}, error)
+}
+
+// SpillConfig 是磁盘溢出的配置，见 ElasticBuf.SetSpill
+type SpillConfig struct {
+	Threshold int         // 内存中最多保留的元素个数，超出的部分写入临时文件
+	Dir       string      // 临时文件所在目录，为空时使用系统临时目录
+	Codec     Codec       // 元素的编解码方式
+	OnError   func(error) // 编码、解码或读写文件失败时的回调；可为 nil
+}
+
+// 编码失败或写文件失败的元素、解码失败的记录以及文件不可读后剩下的元素都会被丢弃，
+// 与溢出丢弃一样计入 Dropped 并交给 SetOverflow 的回调，无法读回的元素以 nil 交给回调
+
+// ErrSpillUnsupported 对非 FIFO 的 ElasticBuf 设置磁盘溢出时返回
+var ErrSpillUnsupported = errors.New("sync: spill requires a FIFO ElasticBuf")
+
+const (
+	spillHeaderLen = 4 // 每条记录前用 4 字节保存其长度
+)
+
+// spillStore 内存中最多保留 Threshold 个元素，之后到达的元素编码后追加到临时文件中，
+// 内存中的元素减少时再按顺序从文件读回，在限制内存的同时保持 FIFO 顺序。
+// 一旦开始溢出，新元素都先写入文件，直到文件中的元素全部读回，文件随即被删除。
+type spillStore struct {
+	mem  ring
+	conf SpillConfig
+	drop func(e interface{}) // 丢弃元素时调用，见 ElasticBuf.drop
+
+	file     *os.File
+	readOff  int64 // 下一条待读回记录的偏移
+	writeOff int64 // 下一条记录的写入偏移
+	spilled  int   // 文件中尚未读回的元素个数
+}
+
+func newSpillStore(conf SpillConfig, drop func(e interface{})) *spillStore {
+	if conf.Threshold < 1 {
+		conf.Threshold = 1
+	}
+	return &spillStore{conf: conf, drop: drop}
+}
+
+func (s *spillStore) len() int {
+	return s.mem.len() + s.spilled
+}
+
+func (s *spillStore) push(e interface{}) {
+	if s.spilled == 0 && s.mem.len() < s.conf.Threshold {
+		s.mem.push(e)
+		return
+	}
+	if err := s.write(e); err != nil {
+		s.report(err)
+		s.drop(e)
+		return
+	}
+	s.spilled++
+}
+
+func (s *spillStore) peek() interface{} {
+	return s.mem.peek()
+}
+
+func (s *spillStore) pop() interface{} {
+	e := s.mem.pop()
+	s.refill()
+	return e
+}
+
+// each 先遍历内存中的元素，再依次解码文件中的元素，跳过解码失败的记录
+func (s *spillStore) each(f func(e interface{}) bool) {
+	stop := false
+	s.mem.each(func(e interface{}) bool {
+		stop = !f(e)
+		return !stop
+	})
+	off := s.readOff
+	for i := 0; i < s.spilled && !stop; i++ {
+		e, next, err := s.read(off)
+		if err != nil {
+			s.report(err)
+			if next == off { // 文件不可读
+				return
+			}
+			off = next
+			continue
+		}
+		off = next
+		stop = !f(e)
+	}
+}
+
+// refill 把文件中的元素按顺序读回内存，直到达到 Threshold 或文件读完
+func (s *spillStore) refill() {
+	for s.spilled > 0 && s.mem.len() < s.conf.Threshold {
+		e, next, err := s.read(s.readOff)
+		if err != nil && next == s.readOff { // 文件已不可读，剩余元素只能丢弃
+			s.report(err)
+			for ; s.spilled > 0; s.spilled-- {
+				s.drop(nil)
+			}
+			break
+		}
+		s.readOff = next
+		s.spilled--
+		if err != nil { // 只是这一条记录解码失败，跳过它
+			s.report(err)
+			s.drop(nil)
+			continue
+		}
+		s.mem.push(e)
+	}
+	if s.spilled == 0 {
+		s.release()
+	}
+}
+
+func (s *spillStore) write(e interface{}) error {
+	b, err := s.conf.Codec.Encode(e)
+	if err != nil {
+		return err
+	}
+	if s.file == nil {
+		if s.file, err = os.CreateTemp(s.conf.Dir, "elasticbuf-spill-*"); err != nil {
+			return err
+		}
+		s.readOff, s.writeOff = 0, 0
+	}
+	rec := make([]byte, spillHeaderLen+len(b))
+	binary.BigEndian.PutUint32(rec, uint32(len(b)))
+	copy(rec[spillHeaderLen:], b)
+	if _, err := s.file.WriteAt(rec, s.writeOff); err != nil {
+		return err
+	}
+	s.writeOff += int64(len(rec))
+	return nil
+}
+
+// read 解码 off 处的记录，并返回下一条记录的偏移。
+// 读文件失败时 next 等于 off；只是解码失败时 err 非 nil，但 next 仍指向下一条记录，可以跳过这一条
+func (s *spillStore) read(off int64) (interface{}, int64, error) {
+	var header [spillHeaderLen]byte
+	if _, err := s.file.ReadAt(header[:], off); err != nil {
+		return nil, off, err
+	}
+	b := make([]byte, binary.BigEndian.Uint32(header[:]))
+	if _, err := s.file.ReadAt(b, off+spillHeaderLen); err != nil {
+		return nil, off, err
+	}
+	e, err := s.conf.Codec.Decode(b)
+	return e, off + spillHeaderLen + int64(len(b)), err
+}
+
+// release 关闭并删除临时文件
+func (s *spillStore) release() {
+	if s.file == nil {
+		return
+	}
+	name := s.file.Name()
+	if err := s.file.Close(); err != nil {
+		s.report(err)
+	}
+	if err := os.Remove(name); err != nil {
+		s.report(err)
+	}
+	s.file = nil
+}
+
+func (s *spillStore) report(err error) {
+	if s.conf.OnError != nil {
+		s.conf.OnError(err)
+	}
+}
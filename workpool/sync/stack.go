@@ -0,0 +1,49 @@
+package sync
+
+// stack 是后进先出的容器，最新放入的元素最先出队
+type stack struct {
+	items []interface{}
+}
+
+func (s *stack) len() int {
+	return len(s.items)
+}
+
+func (s *stack) push(e interface{}) {
+	s.items = append(s.items, e)
+}
+
+func (s *stack) peek() interface{} {
+	return s.items[len(s.items)-1]
+}
+
+// pop 移除并返回栈顶元素，占用率低于 1/4 时缩容
+func (s *stack) pop() interface{} {
+	n := len(s.items) - 1
+	e := s.items[n]
+	s.items[n] = nil // 释放引用
+	s.items = s.items[:n]
+	if c := cap(s.items); c > minRingSize && n < c/4 {
+		s.items = append(make([]interface{}, 0, c/2), s.items...)
+	}
+	return e
+}
+
+// evict 丢弃栈底最早放入的元素，把 e 放在栈顶
+func (s *stack) evict(e interface{}) (interface{}, bool) {
+	if len(s.items) == 0 {
+		return e, false
+	}
+	oldest := s.items[0]
+	copy(s.items, s.items[1:])
+	s.items[len(s.items)-1] = e
+	return oldest, true
+}
+
+func (s *stack) each(f func(e interface{}) bool) {
+	for i := len(s.items) - 1; i >= 0; i-- {
+		if !f(s.items[i]) {
+			return
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package sync
+
+// store 是 ElasticBuf 内部缓存元素的容器，决定元素的出队顺序
+// 只在 Run 的协程中访问，不需要并发安全
+type store interface {
+	len() int
+	push(e interface{})
+	peek() interface{} // 调用方需保证非空
+	pop() interface{}  // 调用方需保证非空
+	each(f func(e interface{}) bool)
+}
+
+// evicter 是可以自行决定 DropOldest 时丢弃哪个元素的 store，
+// 未实现时丢弃队首元素，即 FIFO 中最早的元素
+type evicter interface {
+	// evict 在 store 已满时放入 e 并移除一个元素，返回被移除的元素；
+	// e 本身就是应当丢弃的元素时不放入，返回 e 和 false
+	evict(e interface{}) (dropped interface{}, kept bool)
+}
+
+var (
+	_ evicter = (*heapStore)(nil)
+	_ evicter = (*stack)(nil)
+)
+
+var (
+	_ store = (*ring)(nil)
+	_ store = (*heapStore)(nil)
+	_ store = (*stack)(nil)
+	_ store = (*spillStore)(nil)
+)
@@ -0,0 +1,57 @@
+package sync
+
+import (
+	"sync/atomic"
+	"unsafe"
+)
+
+// TreiberStack 是无锁的并发栈，可用作任务包装对象的空闲链表等。
+//
+// 经典的 Treiber 栈在节点被复用时有 ABA 问题：Pop 读到栈顶 A 及其 next B 之后，
+// 其他协程弹出 A、B 又压回 A，CAS 仍会成功并把已弹出的 B 放回栈顶。
+// 这里每次 Push 都分配新的内部节点且节点不可变，只要还有协程持有某个节点的引用，
+// GC 就不会回收它，同一地址不会以新节点的身份重新出现在栈顶，从而避免了 ABA。
+type TreiberStack struct {
+	_ noCopy
+
+	top unsafe.Pointer // *treiberNode
+	n   int64          // 元素个数，原子操作
+}
+
+type treiberNode struct {
+	v    interface{}
+	next *treiberNode
+}
+
+// Push 压入 v，可并发调用
+func (s *TreiberStack) Push(v interface{}) {
+	n := &treiberNode{v: v}
+	for {
+		old := atomic.LoadPointer(&s.top)
+		n.next = (*treiberNode)(old)
+		if atomic.CompareAndSwapPointer(&s.top, old, unsafe.Pointer(n)) {
+			atomic.AddInt64(&s.n, 1)
+			return
+		}
+	}
+}
+
+// Pop 弹出栈顶元素，栈为空时返回 false，可并发调用
+func (s *TreiberStack) Pop() (interface{}, bool) {
+	for {
+		old := atomic.LoadPointer(&s.top)
+		if old == nil {
+			return nil, false
+		}
+		n := (*treiberNode)(old)
+		if atomic.CompareAndSwapPointer(&s.top, old, unsafe.Pointer(n.next)) {
+			atomic.AddInt64(&s.n, -1)
+			return n.v, true
+		}
+	}
+}
+
+// Len 返回元素个数，与 Push/Pop 并发时只是近似值
+func (s *TreiberStack) Len() int {
+	return int(atomic.LoadInt64(&s.n))
+}
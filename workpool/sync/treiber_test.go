@@ -0,0 +1,59 @@
+package sync
+
+import (
+	stdsync "sync"
+	"testing"
+)
+
+func TestTreiberStackLIFO(t *testing.T) {
+	var s TreiberStack
+	for i := 0; i < 3; i++ {
+		s.Push(i)
+	}
+	for want := 2; want >= 0; want-- {
+		if v, ok := s.Pop(); !ok || v != want {
+			t.Fatalf("Pop = %v, %v, want %d", v, ok, want)
+		}
+	}
+	if _, ok := s.Pop(); ok {
+		t.Error("Pop on empty stack returned ok")
+	}
+}
+
+// TestTreiberStackStress 让多个协程反复弹出再压回同一批元素（最容易触发 ABA 的模式），
+// 结束后每个元素都应恰好还在栈中一次，配合 -race 运行
+func TestTreiberStackStress(t *testing.T) {
+	const items, workers, rounds = 64, 8, 2000
+	var s TreiberStack
+	for i := 0; i < items; i++ {
+		s.Push(i)
+	}
+	var wg stdsync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := 0; r < rounds; r++ {
+				if v, ok := s.Pop(); ok {
+					s.Push(v)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	seen := make(map[interface{}]bool)
+	for {
+		v, ok := s.Pop()
+		if !ok {
+			break
+		}
+		if seen[v] {
+			t.Fatalf("item %v popped twice", v)
+		}
+		seen[v] = true
+	}
+	if len(seen) != items || s.Len() != 0 {
+		t.Errorf("recovered %d items (Len %d), want %d", len(seen), s.Len(), items)
+	}
+}
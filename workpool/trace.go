@@ -0,0 +1,77 @@
+package workpool
+
+import (
+	"context"
+	"runtime/trace"
+	"time"
+)
+
+// 开启 runtime/trace 时（如 go test -trace trace.out），每个任务在 AddTask 时创建一个名为
+// "workpool.task" 的 trace 任务，执行时处于该任务下名为 "workpool.execute" 的 region 中，
+// 结束后 trace 任务随之结束。在 go tool trace 的 User-defined tasks 页面中，
+// 每个任务从创建到 execute region 开始之间的空白就是它的排队等待时间，
+// region 是执行时间，两者合起来是端到端延迟；"queued" 日志标出入队的时刻，
+// worker 取走任务时记下 "dequeued after <等待时间>" 日志，等待时间按入队时记录的时间戳计算。
+//
+// 排队等待不用 region 表示：region 只能在同一个协程中开始和结束，而等待从提交方的协程开始；
+// worker 的协程上虽然知道入队时间，但 trace.StartRegion 只能以当前时刻开始，无法补记过去的一段时间。
+// 没有执行就被丢弃的任务（Down 时留在队列中的，或者入队失败的）记下 "dropped" 日志后同样结束 trace 任务，
+// 不会在 trace 中显示为一直未结束。未开启 trace 时只多一次 trace.IsEnabled 判断
+
+const traceCategory = "github.com/RobinTsai/tearUpGo/workpool"
+
+// traceSubmit 在提交时为 t 创建 trace 任务，以提交时的 context 为父任务
+func (t *task) traceSubmit() {
+	if !trace.IsEnabled() {
+		return
+	}
+	t.traceCtx, t.traceTask = trace.NewTask(t.ctx, "workpool.task")
+	t.traceQueued = time.Now()
+	trace.Log(t.traceCtx, traceCategory, "queued")
+}
+
+// workContext 返回传给 WorkContext 的 context：有 trace 任务时是其 context，
+// 这样任务内部创建的 trace 任务和 region 归在 "workpool.task" 之下
+func (t *task) workContext() context.Context {
+	if t.traceCtx != nil {
+		return t.traceCtx
+	}
+	return t.ctx
+}
+
+// traceBegin 开始 t 的 execute region，traceEnd 结束它以及 t 的 trace 任务；t 没有 trace 任务时什么都不做。
+// 不用 trace.WithRegion 包一个闭包，是因为闭包会逃逸，每个任务多一次分配
+func (t *task) traceBegin() *trace.Region {
+	if t.traceTask == nil {
+		return nil
+	}
+	trace.Logf(t.traceCtx, traceCategory, "dequeued after %v", time.Since(t.traceQueued))
+	return trace.StartRegion(t.traceCtx, "workpool.execute")
+}
+
+func (t *task) traceEnd(region *trace.Region) {
+	if region == nil {
+		return
+	}
+	region.End()
+	t.traceTask.End()
+}
+
+// traceDrop 结束没有执行就被丢弃的 t 的 trace 任务
+func (t *task) traceDrop() {
+	if t.traceTask == nil {
+		return
+	}
+	trace.Log(t.traceCtx, traceCategory, "dropped")
+	t.traceTask.End()
+}
+
+// dropQueued 在所有协程退出后取出 Down 时留在队列中的任务，结束它们的 trace 任务。
+// 这些 task 不归还 taskPool，由 GC 回收
+func (p *Workerpool) dropQueued() {
+	for _, job := range p.elasticJobBuf.Drain() {
+		if t, ok := job.(*task); ok {
+			t.traceDrop()
+		}
+	}
+}
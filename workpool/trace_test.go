@@ -0,0 +1,66 @@
+package workpool
+
+import (
+	"bytes"
+	"context"
+	"runtime/trace"
+	"testing"
+
+	"github.com/RobinTsai/tearUpGo/workpool/sync"
+)
+
+func TestTraceAnnotations(t *testing.T) {
+	if trace.IsEnabled() {
+		t.Skip("tracing is already enabled by go test -trace")
+	}
+	var buf bytes.Buffer
+	if err := trace.Start(&buf); err != nil {
+		t.Fatal(err)
+	}
+	pool := NewWorkerpool(2)
+	pool.Start()
+	ctx, parent := trace.NewTask(context.Background(), "parent")
+	for i := 0; i < 10; i++ {
+		pool.AddTask(ctxWorkload{ctx: ctx, ran: new(bool)})
+		pool.SubmitFunc(func() {})
+	}
+	pool.Shutdown()
+	pool.Wait()
+	parent.End()
+	trace.Stop()
+
+	for _, s := range []string{"workpool.task", "workpool.execute", "queued", "dequeued after"} {
+		if !bytes.Contains(buf.Bytes(), []byte(s)) {
+			t.Errorf("trace does not contain %q", s)
+		}
+	}
+}
+
+// Down 时留在队列中的任务记下 "dropped" 并结束 trace 任务
+func TestTraceDropped(t *testing.T) {
+	defer sync.VerifyNone(t)
+	if trace.IsEnabled() {
+		t.Skip("tracing is already enabled by go test -trace")
+	}
+	var buf bytes.Buffer
+	if err := trace.Start(&buf); err != nil {
+		t.Fatal(err)
+	}
+	pool := NewWorkerpool(1)
+	pool.Start()
+	release := make(chan struct{})
+	f, started := blockingTask(release)
+	pool.SubmitFunc(f)
+	<-started
+	for i := 0; i < 10; i++ {
+		pool.SubmitFunc(func() {}) // Out 中缓冲的少数几个仍可能被执行
+	}
+	pool.Down()
+	close(release)
+	pool.Wait()
+	trace.Stop()
+
+	if !bytes.Contains(buf.Bytes(), []byte("dropped")) {
+		t.Error(`trace does not contain "dropped"`)
+	}
+}
@@ -1,120 +1,593 @@
-package workpool
-
-import (
-	"context"
-	"log"
-	"time"
-	"workpool/internal/sync"
-)
-
-// IWorkload 请勿修改接口
-type IWorkload interface {
-	// Work内包含一些耗时的处理，可能是密集计算或者外部IO
-	Work()
-}
-
-// IProducer 请勿修改接口
-type IProducer interface {
-	// Produce每次调用会返回一个IWorkload实例
-	// 当返回nil时表示已经生产完毕
-	Produce() IWorkload
-}
-type workerpool struct {
-	workerCount       int                // 最大协程数目
-	down              bool               // 标记是否已经下线
-	ctx               context.Context    // 控制立即下线
-	cancel            context.CancelFunc // 控制立即下线
-	elasticJobBuf     *sync.ElasticBuf   // 带缓冲池的任务队列
-	sync.ExtWaitGroup                    // 扩展了 WaitGroup
-}
-
-// NewWorkerpool 初始化固定协程数目 n 的工作池
-func NewWorkerpool(n int) *workerpool {
-	if n <= 0 {
-		return nil
-	}
-
-	ctx, cancel := context.WithCancel(context.Background())
-	return &workerpool{
-		workerCount:   n,
-		ctx:           ctx,
-		cancel:        cancel,
-		elasticJobBuf: sync.NewElasticBuf(),
-	}
-}
-
-const (
-	maxIdleDuration = 3 * time.Second
-)
-
-// define one worker's task: always process job
-func (p *workerpool) spawnOneWorker() {
-	defer p.Done()
-
-	for {
-		select {
-		case job, ok := <-p.elasticJobBuf.Out:
-			if !ok {
-				return
-			}
-			if work, ok := job.(IWorkload); ok {
-				work.Work()
-			} else {
-				log.Printf("Error: Unexpected job type %v\n", work)
-			}
-		case <-time.After(maxIdleDuration): // maxIdleDuration 内没有任务，自动收缩
-			return
-		case <-p.ctx.Done():
-			return
-		}
-	}
-}
-
-// Start 开启工作池
-func (p *workerpool) Start() {
-	p.elasticJobBuf.Run(p.ctx)
-
-	p.Add(1)
-	go p.spawnOneWorker()
-}
-
-// Shutdown 优雅关闭工作池，保证所有工作处理完
-func (p *workerpool) Shutdown() {
-	if p.down {
-		return
-	}
-	close(p.elasticJobBuf.In)
-	p.down = true
-}
-
-// Down 立即下线
-func (p *workerpool) Down() {
-	if p.down {
-		return
-	}
-	close(p.elasticJobBuf.In)
-	p.cancel()
-	p.down = true
-}
-
-// AddTask 非阻塞方式添加任务到工作池
-func (p *workerpool) AddTask(work IWorkload) {
-	if p.down {
-		log.Println("Error: add task into closed pool")
-		return
-	}
-
-	if p.GetWaitCount() == 0 {
-		p.elasticJobBuf.In <- work
-		go p.spawnOneWorker()
-	} else {
-		select {
-		case p.elasticJobBuf.Out <- work: // 抢占进入输出队列
-		default: // 若抢占失败，则进行队列中并尝试 spawn 新协程
-			p.elasticJobBuf.In <- work
-			if wc := p.GetWaitCount(); wc < uint64(p.workerCount) && p.CompareAndAdd(wc, 1) {
-				go p.spawnOneWorker()
-			}
-		}
-	}
-}
+package workpool
+
+import (
+	"context"
+	"errors"
+	"log"
+	"runtime/trace"
+	stdsync "sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/RobinTsai/tearUpGo/workpool/sync"
+	"github.com/RobinTsai/tearUpGo/workpool/xsync"
+)
+
+var (
+	// ErrPoolClosed 工作池已经 Shutdown 或 Down 后再提交任务或启动时返回
+	ErrPoolClosed = errors.New("workpool: pool is closed")
+	// ErrPoolStarted 重复调用 Start 时返回
+	ErrPoolStarted = errors.New("workpool: pool already started")
+	// ErrPoolNotStarted 未调用 Start 就提交任务时返回
+	ErrPoolNotStarted = errors.New("workpool: pool not started")
+	// ErrPoolFull TryAddTask 既没有协程能立即执行任务、排队的任务也已达上限时返回
+	ErrPoolFull = errors.New("workpool: pool is full")
+)
+
+// IWorkload 请勿修改接口
+type IWorkload interface {
+	// Work内包含一些耗时的处理，可能是密集计算或者外部IO
+	Work()
+}
+
+// IContextWorkload 是携带 context 的任务
+// 执行前若 Context() 已经结束，任务会被跳过并计入 ExpiredCount，不再占用协程执行
+type IContextWorkload interface {
+	IWorkload
+	Context() context.Context
+}
+
+// IWorkloadWithContext 是需要提交方 context 的任务
+// 以 AddTaskContext 提交时，协程调用 WorkContext 并传入提交时的 ctx，而不是 Work，
+// ctx 中的值、截止时间以及 runtime/trace 的任务（见 trace.go）由此跨过队列传到执行方；
+// 以 AddTask 提交时传入的是任务自己的 Context（实现了 IContextWorkload 时）或 context.Background()
+type IWorkloadWithContext interface {
+	WorkContext(ctx context.Context)
+}
+
+// IWorkloadWithState 是可以使用协程私有状态的任务
+// 设置了 WithWorkerInit 时，协程会调用 WorkWith 并传入其初始化得到的状态，而不是 Work，
+// 从而无需全局连接池或加锁就能复用连接等资源；未设置时仍调用 Work
+type IWorkloadWithState interface {
+	WorkWith(state interface{})
+}
+
+// IErrWorkload 是会报告执行结果的任务，执行完后 Err 不为 nil 表示任务失败，
+// WithAdaptiveLimit 据此判断下游是否过载。SubmitErrFunc 提交的函数同样以 Err 报告重试后最终的错误
+type IErrWorkload interface {
+	IWorkload
+	Err() error
+}
+
+// IProducer 请勿修改接口
+type IProducer interface {
+	// Produce每次调用会返回一个IWorkload实例
+	// 当返回nil时表示已经生产完毕
+	Produce() IWorkload
+}
+
+// Pool 是 Workerpool 和 ShardedPool 共同的方法，Manager 和 HTTPLimiter 通过它接受两者
+type Pool interface {
+	Start() error
+	Shutdown()
+	Down()
+	Wait() error
+	Drained() <-chan struct{}
+	WaitDrained(ctx context.Context) error
+	Events() <-chan Event
+	Results() <-chan interface{}
+
+	AddTask(work IWorkload) error
+	AddTaskContext(ctx context.Context, work IWorkload) error
+	TryAddTask(ctx context.Context, work IWorkload, maxQueue int) error
+	Call(ctx context.Context, maxQueue int, f func()) error
+	SubmitFunc(f func()) error
+	SubmitFuncContext(ctx context.Context, f func(ctx context.Context)) error
+	SubmitErrFunc(f func() error) error
+
+	ApplyConfig(c Config) error
+	WatchConfig(ctx context.Context, path string, interval time.Duration) error
+
+	GetWaitCount() uint64
+	MaxWorkers() int
+	RunningWorkers() int
+	IdleWorkers() int
+	PendingCount() int
+	ExpiredCount() uint64
+}
+
+var (
+	_ Pool = (*Workerpool)(nil)
+	_ Pool = (*ShardedPool)(nil)
+)
+
+// Workerpool 是按需伸缩协程数的工作池，由 NewWorkerpool 或 Config.New 创建，零值不可用。
+//
+// 生命周期：
+//
+//	NewWorkerpool -> Start -> AddTask... -> Shutdown/Down -> Wait
+//
+// Start 只能成功调用一次，重复调用返回 ErrPoolStarted，关闭后调用返回 ErrPoolClosed；
+// Start 之前 AddTask 返回 ErrPoolNotStarted，关闭之后返回 ErrPoolClosed；
+// Shutdown 和 Down 可重复调用，且可在任意阶段调用。
+type Workerpool struct {
+	running            int64                       // 正在执行任务的协程数目，原子操作，放在首位保证 64 位对齐
+	expired            uint64                      // 因 context 已结束而跳过的任务数，原子操作
+	seq                uint64                      // 下一个任务的提交序号，原子操作
+	workerSeq          uint64                      // 已分配的协程编号，原子操作
+	workerCount        int64                       // 最大协程数目，原子操作，可由 ApplyConfig 和 WithAdaptiveLimit 修改
+	idleTimeout        int64                       // 协程空闲多久后退出（time.Duration），原子操作，见 WithIdleTimeout
+	adm                uint64                      // 打包的存活协程数和等待协程数与未取走任务数之差，原子操作，见 admission.go
+	name               string                      // 工作池名称，非空时开启协程标识，见 WithName
+	mu                 stdsync.RWMutex             // 保护 started 和 down，避免向已关闭的 In 发送
+	started            bool                        // 标记是否已经启动
+	down               xsync.AtomicBool            // 标记是否已经下线，在写锁内修改，可无锁读取
+	ctx                context.Context             // 控制立即下线
+	cancel             context.CancelFunc          // 控制立即下线
+	elasticJobBuf      *sync.ElasticBuf            // 带缓冲池的任务队列
+	events             chan Event                  // 生命周期事件，见 Events
+	drainedEv          xsync.Event                 // 保证 Drained 事件只发出一次，见 WaitDrained
+	scheduler          Scheduler                   // 决定何时启动新协程
+	errHandler         func(err error)             // 处理任务返回的错误，见 WithErrorHandler
+	prefork            bool                        // Start 时启动全部协程且不收缩，见 WithPrefork
+	retries            int32                       // 出错的函数最多重试的次数，原子操作，见 WithRetries
+	reloadMu           stdsync.Mutex               // 串行化 ApplyConfig
+	limiter            atomic.Value                // *xsync.Limiter，任务开始执行的速率限制，为 nil 时不限速，见 WithRateLimit
+	workerInit         func() (interface{}, error) // 协程启动时初始化私有状态，见 WithWorkerInit
+	workerTeardown     func(state interface{})     // 协程退出时释放私有状态，见 WithWorkerTeardown
+	results            *resultBuf                  // 任务结果，见 Results
+	queueBound         int                         // 排队任务数上限，见 WithQueueBound
+	less               func(a, b IWorkload) bool   // 任务优先级，见 WithPriority
+	lifo               bool                        // 任务后进先出，见 WithLIFO
+	edf                bool                        // 最早截止时间优先，见 WithEDF
+	edfCost            time.Duration               // 任务预计的执行时间，见 WithEDF
+	adaptive           *adaptiveLimit              // 自适应的协程数上限，为 nil 时上限固定，见 WithAdaptiveLimit
+	bufOpts            []func(eb *sync.ElasticBuf) // 创建任务队列后依次调用，用于调整队列参数
+	hooks              *poolHooks                  // 调度决策钩子，只供测试使用，见 hooks.go
+	eventLog           *eventLog                   // JSON 事件日志，见 WithEventLog
+	group              *shardGroup                 // 分片共用的状态，不属于 ShardedPool 时为 nil，见 NewShardedPool
+	xsync.ExtWaitGroup                             // 扩展了 WaitGroup
+}
+
+// NewWorkerpool 初始化最大协程数目为 n 的工作池
+func NewWorkerpool(n int, opts ...Option) *Workerpool {
+	if n <= 0 {
+		return nil
+	}
+	return newWorkerpool(n, opts, nil)
+}
+
+// newWorkerpool 实现 NewWorkerpool，setup 不为 nil 时在应用 opts 之后、创建任务队列之前调用，
+// 供 NewShardedPool 调整各分片的选项
+func newWorkerpool(n int, opts []Option, setup func(p *Workerpool)) *Workerpool {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Workerpool{
+		workerCount: int64(n),
+		adm:         uint64(makeAdmState(0, 0)),
+		ctx:         ctx,
+		cancel:      cancel,
+		events:      make(chan Event, eventsBufSize),
+		scheduler:   DefaultScheduler,
+		idleTimeout: int64(maxIdleDuration),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if setup != nil {
+		setup(p)
+	}
+	p.elasticJobBuf = p.newJobBuf()
+	p.OnZero(func() { // 最后一个协程退出时，若已下线则所有任务都已处理完
+		if p.isDown() {
+			p.drained()
+		}
+	})
+	return p
+}
+
+// newJobBuf 按选项创建任务队列
+func (p *Workerpool) newJobBuf() *sync.ElasticBuf {
+	var eb *sync.ElasticBuf
+	if p.edf {
+		eb = sync.NewElasticBufOrdered(func(a, b interface{}) bool {
+			return edfLess(a.(*task), b.(*task))
+		})
+	} else if p.less != nil {
+		eb = sync.NewElasticBufOrdered(func(a, b interface{}) bool {
+			return p.less(a.(*task).work, b.(*task).work)
+		})
+	} else if p.lifo {
+		eb = sync.NewElasticBufLIFO()
+	} else {
+		eb = sync.NewElasticBuf()
+	}
+	eb.SetCap(p.queueBound)
+	for _, opt := range p.bufOpts {
+		opt(eb)
+	}
+	return eb
+}
+
+const (
+	maxIdleDuration = 3 * time.Second
+)
+
+// define one worker's task: always process job
+// waiting 为 true 时协程已经算作在等待任务，见 reserveWorkers
+func (p *Workerpool) spawnOneWorker(waiting bool) {
+	worker := atomic.AddUint64(&p.workerSeq, 1)
+	slot := p.registerWorker(worker)
+	p.emit(WorkerSpawned, nil)
+	p.logEvent("spawn", 0, worker, nil)
+	defer func() {
+		slot.unregister()
+		p.emit(WorkerRetired, nil)
+		p.logEvent("retire", 0, worker, nil)
+		p.Done()
+	}()
+	defer p.Track()()
+
+	var state interface{}
+	if p.workerInit != nil {
+		var err error
+		if state, err = p.workerInit(); err != nil {
+			if waiting {
+				p.stopWaiting()
+			} else {
+				p.releaseWorker()
+			}
+			p.handleError(err)
+			p.hookRetire(retireInitFailed)
+			return
+		}
+	}
+	if p.workerTeardown != nil {
+		defer p.workerTeardown(state)
+	}
+
+	// 空闲计时器在协程内复用，每执行一个任务都用 time.After 会分配一个新的计时器
+	idle := time.NewTimer(time.Duration(atomic.LoadInt64(&p.idleTimeout)))
+	defer idle.Stop()
+	if !waiting {
+		p.startWaiting()
+	}
+	for {
+		select {
+		case job, ok := <-p.elasticJobBuf.Out:
+			if !idle.Stop() { // 已经到期但还没被读取，清空以免 Reset 之后立即触发
+				select {
+				case <-idle.C:
+				default:
+				}
+			}
+			if !ok {
+				p.stopWaiting()
+				p.hookRetire(retireClosed)
+				return
+			}
+			slot.begin(job)
+			p.execute(job, state, worker)
+			slot.end()
+			if p.retireExcess() {
+				p.hookRetire(retireExcess)
+				return
+			}
+			p.startWaiting()
+			idle.Reset(time.Duration(atomic.LoadInt64(&p.idleTimeout)))
+		case <-idle.C: // idleTimeout 内没有任务，自动收缩
+			// 预先启动的协程常驻，不收缩；有任务还没被取走时也不退出，否则任务可能无人执行
+			if p.prefork || !p.retireIdle() {
+				idle.Reset(time.Duration(atomic.LoadInt64(&p.idleTimeout)))
+				continue
+			}
+			p.hookRetire(retireIdle)
+			return
+		case <-p.ctx.Done():
+			p.stopWaiting()
+			p.hookRetire(retireCancelled)
+			return
+		}
+	}
+}
+
+// task 是队列中的元素，包装了提交的任务
+type task struct {
+	work        IWorkload
+	ctx         context.Context // 提交时的 context，见 AddTaskContext
+	seq         uint64          // 提交序号，用于有序返回结果和 EDF 中相同截止时间的先后
+	deadline    time.Time       // ctx 的截止时间，只在开启 EDF 时设置，见 WithEDF
+	id          uint64          // 事件日志中的任务编号，未开启时为 0
+	traceCtx    context.Context // runtime/trace 开启时任务所属的 trace 任务，见 trace.go
+	traceTask   *trace.Task
+	traceQueued time.Time // 创建 trace 任务（入队）的时刻，用于记录排队等待时间
+}
+
+// taskPool 复用 task，使提交一个任务不需要分配内存，见 TestAddTaskAllocs
+var taskPool = stdsync.Pool{New: func() interface{} { return new(task) }}
+
+// freeTask 在任务执行完或提交失败后归还 t，之后不能再使用 t。
+// Down 时留在队列中被丢弃的 task 不归还，由 GC 回收，见 dropQueued
+func freeTask(t *task) {
+	*t = task{}
+	taskPool.Put(t)
+}
+
+// execute 执行队列中取出的一个任务，并在开启结果通道时提交其结果
+// state 为当前协程由 WithWorkerInit 创建的私有状态，worker 为事件日志中的协程编号
+func (p *Workerpool) execute(job interface{}, state interface{}, worker uint64) {
+	t, ok := job.(*task)
+	if !ok {
+		log.Printf("Error: Unexpected job type %v\n", job)
+		return
+	}
+
+	region := t.traceBegin()
+	ran := p.run(t, state, worker)
+	t.traceEnd(region)
+	if p.results != nil {
+		var res result
+		if rw, ok := t.work.(IResultWorkload); ok && ran {
+			res = result{val: rw.Result(), ok: true}
+		}
+		p.results.complete(p.ctx, t.seq, res)
+	}
+	freeTask(t)
+}
+
+// run 执行任务并维护 running 计数，任务被跳过时返回 false
+// worker 为事件日志中的协程编号
+func (p *Workerpool) run(t *task, state interface{}, worker uint64) bool {
+	work, id := t.work, t.id
+	if l := p.rateLimiter(); l != nil && l.Wait(p.ctx) != nil { // Down 时放弃等待，任务不再执行
+		return false
+	}
+	err := t.ctx.Err()
+	if err == nil && p.hopeless(t) { // 排队期间已经来不及，见 WithEDF
+		err = context.DeadlineExceeded
+	}
+	if err != nil {
+		atomic.AddUint64(&p.expired, 1)
+		p.emit(TaskExpired, work)
+		p.logEvent("expired", id, worker, err)
+		return false
+	}
+
+	atomic.AddInt64(&p.running, 1)
+	p.emit(TaskStarted, work)
+	p.logEvent("start", id, worker, nil)
+	defer func() {
+		atomic.AddInt64(&p.running, -1)
+		p.emit(TaskFinished, work)
+		p.logEvent("finish", id, worker, nil)
+	}()
+	var start time.Time
+	if p.adaptive != nil {
+		start = time.Now()
+	}
+	if sw, ok := work.(IWorkloadWithState); ok && p.workerInit != nil {
+		sw.WorkWith(state)
+	} else if cw, ok := work.(IWorkloadWithContext); ok {
+		cw.WorkContext(t.workContext())
+	} else {
+		work.Work()
+	}
+	if p.adaptive != nil {
+		ew, ok := work.(IErrWorkload)
+		p.adaptive.sample(p, start, time.Since(start), ok && ew.Err() != nil)
+	}
+	return true
+}
+
+// ExpiredCount 返回因 context 已结束（或开启 EDF 时来不及执行完）而被跳过的任务数
+func (p *Workerpool) ExpiredCount() uint64 {
+	return atomic.LoadUint64(&p.expired)
+}
+
+// RunningWorkers 返回正在执行任务的协程数目
+func (p *Workerpool) RunningWorkers() int {
+	return int(atomic.LoadInt64(&p.running))
+}
+
+// MaxWorkers 返回协程数目上限
+func (p *Workerpool) MaxWorkers() int {
+	return int(atomic.LoadInt64(&p.workerCount))
+}
+
+// IdleWorkers 返回已启动但正在等待任务的协程数目
+func (p *Workerpool) IdleWorkers() int {
+	idle := int(p.GetWaitCount()) - p.RunningWorkers()
+	if idle < 0 { // 两个计数不是同时读取的，可能短暂不一致
+		return 0
+	}
+	return idle
+}
+
+// Start 开启工作池，只有第一次调用生效
+func (p *Workerpool) Start() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.down.Load() {
+		return ErrPoolClosed
+	}
+	if p.started {
+		return ErrPoolStarted
+	}
+	p.started = true
+
+	if err := p.elasticJobBuf.Run(p.ctx); err != nil {
+		return err
+	}
+
+	n := 1
+	if p.prefork {
+		n = p.MaxWorkers()
+	}
+	p.reserveWorkers(n)
+	p.Add(n)
+	for i := 0; i < n; i++ {
+		go p.spawnOneWorker(true)
+	}
+	return nil
+}
+
+// Shutdown 优雅关闭工作池，保证所有工作处理完
+func (p *Workerpool) Shutdown() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.down.Load() {
+		return
+	}
+	p.closeLocked()
+}
+
+// Down 立即下线，Shutdown 之后调用也会立即终止剩余任务
+func (p *Workerpool) Down() {
+	p.cancel() // 先取消，使阻塞在 In 上的 AddTask 退出并释放读锁
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.down.Load() {
+		return
+	}
+	p.closeLocked()
+}
+
+// closeLocked 关闭任务输入并标记下线，调用方需持有写锁
+func (p *Workerpool) closeLocked() {
+	close(p.elasticJobBuf.In)
+	p.down.Store(true)
+	p.emit(ShutdownBegan, nil)
+	p.logEvent("shutdown", 0, 0, nil)
+	if p.GetWaitCount() == 0 {
+		p.drained()
+	}
+}
+
+// isDown 返回是否已经下线，无需加锁
+func (p *Workerpool) isDown() bool {
+	return p.down.Load()
+}
+
+// AddTask 非阻塞方式添加任务到工作池（设置了 WithQueueBound 且队列已满时会阻塞）
+// 工作池已关闭时返回 ErrPoolClosed，任务不会被执行
+func (p *Workerpool) AddTask(work IWorkload) error {
+	ctx := context.Background()
+	if cw, ok := work.(IContextWorkload); ok {
+		ctx = cw.Context()
+	}
+	return p.AddTaskContext(ctx, work)
+}
+
+// AddTaskContext 与 AddTask 相同，但把 ctx 随任务一起入队：
+// 执行前 ctx 已经结束时任务被跳过并计入 ExpiredCount（与 IContextWorkload 相同），
+// 任务实现了 IWorkloadWithContext 时 WorkContext 收到的就是 ctx，
+// 从而请求范围的值（如请求 ID、认证信息）、截止时间和 trace 任务不会在队列处丢失。
+// 任务同时实现了 IContextWorkload 时，以 ctx 为准
+func (p *Workerpool) AddTaskContext(ctx context.Context, work IWorkload) error {
+	return p.addTask(ctx, work, -1)
+}
+
+// TryAddTask 与 AddTaskContext 相同，但不让任务无限排队：有协程能立即执行它（有等待的协程，或协程数未达上限），
+// 或者还没有协程认领的排队任务少于 maxQueue 时接受，否则不入队并返回 ErrPoolFull。
+// 判断和占位是同一次原子操作，并发的调用不会一起越过上限。协程数未达上限时总会启动协程，不询问 Scheduler
+func (p *Workerpool) TryAddTask(ctx context.Context, work IWorkload, maxQueue int) error {
+	if maxQueue < 0 {
+		maxQueue = 0
+	}
+	return p.addTask(ctx, work, maxQueue)
+}
+
+// addTask 实现 AddTaskContext 和 TryAddTask。maxQueue < 0 时按 admit 正常排队，
+// 否则按 admitBounded 判断能否接受，不能时返回 ErrPoolFull
+func (p *Workerpool) addTask(ctx context.Context, work IWorkload, maxQueue int) error {
+	// 持有读锁期间 In 不会被关闭，Shutdown/Down 会等待正在进行的提交
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.down.Load() {
+		return ErrPoolClosed
+	}
+	if !p.started {
+		return ErrPoolNotStarted
+	}
+	var spawn bool
+	var decision spawnDecision
+	var st SchedState
+	bounded := maxQueue >= 0
+	if bounded {
+		var ok bool
+		if spawn, decision, st, ok = p.admitBounded(maxQueue); !ok {
+			return ErrPoolFull
+		}
+	}
+
+	t := taskPool.Get().(*task)
+	t.work, t.ctx, t.id = work, ctx, p.eventLog.nextTask()
+	if p.edf {
+		t.deadline, _ = ctx.Deadline()
+		if p.hopeless(t) { // 来不及执行完，不占用队列
+			if bounded {
+				p.unadmit(spawn)
+			}
+			freeTask(t)
+			return ErrDeadlineUnreachable
+		}
+	}
+	if p.results != nil || p.edf {
+		t.seq = p.nextSeq()
+	}
+	t.traceSubmit()
+	p.logEvent("submit", t.id, 0, nil) // 在入队之前写出，保证先于该任务的 start
+
+	// 先决定是否需要启动协程，再入队，见 admission.go
+	if !bounded {
+		spawn, decision, st = p.admit()
+	}
+	path := dispatchQueued
+	select {
+	case p.elasticJobBuf.Out <- t: // 抢占进入输出队列
+		path = dispatchDirect
+	default:
+		if err := p.enqueue(t); err != nil {
+			p.unadmit(spawn)
+			p.logEvent("fail", t.id, 0, err)
+			t.traceDrop()
+			freeTask(t)
+			return err
+		}
+	}
+	p.hookDispatch(path)
+	if decision == spawnAtCap {
+		p.emit(QueueFull, work)
+	}
+	p.hookSpawn(decision, st)
+	if spawn { // 持有读锁，Add 一定在关闭之前
+		p.Add(1)
+		go p.spawnOneWorker(false)
+	}
+	return nil
+}
+
+// nextSeq 分配下一个提交序号。ShardedPool 的分片共用一个计数，有序结果按整个池的提交顺序发送
+func (p *Workerpool) nextSeq() uint64 {
+	seq := &p.seq
+	if p.group != nil {
+		seq = &p.group.seq
+	}
+	return atomic.AddUint64(seq, 1) - 1
+}
+
+// PendingCount 返回已提交但还未被 worker 取走的任务数，
+// 包括弹性缓冲中的积压以及 In/Out 两个通道中缓冲的任务，可用于生产者自行控制节奏
+func (p *Workerpool) PendingCount() int {
+	return p.elasticJobBuf.Len()
+}
+
+// enqueue 将任务放入弹性队列，Down 之后不再阻塞
+func (p *Workerpool) enqueue(t *task) error {
+	select {
+	case p.elasticJobBuf.In <- t:
+		return nil
+	case <-p.ctx.Done():
+		return ErrPoolClosed
+	}
+}
@@ -0,0 +1,612 @@
+package workpool
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	stdsync "sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/RobinTsai/tearUpGo/workpool/sync"
+)
+
+type nopWorkload struct{}
+
+func (nopWorkload) Work() {}
+
+func TestAddTaskAfterClose(t *testing.T) {
+	defer sync.VerifyNone(t)
+	for name, closeFn := range map[string]func(p *Workerpool){
+		"Shutdown": (*Workerpool).Shutdown,
+		"Down":     (*Workerpool).Down,
+	} {
+		pool := NewWorkerpool(2)
+		pool.Start()
+		if err := pool.AddTask(nopWorkload{}); err != nil {
+			t.Fatalf("%s: AddTask before close: %v", name, err)
+		}
+		closeFn(pool)
+		if err := pool.AddTask(nopWorkload{}); err != ErrPoolClosed {
+			t.Errorf("%s: AddTask after close = %v, want ErrPoolClosed", name, err)
+		}
+		pool.Wait()
+	}
+}
+
+func TestStartLifecycle(t *testing.T) {
+	defer sync.VerifyNone(t)
+	pool := NewWorkerpool(1)
+	if err := pool.AddTask(nopWorkload{}); err != ErrPoolNotStarted {
+		t.Errorf("AddTask before Start = %v, want ErrPoolNotStarted", err)
+	}
+	if err := pool.Start(); err != nil {
+		t.Fatalf("first Start: %v", err)
+	}
+	if err := pool.Start(); err != ErrPoolStarted {
+		t.Errorf("second Start = %v, want ErrPoolStarted", err)
+	}
+	pool.Shutdown()
+	if err := pool.Start(); err != ErrPoolClosed {
+		t.Errorf("Start after Shutdown = %v, want ErrPoolClosed", err)
+	}
+	pool.Wait()
+}
+
+func TestEventsDrained(t *testing.T) {
+	defer sync.VerifyNone(t)
+	pool := NewWorkerpool(2)
+	pool.Start()
+	for i := 0; i < 3; i++ {
+		pool.AddTask(nopWorkload{})
+	}
+	pool.Shutdown()
+	pool.Wait()
+
+	seen := make(map[EventType]int)
+	timeout := time.After(time.Second)
+	for seen[Drained] == 0 {
+		select {
+		case e := <-pool.Events():
+			seen[e.Type]++
+		case <-timeout:
+			t.Fatalf("no Drained event, got %v", seen)
+		}
+	}
+	if seen[TaskStarted] != 3 || seen[TaskFinished] != 3 {
+		t.Errorf("TaskStarted/TaskFinished = %d/%d, want 3/3", seen[TaskStarted], seen[TaskFinished])
+	}
+	if seen[ShutdownBegan] != 1 {
+		t.Errorf("ShutdownBegan = %d, want 1", seen[ShutdownBegan])
+	}
+}
+
+func TestSubmitFunc(t *testing.T) {
+	defer sync.VerifyNone(t)
+	errc := make(chan error, 1)
+	pool := NewWorkerpool(2, WithErrorHandler(func(err error) { errc <- err }))
+	pool.Start()
+
+	done := make(chan struct{})
+	if err := pool.SubmitFunc(func() { close(done) }); err != nil {
+		t.Fatal(err)
+	}
+	want := errors.New("boom")
+	if err := pool.SubmitErrFunc(func() error { return want }); err != nil {
+		t.Fatal(err)
+	}
+	pool.Shutdown()
+	pool.Wait()
+
+	<-done
+	if err := <-errc; err != want {
+		t.Errorf("error handler got %v, want %v", err, want)
+	}
+}
+
+type ctxWorkload struct {
+	ctx context.Context
+	ran *bool
+}
+
+func (w ctxWorkload) Work()                    { *w.ran = true }
+func (w ctxWorkload) Context() context.Context { return w.ctx }
+
+func TestSkipExpiredContext(t *testing.T) {
+	defer sync.VerifyNone(t)
+	pool := NewWorkerpool(1)
+	pool.Start()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var ran bool
+	pool.AddTask(ctxWorkload{ctx: ctx, ran: &ran})
+	pool.Shutdown()
+	pool.Wait()
+
+	if ran {
+		t.Error("workload with canceled context was executed")
+	}
+	if n := pool.ExpiredCount(); n != 1 {
+		t.Errorf("ExpiredCount = %d, want 1", n)
+	}
+}
+
+type requestIDKey struct{}
+
+// AddTaskContext 提交的 ctx 中的值和截止时间在 WorkContext 中可见，已结束的 ctx 使任务被跳过
+func TestAddTaskContext(t *testing.T) {
+	defer sync.VerifyNone(t)
+	pool := NewWorkerpool(2)
+	pool.Start()
+
+	ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), requestIDKey{}, "req-1"), time.Minute)
+	defer cancel()
+	got := make(chan context.Context, 1)
+	if err := pool.SubmitFuncContext(ctx, func(ctx context.Context) { got <- ctx }); err != nil {
+		t.Fatal(err)
+	}
+	done, cancelDone := context.WithCancel(context.Background())
+	cancelDone()
+	var ran bool
+	pool.AddTaskContext(done, ctxWorkload{ctx: context.Background(), ran: &ran})
+	pool.Shutdown()
+	pool.Wait()
+
+	wctx := <-got
+	if v := wctx.Value(requestIDKey{}); v != "req-1" {
+		t.Errorf("request id = %v, want req-1", v)
+	}
+	want, _ := ctx.Deadline()
+	if d, ok := wctx.Deadline(); !ok || !d.Equal(want) {
+		t.Errorf("deadline = %v, %v, want %v", d, ok, want)
+	}
+	if ran {
+		t.Error("workload submitted with a canceled context was executed")
+	}
+	if n := pool.ExpiredCount(); n != 1 {
+		t.Errorf("ExpiredCount = %d, want 1", n)
+	}
+}
+
+type stateWorkload struct{ got chan interface{} }
+
+func (w stateWorkload) Work()                      { w.got <- nil }
+func (w stateWorkload) WorkWith(state interface{}) { w.got <- state }
+
+func TestWorkerState(t *testing.T) {
+	defer sync.VerifyNone(t)
+	var teardowns int32
+	pool := NewWorkerpool(1,
+		WithWorkerInit(func() (interface{}, error) { return "conn", nil }),
+		WithWorkerTeardown(func(state interface{}) {
+			if state == "conn" {
+				atomic.AddInt32(&teardowns, 1)
+			}
+		}),
+	)
+	pool.Start()
+
+	w := stateWorkload{got: make(chan interface{}, 1)}
+	pool.AddTask(w)
+	pool.Shutdown()
+	pool.Wait()
+
+	if state := <-w.got; state != "conn" {
+		t.Errorf("WorkWith got state %v, want conn", state)
+	}
+	if n := atomic.LoadInt32(&teardowns); n != 1 {
+		t.Errorf("teardown called %d times, want 1", n)
+	}
+}
+
+type resultWorkload struct {
+	i   int
+	out int
+}
+
+func (w *resultWorkload) Work() {
+	time.Sleep(time.Duration(w.i%3) * time.Millisecond) // 打乱完成顺序
+	w.out = w.i * w.i
+}
+
+func (w *resultWorkload) Result() interface{} { return w.out }
+
+func TestOrderedResults(t *testing.T) {
+	defer sync.VerifyNone(t)
+	const n = 50
+	pool := NewWorkerpool(4, WithOrderedResults())
+	pool.Start()
+	go func() {
+		for i := 0; i < n; i++ {
+			pool.AddTask(&resultWorkload{i: i})
+		}
+		pool.Shutdown()
+	}()
+
+	i := 0
+	for v := range pool.Results() {
+		if v != i*i {
+			t.Fatalf("result %d = %v, want %d", i, v, i*i)
+		}
+		i++
+	}
+	if i != n {
+		t.Errorf("got %d results, want %d", i, n)
+	}
+	pool.Wait()
+}
+
+func TestQueueBound(t *testing.T) {
+	defer sync.VerifyNone(t)
+	pool := NewWorkerpool(1, WithQueueBound(2))
+	pool.Start()
+
+	release := make(chan struct{})
+	submitted := make(chan struct{})
+	go func() {
+		for i := 0; i < 20; i++ {
+			pool.SubmitFunc(func() { <-release })
+		}
+		close(submitted)
+	}()
+
+	select {
+	case <-submitted:
+		t.Fatal("AddTask did not block on a full queue")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	<-submitted
+	pool.Shutdown()
+	pool.Wait()
+}
+
+func TestSubmitFuture(t *testing.T) {
+	defer sync.VerifyNone(t)
+	pool := NewWorkerpool(2)
+	pool.Start()
+	f, err := SubmitFuture(pool, func() (int, error) { return 6 * 7, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := f.Get(context.Background()); v != 42 || err != nil {
+		t.Errorf("Get = %v, %v, want 42, nil", v, err)
+	}
+	pool.Shutdown()
+	pool.Wait()
+}
+
+func TestWaitDrained(t *testing.T) {
+	defer sync.VerifyNone(t)
+	pool := NewWorkerpool(1)
+	pool.Start()
+	pool.AddTask(nopWorkload{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := pool.WaitDrained(ctx); err != context.DeadlineExceeded {
+		t.Errorf("WaitDrained before Shutdown = %v, want DeadlineExceeded", err)
+	}
+	pool.Shutdown()
+	if err := pool.WaitDrained(context.Background()); err != nil {
+		t.Errorf("WaitDrained after Shutdown = %v", err)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	defer sync.VerifyNone(t)
+	pool := NewWorkerpool(4, WithRateLimit(100, 1))
+	pool.Start()
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		pool.AddTask(nopWorkload{})
+	}
+	pool.Shutdown()
+	pool.Wait()
+	if d := time.Since(start); d < 45*time.Millisecond {
+		t.Errorf("6 tasks at 100/s with burst 1 took %v, want >= 50ms", d)
+	}
+}
+
+// prioWorkload 执行时把自己的优先级记入 order
+type prioWorkload struct {
+	prio  int
+	order *[]int
+}
+
+func (w prioWorkload) Work() { *w.order = append(*w.order, w.prio) }
+
+// 排队的任务按优先级出队
+func TestPriorityOrder(t *testing.T) {
+	defer sync.VerifyNone(t)
+	pool := NewWorkerpool(1, WithChanSize(0, 0), WithPriority(func(a, b IWorkload) bool {
+		return a.(prioWorkload).prio < b.(prioWorkload).prio
+	}))
+	pool.Start()
+	release := make(chan struct{})
+	f, started := blockingTask(release)
+	pool.SubmitFunc(f)
+	<-started
+
+	var order []int
+	for _, prio := range []int{3, 5, 1, 4, 2} {
+		pool.AddTask(prioWorkload{prio: prio, order: &order})
+	}
+	waitFor(t, func() bool { return pool.PendingCount() == 5 })
+	close(release)
+	pool.Shutdown()
+	pool.Wait()
+
+	if want := []int{1, 2, 3, 4, 5}; !reflect.DeepEqual(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+}
+
+// 排队的任务后进先出
+func TestLIFOOrder(t *testing.T) {
+	defer sync.VerifyNone(t)
+	pool := NewWorkerpool(1, WithChanSize(0, 0), WithLIFO())
+	pool.Start()
+	release := make(chan struct{})
+	f, started := blockingTask(release)
+	pool.SubmitFunc(f)
+	<-started
+
+	var order []int
+	for i := 1; i <= 5; i++ {
+		pool.AddTask(prioWorkload{prio: i, order: &order})
+	}
+	waitFor(t, func() bool { return pool.PendingCount() == 5 })
+	close(release)
+	pool.Shutdown()
+	pool.Wait()
+
+	if want := []int{5, 4, 3, 2, 1}; !reflect.DeepEqual(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+}
+
+// 协程都被占住时提交的任务计入 PendingCount，包括通道中缓冲的；
+// Shutdown 之后积压的任务仍会全部执行，执行完之前 Out 不关闭、工作池不会排空
+func TestPendingCount(t *testing.T) {
+	defer sync.VerifyNone(t)
+	pool := NewWorkerpool(1)
+	pool.Start()
+	release := make(chan struct{})
+	f, started := blockingTask(release)
+	pool.SubmitFunc(f)
+	<-started
+	waitFor(t, func() bool { return pool.PendingCount() == 0 })
+
+	var ran int32
+	const n = 5
+	for i := 0; i < n; i++ {
+		pool.SubmitFunc(func() { atomic.AddInt32(&ran, 1) })
+	}
+	waitFor(t, func() bool { return pool.PendingCount() == n })
+
+	pool.Shutdown()
+	if err := pool.AddTask(nopWorkload{}); err != ErrPoolClosed {
+		t.Errorf("AddTask after Shutdown = %v, want ErrPoolClosed", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if c := pool.PendingCount(); c != n {
+		t.Errorf("PendingCount after Shutdown = %d, want %d", c, n)
+	}
+	select {
+	case <-pool.Drained():
+		t.Fatal("pool drained with tasks still queued")
+	default:
+	}
+
+	close(release)
+	pool.Wait()
+	<-pool.Drained()
+	if r := atomic.LoadInt32(&ran); r != n {
+		t.Errorf("ran %d queued tasks, want %d", r, n)
+	}
+	if c := pool.PendingCount(); c != 0 {
+		t.Errorf("PendingCount after drain = %d, want 0", c)
+	}
+}
+
+// GetWaitCount 是已启动的协程数，其中执行任务的计入 RunningWorkers，等待任务的计入 IdleWorkers
+func TestRunningIdleWorkers(t *testing.T) {
+	defer sync.VerifyNone(t)
+	pool := NewWorkerpool(3)
+	pool.Start()
+	check := func(workers, running, idle int) {
+		t.Helper()
+		waitFor(t, func() bool {
+			return int(pool.GetWaitCount()) == workers && pool.RunningWorkers() == running && pool.IdleWorkers() == idle
+		})
+	}
+	check(1, 0, 1)
+
+	first, second := make(chan struct{}), make(chan struct{})
+	f, started := blockingTask(first)
+	pool.SubmitFunc(f)
+	<-started
+	g, started := blockingTask(second)
+	pool.SubmitFunc(g)
+	<-started
+	check(2, 2, 0)
+
+	close(first)
+	check(2, 1, 1)
+	close(second)
+	check(2, 0, 2)
+
+	pool.Shutdown()
+	pool.Wait()
+	check(0, 0, 0)
+}
+
+// WithPrefork 在 Start 时启动全部协程，这些协程空闲超时后也不退出；没有 WithPrefork 时 Start 只启动一个，空闲超时后退出
+func TestPrefork(t *testing.T) {
+	for _, c := range []struct {
+		name          string
+		opts          []Option
+		started, left int
+	}{
+		{"Prefork", []Option{WithPrefork()}, 4, 4},
+		{"Lazy", nil, 1, 0},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			defer sync.VerifyNone(t)
+			pool := NewWorkerpool(4, append(c.opts, WithIdleTimeout(10*time.Millisecond))...)
+			if n := pool.GetWaitCount(); n != 0 {
+				t.Errorf("workers before Start = %d, want 0", n)
+			}
+			pool.Start()
+			if n := int(pool.GetWaitCount()); n != c.started {
+				t.Errorf("workers after Start = %d, want %d", n, c.started)
+			}
+			waitFor(t, func() bool { return pool.IdleWorkers() == c.started })
+
+			time.Sleep(50 * time.Millisecond) // 数倍于空闲超时
+			if n := int(pool.GetWaitCount()); n != c.left {
+				t.Errorf("workers after idle timeout = %d, want %d", n, c.left)
+			}
+			pool.Shutdown()
+			pool.Wait()
+		})
+	}
+}
+
+var errInitFailed = errors.New("init failed")
+
+// workerState 是测试用的协程私有状态
+type workerState struct {
+	torndown int32
+}
+
+// workerHooks 返回记录每个协程私有状态的 WithWorkerInit 和 WithWorkerTeardown，failFirst 为 true 时第一次初始化失败
+func workerHooks(failFirst bool) (states func() []*workerState, opts []Option) {
+	var mu stdsync.Mutex
+	var all []*workerState
+	init := func() (interface{}, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if failFirst {
+			failFirst = false
+			return nil, errInitFailed
+		}
+		s := &workerState{}
+		all = append(all, s)
+		return s, nil
+	}
+	teardown := func(s interface{}) { atomic.AddInt32(&s.(*workerState).torndown, 1) }
+	return func() []*workerState {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]*workerState(nil), all...)
+	}, []Option{WithWorkerInit(init), WithWorkerTeardown(teardown)}
+}
+
+// 每个协程初始化一次私有状态，执行的任务都拿到所在协程的状态，协程在 Down 时退出并释放状态
+func TestWorkerStateTeardownOnDown(t *testing.T) {
+	defer sync.VerifyNone(t)
+	states, opts := workerHooks(false)
+	pool := NewWorkerpool(2, append(opts, WithPrefork())...)
+	pool.Start()
+	waitFor(t, func() bool { return len(states()) == 2 })
+
+	w := stateWorkload{got: make(chan interface{}, 20)}
+	for i := 0; i < cap(w.got); i++ {
+		pool.AddTask(w)
+	}
+	own := make(map[*workerState]bool)
+	for _, s := range states() {
+		own[s] = true
+	}
+	for i := 0; i < cap(w.got); i++ {
+		if s, _ := (<-w.got).(*workerState); !own[s] {
+			t.Fatalf("task got state %v, not created by WithWorkerInit", s)
+		}
+	}
+
+	pool.Down()
+	pool.Wait()
+	for i, s := range states() {
+		if n := atomic.LoadInt32(&s.torndown); n != 1 {
+			t.Errorf("worker %d: teardown called %d times, want 1", i, n)
+		}
+	}
+}
+
+// 空闲收缩的协程退出时释放状态
+func TestWorkerStateTeardownOnRetire(t *testing.T) {
+	defer sync.VerifyNone(t)
+	states, opts := workerHooks(false)
+	pool := NewWorkerpool(1, append(opts, WithIdleTimeout(10*time.Millisecond))...)
+	pool.Start()
+	waitFor(t, func() bool { return len(states()) == 1 })
+	waitFor(t, func() bool { return atomic.LoadInt32(&states()[0].torndown) == 1 })
+	waitFor(t, func() bool { return pool.GetWaitCount() == 0 }) // teardown 在协程退出之前调用
+	pool.Shutdown()
+	pool.Wait()
+}
+
+// 初始化失败的协程把错误交给错误处理函数后直接退出，不领取任务也不调用 teardown，之后提交的任务由新协程执行
+func TestWorkerInitError(t *testing.T) {
+	defer sync.VerifyNone(t)
+	states, opts := workerHooks(true)
+	errs := make(chan error, 1)
+	pool := NewWorkerpool(1, append(opts, WithErrorHandler(func(err error) { errs <- err }))...)
+	pool.Start()
+	if err := <-errs; err != errInitFailed {
+		t.Fatalf("error handler got %v, want errInitFailed", err)
+	}
+	waitFor(t, func() bool { return pool.GetWaitCount() == 0 })
+
+	w := stateWorkload{got: make(chan interface{}, 1)}
+	pool.AddTask(w)
+	if s := <-w.got; s == nil {
+		t.Error("task got nil state")
+	}
+	pool.Shutdown()
+	pool.Wait()
+	if all := states(); len(all) != 1 || atomic.LoadInt32(&all[0].torndown) != 1 {
+		t.Errorf("states = %v, want one torn down state", all)
+	}
+}
+
+// 并发的 TryAddTask 一起抢占时，接受的任务数恰好是能立即执行的加上 maxQueue 个
+func TestTryAddTask(t *testing.T) {
+	defer sync.VerifyNone(t)
+	for _, maxQueue := range []int{0, 3} {
+		pool := NewWorkerpool(1)
+		pool.Start()
+		release := make(chan struct{})
+		var accepted, full int32
+		var wg stdsync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				f, _ := blockingTask(release)
+				switch err := pool.TryAddTask(context.Background(), funcWorkload(f), maxQueue); err {
+				case nil:
+					atomic.AddInt32(&accepted, 1)
+				case ErrPoolFull:
+					atomic.AddInt32(&full, 1)
+				default:
+					t.Errorf("TryAddTask = %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+		if a, f := atomic.LoadInt32(&accepted), atomic.LoadInt32(&full); a != int32(1+maxQueue) || f != int32(49-maxQueue) {
+			t.Errorf("maxQueue %d: accepted/full = %d/%d, want %d/%d", maxQueue, a, f, 1+maxQueue, 49-maxQueue)
+		}
+		close(release)
+		pool.Shutdown()
+		pool.Wait()
+		if err := pool.TryAddTask(context.Background(), nopWorkload{}, maxQueue); err != ErrPoolClosed {
+			t.Errorf("TryAddTask after Shutdown = %v, want ErrPoolClosed", err)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package xsync
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Atomic 是类型安全的原子值，零值的 Load 返回 T 的零值。
+// 内部用 atomic.Value 保存一层包装，因此 T 为接口类型时也可以存入不同的动态类型以及 nil
+type Atomic[T any] struct {
+	_ noCopy
+	v atomic.Value // atomicBox[T]
+}
+
+type atomicBox[T any] struct {
+	v T
+}
+
+func (a *Atomic[T]) Load() T {
+	b, _ := a.v.Load().(atomicBox[T])
+	return b.v
+}
+
+func (a *Atomic[T]) Store(v T) {
+	a.v.Store(atomicBox[T]{v})
+}
+
+// Swap 存入 v 并返回旧值
+func (a *Atomic[T]) Swap(v T) T {
+	b, _ := a.v.Swap(atomicBox[T]{v}).(atomicBox[T])
+	return b.v
+}
+
+// CompareAndSwap 当前值等于 old 时存入 new 并返回 true，T 的动态值不可比较时 panic
+func (a *Atomic[T]) CompareAndSwap(old, new T) bool {
+	if a.v.CompareAndSwap(atomicBox[T]{old}, atomicBox[T]{new}) {
+		return true
+	}
+	// 从未存入过时 atomic.Value 中为 nil，视为零值
+	var zero T
+	return a.v.Load() == nil && any(old) == any(zero) && a.v.CompareAndSwap(nil, atomicBox[T]{new})
+}
+
+// AtomicBool 是原子的 bool，零值为 false
+type AtomicBool struct {
+	_ noCopy
+	v uint32
+}
+
+func (b *AtomicBool) Load() bool {
+	return atomic.LoadUint32(&b.v) != 0
+}
+
+func (b *AtomicBool) Store(v bool) {
+	atomic.StoreUint32(&b.v, b2u(v))
+}
+
+// Swap 存入 v 并返回旧值
+func (b *AtomicBool) Swap(v bool) bool {
+	return atomic.SwapUint32(&b.v, b2u(v)) != 0
+}
+
+func (b *AtomicBool) CompareAndSwap(old, new bool) bool {
+	return atomic.CompareAndSwapUint32(&b.v, b2u(old), b2u(new))
+}
+
+func b2u(v bool) uint32 {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+// AtomicDuration 是原子的 time.Duration
+type AtomicDuration struct {
+	_ noCopy
+	v int64
+}
+
+func (d *AtomicDuration) Load() time.Duration {
+	return time.Duration(atomic.LoadInt64(&d.v))
+}
+
+func (d *AtomicDuration) Store(v time.Duration) {
+	atomic.StoreInt64(&d.v, int64(v))
+}
+
+// Add 加上 delta 并返回新值
+func (d *AtomicDuration) Add(delta time.Duration) time.Duration {
+	return time.Duration(atomic.AddInt64(&d.v, int64(delta)))
+}
+
+func (d *AtomicDuration) Swap(v time.Duration) time.Duration {
+	return time.Duration(atomic.SwapInt64(&d.v, int64(v)))
+}
+
+func (d *AtomicDuration) CompareAndSwap(old, new time.Duration) bool {
+	return atomic.CompareAndSwapInt64(&d.v, int64(old), int64(new))
+}
+
+// AtomicError 是原子的 error，可以存入 nil 以及不同类型的错误
+type AtomicError = Atomic[error]
+
+// AtomicUint64 是原子的 uint64，64 位对齐由调用方保证（放在结构体首位）
+type AtomicUint64 struct {
+	_ noCopy
+	v uint64
+}
+
+func (u *AtomicUint64) Load() uint64 {
+	return atomic.LoadUint64(&u.v)
+}
+
+func (u *AtomicUint64) Store(v uint64) {
+	atomic.StoreUint64(&u.v, v)
+}
+
+// Add 加上 delta 并返回新值，减一可传入 ^uint64(0)
+func (u *AtomicUint64) Add(delta uint64) uint64 {
+	return atomic.AddUint64(&u.v, delta)
+}
+
+func (u *AtomicUint64) Swap(v uint64) uint64 {
+	return atomic.SwapUint64(&u.v, v)
+}
+
+func (u *AtomicUint64) CompareAndSwap(old, new uint64) bool {
+	return atomic.CompareAndSwapUint64(&u.v, old, new)
+}
@@ -0,0 +1,37 @@
+package xsync
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAtomic(t *testing.T) {
+	var a Atomic[string]
+	if v := a.Load(); v != "" {
+		t.Errorf("zero Load = %q", v)
+	}
+	if !a.CompareAndSwap("", "x") {
+		t.Error("CompareAndSwap from zero value failed")
+	}
+	if old := a.Swap("y"); old != "x" || a.Load() != "y" {
+		t.Errorf("Swap returned %q, value %q", old, a.Load())
+	}
+
+	var e AtomicError
+	boom := errors.New("boom")
+	e.Store(boom)
+	e.Store(nil) // 不同的动态类型以及 nil 都可以存入
+	if !e.CompareAndSwap(nil, boom) || e.Load() != boom {
+		t.Error("AtomicError CompareAndSwap failed")
+	}
+
+	var b AtomicBool
+	if b.Swap(true) || !b.Load() || b.CompareAndSwap(false, true) {
+		t.Error("AtomicBool misbehaves")
+	}
+	var d AtomicDuration
+	if d.Add(time.Second) != time.Second {
+		t.Error("AtomicDuration Add failed")
+	}
+}
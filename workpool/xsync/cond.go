@@ -0,0 +1,66 @@
+package xsync
+
+import (
+	"context"
+	"sync"
+)
+
+// Cond 是可被 context 打断的条件变量，用法与 sync.Cond 相同，但 Wait 接受 ctx
+type Cond struct {
+	L sync.Locker
+
+	mu      sync.Mutex
+	waiters []chan struct{} // 按等待顺序排列，Signal 唤醒最早的一个
+}
+
+func NewCond(l sync.Locker) *Cond {
+	return &Cond{L: l}
+}
+
+// Wait 原子地解锁 c.L 并挂起，被 Signal/Broadcast 唤醒或 ctx 结束后重新锁住 c.L 再返回。
+// ctx 结束时返回 ctx.Err()；与 sync.Cond 一样，被唤醒后调用方需在循环中重新检查条件
+func (c *Cond) Wait(ctx context.Context) error {
+	ch := make(chan struct{})
+	c.mu.Lock()
+	c.waiters = append(c.waiters, ch)
+	c.mu.Unlock()
+
+	c.L.Unlock()
+	defer c.L.Lock()
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, w := range c.waiters {
+		if w == ch {
+			c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+			return ctx.Err()
+		}
+	}
+	return nil // 与 ctx 结束同时被唤醒，视为被唤醒，避免 Signal 丢失
+}
+
+// Signal 唤醒一个等待者（如有），调用时可以不持有 c.L
+func (c *Cond) Signal() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.waiters) > 0 {
+		close(c.waiters[0])
+		c.waiters[0] = nil
+		c.waiters = c.waiters[1:]
+	}
+}
+
+// Broadcast 唤醒所有等待者，调用时可以不持有 c.L
+func (c *Cond) Broadcast() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, w := range c.waiters {
+		close(w)
+	}
+	c.waiters = nil
+}
@@ -0,0 +1,52 @@
+package xsync
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCond(t *testing.T) {
+	var mu sync.Mutex
+	c := NewCond(&mu)
+	ready := false
+
+	var wg ExtWaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Go(func() error {
+			mu.Lock()
+			defer mu.Unlock()
+			for !ready {
+				if err := c.Wait(context.Background()); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+	time.Sleep(10 * time.Millisecond)
+	mu.Lock()
+	ready = true
+	mu.Unlock()
+	c.Broadcast()
+	if err := wg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCondWaitCanceled(t *testing.T) {
+	var mu sync.Mutex
+	c := NewCond(&mu)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	mu.Lock()
+	if err := c.Wait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Wait = %v, want DeadlineExceeded", err)
+	}
+	mu.Unlock() // Wait 返回时已重新持有锁
+	if n := len(c.waiters); n != 0 {
+		t.Errorf("%d waiters left after cancellation", n)
+	}
+}
@@ -0,0 +1,58 @@
+package xsync
+
+import (
+	"sync"
+	"time"
+)
+
+// Debounce 返回一个函数，每次调用都会推迟 fn 的执行，直到距最后一次调用过去 d 之后才执行一次 fn，
+// 用于合并配置文件连续多次写入这类成串的事件。fn 在单独的协程中执行
+func Debounce(d time.Duration, fn func()) func() {
+	var (
+		mu    sync.Mutex
+		timer *time.Timer
+	)
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if timer == nil {
+			timer = time.AfterFunc(d, fn)
+			return
+		}
+		timer.Reset(d)
+	}
+}
+
+// Throttle 返回一个函数，使 fn 每个 interval 内最多执行一次：
+// 距上次执行已超过 interval 时立即在调用方协程中执行，否则在本周期结束时于单独的协程中补执行一次，
+// 期间的多次调用被合并，最后一次调用总会引起一次执行，适合水位线回调这类频繁触发的通知
+func Throttle(interval time.Duration, fn func()) func() {
+	var (
+		mu      sync.Mutex
+		last    time.Time // 上次执行的时间
+		pending bool      // 已安排补执行
+	)
+	return func() {
+		mu.Lock()
+		if pending {
+			mu.Unlock()
+			return
+		}
+		now := time.Now()
+		if wait := last.Add(interval).Sub(now); wait > 0 {
+			pending = true
+			mu.Unlock()
+			time.AfterFunc(wait, func() {
+				mu.Lock()
+				pending = false
+				last = time.Now()
+				mu.Unlock()
+				fn()
+			})
+			return
+		}
+		last = now
+		mu.Unlock()
+		fn()
+	}
+}
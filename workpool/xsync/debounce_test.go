@@ -0,0 +1,35 @@
+package xsync
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDebounce(t *testing.T) {
+	var n int32
+	f := Debounce(20*time.Millisecond, func() { atomic.AddInt32(&n, 1) })
+	for i := 0; i < 5; i++ {
+		f()
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if got := atomic.LoadInt32(&n); got != 1 {
+		t.Errorf("fn ran %d times, want 1", got)
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	var n int32
+	f := Throttle(50*time.Millisecond, func() { atomic.AddInt32(&n, 1) })
+	for i := 0; i < 10; i++ {
+		f()
+	}
+	if got := atomic.LoadInt32(&n); got != 1 {
+		t.Errorf("fn ran %d times immediately, want 1 (leading call)", got)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if got := atomic.LoadInt32(&n); got != 2 {
+		t.Errorf("fn ran %d times, want 2 (leading + trailing)", got)
+	}
+}
@@ -0,0 +1,160 @@
+//go:build xsyncdebug
+
+package xsync
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// DebugMutex 是用于排查死锁的互斥锁，只在使用 xsyncdebug 构建标签时生效：
+//
+//	go test -tags xsyncdebug ./...
+//
+// 它记录持有者的协程 id 以及各锁之间的获取顺序，发现以下情况时连同调用栈一起报告：
+//   - 加锁顺序出现环（持有 A 时获取 B，另一处持有 B 时获取 A），即潜在的死锁；
+//   - 同一协程重复加锁；
+//   - 持有时间超过 SetDebugMutexOptions 设置的上限。
+//
+// 为了记录加锁顺序，用过的 DebugMutex 不会被回收，只适合调试。零值可直接使用
+type DebugMutex struct {
+	Name string // 出现在报告中，便于辨认，为空时使用地址
+
+	mu       sync.Mutex
+	holder   uint64      // 持有者的协程 id
+	holdTime *time.Timer // 持有超时后报告
+}
+
+// debugMutexState 是所有 DebugMutex 共享的记录
+type debugMutexState struct {
+	sync.Mutex
+	holdLimit time.Duration
+	report    func(msg string)
+	held      map[uint64][]*DebugMutex               // 协程 id -> 按获取顺序排列的已持有的锁
+	order     map[*DebugMutex]map[*DebugMutex][]byte // a -> b -> 持有 a 时首次获取 b 的调用栈
+}
+
+var debugMutexes = debugMutexState{
+	report: func(msg string) { fmt.Fprintln(os.Stderr, msg) },
+	held:   make(map[uint64][]*DebugMutex),
+	order:  make(map[*DebugMutex]map[*DebugMutex][]byte),
+}
+
+// SetDebugMutexOptions 设置持有时间上限（<= 0 时不检查）以及报告的输出方式（为 nil 时写到标准错误）
+func SetDebugMutexOptions(holdLimit time.Duration, report func(msg string)) {
+	if report == nil {
+		report = func(msg string) { fmt.Fprintln(os.Stderr, msg) }
+	}
+	debugMutexes.Lock()
+	defer debugMutexes.Unlock()
+	debugMutexes.holdLimit, debugMutexes.report = holdLimit, report
+}
+
+func (m *DebugMutex) String() string {
+	if m.Name != "" {
+		return m.Name
+	}
+	return fmt.Sprintf("DebugMutex@%p", m)
+}
+
+func (m *DebugMutex) Lock() {
+	gid := goroutineID()
+	m.checkOrder(gid)
+	m.mu.Lock()
+
+	d := &debugMutexes
+	d.Lock()
+	d.held[gid] = append(d.held[gid], m)
+	limit, report := d.holdLimit, d.report
+	d.Unlock()
+	m.holder = gid
+	if limit > 0 {
+		m.holdTime = time.AfterFunc(limit, func() {
+			report(fmt.Sprintf("xsync: %v held for more than %v by goroutine %d:\n%s",
+				m, limit, gid, stackOf(allStacks(), gid)))
+		})
+	}
+}
+
+func (m *DebugMutex) Unlock() {
+	if m.holdTime != nil {
+		m.holdTime.Stop()
+		m.holdTime = nil
+	}
+	d := &debugMutexes
+	d.Lock()
+	held := d.held[m.holder]
+	for i := len(held) - 1; i >= 0; i-- {
+		if held[i] == m {
+			held = append(held[:i], held[i+1:]...)
+			break
+		}
+	}
+	if len(held) == 0 {
+		delete(d.held, m.holder)
+	} else {
+		d.held[m.holder] = held
+	}
+	d.Unlock()
+	m.holder = 0
+	m.mu.Unlock()
+}
+
+// checkOrder 在协程 gid 获取 m 之前，记录它已持有的锁到 m 的顺序，并报告由此形成的环
+func (m *DebugMutex) checkOrder(gid uint64) {
+	d := &debugMutexes
+	var msgs []string
+	d.Lock()
+	for _, h := range d.held[gid] {
+		if h == m {
+			msgs = append(msgs, fmt.Sprintf("xsync: %v locked twice by goroutine %d:\n%s", m, gid, currentStack()))
+			continue
+		}
+		if _, ok := d.order[h][m]; ok {
+			continue
+		}
+		stack := currentStack()
+		if d.order[h] == nil {
+			d.order[h] = make(map[*DebugMutex][]byte)
+		}
+		d.order[h][m] = stack
+		if prev, ok := d.pathLocked(m, h); ok {
+			msgs = append(msgs, fmt.Sprintf("xsync: lock order inversion: %v -> %v here:\n%s\n\nbut previously %v -> %v:\n%s",
+				h, m, stack, m, h, prev))
+		}
+	}
+	report := d.report
+	d.Unlock()
+	for _, msg := range msgs {
+		report(msg)
+	}
+}
+
+// pathLocked 判断加锁顺序图中是否存在 from 到 to 的路径，存在时返回路径上第一条边的调用栈
+func (d *debugMutexState) pathLocked(from, to *DebugMutex) ([]byte, bool) {
+	seen := make(map[*DebugMutex]bool)
+	var visit func(n *DebugMutex) bool
+	visit = func(n *DebugMutex) bool {
+		if n == to {
+			return true
+		}
+		if seen[n] {
+			return false
+		}
+		seen[n] = true
+		for next := range d.order[n] {
+			if visit(next) {
+				return true
+			}
+		}
+		return false
+	}
+	for next, stack := range d.order[from] {
+		if visit(next) {
+			return stack, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,27 @@
+//go:build !xsyncdebug
+
+package xsync
+
+import (
+	"sync"
+	"time"
+)
+
+// DebugMutex 在未使用 xsyncdebug 构建标签时就是普通的互斥锁，没有额外开销，
+// 开启后的死锁检测见 debugmutex.go
+type DebugMutex struct {
+	Name string // 出现在报告中，便于辨认
+
+	mu sync.Mutex
+}
+
+// SetDebugMutexOptions 未使用 xsyncdebug 构建标签时什么也不做
+func SetDebugMutexOptions(holdLimit time.Duration, report func(msg string)) {}
+
+func (m *DebugMutex) Lock() {
+	m.mu.Lock()
+}
+
+func (m *DebugMutex) Unlock() {
+	m.mu.Unlock()
+}
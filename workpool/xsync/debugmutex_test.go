@@ -0,0 +1,46 @@
+//go:build xsyncdebug
+
+package xsync
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 需使用 go test -tags xsyncdebug 运行
+func TestDebugMutex(t *testing.T) {
+	var mu sync.Mutex
+	var reports []string
+	SetDebugMutexOptions(10*time.Millisecond, func(msg string) {
+		mu.Lock()
+		reports = append(reports, msg)
+		mu.Unlock()
+	})
+	defer SetDebugMutexOptions(0, nil)
+
+	a, b := &DebugMutex{Name: "a"}, &DebugMutex{Name: "b"}
+	a.Lock()
+	b.Lock()
+	b.Unlock()
+	a.Unlock()
+	b.Lock()
+	a.Lock() // 与上面的顺序相反，虽然没有真的死锁，但应报告
+	a.Unlock()
+	b.Unlock()
+
+	a.Lock()
+	time.Sleep(30 * time.Millisecond)
+	a.Unlock()
+
+	mu.Lock()
+	defer mu.Unlock()
+	all := strings.Join(reports, "\n")
+	if !strings.Contains(all, "lock order inversion: b -> a") {
+		t.Errorf("lock order inversion not reported:\n%s", all)
+	}
+	if !strings.Contains(all, "a held for more than") {
+		t.Errorf("long hold not reported:\n%s", all)
+	}
+}
@@ -0,0 +1,57 @@
+package xsync
+
+import (
+	"context"
+	"sync"
+)
+
+// Event 是一次性的电平触发事件：Set 之后所有正在以及之后的 Wait 都立即返回，不能复位。
+// 用于向多个等待者通知“工作池已排空”这类状态转换，零值可直接使用
+type Event struct {
+	mu  sync.Mutex
+	ch  chan struct{} // Set 时关闭，首次使用时创建
+	set bool
+}
+
+func (e *Event) chLocked() chan struct{} {
+	if e.ch == nil {
+		e.ch = make(chan struct{})
+	}
+	return e.ch
+}
+
+// Set 触发事件，只有第一次调用返回 true
+func (e *Event) Set() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.set {
+		return false
+	}
+	e.set = true
+	close(e.chLocked())
+	return true
+}
+
+// IsSet 返回事件是否已触发
+func (e *Event) IsSet() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.set
+}
+
+// Done 返回事件触发时关闭的通道，可与其他通道一起 select
+func (e *Event) Done() <-chan struct{} {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.chLocked()
+}
+
+// Wait 阻塞直到事件触发，ctx 先结束时返回 ctx.Err()
+func (e *Event) Wait(ctx context.Context) error {
+	select {
+	case <-e.Done():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,33 @@
+package xsync
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestEvent(t *testing.T) {
+	var e Event
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := e.Wait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Wait before Set = %v, want DeadlineExceeded", err)
+	}
+
+	var waiters ExtWaitGroup
+	for i := 0; i < 3; i++ {
+		waiters.Go(func() error { return e.Wait(context.Background()) })
+	}
+	if !e.Set() {
+		t.Error("first Set returned false")
+	}
+	if e.Set() {
+		t.Error("second Set returned true")
+	}
+	if err := waiters.Wait(); err != nil {
+		t.Error(err)
+	}
+	if !e.IsSet() {
+		t.Error("IsSet = false after Set")
+	}
+}
@@ -0,0 +1,47 @@
+package xsync
+
+import "sync"
+
+// FairMutex 是严格按到达顺序授予所有权的互斥锁（排号锁语义）：解锁时直接把锁交给等待最久的协程，
+// 新来的协程不能插队。
+//
+// 标准库的 sync.Mutex 允许新来的协程与被唤醒的协程竞争（正在 CPU 上运行的新协程往往获胜），
+// 吞吐量更高，但个别等待者的延迟可能很长，直到等待超过 1ms 才切换到饥饿模式按顺序交接。
+// FairMutex 每次交接都需要唤醒一个协程，吞吐量和典型的等待时间都明显更差，
+// 换来的是没有协程会被反复插队，最长等待时间有界，两者的对比见 BenchmarkMutexFairness。零值可直接使用
+type FairMutex struct {
+	mu      sync.Mutex
+	locked  bool
+	waiters []chan struct{} // 按到达顺序排列
+}
+
+var _ sync.Locker = (*FairMutex)(nil)
+
+func (m *FairMutex) Lock() {
+	m.mu.Lock()
+	if !m.locked && len(m.waiters) == 0 {
+		m.locked = true
+		m.mu.Unlock()
+		return
+	}
+	ch := make(chan struct{})
+	m.waiters = append(m.waiters, ch)
+	m.mu.Unlock()
+	<-ch // Unlock 直接把锁交给我们，locked 保持为 true
+}
+
+// Unlock 解锁，有等待者时把锁交给最早到达的一个；锁未被持有时 panic
+func (m *FairMutex) Unlock() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.locked {
+		panic("xsync: unlock of unlocked FairMutex")
+	}
+	if len(m.waiters) == 0 {
+		m.locked = false
+		return
+	}
+	close(m.waiters[0])
+	m.waiters[0] = nil
+	m.waiters = m.waiters[1:]
+}
@@ -0,0 +1,137 @@
+package xsync
+
+import (
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFairMutexOrder(t *testing.T) {
+	var m FairMutex
+	m.Lock()
+	var order []int
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			m.Lock()
+			order = append(order, i)
+			m.Unlock()
+		}(i)
+		for { // 等第 i 个协程排上队再启动下一个，使到达顺序确定
+			m.mu.Lock()
+			n := len(m.waiters)
+			m.mu.Unlock()
+			if n == i+1 {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	m.Unlock()
+	wg.Wait()
+	for i, v := range order {
+		if v != i {
+			t.Fatalf("lock order = %v, want arrival order", order)
+		}
+	}
+}
+
+// benchmarkLock 让多个协程争用同一把锁，除吞吐量外报告每次加锁等待时间的 p99 和最大值
+func benchmarkLock(b *testing.B, l sync.Locker) {
+	var mu sync.Mutex
+	var waits []time.Duration
+	b.RunParallel(func(pb *testing.PB) {
+		local := make([]time.Duration, 0, 1024)
+		for pb.Next() {
+			start := time.Now()
+			l.Lock()
+			local = append(local, time.Since(start))
+			for i := 0; i < 50; i++ { // 临界区中做少量工作
+			}
+			l.Unlock()
+		}
+		mu.Lock()
+		waits = append(waits, local...)
+		mu.Unlock()
+	})
+	if len(waits) == 0 {
+		return
+	}
+	sort.Slice(waits, func(i, j int) bool { return waits[i] < waits[j] })
+	b.ReportMetric(float64(waits[len(waits)*99/100].Nanoseconds()), "p99-ns")
+	b.ReportMetric(float64(waits[len(waits)-1].Nanoseconds()), "max-ns")
+}
+
+// BenchmarkMutexFairness 对比允许插队的 sync.Mutex 与严格 FIFO 的 FairMutex：
+// 前者吞吐量高、p99 等待短，但最长等待可能很长；后者每次都要交接，p99 更高，最长等待更短
+func BenchmarkMutexFairness(b *testing.B) {
+	b.Run("sync.Mutex", func(b *testing.B) { benchmarkLock(b, &sync.Mutex{}) })
+	b.Run("FairMutex", func(b *testing.B) { benchmarkLock(b, &FairMutex{}) })
+}
+
+// lockModes 与 tearUpGo 中注释版 sync（src/sync/mutex_modes_test.go）的场景和子测试名相同，
+// 两边的 BenchmarkLockModes 结果可以直接用 benchstat 对比
+var lockModes = []struct {
+	name        string
+	parallelism int           // 每个 P 的协程数，见 b.SetParallelism；0 表示单个协程
+	hold        time.Duration // 临界区的长度
+}{
+	{"uncontended", 0, 0},
+	{"normal", 1, time.Microsecond},         // 等待很少超过 1ms，sync.Mutex 保持在正常模式
+	{"starving", 4, 100 * time.Microsecond}, // 等待很快超过 1ms，sync.Mutex 进入饥饿模式
+}
+
+// BenchmarkLockModes 对比 sync.Mutex 在正常模式和饥饿模式下与 FairMutex 的吞吐和等待时间：
+// FairMutex 始终按到达顺序交接，相当于一直处于饥饿模式
+func BenchmarkLockModes(b *testing.B) {
+	locks := []struct {
+		name string
+		new  func() sync.Locker
+	}{
+		{"sync.Mutex", func() sync.Locker { return new(sync.Mutex) }},
+		{"FairMutex", func() sync.Locker { return new(FairMutex) }},
+	}
+	for _, l := range locks {
+		for _, m := range lockModes {
+			b.Run(l.name+"/"+m.name, func(b *testing.B) {
+				benchmarkLockMode(b, l.new(), m.parallelism, m.hold)
+			})
+		}
+	}
+}
+
+// benchmarkLockMode 与 benchmarkLock 相同，但临界区忙等 hold，协程数由 parallelism 决定
+func benchmarkLockMode(b *testing.B, l sync.Locker, parallelism int, hold time.Duration) {
+	var mu sync.Mutex
+	var waits []time.Duration
+	worker := func(next func() bool) {
+		local := make([]time.Duration, 0, 1024)
+		for next() {
+			start := time.Now()
+			l.Lock()
+			local = append(local, time.Since(start))
+			for end := time.Now().Add(hold); time.Now().Before(end); {
+			}
+			l.Unlock()
+		}
+		mu.Lock()
+		waits = append(waits, local...)
+		mu.Unlock()
+	}
+	if parallelism == 0 {
+		i := 0
+		worker(func() bool { i++; return i <= b.N })
+	} else {
+		b.SetParallelism(parallelism)
+		b.RunParallel(func(pb *testing.PB) { worker(pb.Next) })
+	}
+	if len(waits) == 0 {
+		return
+	}
+	sort.Slice(waits, func(i, j int) bool { return waits[i] < waits[j] })
+	b.ReportMetric(float64(waits[len(waits)*99/100].Nanoseconds()), "p99-ns")
+	b.ReportMetric(float64(waits[len(waits)-1].Nanoseconds()), "max-ns")
+}
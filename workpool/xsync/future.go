@@ -0,0 +1,69 @@
+package xsync
+
+import (
+	"context"
+	"sync"
+)
+
+// Promise 是 Future 的写入端，结果只能设置一次
+type Promise[T any] struct {
+	f *Future[T]
+}
+
+// Future 是异步计算结果的读取端，可被多个协程同时等待
+type Future[T any] struct {
+	_ noCopy
+
+	once sync.Once
+	done chan struct{} // 结果设置后关闭
+	val  T
+	err  error
+}
+
+// NewPromise 创建一对相互关联的 Promise 和 Future
+func NewPromise[T any]() (*Promise[T], *Future[T]) {
+	f := &Future[T]{done: make(chan struct{})}
+	return &Promise[T]{f: f}, f
+}
+
+// Set 设置结果并唤醒所有等待者，只有第一次调用生效，之后返回 false
+func (p *Promise[T]) Set(val T, err error) bool {
+	set := false
+	p.f.once.Do(func() {
+		p.f.val, p.f.err = val, err
+		close(p.f.done)
+		set = true
+	})
+	return set
+}
+
+// Future 返回与 p 关联的 Future
+func (p *Promise[T]) Future() *Future[T] {
+	return p.f
+}
+
+// Done 返回结果设置后关闭的通道，可与其他通道一起 select
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Get 等待并返回结果，ctx 先结束时返回 T 的零值和 ctx.Err()
+func (f *Future[T]) Get(ctx context.Context) (T, error) {
+	select {
+	case <-f.done:
+		return f.val, f.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// TryGet 非阻塞地读取结果，结果还未设置时 ok 为 false
+func (f *Future[T]) TryGet() (val T, err error, ok bool) {
+	select {
+	case <-f.done:
+		return f.val, f.err, true
+	default:
+		return val, nil, false
+	}
+}
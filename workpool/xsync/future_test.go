@@ -0,0 +1,31 @@
+package xsync
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPromise(t *testing.T) {
+	p, f := NewPromise[int]()
+	if _, _, ok := f.TryGet(); ok {
+		t.Error("TryGet before Set returned ok")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := f.Get(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Get before Set = %v, want DeadlineExceeded", err)
+	}
+
+	go p.Set(42, nil)
+	if v, err := f.Get(context.Background()); v != 42 || err != nil {
+		t.Errorf("Get = %v, %v, want 42, nil", v, err)
+	}
+	if p.Set(0, errors.New("late")) {
+		t.Error("second Set returned true")
+	}
+	if v, _, _ := f.TryGet(); v != 42 {
+		t.Errorf("result changed to %v after second Set", v)
+	}
+}
@@ -0,0 +1,63 @@
+package xsync
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+)
+
+// goroutineID 从当前协程的调用栈中解析出协程 id
+func goroutineID() uint64 {
+	var buf [64]byte
+	return parseGoroutineID(buf[:runtime.Stack(buf[:], false)])
+}
+
+// parseGoroutineID 解析 "goroutine 18 [running]:" 形式的首行
+func parseGoroutineID(stack []byte) uint64 {
+	stack = bytes.TrimPrefix(stack, []byte("goroutine "))
+	if i := bytes.IndexByte(stack, ' '); i > 0 {
+		id, _ := strconv.ParseUint(string(stack[:i]), 10, 64)
+		return id
+	}
+	return 0
+}
+
+// allStacks 返回所有协程的调用栈，各协程之间以空行分隔
+func allStacks() []byte {
+	buf := make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// stackOf 从 allStacks 的结果中找出协程 id 的调用栈，找不到时返回 nil
+func stackOf(stacks []byte, id uint64) []byte {
+	for _, g := range bytes.Split(stacks, []byte("\n\n")) {
+		if parseGoroutineID(g) == id {
+			return g
+		}
+	}
+	return nil
+}
+
+// currentStack 返回当前协程的调用栈
+func currentStack() []byte {
+	buf := make([]byte, 4096)
+	for {
+		n := runtime.Stack(buf, false)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// GoroutineID 返回当前协程的 id，即 panic 和 SIGQUIT 转储中 "goroutine N [...]" 的 N。
+// 需要解析调用栈，只应用于诊断
+func GoroutineID() uint64 {
+	return goroutineID()
+}
@@ -0,0 +1,54 @@
+package xsync
+
+import "sync"
+
+// KeyedMutex 是按键加锁的互斥锁集合，用于串行化对动态资源（用户 id、文件路径等）的访问。
+// 每个键的锁在首次使用时创建，没有协程持有或等待时即被回收，零值可直接使用
+type KeyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*keyedLock
+}
+
+type keyedLock struct {
+	sync.Mutex
+	refs int // 持有或等待该锁的协程数，由 KeyedMutex.mu 保护
+}
+
+// Lock 锁住 key，key 已被锁住时阻塞
+func (k *KeyedMutex) Lock(key string) {
+	k.mu.Lock()
+	if k.locks == nil {
+		k.locks = make(map[string]*keyedLock)
+	}
+	l, ok := k.locks[key]
+	if !ok {
+		l = &keyedLock{}
+		k.locks[key] = l
+	}
+	l.refs++
+	k.mu.Unlock()
+	l.Lock()
+}
+
+// Unlock 解锁 key，key 未被锁住时 panic
+func (k *KeyedMutex) Unlock(key string) {
+	k.mu.Lock()
+	l, ok := k.locks[key]
+	if !ok {
+		k.mu.Unlock()
+		panic("xsync: unlock of unlocked key " + key)
+	}
+	l.refs--
+	if l.refs == 0 {
+		delete(k.locks, key)
+	}
+	k.mu.Unlock()
+	l.Unlock()
+}
+
+// Len 返回当前被持有或等待的键的个数
+func (k *KeyedMutex) Len() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return len(k.locks)
+}
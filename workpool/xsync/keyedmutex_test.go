@@ -0,0 +1,31 @@
+package xsync
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestKeyedMutex(t *testing.T) {
+	var km KeyedMutex
+	counts := make([]int, 4)
+	var wg ExtWaitGroup
+	for i := 0; i < 100; i++ {
+		k := i % len(counts)
+		wg.Go(func() error {
+			key := strconv.Itoa(k)
+			km.Lock(key)
+			defer km.Unlock(key)
+			counts[k]++ // 由同一个键的锁保护
+			return nil
+		})
+	}
+	wg.Wait()
+	for k, n := range counts {
+		if n != 25 {
+			t.Errorf("counts[%d] = %d, want 25", k, n)
+		}
+	}
+	if n := km.Len(); n != 0 {
+		t.Errorf("Len after all unlocked = %d, want 0", n)
+	}
+}
@@ -0,0 +1,64 @@
+package xsync
+
+import (
+	"context"
+	"sync"
+)
+
+// Latch 是一次性的倒计数门闩：创建时给定计数，每次 CountDown 减一，归零后所有 Wait 返回。
+// 与 WaitGroup 不同，计数不能再增加，归零之后也不能重置
+type Latch struct {
+	mu    sync.Mutex
+	count int
+	done  chan struct{} // 计数归零时关闭
+}
+
+// NewLatch 创建计数为 n 的 Latch，n <= 0 时立即处于打开状态
+func NewLatch(n int) *Latch {
+	l := &Latch{count: n, done: make(chan struct{})}
+	if n <= 0 {
+		l.count = 0
+		close(l.done)
+	}
+	return l
+}
+
+// CountDown 计数减一，已归零时什么也不做
+func (l *Latch) CountDown() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.count == 0 {
+		return
+	}
+	l.count--
+	if l.count == 0 {
+		close(l.done)
+	}
+}
+
+// Count 返回当前计数
+func (l *Latch) Count() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.count
+}
+
+// Wait 阻塞直到计数归零
+func (l *Latch) Wait() {
+	<-l.done
+}
+
+// WaitContext 阻塞直到计数归零，ctx 先结束时返回 ctx.Err()
+func (l *Latch) WaitContext(ctx context.Context) error {
+	select {
+	case <-l.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Done 返回计数归零时关闭的通道，可与其他通道一起 select
+func (l *Latch) Done() <-chan struct{} {
+	return l.done
+}
@@ -0,0 +1,24 @@
+package xsync
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLatch(t *testing.T) {
+	l := NewLatch(2)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.WaitContext(ctx); err != context.DeadlineExceeded {
+		t.Errorf("WaitContext before count down = %v, want DeadlineExceeded", err)
+	}
+	go l.CountDown()
+	go l.CountDown()
+	l.Wait()
+	l.CountDown() // 归零之后再调用无影响
+	if n := l.Count(); n != 0 {
+		t.Errorf("Count = %d, want 0", n)
+	}
+	NewLatch(0).Wait()
+}
@@ -0,0 +1,113 @@
+package xsync
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter 是令牌桶限速器：令牌以每秒 rate 个的速度生成，桶中最多存 burst 个。
+// 与 Semaphore 限制同时进行的数量不同，Limiter 限制的是单位时间内开始的数量，令牌用掉就不再归还。
+// 实现只记录上次计算时的令牌数和时间，取令牌时按流逝的时间补足，不需要后台协程
+type Limiter struct {
+	mu     sync.Mutex
+	rate   float64   // 每秒生成的令牌数
+	burst  float64   // 桶的容量
+	tokens float64   // last 时刻桶中的令牌数，预约了未来的令牌时为负
+	last   time.Time // 上次更新 tokens 的时间
+}
+
+// NewLimiter 创建每秒生成 rate 个令牌、最多积攒 burst 个的限速器，初始时桶是满的。
+// rate 和 burst 必须大于 0
+func NewLimiter(rate float64, burst int) *Limiter {
+	if rate <= 0 || burst <= 0 {
+		panic("xsync: NewLimiter with non-positive rate or burst")
+	}
+	return &Limiter{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// SetLimit 修改令牌的生成速度和桶的容量，已有的令牌保留（超过新容量的部分丢弃），
+// 已经在 Wait 中预约的等待者仍按原来的速度计算的时间醒来。rate 和 burst 必须大于 0
+func (l *Limiter) SetLimit(rate float64, burst int) {
+	if rate <= 0 || burst <= 0 {
+		panic("xsync: SetLimit with non-positive rate or burst")
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.advance(time.Now())
+	l.rate, l.burst = rate, float64(burst)
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+}
+
+// Limit 返回当前的令牌生成速度和桶的容量
+func (l *Limiter) Limit() (rate float64, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rate, int(l.burst)
+}
+
+// advance 按距 last 流逝的时间补充令牌，调用方需持有 mu
+func (l *Limiter) advance(now time.Time) {
+	if now.After(l.last) {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+		l.last = now
+	}
+}
+
+// Allow 有令牌时取走一个并返回 true，否则立即返回 false
+func (l *Limiter) Allow() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.advance(time.Now())
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// Wait 取得一个令牌，没有时等到令牌生成为止；ctx 先结束时放弃并返回 ctx.Err()。
+// 等待者先预约令牌（tokens 记为负数）再睡眠，多个等待者按调用顺序依次得到令牌；
+// 放弃等待时归还预约的令牌
+func (l *Limiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.advance(now)
+	l.tokens--
+	if l.tokens >= 0 {
+		l.mu.Unlock()
+		return nil
+	}
+	delay := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	if deadline, ok := ctx.Deadline(); ok && deadline.Before(now.Add(delay)) {
+		l.tokens++ // 等不到令牌生成，不必睡眠
+		l.mu.Unlock()
+		return context.DeadlineExceeded
+	}
+	l.mu.Unlock()
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// Tokens 返回当前可用的令牌数，有等待者预约时为负
+func (l *Limiter) Tokens() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.advance(time.Now())
+	return l.tokens
+}
@@ -0,0 +1,74 @@
+package xsync
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLimiterAllow(t *testing.T) {
+	l := NewLimiter(10, 3)
+	for i := 0; i < 3; i++ {
+		if !l.Allow() {
+			t.Fatalf("Allow %d failed with a full bucket", i)
+		}
+	}
+	if l.Allow() {
+		t.Fatal("Allow succeeded with an empty bucket")
+	}
+	time.Sleep(150 * time.Millisecond) // 以 10/s 的速度至少生成 1 个
+	if !l.Allow() {
+		t.Fatal("Allow failed after tokens were refilled")
+	}
+}
+
+// Wait 的等待者依次预约令牌，n 个等待者大约需要 (n-burst)/rate 的时间
+func TestLimiterWait(t *testing.T) {
+	l := NewLimiter(100, 1)
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		if err := l.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d := time.Since(start); d < 45*time.Millisecond {
+		t.Errorf("6 waits at 100/s with burst 1 took %v, want >= 50ms", d)
+	}
+}
+
+// ctx 结束时放弃等待并归还预约的令牌
+func TestLimiterWaitContext(t *testing.T) {
+	l := NewLimiter(1, 1)
+	l.Allow()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Wait = %v, want DeadlineExceeded", err)
+	}
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	if err := l.Wait(ctx); err != context.Canceled {
+		t.Errorf("Wait = %v, want Canceled", err)
+	}
+	if tok := l.Tokens(); tok < 0 {
+		t.Errorf("Tokens = %v after abandoned waits, want >= 0", tok)
+	}
+}
+
+func TestLimiterSetLimit(t *testing.T) {
+	l := NewLimiter(1, 5)
+	l.SetLimit(1000, 2)
+	if rate, burst := l.Limit(); rate != 1000 || burst != 2 {
+		t.Errorf("Limit = %v, %d, want 1000, 2", rate, burst)
+	}
+	if tok := l.Tokens(); tok != 2 {
+		t.Errorf("Tokens after shrinking burst = %v, want 2", tok)
+	}
+	l.Allow()
+	l.Allow()
+	time.Sleep(20 * time.Millisecond) // 以 1000/s 的速度早已补满
+	if !l.Allow() {
+		t.Error("Allow failed after raising the rate")
+	}
+}
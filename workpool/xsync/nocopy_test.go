@@ -0,0 +1,61 @@
+package xsync
+
+import (
+	"reflect"
+	"testing"
+)
+
+// containsLock 与 go vet 的 copylocks 检查的判断相同：*T 有 Lock 和 Unlock 方法，
+// 或者 T 是包含这样字段的结构体，或者是这样元素的数组
+func containsLock(t reflect.Type) bool {
+	pt := reflect.PtrTo(t)
+	_, lock := pt.MethodByName("Lock")
+	_, unlock := pt.MethodByName("Unlock")
+	if lock && unlock {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if containsLock(t.Field(i).Type) {
+				return true
+			}
+		}
+	case reflect.Array:
+		return containsLock(t.Elem())
+	}
+	return false
+}
+
+// 首次使用后不能复制的类型都要能被 copylocks 检查发现
+func TestNoCopy(t *testing.T) {
+	for _, typ := range []reflect.Type{
+		reflect.TypeOf((*ExtWaitGroup)(nil)).Elem(),
+		reflect.TypeOf((*Future[int])(nil)).Elem(),
+		reflect.TypeOf((*Atomic[error])(nil)).Elem(),
+		reflect.TypeOf((*AtomicBool)(nil)).Elem(),
+		reflect.TypeOf((*AtomicDuration)(nil)).Elem(),
+		reflect.TypeOf((*AtomicUint64)(nil)).Elem(),
+		reflect.TypeOf((*Event)(nil)).Elem(),
+		reflect.TypeOf((*Latch)(nil)).Elem(),
+		reflect.TypeOf((*Semaphore)(nil)).Elem(),
+		reflect.TypeOf((*TryMutex)(nil)).Elem(),
+	} {
+		if !containsLock(typ) {
+			t.Errorf("%v can be copied without a vet copylocks warning", typ)
+		}
+	}
+}
+
+// noCopy 放在首位且大小为 0，原子类型的大小和对齐不变
+func TestNoCopySize(t *testing.T) {
+	for typ, want := range map[reflect.Type]uintptr{
+		reflect.TypeOf((*AtomicBool)(nil)).Elem():     4,
+		reflect.TypeOf((*AtomicDuration)(nil)).Elem(): 8,
+		reflect.TypeOf((*AtomicUint64)(nil)).Elem():   8,
+	} {
+		if typ.Size() != want {
+			t.Errorf("size of %v = %d, want %d", typ, typ.Size(), want)
+		}
+	}
+}
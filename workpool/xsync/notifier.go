@@ -0,0 +1,33 @@
+package xsync
+
+import "sync"
+
+// Notifier 是可重复触发的广播信号：每次 Broadcast 关闭当前的通道并换上新的，
+// 在此之前通过 C 拿到通道的订阅者都会收到通知。用于配置重载、暂停恢复等反复发生的状态变化，
+// 替代到处手写的“关闭一个通道再重建”。零值可直接使用
+type Notifier struct {
+	mu sync.Mutex
+	ch chan struct{}
+}
+
+// C 返回下一次 Broadcast 时关闭的通道。
+// 订阅者收到通知后重新调用 C 以等待下一次；应先调用 C 再读取相关状态，
+// 这样在两者之间发生的 Broadcast 不会被错过
+func (n *Notifier) C() <-chan struct{} {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.ch == nil {
+		n.ch = make(chan struct{})
+	}
+	return n.ch
+}
+
+// Broadcast 通知所有通过 C 拿到当前通道的订阅者
+func (n *Notifier) Broadcast() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.ch != nil {
+		close(n.ch)
+	}
+	n.ch = make(chan struct{})
+}
@@ -0,0 +1,22 @@
+package xsync
+
+import "testing"
+
+func TestNotifier(t *testing.T) {
+	var n Notifier
+	c1 := n.C()
+	c2 := n.C()
+	n.Broadcast()
+	for _, c := range []<-chan struct{}{c1, c2} {
+		select {
+		case <-c:
+		default:
+			t.Fatal("subscriber not notified")
+		}
+	}
+	select {
+	case <-n.C():
+		t.Fatal("new channel already closed before the next Broadcast")
+	default:
+	}
+}
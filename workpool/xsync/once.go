@@ -0,0 +1,53 @@
+package xsync
+
+import "sync"
+
+// OnceValue 返回一个函数，第一次调用时执行 f 并缓存其结果，之后的调用直接返回该结果，可并发调用。
+// f panic 时，之后的每次调用都以相同的值 panic
+func OnceValue[T any](f func() T) func() T {
+	var (
+		once     sync.Once
+		val      T
+		panicked bool
+		p        interface{}
+	)
+	return func() T {
+		once.Do(func() {
+			panicked = true
+			defer func() {
+				p = recover()
+			}()
+			val = f()
+			panicked = false
+		})
+		if panicked {
+			panic(p)
+		}
+		return val
+	}
+}
+
+// OnceErr 返回一个函数，执行 f 并缓存其结果和错误，可并发调用，f 不会并发执行。
+// retry 为 false 时与 OnceValue 一样只执行一次；为 true 时 f 返回的错误不会被缓存，
+// 下一次调用会重新执行 f，直到成功为止，适合初始化可能因暂时性故障失败的资源
+func OnceErr[T any](f func() (T, error), retry bool) func() (T, error) {
+	var (
+		mu   sync.Mutex
+		done bool
+		val  T
+		err  error
+	)
+	return func() (T, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if done {
+			return val, err
+		}
+		v, e := f()
+		if e != nil && retry {
+			return v, e
+		}
+		val, err, done = v, e, true
+		return val, err
+	}
+}
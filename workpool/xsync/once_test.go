@@ -0,0 +1,52 @@
+package xsync
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestOnceValue(t *testing.T) {
+	calls := 0
+	get := OnceValue(func() int { calls++; return 42 })
+	var wg ExtWaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Go(func() error {
+			if v := get(); v != 42 {
+				return errors.New("wrong value")
+			}
+			return nil
+		})
+	}
+	if err := wg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Errorf("f called %d times, want 1", calls)
+	}
+}
+
+func TestOnceErr(t *testing.T) {
+	boom := errors.New("boom")
+	for _, retry := range []bool{false, true} {
+		calls := 0
+		get := OnceErr(func() (int, error) {
+			calls++
+			if calls == 1 {
+				return 0, boom
+			}
+			return calls, nil
+		}, retry)
+		_, err1 := get()
+		v, err2 := get()
+		get()
+		if err1 != boom {
+			t.Errorf("retry=%v: first call err = %v, want boom", retry, err1)
+		}
+		if retry && (v != 2 || err2 != nil || calls != 2) {
+			t.Errorf("retry=true: got %v, %v after %d calls, want 2, nil after 2", v, err2, calls)
+		}
+		if !retry && (err2 != boom || calls != 1) {
+			t.Errorf("retry=false: got err %v after %d calls, want cached boom after 1", err2, calls)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package xsync
+
+import "sync"
+
+// RWPolicy 决定读者和写者同时等待时谁优先
+type RWPolicy int
+
+const (
+	// WriterPreferring 有写者等待时新来的读者也要等待，与 sync.RWMutex 相同。
+	// 写者不会饿死，但写操作频繁时读者可能长时间拿不到锁
+	WriterPreferring RWPolicy = iota
+	// ReaderPreferring 只要没有写者持有锁，读者就可以进入。
+	// 读者的延迟最低，但读者不断重叠时写者可能永远拿不到锁
+	ReaderPreferring
+	// PhaseFair 读阶段和写阶段交替：有写者等待时新来的读者等到下一个读阶段，
+	// 写者释放锁时，此前等待的读者全部进入，之后才轮到下一个写者。两边都不会饿死
+	PhaseFair
+)
+
+func (p RWPolicy) String() string {
+	switch p {
+	case WriterPreferring:
+		return "WriterPreferring"
+	case ReaderPreferring:
+		return "ReaderPreferring"
+	case PhaseFair:
+		return "PhaseFair"
+	}
+	return "RWPolicy(?)"
+}
+
+// PolicyRWMutex 是可以选择读写优先策略的读写锁，与 tearUpGo 中注释版的 sync.RWMutex
+// （固定为写者优先，用 readerCount 为负表示有写者等待）对照，用来比较不同策略下谁会饿死，
+// 见 BenchmarkRWPolicy。实现用一个 Mutex 和 Cond 保护计数，追求的是清晰而不是速度。
+//
+// 零值是写者优先的读写锁，Policy 需在首次使用前设置
+type PolicyRWMutex struct {
+	Policy RWPolicy
+
+	mu             sync.Mutex
+	cond           sync.Cond // L 为 &mu，首次使用时设置
+	readers        int       // 持有读锁的读者数
+	writer         bool      // 有写者持有锁
+	waitingWriters int       // 等待的写者数
+	waitingReaders int       // PhaseFair 中等待下一个读阶段的读者数
+	admitting      int       // PhaseFair 中被本次读阶段放行、还没拿到读锁的读者数
+	phase          uint64    // PhaseFair 中写者每次释放锁加一，开始新的读阶段
+}
+
+var _ sync.Locker = (*PolicyRWMutex)(nil)
+
+// lock 锁住 mu 并在首次使用时初始化 cond
+func (m *PolicyRWMutex) lock() {
+	m.mu.Lock()
+	if m.cond.L == nil {
+		m.cond.L = &m.mu
+	}
+}
+
+func (m *PolicyRWMutex) RLock() {
+	m.lock()
+	defer m.mu.Unlock()
+	switch m.Policy {
+	case ReaderPreferring:
+		for m.writer {
+			m.cond.Wait()
+		}
+	case PhaseFair:
+		if m.writer || m.waitingWriters > 0 {
+			m.waitingReaders++
+			for phase := m.phase; m.phase == phase; {
+				m.cond.Wait()
+			}
+			m.admitting--
+		}
+	default:
+		for m.writer || m.waitingWriters > 0 {
+			m.cond.Wait()
+		}
+	}
+	m.readers++
+}
+
+// RUnlock 释放读锁；未持有读锁时 panic
+func (m *PolicyRWMutex) RUnlock() {
+	m.lock()
+	defer m.mu.Unlock()
+	if m.readers <= 0 {
+		panic("xsync: RUnlock of unlocked PolicyRWMutex")
+	}
+	m.readers--
+	if m.readers == 0 {
+		m.cond.Broadcast()
+	}
+}
+
+func (m *PolicyRWMutex) Lock() {
+	m.lock()
+	defer m.mu.Unlock()
+	m.waitingWriters++
+	for m.writer || m.readers > 0 || m.admitting > 0 {
+		m.cond.Wait()
+	}
+	m.waitingWriters--
+	m.writer = true
+}
+
+// Unlock 释放写锁；未持有写锁时 panic
+func (m *PolicyRWMutex) Unlock() {
+	m.lock()
+	defer m.mu.Unlock()
+	if !m.writer {
+		panic("xsync: Unlock of unlocked PolicyRWMutex")
+	}
+	m.writer = false
+	if m.Policy == PhaseFair && m.waitingReaders > 0 { // 开始新的读阶段，放行此前等待的读者
+		m.phase++
+		m.admitting += m.waitingReaders
+		m.waitingReaders = 0
+	}
+	m.cond.Broadcast()
+}
+
+// RLocker 返回以 RLock/RUnlock 实现 Lock/Unlock 的 sync.Locker
+func (m *PolicyRWMutex) RLocker() sync.Locker {
+	return (*policyRLocker)(m)
+}
+
+type policyRLocker PolicyRWMutex
+
+func (r *policyRLocker) Lock()   { (*PolicyRWMutex)(r).RLock() }
+func (r *policyRLocker) Unlock() { (*PolicyRWMutex)(r).RUnlock() }
@@ -0,0 +1,231 @@
+package xsync
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitWriters 等到 m 中有 n 个等待的写者
+func waitWriters(t *testing.T, m *PolicyRWMutex, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		m.mu.Lock()
+		w := m.waitingWriters
+		m.mu.Unlock()
+		if w == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("waiting writers = %d, want %d", w, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// tryRLock 在另一个协程中 RLock，返回 d 内是否拿到了读锁；拿到后保持到 release 关闭
+func tryRLock(m *PolicyRWMutex, d time.Duration, release <-chan struct{}) bool {
+	got := make(chan struct{})
+	go func() {
+		m.RLock()
+		close(got)
+		<-release
+		m.RUnlock()
+	}()
+	select {
+	case <-got:
+		return true
+	case <-time.After(d):
+		return false
+	}
+}
+
+// 有读者持有锁、写者在等待时，新来的读者能否进入取决于策略
+func TestPolicyRWMutexPendingWriter(t *testing.T) {
+	for policy, wantEnter := range map[RWPolicy]bool{
+		WriterPreferring: false,
+		ReaderPreferring: true,
+		PhaseFair:        false,
+	} {
+		t.Run(policy.String(), func(t *testing.T) {
+			m := &PolicyRWMutex{Policy: policy}
+			m.RLock()
+			writerDone := make(chan struct{})
+			go func() {
+				m.Lock()
+				m.Unlock()
+				close(writerDone)
+			}()
+			waitWriters(t, m, 1)
+
+			release := make(chan struct{})
+			if got := tryRLock(m, 20*time.Millisecond, release); got != wantEnter {
+				t.Errorf("new reader entered = %v, want %v", got, wantEnter)
+			}
+			close(release)
+			m.RUnlock()
+			<-writerDone
+		})
+	}
+}
+
+// PhaseFair 中写者释放锁后，此前等待的读者先于下一个写者进入
+func TestPolicyRWMutexPhaseFair(t *testing.T) {
+	m := &PolicyRWMutex{Policy: PhaseFair}
+	m.Lock()
+
+	var order []string
+	var mu sync.Mutex
+	record := func(s string) {
+		mu.Lock()
+		order = append(order, s)
+		mu.Unlock()
+	}
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		m.RLock()
+		record("reader")
+		time.Sleep(10 * time.Millisecond)
+		m.RUnlock()
+	}()
+	go func() {
+		defer wg.Done()
+		m.RLock()
+		record("reader")
+		time.Sleep(10 * time.Millisecond)
+		m.RUnlock()
+	}()
+	for { // 等两个读者进入等待
+		m.mu.Lock()
+		n := m.waitingReaders
+		m.mu.Unlock()
+		if n == 2 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	go func() {
+		defer wg.Done()
+		m.Lock()
+		record("writer")
+		m.Unlock()
+	}()
+	waitWriters(t, m, 1)
+
+	m.Unlock()
+	wg.Wait()
+	if len(order) != 3 || order[2] != "writer" {
+		t.Errorf("order = %v, want both readers before the writer", order)
+	}
+}
+
+func TestPolicyRWMutexExclusion(t *testing.T) {
+	for _, policy := range []RWPolicy{WriterPreferring, ReaderPreferring, PhaseFair} {
+		t.Run(policy.String(), func(t *testing.T) {
+			m := &PolicyRWMutex{Policy: policy}
+			var readers, writers int32
+			var wg sync.WaitGroup
+			for g := 0; g < 8; g++ {
+				wg.Add(1)
+				go func(g int) {
+					defer wg.Done()
+					for i := 0; i < 200; i++ {
+						if (g+i)%4 == 0 {
+							m.Lock()
+							if atomic.AddInt32(&writers, 1) != 1 || atomic.LoadInt32(&readers) != 0 {
+								t.Error("writer does not hold the lock exclusively")
+							}
+							atomic.AddInt32(&writers, -1)
+							m.Unlock()
+						} else {
+							m.RLock()
+							atomic.AddInt32(&readers, 1)
+							if atomic.LoadInt32(&writers) != 0 {
+								t.Error("reader holds the lock with a writer")
+							}
+							atomic.AddInt32(&readers, -1)
+							m.RUnlock()
+						}
+					}
+				}(g)
+			}
+			wg.Wait()
+		})
+	}
+}
+
+// BenchmarkRWPolicy 让 4 个读者持续重叠地持有读锁，同时一个写者不断加写锁，
+// 报告写者和读者每次加锁等待时间的最大值以及写者完成的次数：
+// ReaderPreferring 下读者的读锁首尾相接，写者几乎拿不到锁（writes/op 接近 0）；
+// WriterPreferring 下写者不停地写，读者反过来被饿住（reader-max-ns 很大）；
+// PhaseFair 下读写交替，两边的最长等待都有界
+func BenchmarkRWPolicy(b *testing.B) {
+	for _, policy := range []RWPolicy{WriterPreferring, ReaderPreferring, PhaseFair} {
+		b.Run(policy.String(), func(b *testing.B) {
+			benchmarkRWPolicy(b, &PolicyRWMutex{Policy: policy})
+		})
+	}
+}
+
+func benchmarkRWPolicy(b *testing.B, m *PolicyRWMutex) {
+	const readers = 4
+	hold := func() {
+		for end := time.Now().Add(10 * time.Microsecond); time.Now().Before(end); {
+		}
+	}
+	var (
+		mu          sync.Mutex
+		readerWaits []time.Duration
+		writerWaits []time.Duration
+		done        int32
+	)
+	writerDone := make(chan struct{})
+	go func() { // 写者一直运行到读者全部结束
+		defer close(writerDone)
+		for atomic.LoadInt32(&done) == 0 {
+			start := time.Now()
+			m.Lock()
+			writerWaits = append(writerWaits, time.Since(start))
+			hold()
+			m.Unlock()
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for r := 0; r < readers; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			local := make([]time.Duration, 0, b.N)
+			for i := 0; i < b.N; i++ {
+				start := time.Now()
+				m.RLock()
+				local = append(local, time.Since(start))
+				hold()
+				m.RUnlock()
+			}
+			mu.Lock()
+			readerWaits = append(readerWaits, local...)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	atomic.StoreInt32(&done, 1)
+	<-writerDone
+
+	max := func(ws []time.Duration) float64 {
+		if len(ws) == 0 {
+			return 0
+		}
+		sort.Slice(ws, func(i, j int) bool { return ws[i] < ws[j] })
+		return float64(ws[len(ws)-1].Nanoseconds())
+	}
+	b.ReportMetric(max(readerWaits), "reader-max-ns")
+	b.ReportMetric(max(writerWaits), "writer-max-ns")
+	b.ReportMetric(float64(len(writerWaits))/float64(b.N), "writes/op")
+}
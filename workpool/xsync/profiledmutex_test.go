@@ -0,0 +1,84 @@
+package xsync
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProfiledMutex(t *testing.T) {
+	hot := &ProfiledMutex{Name: "hot"}
+	cold := &ProfiledMutex{Name: "cold"}
+	cold.Lock()
+	cold.Unlock()
+
+	hot.Lock()
+	time.AfterFunc(10*time.Millisecond, hot.Unlock)
+	hot.Lock() // 等待约 10ms
+	hot.Unlock()
+
+	p := hot.Profile()
+	if p.Acquires != 2 || p.Contended != 1 || p.WaitTime <= 0 {
+		t.Errorf("hot profile = %+v, want 2 acquires, 1 contended, positive wait", p)
+	}
+	var names []string
+	for _, p := range MutexProfiles() {
+		if p.Name == "hot" || p.Name == "cold" {
+			names = append(names, p.Name)
+		}
+	}
+	if len(names) != 2 || names[0] != "hot" {
+		t.Errorf("MutexProfiles order = %v, want hot first", names)
+	}
+}
+
+func TestProfiledRWMutex(t *testing.T) {
+	m := &ProfiledRWMutex{Name: "rw"}
+	m.RLock()
+	m.RLock()
+	m.RUnlock()
+	m.RUnlock()
+
+	m.Lock()
+	time.AfterFunc(10*time.Millisecond, m.Unlock)
+	m.RLock() // 等待写锁释放
+	m.RUnlock()
+
+	p := m.Profile()
+	if p.Acquires != 1 || p.Contended != 0 {
+		t.Errorf("write side = %d acquires, %d contended, want 1, 0", p.Acquires, p.Contended)
+	}
+	if p.ReadAcquires != 3 || p.ReadContended != 1 || p.ReadWaitTime <= 0 {
+		t.Errorf("read side = %+v, want 3 acquires, 1 contended, positive wait", p)
+	}
+	if p.TotalWait() != p.WaitTime+p.ReadWaitTime {
+		t.Errorf("TotalWait = %v, want %v", p.TotalWait(), p.WaitTime+p.ReadWaitTime)
+	}
+}
+
+func TestWriteMutexProfiles(t *testing.T) {
+	m := &ProfiledRWMutex{Name: "table-rw"}
+	m.Lock()
+	time.AfterFunc(5*time.Millisecond, m.Unlock)
+	m.RLock()
+	m.RUnlock()
+
+	var buf bytes.Buffer
+	if err := WriteMutexProfiles(&buf, 0); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.HasPrefix(out, "NAME") {
+		t.Errorf("missing header:\n%s", out)
+	}
+	if !strings.Contains(out, "table-rw ") || !strings.Contains(out, "table-rw(r)") {
+		t.Errorf("missing rows for table-rw:\n%s", out)
+	}
+
+	buf.Reset()
+	WriteMutexProfiles(&buf, 1)
+	if n := strings.Count(buf.String(), "\n"); n < 2 || n > 3 { // 表头、一把锁，读写锁还有读锁一行
+		t.Errorf("WriteMutexProfiles(1) wrote %d lines:\n%s", n, buf.String())
+	}
+}
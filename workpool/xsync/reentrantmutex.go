@@ -0,0 +1,52 @@
+package xsync
+
+import "sync"
+
+// ReentrantMutex 是可重入的互斥锁：持有者可以再次加锁，加锁几次就要解锁几次。
+// 主要用于示例中与其他锁的设计对比，不建议在实际代码中使用。
+//
+// Go 标准库有意不提供可重入锁：互斥锁的意义在于保护不变式，加锁之后可以假定不变式成立，
+// 解锁之前恢复它。可重入意味着持有锁的代码在不变式被临时破坏的中途调用了另一个也加锁的函数，
+// 后者却以为不变式成立——可重入锁把这种错误从死锁变成了难以察觉的数据错误。
+// 正确的做法是把需要在持锁状态下调用的逻辑拆成不加锁的内部函数。
+// 此外 Go 没有公开的协程 id，这里从调用栈中解析，每次加锁都有不小的开销
+type ReentrantMutex struct {
+	mu    sync.Mutex
+	cond  *sync.Cond
+	owner uint64 // 持有者的协程 id，0 表示未被持有
+	depth int    // 持有者加锁的次数
+}
+
+var _ sync.Locker = (*ReentrantMutex)(nil)
+
+func (m *ReentrantMutex) Lock() {
+	gid := goroutineID()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.cond == nil {
+		m.cond = sync.NewCond(&m.mu)
+	}
+	if m.owner == gid {
+		m.depth++
+		return
+	}
+	for m.owner != 0 {
+		m.cond.Wait()
+	}
+	m.owner, m.depth = gid, 1
+}
+
+// Unlock 解锁一次，不是由持有者调用时 panic
+func (m *ReentrantMutex) Unlock() {
+	gid := goroutineID()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.owner != gid {
+		panic("xsync: unlock of ReentrantMutex not held by this goroutine")
+	}
+	m.depth--
+	if m.depth == 0 {
+		m.owner = 0
+		m.cond.Signal()
+	}
+}
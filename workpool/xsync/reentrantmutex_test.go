@@ -0,0 +1,30 @@
+package xsync
+
+import "testing"
+
+func TestReentrantMutex(t *testing.T) {
+	var m ReentrantMutex
+	m.Lock()
+	m.Lock() // 同一协程可再次加锁
+	locked := make(chan struct{})
+	go func() {
+		m.Lock()
+		close(locked)
+		m.Unlock()
+	}()
+	m.Unlock()
+	select {
+	case <-locked:
+		t.Fatal("other goroutine acquired the lock before the final Unlock")
+	default:
+	}
+	m.Unlock()
+	<-locked
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Unlock by non-owner did not panic")
+		}
+	}()
+	m.Unlock()
+}
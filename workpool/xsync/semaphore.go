@@ -0,0 +1,100 @@
+package xsync
+
+import (
+	"context"
+	"sync"
+)
+
+// Semaphore 是纯用户态实现的计数信号量，仿照运行时的 sema：
+// 没有令牌时等待者排队，Release 把令牌直接交给队首的等待者。
+//
+// 注释版 sync.Mutex（src/sync/mutex.go）中，被唤醒后又没抢到锁的协程调用
+// runtime_SemacquireMutex(&m.sema, queueLifo) 时 queueLifo 为 true，排到队首而不是队尾，
+// 以免已经等过一轮的协程重新排在所有新来者后面。AcquireQueue 的 lifo 参数与之对应，
+// 可以用真实代码和基准测试比较两种排队策略，见 BenchmarkSemaphoreQueue。
+type Semaphore struct {
+	mu      sync.Mutex
+	count   int
+	lifo    bool            // Acquire 默认的排队方式
+	waiters []chan struct{} // 队首在前
+}
+
+// NewSemaphore 创建有 count 个令牌的信号量，lifo 为 true 时 Acquire 排到等待队列的队首
+func NewSemaphore(count int, lifo bool) *Semaphore {
+	return &Semaphore{count: count, lifo: lifo}
+}
+
+// Acquire 取得一个令牌，没有令牌时按创建时指定的方式排队等待
+func (s *Semaphore) Acquire() {
+	s.AcquireQueue(s.lifo)
+}
+
+// AcquireQueue 取得一个令牌，没有令牌时排队等待：lifo 为 true 时排到队首，否则排到队尾
+func (s *Semaphore) AcquireQueue(lifo bool) {
+	if ch := s.enqueue(lifo); ch != nil {
+		<-ch
+	}
+}
+
+// AcquireContext 与 Acquire 相同，但 ctx 先结束时放弃等待并返回 ctx.Err()
+func (s *Semaphore) AcquireContext(ctx context.Context) error {
+	ch := s.enqueue(s.lifo)
+	if ch == nil {
+		return nil
+	}
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, w := range s.waiters {
+		if w == ch {
+			s.waiters = append(s.waiters[:i], s.waiters[i+1:]...)
+			return ctx.Err()
+		}
+	}
+	return nil // 与 ctx 结束同时拿到了令牌
+}
+
+// enqueue 有令牌时直接取得并返回 nil，否则排队并返回拿到令牌时关闭的通道
+func (s *Semaphore) enqueue(lifo bool) chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.count > 0 && len(s.waiters) == 0 {
+		s.count--
+		return nil
+	}
+	ch := make(chan struct{})
+	if lifo {
+		s.waiters = append([]chan struct{}{ch}, s.waiters...)
+	} else {
+		s.waiters = append(s.waiters, ch)
+	}
+	return ch
+}
+
+// TryAcquire 有令牌时取得一个并返回 true，否则立即返回 false
+func (s *Semaphore) TryAcquire() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.count > 0 && len(s.waiters) == 0 {
+		s.count--
+		return true
+	}
+	return false
+}
+
+// Release 归还一个令牌，有等待者时直接交给队首的等待者
+func (s *Semaphore) Release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.waiters) == 0 {
+		s.count++
+		return
+	}
+	close(s.waiters[0])
+	s.waiters[0] = nil
+	s.waiters = s.waiters[1:]
+}
@@ -0,0 +1,74 @@
+package xsync
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// waitQueued 等到 s 的等待队列长度为 n
+func waitQueued(s *Semaphore, n int) {
+	for {
+		s.mu.Lock()
+		l := len(s.waiters)
+		s.mu.Unlock()
+		if l == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSemaphoreQueueOrder(t *testing.T) {
+	for _, lifo := range []bool{false, true} {
+		s := NewSemaphore(1, lifo)
+		s.Acquire()
+		got := make(chan int, 3)
+		for i := 0; i < 3; i++ {
+			go func(i int) {
+				s.Acquire()
+				got <- i
+				s.Release()
+			}(i)
+			waitQueued(s, i+1)
+		}
+		s.Release()
+		want := []int{0, 1, 2}
+		if lifo {
+			want = []int{2, 1, 0}
+		}
+		for _, w := range want {
+			if g := <-got; g != w {
+				t.Fatalf("lifo=%v: woke %d, want %d", lifo, g, w)
+			}
+		}
+	}
+}
+
+func TestSemaphoreContext(t *testing.T) {
+	s := NewSemaphore(0, false)
+	if s.TryAcquire() {
+		t.Fatal("TryAcquire on empty semaphore succeeded")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := s.AcquireContext(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("AcquireContext = %v, want DeadlineExceeded", err)
+	}
+	s.Release()
+	if !s.TryAcquire() {
+		t.Error("token lost after canceled AcquireContext")
+	}
+}
+
+// semLocker 把只有一个令牌的信号量当作锁使用
+type semLocker struct{ s *Semaphore }
+
+func (l semLocker) Lock()   { l.s.Acquire() }
+func (l semLocker) Unlock() { l.s.Release() }
+
+// BenchmarkSemaphoreQueue 对比两种排队策略下的吞吐量和等待时间分布
+func BenchmarkSemaphoreQueue(b *testing.B) {
+	b.Run("FIFO", func(b *testing.B) { benchmarkLock(b, semLocker{NewSemaphore(1, false)}) })
+	b.Run("LIFO", func(b *testing.B) { benchmarkLock(b, semLocker{NewSemaphore(1, true)}) })
+}
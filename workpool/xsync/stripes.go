@@ -0,0 +1,41 @@
+package xsync
+
+import "sync"
+
+// Stripes 是固定数目的一组互斥锁，按键的哈希选取其中一把。
+// 不同的键可能共用一把锁，以精确度换取零分配，适合作为 KeyedMutex 在热点路径上的廉价替代
+type Stripes struct {
+	locks []paddedMutex
+	mask  uint32
+}
+
+// paddedMutex 独占一个缓存行，避免相邻的锁之间伪共享
+type paddedMutex struct {
+	sync.Mutex
+	_ [64 - 8]byte
+}
+
+// NewStripes 创建至少包含 n 把锁的 Stripes，锁的数目向上取整为 2 的幂
+func NewStripes(n int) *Stripes {
+	size := 1
+	for size < n {
+		size <<= 1
+	}
+	return &Stripes{locks: make([]paddedMutex, size), mask: uint32(size - 1)}
+}
+
+// For 返回 key 对应的锁
+func (s *Stripes) For(key string) *sync.Mutex {
+	// FNV-1a，逐字节计算，不产生分配
+	h := uint32(2166136261)
+	for i := 0; i < len(key); i++ {
+		h ^= uint32(key[i])
+		h *= 16777619
+	}
+	return &s.locks[h&s.mask].Mutex
+}
+
+// Len 返回锁的数目
+func (s *Stripes) Len() int {
+	return len(s.locks)
+}
@@ -0,0 +1,20 @@
+package xsync
+
+import "testing"
+
+func TestStripes(t *testing.T) {
+	s := NewStripes(5)
+	if s.Len() != 8 {
+		t.Errorf("Len = %d, want 8", s.Len())
+	}
+	if s.For("user-1") != s.For("user-1") {
+		t.Error("same key mapped to different locks")
+	}
+	if n := testing.AllocsPerRun(100, func() {
+		l := s.For("user-1")
+		l.Lock()
+		l.Unlock()
+	}); n != 0 {
+		t.Errorf("For allocates %v times, want 0", n)
+	}
+}
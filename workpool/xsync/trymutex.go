@@ -0,0 +1,72 @@
+package xsync
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// TryMutex 是基于容量为 1 的通道实现的互斥锁，除 Lock/Unlock 外还提供 TryLock，
+// 适合统计采集这类宁可跳过也不能阻塞的热点路径；LockContext/LockTimeout 则保证
+// 有截止时间的代码路径不会在被争用的锁上无限阻塞。零值可直接使用
+type TryMutex struct {
+	once sync.Once
+	ch   chan struct{} // 持锁时其中有一个元素
+}
+
+var _ sync.Locker = (*TryMutex)(nil)
+
+func (m *TryMutex) init() {
+	m.once.Do(func() { m.ch = make(chan struct{}, 1) })
+}
+
+// Lock 加锁，锁已被持有时阻塞
+func (m *TryMutex) Lock() {
+	m.init()
+	m.ch <- struct{}{}
+}
+
+// TryLock 尝试加锁，锁已被持有时立即返回 false
+func (m *TryMutex) TryLock() bool {
+	m.init()
+	select {
+	case m.ch <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// LockContext 加锁，ctx 先结束时放弃加锁并返回 ctx.Err()
+func (m *TryMutex) LockContext(ctx context.Context) error {
+	m.init()
+	select {
+	case m.ch <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// LockTimeout 加锁，d 时间内没有拿到锁时放弃并返回 false
+func (m *TryMutex) LockTimeout(d time.Duration) bool {
+	m.init()
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case m.ch <- struct{}{}:
+		return true
+	case <-t.C:
+		return false
+	}
+}
+
+// Unlock 解锁，锁未被持有时 panic。与 sync.Mutex 一样，可由其他协程解锁
+func (m *TryMutex) Unlock() {
+	m.init()
+	select {
+	case <-m.ch:
+	default:
+		panic("xsync: unlock of unlocked TryMutex")
+	}
+}
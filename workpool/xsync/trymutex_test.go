@@ -0,0 +1,51 @@
+package xsync
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTryMutex(t *testing.T) {
+	var m TryMutex
+	if !m.TryLock() {
+		t.Fatal("TryLock on unlocked mutex failed")
+	}
+	if m.TryLock() {
+		t.Fatal("TryLock on locked mutex succeeded")
+	}
+	m.Unlock()
+
+	var wg ExtWaitGroup
+	n := 0
+	for i := 0; i < 100; i++ {
+		wg.Go(func() error {
+			m.Lock()
+			defer m.Unlock()
+			n++
+			return nil
+		})
+	}
+	wg.Wait()
+	if n != 100 {
+		t.Errorf("n = %d, want 100", n)
+	}
+}
+
+func TestTryMutexDeadline(t *testing.T) {
+	var m TryMutex
+	m.Lock()
+	if m.LockTimeout(10 * time.Millisecond) {
+		t.Fatal("LockTimeout on held mutex succeeded")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := m.LockContext(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("LockContext on held mutex = %v, want DeadlineExceeded", err)
+	}
+	time.AfterFunc(10*time.Millisecond, m.Unlock)
+	if err := m.LockContext(context.Background()); err != nil {
+		t.Fatalf("LockContext after Unlock = %v", err)
+	}
+	m.Unlock()
+}
@@ -0,0 +1,40 @@
+package xsync
+
+import "sync"
+
+// TryLocker 是支持非阻塞加锁的锁，TryMutex 和 TryRWMutex 都实现了它
+type TryLocker interface {
+	sync.Locker
+	TryLock() bool
+}
+
+var (
+	_ TryLocker = (*TryMutex)(nil)
+	_ TryLocker = (*TryRWMutex)(nil)
+)
+
+// TryRWMutex 是读写锁的包装，提供 TryLock 和 TryRLock，
+// 使读多写少的结构（统计信息、任务登记表等）在锁繁忙时可以跳过可有可无的工作。零值可直接使用
+type TryRWMutex struct {
+	rw sync.RWMutex
+}
+
+func (m *TryRWMutex) Lock()    { m.rw.Lock() }
+func (m *TryRWMutex) Unlock()  { m.rw.Unlock() }
+func (m *TryRWMutex) RLock()   { m.rw.RLock() }
+func (m *TryRWMutex) RUnlock() { m.rw.RUnlock() }
+
+// TryLock 尝试加写锁，已有读者或写者时立即返回 false
+func (m *TryRWMutex) TryLock() bool {
+	return m.rw.TryLock()
+}
+
+// TryRLock 尝试加读锁，已有写者持有或等待时立即返回 false
+func (m *TryRWMutex) TryRLock() bool {
+	return m.rw.TryRLock()
+}
+
+// RLocker 返回以 RLock/RUnlock 实现 Lock/Unlock 的 sync.Locker
+func (m *TryRWMutex) RLocker() sync.Locker {
+	return m.rw.RLocker()
+}
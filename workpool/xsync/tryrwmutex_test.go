@@ -0,0 +1,23 @@
+package xsync
+
+import "testing"
+
+func TestTryRWMutex(t *testing.T) {
+	var m TryRWMutex
+	if !m.TryRLock() || !m.TryRLock() {
+		t.Fatal("TryRLock failed with no writer")
+	}
+	if m.TryLock() {
+		t.Fatal("TryLock succeeded while readers hold the lock")
+	}
+	m.RUnlock()
+	m.RUnlock()
+
+	if !m.TryLock() {
+		t.Fatal("TryLock failed on unlocked mutex")
+	}
+	if m.TryRLock() {
+		t.Fatal("TryRLock succeeded while a writer holds the lock")
+	}
+	m.Unlock()
+}
@@ -0,0 +1,73 @@
+package xsync
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// SetWaitDebug 开启阻塞诊断：Wait、WaitContext 或 WaitN 阻塞超过 d 时，
+// 把仍未完成的协程（见 Track）的调用栈写入 out，为 nil 时写到 os.Stderr，便于找出卡住的任务。
+// 每次等待最多输出一次，需在计数增加之前调用
+func (w *ExtWaitGroup) SetWaitDebug(d time.Duration, out io.Writer) {
+	if out == nil {
+		out = os.Stderr
+	}
+	w.debugAfter, w.debugOut = d, out
+	w.tracked = make(map[uint64]struct{})
+}
+
+// Track 把当前协程登记为未完成的协程，返回的函数用于注销，一般在协程开始时 defer w.Track()()。
+// Go 启动的协程会自动登记；未开启 SetWaitDebug 时什么也不做
+func (w *ExtWaitGroup) Track() (untrack func()) {
+	if w.debugAfter <= 0 {
+		return func() {}
+	}
+	id := goroutineID()
+	w.debugMu.Lock()
+	w.tracked[id] = struct{}{}
+	w.debugMu.Unlock()
+	return func() {
+		w.debugMu.Lock()
+		delete(w.tracked, id)
+		w.debugMu.Unlock()
+	}
+}
+
+// block 等待 ch 关闭，阻塞超过 debugAfter 时输出一次诊断信息
+func (w *ExtWaitGroup) block(ch <-chan struct{}, cancel <-chan struct{}) bool {
+	var timeout <-chan time.Time
+	if w.debugAfter > 0 {
+		t := time.NewTimer(w.debugAfter)
+		defer t.Stop()
+		timeout = t.C
+	}
+	for {
+		select {
+		case <-ch:
+			return true
+		case <-cancel:
+			return false
+		case <-timeout:
+			w.dumpTracked()
+			timeout = nil
+		}
+	}
+}
+
+// dumpTracked 输出已登记协程的调用栈；没有登记任何协程时输出全部协程
+func (w *ExtWaitGroup) dumpTracked() {
+	buf := allStacks()
+	w.debugMu.Lock()
+	defer w.debugMu.Unlock()
+	fmt.Fprintf(w.debugOut, "xsync: Wait blocked for %v, %d outstanding, stacks:\n\n",
+		w.debugAfter, w.GetWaitCount())
+	for _, g := range bytes.Split(buf, []byte("\n\n")) {
+		if _, ok := w.tracked[parseGoroutineID(g)]; ok || len(w.tracked) == 0 {
+			w.debugOut.Write(g)
+			w.debugOut.Write([]byte("\n\n"))
+		}
+	}
+}
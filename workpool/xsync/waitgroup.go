@@ -0,0 +1,231 @@
+// Package xsync 提供可在工作池之外单独使用的同步原语，API 保持稳定
+package xsync
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ExtWaitGroup 是可以读取当前计数的 WaitGroup，零值可直接使用
+// 计数只保存在 waitCount 一处，Wait 通过 zero 通道等待归零，读到的计数与 Wait 的行为始终一致
+type ExtWaitGroup struct {
+	_ noCopy
+
+	waitCount AtomicUint64
+
+	mu   sync.Mutex    // 串行化 zero 的创建和关闭
+	zero chan struct{} // 计数为正时创建，归零时关闭，Wait 在其上等待
+
+	nwaiters int32     // waiters 的长度，原子操作，使没有 WaitN 时 Add/Done 无需加锁
+	waiters  []waiterN // 阻塞在 WaitN 上的调用，由 mu 保护
+
+	errOnce sync.Once
+	err     error // Go 中第一个返回的错误
+
+	zeroMu sync.Mutex
+	onZero []func() // 计数归零时的回调，见 OnZero
+
+	debugAfter time.Duration       // 阻塞超过该时长时输出诊断信息，见 SetWaitDebug
+	debugOut   io.Writer           // 诊断信息的输出
+	debugMu    sync.Mutex          // 保护 tracked
+	tracked    map[uint64]struct{} // 未完成的协程 id，见 Track
+}
+
+// Add 并返回新值，n 可以为负数，计数小于 0 时 panic
+func (w *ExtWaitGroup) Add(n int) uint64 {
+	c := w.waitCount.Add(uint64(n))
+	if int64(c) < 0 {
+		panic("xsync: negative ExtWaitGroup counter")
+	}
+	w.transition(c, n)
+	if n < 0 && atomic.LoadInt32(&w.nwaiters) > 0 {
+		w.releaseWaiters()
+	}
+	return c
+}
+
+// CompareAndAdd 判断值为预期的 old 值再加一
+func (w *ExtWaitGroup) CompareAndAdd(old, delta uint64) bool {
+	if !w.waitCount.CompareAndSwap(old, old+delta) {
+		return false
+	}
+	w.transition(old+delta, int(delta))
+	return true
+}
+
+// TryAddCapped 在计数加上 delta 不超过 max 时原子地加上并返回 true，否则不修改并返回 false
+func (w *ExtWaitGroup) TryAddCapped(delta, max uint64) bool {
+	for {
+		old := w.waitCount.Load()
+		if old+delta > max {
+			return false
+		}
+		if w.CompareAndAdd(old, delta) {
+			return true
+		}
+	}
+}
+
+func (w *ExtWaitGroup) Done() {
+	w.Add(-1)
+}
+
+// transition 在计数加上 delta 变为 c 之后，处理从 0 变为正数以及归零这两种转换
+func (w *ExtWaitGroup) transition(c uint64, delta int) {
+	switch {
+	case c == 0 && delta != 0:
+		w.fireZero() // 回调执行完再释放 Wait，使 Wait 返回时回调已全部完成
+		w.sync()
+	case delta > 0 && c == uint64(delta):
+		w.sync()
+	}
+}
+
+// sync 按当前计数创建或关闭 zero。
+// 转换可能与其他 Add/Done 交错，因此在锁内重新读取计数，而不是使用调用方看到的值：
+// 最后一次使计数归零的调用总会在之后进入这里并关闭 zero
+func (w *ExtWaitGroup) sync() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.syncLocked()
+}
+
+func (w *ExtWaitGroup) syncLocked() {
+	open := w.zero != nil && !isClosed(w.zero)
+	if w.waitCount.Load() == 0 {
+		if open {
+			close(w.zero)
+		}
+	} else if !open {
+		w.zero = make(chan struct{})
+	}
+}
+
+func isClosed(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+// wait 返回等待归零的通道，计数已为 0 时返回 nil
+func (w *ExtWaitGroup) wait() chan struct{} {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.syncLocked() // 与计数从 0 变为正数的 Add 并发时，zero 可能还未创建
+	if w.waitCount.Load() == 0 {
+		return nil
+	}
+	return w.zero
+}
+
+// waiterN 是阻塞在 WaitN 上的一个调用
+type waiterN struct {
+	n  uint64
+	ch chan struct{}
+}
+
+// WaitN 阻塞直到计数不超过 n，可用于“等到最多剩 K 个任务”的滚动重启、柔性排空等场景。
+// WaitN(0) 等同于 Wait，但不返回错误
+func (w *ExtWaitGroup) WaitN(n uint64) {
+	w.mu.Lock()
+	atomic.AddInt32(&w.nwaiters, 1) // 先登记再读取计数，与之后的 Done 不会错过唤醒
+	if w.waitCount.Load() <= n {
+		atomic.AddInt32(&w.nwaiters, -1)
+		w.mu.Unlock()
+		return
+	}
+	ch := make(chan struct{})
+	w.waiters = append(w.waiters, waiterN{n: n, ch: ch})
+	w.mu.Unlock()
+	w.block(ch, nil)
+}
+
+// releaseWaiters 唤醒计数已不超过其阈值的 WaitN
+func (w *ExtWaitGroup) releaseWaiters() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	c := w.waitCount.Load()
+	waiters := w.waiters[:0]
+	for _, wt := range w.waiters {
+		if c <= wt.n {
+			close(wt.ch)
+			atomic.AddInt32(&w.nwaiters, -1)
+		} else {
+			waiters = append(waiters, wt)
+		}
+	}
+	for i := len(waiters); i < len(w.waiters); i++ {
+		w.waiters[i] = waiterN{} // 释放引用
+	}
+	w.waiters = waiters
+}
+
+// OnZero 注册计数归零时的回调，每次归零都会按注册顺序调用。
+// 回调在使计数归零的 Done 所在协程中同步执行，无需专门的协程阻塞在 Wait 上
+func (w *ExtWaitGroup) OnZero(f func()) {
+	w.zeroMu.Lock()
+	defer w.zeroMu.Unlock()
+	w.onZero = append(w.onZero, f)
+}
+
+func (w *ExtWaitGroup) fireZero() {
+	w.zeroMu.Lock()
+	fs := w.onZero
+	w.zeroMu.Unlock()
+	for _, f := range fs {
+		f()
+	}
+}
+
+func (w *ExtWaitGroup) GetWaitCount() uint64 {
+	return w.waitCount.Load()
+}
+
+// Go 计数加一并在新协程中执行 f，f 返回或 panic 后计数减一。
+// 第一个非 nil 的错误（panic 会转为错误）由 Wait 返回，之后的错误被忽略
+func (w *ExtWaitGroup) Go(f func() error) {
+	w.Add(1)
+	go func() {
+		defer w.Done()
+		defer w.Track()()
+		defer func() {
+			if r := recover(); r != nil {
+				w.setErr(fmt.Errorf("xsync: goroutine panicked: %v", r))
+			}
+		}()
+		if err := f(); err != nil {
+			w.setErr(err)
+		}
+	}()
+}
+
+func (w *ExtWaitGroup) setErr(err error) {
+	w.errOnce.Do(func() { w.err = err })
+}
+
+// Wait 等待计数归零，并返回 Go 中第一个出现的错误
+func (w *ExtWaitGroup) Wait() error {
+	if zero := w.wait(); zero != nil {
+		w.block(zero, nil)
+	}
+	return w.err
+}
+
+// WaitContext 等待计数归零，ctx 先结束时返回 ctx.Err()
+func (w *ExtWaitGroup) WaitContext(ctx context.Context) error {
+	zero := w.wait()
+	if zero == nil {
+		return nil
+	}
+	if !w.block(zero, ctx.Done()) {
+		return ctx.Err()
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package xsync
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWaitContext(t *testing.T) {
+	var wg ExtWaitGroup
+	wg.Add(1)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := wg.WaitContext(ctx); err != context.DeadlineExceeded {
+		t.Errorf("WaitContext = %v, want DeadlineExceeded", err)
+	}
+	wg.Done()
+	if err := wg.WaitContext(context.Background()); err != nil {
+		t.Errorf("WaitContext after Done = %v, want nil", err)
+	}
+}
+
+func TestGo(t *testing.T) {
+	var wg ExtWaitGroup
+	want := errors.New("boom")
+	wg.Go(func() error { return nil })
+	wg.Go(func() error { return want })
+	if err := wg.Wait(); err != want {
+		t.Errorf("Wait = %v, want %v", err, want)
+	}
+
+	var pwg ExtWaitGroup
+	pwg.Go(func() error { panic("oops") })
+	if err := pwg.Wait(); err == nil {
+		t.Error("Wait after panic = nil, want error")
+	}
+}
+
+func TestOnZero(t *testing.T) {
+	var wg ExtWaitGroup
+	var zeros int
+	wg.OnZero(func() { zeros++ })
+	for round := 0; round < 2; round++ {
+		wg.Add(2)
+		wg.Done()
+		wg.Done()
+	}
+	wg.Wait()
+	if zeros != 2 {
+		t.Errorf("OnZero fired %d times, want 2", zeros)
+	}
+}
+
+func TestExtWaitGroupRace(t *testing.T) {
+	var wg ExtWaitGroup
+	var inner ExtWaitGroup
+	for i := 0; i < 100; i++ {
+		inner.Add(1)
+		go func() {
+			defer inner.Done()
+			for j := 0; j < 100; j++ {
+				wg.Add(1)
+				go func() {
+					wg.GetWaitCount()
+					wg.Done()
+				}()
+			}
+		}()
+	}
+	inner.Wait()
+	wg.Wait()
+	if n := wg.GetWaitCount(); n != 0 {
+		t.Errorf("GetWaitCount after Wait = %d, want 0", n)
+	}
+	if err := wg.WaitContext(context.Background()); err != nil {
+		t.Errorf("WaitContext at zero = %v", err)
+	}
+}
+
+func TestExtWaitGroupReuse(t *testing.T) {
+	var wg ExtWaitGroup
+	for round := 0; round < 100; round++ {
+		wg.Add(2)
+		go wg.Done()
+		go wg.Done()
+		done := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("round %d: Wait did not return", round)
+		}
+	}
+}
+
+func TestExtWaitGroupNegative(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Done below zero did not panic")
+		}
+	}()
+	var wg ExtWaitGroup
+	wg.Done()
+}
+
+func TestWaitN(t *testing.T) {
+	var wg ExtWaitGroup
+	wg.Add(5)
+	released := make(chan struct{})
+	go func() {
+		wg.WaitN(2)
+		close(released)
+	}()
+	for i := 0; i < 2; i++ {
+		wg.Done()
+	}
+	select {
+	case <-released:
+		t.Fatal("WaitN(2) returned with count 3")
+	case <-time.After(20 * time.Millisecond):
+	}
+	wg.Done()
+	select {
+	case <-released:
+	case <-time.After(time.Second):
+		t.Fatal("WaitN(2) did not return with count 2")
+	}
+	wg.WaitN(2) // 已满足时立即返回
+}
+
+func TestWaitDebug(t *testing.T) {
+	var wg ExtWaitGroup
+	var out bytes.Buffer
+	wg.SetWaitDebug(10*time.Millisecond, &out)
+	release := make(chan struct{})
+	wg.Go(func() error {
+		<-release
+		return nil
+	})
+	time.AfterFunc(50*time.Millisecond, func() { close(release) })
+	wg.Wait()
+
+	if s := out.String(); !strings.Contains(s, "1 outstanding") || !strings.Contains(s, "TestWaitDebug") {
+		t.Errorf("diagnostics missing the stuck goroutine:\n%s", s)
+	}
+}
+
+func TestTryAddCapped(t *testing.T) {
+	var wg, added ExtWaitGroup
+	const max = 10
+	for i := 0; i < 100; i++ {
+		added.Go(func() error {
+			wg.TryAddCapped(1, max)
+			return nil
+		})
+	}
+	added.Wait()
+	if n := wg.GetWaitCount(); n != max {
+		t.Errorf("count = %d, want %d", n, max)
+	}
+	if wg.TryAddCapped(1, max) {
+		t.Error("TryAddCapped exceeded the cap")
+	}
+}